@@ -19,20 +19,25 @@ fi
 
 echo "Using Go executable: $GO_CMD"
 
+# Stamp version information into the binaries
+VERSION=${VERSION:-$(git describe --tags --always --dirty 2>/dev/null || echo dev)}
+GIT_COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+LDFLAGS="-X github.com/AbhishekGY/mydocker/pkg/version.Version=$VERSION -X github.com/AbhishekGY/mydocker/pkg/version.GitCommit=$GIT_COMMIT"
+
 # Create bin directory if it doesn't exist
 mkdir -p bin
 
 # Build the container-init binary (must be built first, as it's needed by the daemon)
 echo "Building container-init..."
-$GO_CMD build -o bin/container-init cmd/container-init/main.go
+$GO_CMD build -o bin/container-init ./cmd/container-init
 
 # Build the mydockerd daemon
 echo "Building mydockerd..."
-$GO_CMD build -o bin/mydockerd cmd/mydockerd/main.go
+$GO_CMD build -ldflags "$LDFLAGS" -o bin/mydockerd ./cmd/mydockerd
 
 # Build the mydocker client
 echo "Building mydocker client..."
-$GO_CMD build -o bin/mydocker cmd/mydocker/main.go
+$GO_CMD build -ldflags "$LDFLAGS" -o bin/mydocker ./cmd/mydocker
 
 echo "Build completed. The binaries are in the bin/ directory."
 echo ""
@@ -11,10 +11,10 @@ import (
 // It sets up the container environment (mounts, pivot_root, etc.) and then
 // execs the actual container command
 func main() {
-	// Get the rootfs path from environment
-	rootfs := os.Getenv("CONTAINER_ROOTFS")
-	if rootfs == "" {
-		fmt.Fprintf(os.Stderr, "Error: CONTAINER_ROOTFS environment variable not set\n")
+	// Get the init configuration (rootfs, secrets) the daemon sends over a pipe
+	cfg, err := namespace.ReadInitConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -33,7 +33,8 @@ func main() {
 
 	// Set up the container environment and exec the command
 	// This function will not return - it will replace this process with the container command
-	if err := namespace.ContainerInit(rootfs, command, args); err != nil {
+	if err := namespace.ContainerInit(cfg, command, args); err != nil {
+		cfg.ReportError(err)
 		fmt.Fprintf(os.Stderr, "Error initializing container: %v\n", err)
 		os.Exit(1)
 	}
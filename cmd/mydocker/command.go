@@ -0,0 +1,230 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AbhishekGY/mydocker/pkg/client"
+)
+
+// command is a node in the CLI command tree
+// Group commands have subcommands and no run function; leaf commands have a run function
+type command struct {
+	name        string
+	aliases     []string
+	usage       string // Argument synopsis shown after the command path, e.g. "[flags] <container-id>"
+	short       string
+	examples    []string
+	run         func(cmd *command, args []string)
+	subcommands []*command
+	parent      *command
+}
+
+// addCommands attaches subcommands to c
+func (c *command) addCommands(subs ...*command) *command {
+	for _, sub := range subs {
+		sub.parent = c
+		c.subcommands = append(c.subcommands, sub)
+	}
+	return c
+}
+
+// path returns the full command path, e.g. "mydocker container ls"
+func (c *command) path() string {
+	if c.parent == nil {
+		return c.name
+	}
+	return c.parent.path() + " " + c.name
+}
+
+// find returns the subcommand matching name or one of its aliases
+func (c *command) find(name string) *command {
+	for _, sub := range c.subcommands {
+		if sub.name == name {
+			return sub
+		}
+		for _, alias := range sub.aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+// execute dispatches args to the matching subcommand or runs c
+func (c *command) execute(args []string) {
+	if len(c.subcommands) > 0 && len(args) > 0 {
+		if sub := c.find(args[0]); sub != nil {
+			sub.execute(args[1:])
+			return
+		}
+	}
+
+	if c.run != nil {
+		c.run(c, args)
+		return
+	}
+
+	// Group command without a (known) subcommand
+	if len(args) == 0 || isHelpArg(args[0]) {
+		c.printHelp(nil)
+		if len(args) == 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s %s\n\n", c.path(), args[0])
+	c.printHelp(nil)
+	os.Exit(1)
+}
+
+// flagSet returns a FlagSet whose -h/--help output is this command's help
+func (c *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.path(), flag.ExitOnError)
+	fs.Usage = func() {
+		c.printHelp(fs)
+	}
+	return fs
+}
+
+// parseFlags parses args into fs, exiting on error
+func (c *command) parseFlags(fs *flag.FlagSet, args []string) {
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// usageError prints an error and the command's usage line, then exits
+func (c *command) usageError(format string, args ...interface{}) {
+	fmt.Printf("Error: "+format+"\n", args...)
+	fmt.Printf("Usage: %s %s\n", c.path(), c.usage)
+	fmt.Printf("Run '%s --help' for more information\n", c.path())
+	os.Exit(1)
+}
+
+// printHelp prints usage, description, subcommands, flags and examples
+func (c *command) printHelp(fs *flag.FlagSet) {
+	if len(c.subcommands) > 0 && c.run == nil {
+		fmt.Printf("Usage: %s COMMAND\n", c.path())
+	} else {
+		fmt.Printf("Usage: %s %s\n", c.path(), c.usage)
+	}
+
+	if c.short != "" {
+		fmt.Printf("\n%s\n", c.short)
+	}
+
+	if len(c.aliases) > 0 {
+		fmt.Printf("\nAliases:\n  %s\n", strings.Join(append([]string{c.name}, c.aliases...), ", "))
+	}
+
+	if len(c.subcommands) > 0 {
+		var groups, leaves []*command
+		for _, sub := range c.subcommands {
+			if len(sub.subcommands) > 0 {
+				groups = append(groups, sub)
+			} else {
+				leaves = append(leaves, sub)
+			}
+		}
+
+		printCommandList("Management Commands", groups)
+		printCommandList("Commands", leaves)
+		fmt.Printf("\nRun '%s COMMAND --help' for more information on a command\n", c.path())
+	}
+
+	if fs != nil {
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Println("\nFlags:")
+			fs.SetOutput(os.Stdout)
+			fs.PrintDefaults()
+		}
+	}
+
+	if c.parent == nil {
+		fmt.Println("\nGlobal Flags:")
+		globalFlagSet(&globalOptions{}).PrintDefaults()
+	}
+
+	if len(c.examples) > 0 {
+		fmt.Println("\nExamples:")
+		for _, example := range c.examples {
+			fmt.Printf("  %s\n", example)
+		}
+	}
+}
+
+// printCommandList prints a titled, aligned list of commands
+func printCommandList(title string, commands []*command) {
+	if len(commands) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s:\n", title)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, sub := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", sub.name, sub.short)
+	}
+	w.Flush()
+}
+
+// isHelpArg reports whether arg requests help
+func isHelpArg(arg string) bool {
+	return arg == "-h" || arg == "--help" || arg == "-help" || arg == "help"
+}
+
+// globalOptions are flags accepted before any command
+type globalOptions struct {
+	socket      string
+	host        string
+	context     string
+	allContexts bool
+}
+
+// globalFlagSet defines the global flags on a new FlagSet bound to opts
+func globalFlagSet(opts *globalOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("mydocker", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	fs.StringVar(&opts.socket, "socket", defaultSocket(), "Path to the daemon's Unix socket (env MYDOCKER_SOCKET)")
+	fs.StringVar(&opts.host, "host", "", "Daemon to connect to, e.g. ssh://user@host or unix:///path; overrides --socket (env MYDOCKER_HOST)")
+	fs.StringVar(&opts.host, "H", "", "Shorthand for --host")
+	fs.StringVar(&opts.context, "context", "", "Name of the context to use; overrides the current context (env MYDOCKER_CONTEXT)")
+	fs.BoolVar(&opts.allContexts, "all-contexts", false, "Query every configured context at once (ps only)")
+	return fs
+}
+
+// defaultSocket returns the socket from MYDOCKER_SOCKET or the built-in default
+func defaultSocket() string {
+	if socket := os.Getenv("MYDOCKER_SOCKET"); socket != "" {
+		return socket
+	}
+	return client.DefaultSocketPath
+}
+
+// helpCommand prints help for the command path given in args
+func helpCommand(cmd *command, args []string) {
+	target := cmd.parent
+	for _, arg := range args {
+		sub := target.find(arg)
+		if sub == nil {
+			fmt.Fprintf(os.Stderr, "Unknown command: %s %s\n", target.path(), arg)
+			os.Exit(1)
+		}
+		target = sub
+	}
+
+	// Leaf commands print their flags through their own FlagSet
+	if target.run != nil && len(target.subcommands) == 0 {
+		target.run(target, []string{"--help"})
+		return
+	}
+	target.printHelp(nil)
+}
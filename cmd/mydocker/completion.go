@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// completionBashCommand prints a bash completion script generated from the command tree
+func completionBashCommand(cmd *command, args []string) {
+	cmd.parseFlags(cmd.flagSet(), args)
+	fmt.Print(bashCompletionScript(rootOf(cmd)))
+}
+
+// completionZshCommand prints a zsh completion script (the bash script loaded through bashcompinit)
+func completionZshCommand(cmd *command, args []string) {
+	cmd.parseFlags(cmd.flagSet(), args)
+	fmt.Println("autoload -U +X compinit && compinit")
+	fmt.Println("autoload -U +X bashcompinit && bashcompinit")
+	fmt.Print(bashCompletionScript(rootOf(cmd)))
+}
+
+// rootOf returns the root of the command tree containing c
+func rootOf(c *command) *command {
+	for c.parent != nil {
+		c = c.parent
+	}
+	return c
+}
+
+// bashCompletionScript completes command names at every level of the tree
+// The words typed so far (ignoring flags) select the level to complete
+func bashCompletionScript(root *command) string {
+	cases := map[string][]string{}
+	var walk func(c *command, prefix string)
+	walk = func(c *command, prefix string) {
+		if len(c.subcommands) == 0 {
+			return
+		}
+		var words []string
+		for _, sub := range c.subcommands {
+			words = append(words, sub.name)
+			words = append(words, sub.aliases...)
+			// Aliases lead to the same subtree
+			for _, name := range append([]string{sub.name}, sub.aliases...) {
+				walk(sub, prefix+" "+name)
+			}
+		}
+		cases[prefix] = words
+	}
+	walk(root, "")
+
+	prefixes := make([]string, 0, len(cases))
+	for prefix := range cases {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n", root.name)
+	fmt.Fprintf(&b, "_%s() {\n", root.name)
+	b.WriteString("    local cur path i\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    path=\"\"\n")
+	b.WriteString("    for ((i=1; i<COMP_CWORD; i++)); do\n")
+	b.WriteString("        case \"${COMP_WORDS[i]}\" in\n")
+	b.WriteString("            --socket|--host|-H|--context) ((i++)) ;;\n")
+	b.WriteString("            -*) ;;\n")
+	b.WriteString("            *) path=\"$path ${COMP_WORDS[i]}\" ;;\n")
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    local opts=\"\"\n")
+	b.WriteString("    case \"$path\" in\n")
+	for _, prefix := range prefixes {
+		fmt.Fprintf(&b, "        %q) opts=%q ;;\n", prefix, strings.Join(cases[prefix], " "))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    COMPREPLY=( $(compgen -W \"$opts\" -- \"$cur\") )\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -F _%s %s\n", root.name, root.name)
+
+	return b.String()
+}
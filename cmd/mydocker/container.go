@@ -0,0 +1,1082 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/client"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/formatter"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+)
+
+func runCommand(cmd *command, args []string) {
+	// Create a new FlagSet for the run command
+	runFlags := cmd.flagSet()
+	buildRequest := containerFlags(cmd, runFlags)
+	detach := runFlags.Bool("d", false, "Run container in detached mode (background)")
+	runFlags.Bool("detach", false, "Run container in detached mode (background)")
+	noDeps := runFlags.Bool("no-deps", false, "Don't start the containers this one depends on")
+	noTTY := runFlags.Bool("no-tty", false, "Give the container stdio pipes instead of a PTY, keeping stdout and stderr apart")
+	var streamFlags stringSlice
+	runFlags.Var(&streamFlags, "stream", "Attach only this stream: stdin, stdout or stderr (repeatable, default all)")
+	stdinOnce := runFlags.Bool("stdin-once", false, "Close the container's stdin once the local stdin ends (needs --no-tty)")
+	daemonless := runFlags.Bool("daemonless", false, "Run the container in the foreground from this process, without a daemon (needs root)")
+
+	// Parse flags
+	cmd.parseFlags(runFlags, args)
+
+	streams, err := parseStreams(streamFlags)
+	if err != nil {
+		cmd.usageError("%v", err)
+	}
+
+	req := buildRequest()
+	if *daemonless {
+		if *detach || *noDeps || *noTTY || *stdinOnce || len(streamFlags) > 0 {
+			cmd.usageError("--daemonless can't be combined with -d, --no-deps, --no-tty, --stream or --stdin-once")
+		}
+		os.Exit(runDaemonless(cmd, req))
+	}
+	req.Detach = *detach
+	req.NoDeps = *noDeps
+	req.NoTTY = *noTTY
+	req.StdinOnce = *stdinOnce
+	req.AttachStdin, req.AttachStdout, req.AttachStderr = streams.Stdin, streams.Stdout, streams.Stderr
+
+	// Create client
+	cli := newClient()
+
+	// Detached containers just print their ID
+	if *detach {
+		resp, err := cli.ContainerCreate(context.Background(), req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating container: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(resp.ID)
+		if resp.Queued {
+			fmt.Fprintln(os.Stderr, "The container is queued and will start once it is admitted (see 'mydocker system queue')")
+		}
+		return
+	}
+
+	// Attached containers stream their PTY or stdio to the local terminal
+	id, stream, err := cli.ContainerCreateAttach(context.Background(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating container: %v\n", err)
+		os.Exit(1)
+	}
+	defer stream.Close()
+
+	if err := streamAttached(stream, len(streamFlags) == 0 || streams.Stdin); err != nil {
+		fmt.Fprintf(os.Stderr, "Error attaching to container: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(id)
+}
+
+// runDaemonless runs a container from this process with the local terminal as its stdio and returns its exit code
+// Only what the container package does itself is supported; everything the daemon adds is refused rather than ignored
+func runDaemonless(cmd *command, req api.ContainerCreateRequest) int {
+	unsupported := req
+	unsupported.Image, unsupported.Command, unsupported.Rootfs, unsupported.Env = "", nil, "", nil
+	unsupported.Memory, unsupported.MemorySwap, unsupported.CpuShares, unsupported.CpuBurst = 0, 0, 0, 0
+	unsupported.CpuQuota, unsupported.CpuPeriod, unsupported.PidsLimit = 0, 0, 0
+	unsupported.HugetlbLimits, unsupported.MaskedPaths, unsupported.TimeOffsets = nil, nil, nil
+	if len(unsupported.Labels) == 0 {
+		unsupported.Labels = nil
+	}
+	if !reflect.ValueOf(unsupported).IsZero() {
+		cmd.usageError("--daemonless supports only resource limits, environment variables, --mask and time offsets")
+	}
+
+	cfg := container.Config{
+		Command: req.Command,
+		Rootfs:  req.Rootfs,
+		Env:     req.Env,
+		Limits: cgroups.ResourceLimits{
+			MemoryLimit:     req.Memory,
+			MemorySwapLimit: req.MemorySwap,
+			CpuShares:       req.CpuShares,
+			CpuQuota:        req.CpuQuota,
+			CpuPeriod:       req.CpuPeriod,
+			CpuBurst:        req.CpuBurst,
+			PidsLimit:       req.PidsLimit,
+			HugetlbLimits:   req.HugetlbLimits,
+		},
+		MaskedPaths: req.MaskedPaths,
+		Stdio:       container.Stdio{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr},
+	}
+	if req.TimeOffsets != nil {
+		cfg.TimeOffsets = &namespace.TimeOffsets{Monotonic: req.TimeOffsets.Monotonic, Boottime: req.TimeOffsets.Boottime}
+	}
+
+	// Interrupting mydocker kills the container, which runs in a session of its own
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	code, err := container.Run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running container: %v\n", err)
+		if code < 0 {
+			return 1
+		}
+	}
+	return code
+}
+
+// containerFlags defines the flags describing a container on fs, shared by run and schedule create
+// The returned function builds the create request from them and the remaining arguments once fs is parsed
+func containerFlags(cmd *command, fs *flag.FlagSet) func() api.ContainerCreateRequest {
+	template := fs.String("template", "", "Preset from the daemon to start from; only the flags given override it")
+	buildRequest := containerTemplateFlags(cmd, fs)
+
+	return func() api.ContainerCreateRequest {
+		req := buildRequest(*template != "")
+		req.Preset = *template
+		return req
+	}
+}
+
+// containerTemplateFlags defines the flags describing a container on fs, shared by containerFlags and preset create
+// The returned function builds the create request once fs is parsed; a partial request may leave out the command
+// and rootfs, and leaves every limit not given on the command line unset so a preset can fill it in
+func containerTemplateFlags(cmd *command, fs *flag.FlagSet) func(partial bool) api.ContainerCreateRequest {
+	// Define resource limit flags
+	memory := fs.Uint64("memory", 0, "Memory limit in bytes")
+	memorySwap := fs.Int64("memory-swap", 0, "Memory + swap limit in bytes: equal to --memory disables swap, -1 allows unlimited swap (default 2x --memory)")
+	cpuShares := fs.Uint64("cpu-shares", 0, "CPU shares (relative weight, default 1024 unless the daemon config or --profile sets one)")
+	cpuQuota := fs.Int64("cpu-quota", -1, "CPU quota in microseconds")
+	cpuPeriod := fs.Uint64("cpu-period", 100000, "CPU period in microseconds")
+	cpuBurst := fs.Uint64("cpu-burst", 0, "Unused CPU quota that can be saved up for bursts, in microseconds (at most --cpu-quota)")
+	pidsLimit := fs.Int64("pids-limit", 0, "Maximum number of PIDs/processes")
+	profile := fs.String("profile", "", "Resource profile from the daemon config for limits not set by flags")
+	var hugetlbFlags stringSlice
+	fs.Var(&hugetlbFlags, "hugetlb-limit", "Huge page limit in bytes for a page size, e.g. 2MB=1073741824 (repeatable)")
+	rootfs := fs.String("rootfs", "", "Path to the rootfs directory, or the name of one in the daemon's rootfs directory")
+	platformFlag := fs.String("platform", "", "Platform the rootfs was built for, e.g. linux/arm64 (runs under qemu emulation if foreign)")
+	var envFlags, envFiles, secretFlags stringSlice
+	fs.Var(&envFlags, "e", "Set environment variable KEY=VALUE (repeatable)")
+	fs.Var(&envFlags, "env", "Set environment variable KEY=VALUE (repeatable)")
+	fs.Var(&envFiles, "env-file", "Read environment variables from a file (repeatable)")
+	fs.Var(&secretFlags, "secret", "Expose a file at /run/secrets/NAME: src=/path[,target=NAME] (repeatable)")
+	var mountFlags, maskFlags stringSlice
+	fs.Var(&mountFlags, "mount", "Add a mount: [type=bind|tmpfs,]src=/path,dst=/path[,ro,z|Z,...] (repeatable; z and Z relabel bind sources for SELinux)")
+	fs.Var(&maskFlags, "mask", "Hide a path inside the container (repeatable)")
+	var labelFlags, dependsOn stringSlice
+	fs.Var(&labelFlags, "label", "Set a container label KEY=VALUE (repeatable)")
+	fs.Var(&dependsOn, "depends-on", "Start after the given container and stop before it (repeatable)")
+	var securityOpts stringSlice
+	fs.Var(&securityOpts, "security-opt", "Security option: apparmor=<profile|unconfined>, label=level:<level>, label=disable or label=relabel (repeatable)")
+	timeNS := fs.Bool("timens", false, "Run in a new time namespace (implied by the offset flags)")
+	monotonicOffset := fs.Duration("monotonic-offset", 0, "Shift the container's monotonic clock, e.g. 24h (negative offsets move it back)")
+	boottimeOffset := fs.Duration("boottime-offset", 0, "Shift the container's boot-time clock, e.g. 720h")
+	var pressureFlags stringSlice
+	fs.Var(&pressureFlags, "pressure-threshold", "Emit a pressure event when a stall percentage exceeds a limit, e.g. memory.some=10 (repeatable, cgroups v2 only)")
+	var waitPaths, waitUnits stringSlice
+	fs.Var(&waitPaths, "wait-for-path", "Hold starts until a host path such as /dev/ttyUSB0 exists (repeatable)")
+	fs.Var(&waitUnits, "wait-for-unit", "Hold starts until a systemd unit such as nfs.mount is active (repeatable)")
+	var hotplugFlags stringSlice
+	fs.Var(&hotplugFlags, "device-hotplug", "Mirror host devices matching a glob such as /dev/ttyUSB* into /dev as they are plugged in and removed (repeatable)")
+	waitTimeout := fs.Duration("wait-timeout", 0, "Give up a start still waiting for --wait-for-path or --wait-for-unit after this long (default no limit)")
+	resourceViews := fs.Bool("resource-views", false, "Show the memory and CPU limits in /proc/meminfo, /proc/cpuinfo and /sys instead of the host's totals")
+	autoscaleMemoryStep := fs.Float64("autoscale-memory-step", 0, "Raise the memory limit by this percent whenever the container hits it (needs --autoscale-memory-max)")
+	autoscaleMemoryMax := fs.Uint64("autoscale-memory-max", 0, "Memory limit in bytes autoscaling stops at")
+	autoscaleCpuStep := fs.Float64("autoscale-cpu-step", 0, "Raise the CPU quota by this percent whenever the container is throttled (needs --autoscale-cpu-max)")
+	autoscaleCpuMax := fs.Int64("autoscale-cpu-max", 0, "CPU quota in microseconds autoscaling stops at")
+	autoscaleCooldown := fs.Duration("autoscale-cooldown", 0, "Minimum time between autoscale adjustments of a resource (default 30s)")
+	logDriver := fs.String("log-driver", "", "Ship the container's output to a collector: fluentd or gelf")
+	var logOpts stringSlice
+	fs.Var(&logOpts, "log-opt", "Log driver option KEY=VALUE, e.g. fluentd-address=localhost:24224, mode=non-blocking or tag={{.ID}} (repeatable)")
+
+	return func(partial bool) api.ContainerCreateRequest {
+		// Get the remaining arguments (command and args)
+		var remainingArgs []string
+		if fs.NArg() > 0 {
+			remainingArgs = fs.Args()
+		} else if !partial {
+			cmd.usageError("No command specified")
+		}
+
+		if *rootfs == "" && !partial {
+			cmd.usageError("--rootfs flag is required")
+		}
+		if *rootfs != "" {
+			*rootfs = absRootfs(*rootfs)
+		}
+
+		// The CPU flags default to the kernel's values, which would override a preset's
+		if partial {
+			given := make(map[string]bool)
+			fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+			if !given["cpu-quota"] {
+				*cpuQuota = 0
+			}
+			if !given["cpu-period"] {
+				*cpuPeriod = 0
+			}
+		}
+
+		// Env files come first so -e can override them
+		var env []string
+		for _, path := range envFiles {
+			fileEnv, err := parseEnvFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			env = append(env, fileEnv...)
+		}
+		for _, value := range envFlags {
+			if kv, ok := parseEnvFlag(value); ok {
+				env = append(env, kv)
+			}
+		}
+
+		var secrets []api.Secret
+		for _, value := range secretFlags {
+			secret, err := parseSecretFlag(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --secret %q: %v\n", value, err)
+				os.Exit(1)
+			}
+			secrets = append(secrets, secret)
+		}
+
+		var mounts []api.Mount
+		for _, value := range mountFlags {
+			mount, err := parseMountFlag(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --mount %q: %v\n", value, err)
+				os.Exit(1)
+			}
+			mounts = append(mounts, mount)
+		}
+
+		labels, err := parseLabels(labelFlags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(dependsOn) > 0 {
+			labels[api.DependsOnLabel] = strings.Join(dependsOn, ",")
+		}
+
+		var hugetlbLimits map[string]uint64
+		for _, value := range hugetlbFlags {
+			size, limit, err := parseHugetlbLimit(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --hugetlb-limit %q: %v\n", value, err)
+				os.Exit(1)
+			}
+			if hugetlbLimits == nil {
+				hugetlbLimits = make(map[string]uint64)
+			}
+			hugetlbLimits[size] = limit
+		}
+
+		var pressureThresholds map[string]float64
+		for _, value := range pressureFlags {
+			key, threshold, err := parsePressureThreshold(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --pressure-threshold %q: %v\n", value, err)
+				os.Exit(1)
+			}
+			if pressureThresholds == nil {
+				pressureThresholds = make(map[string]float64)
+			}
+			pressureThresholds[key] = threshold
+		}
+
+		var timeOffsets *api.TimeOffsets
+		if *timeNS || *monotonicOffset != 0 || *boottimeOffset != 0 {
+			timeOffsets = &api.TimeOffsets{Monotonic: *monotonicOffset, Boottime: *boottimeOffset}
+		}
+
+		var waitFor *api.WaitConditions
+		if len(waitPaths) > 0 || len(waitUnits) > 0 {
+			waitFor = &api.WaitConditions{Paths: waitPaths, Units: waitUnits, Timeout: *waitTimeout}
+		} else if *waitTimeout != 0 {
+			cmd.usageError("--wait-timeout needs --wait-for-path or --wait-for-unit")
+		}
+
+		var autoscale *api.AutoscalePolicy
+		if *autoscaleMemoryStep != 0 || *autoscaleCpuStep != 0 {
+			autoscale = &api.AutoscalePolicy{
+				MemoryStep:    *autoscaleMemoryStep,
+				MemoryCeiling: *autoscaleMemoryMax,
+				CpuStep:       *autoscaleCpuStep,
+				CpuCeiling:    *autoscaleCpuMax,
+				Cooldown:      *autoscaleCooldown,
+			}
+		} else if *autoscaleMemoryMax != 0 || *autoscaleCpuMax != 0 || *autoscaleCooldown != 0 {
+			cmd.usageError("autoscale limits need --autoscale-memory-step or --autoscale-cpu-step")
+		}
+
+		var logConfig *api.LogConfig
+		if *logDriver != "" {
+			options, err := parseLogOpts(logOpts)
+			if err != nil {
+				cmd.usageError("%v", err)
+			}
+			logConfig = &api.LogConfig{Driver: *logDriver, Options: options}
+		} else if len(logOpts) > 0 {
+			cmd.usageError("--log-opt needs --log-driver")
+		}
+
+		// Build request
+		return api.ContainerCreateRequest{
+			Image:      *rootfs, // Using rootfs as image for now
+			Command:    remainingArgs,
+			Rootfs:     *rootfs,
+			Platform:   *platformFlag,
+			Env:        env,
+			Secrets:    secrets,
+			Labels:     labels,
+			Memory:     *memory,
+			MemorySwap: *memorySwap,
+			CpuShares:  *cpuShares,
+			CpuQuota:   *cpuQuota,
+			CpuPeriod:  *cpuPeriod,
+			CpuBurst:   *cpuBurst,
+			PidsLimit:  *pidsLimit,
+			Profile:    *profile,
+
+			HugetlbLimits:      hugetlbLimits,
+			PressureThresholds: pressureThresholds,
+			SecurityOpt:        securityOpts,
+			TimeOffsets:        timeOffsets,
+			Mounts:             mounts,
+			MaskedPaths:        maskFlags,
+			WaitFor:            waitFor,
+
+			ResourceViews: *resourceViews,
+			Autoscale:     autoscale,
+
+			LogConfig: logConfig,
+
+			DeviceHotplug: hotplugFlags,
+		}
+	}
+}
+
+// absRootfs makes a relative rootfs path absolute; a plain name refers to a rootfs managed by the daemon
+func absRootfs(rootfs string) string {
+	if !strings.Contains(rootfs, "/") || strings.HasPrefix(rootfs, "/") {
+		return rootfs
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return wd + "/" + rootfs
+}
+
+// adoptCommand hands a running process started outside the daemon, such as one from unshare or another
+// runtime, to the daemon as a container
+func adoptCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	memory := fs.Uint64("memory", 0, "Memory limit in bytes")
+	cpuShares := fs.Uint64("cpu-shares", 0, "CPU shares (relative weight, default 1024 unless the daemon config or --profile sets one)")
+	cpuQuota := fs.Int64("cpu-quota", -1, "CPU quota in microseconds")
+	cpuPeriod := fs.Uint64("cpu-period", 100000, "CPU period in microseconds")
+	pidsLimit := fs.Int64("pids-limit", 0, "Maximum number of PIDs/processes")
+	profile := fs.String("profile", "", "Resource profile from the daemon config for limits not set by flags")
+	var labelFlags stringSlice
+	fs.Var(&labelFlags, "label", "Set a container label KEY=VALUE (repeatable)")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Process ID required")
+	}
+	pid, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || pid <= 0 {
+		cmd.usageError("Invalid process ID %q", fs.Arg(0))
+	}
+	labels, err := parseLabels(labelFlags)
+	if err != nil {
+		cmd.usageError("%v", err)
+	}
+
+	req := api.ContainerAdoptRequest{
+		PID:       pid,
+		Labels:    labels,
+		Memory:    *memory,
+		CpuShares: *cpuShares,
+		CpuQuota:  *cpuQuota,
+		CpuPeriod: *cpuPeriod,
+		PidsLimit: *pidsLimit,
+		Profile:   *profile,
+	}
+
+	// Create client
+	cli := newClient()
+
+	resp, err := cli.ContainerAdopt(context.Background(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error adopting process: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(resp.ID)
+}
+
+// cloneCommand copies a container's configuration into a new container, printing its ID
+func cloneCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	var labelFlags stringSlice
+	fs.Var(&labelFlags, "label", "Set a label KEY=VALUE on the clone, replacing a copied one (repeatable)")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+	labels, err := parseLabels(labelFlags)
+	if err != nil {
+		cmd.usageError("%v", err)
+	}
+
+	// Create client
+	cli := newClient()
+
+	resp, err := cli.ContainerClone(context.Background(), fs.Arg(0), api.ContainerCloneRequest{Labels: labels})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error cloning container: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(resp.ID)
+}
+
+// debugCommand starts a throwaway container from a debug rootfs in the network, PID and IPC namespaces of
+// a running container and attaches to it; the debug container is removed when its shell exits
+func debugCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	rootfs := fs.String("rootfs", "", "Rootfs with the debugging tools, a path or the name of one in the daemon's rootfs directory")
+	var envFlags stringSlice
+	fs.Var(&envFlags, "e", "Set environment variable KEY=VALUE (repeatable)")
+	fs.Var(&envFlags, "env", "Set environment variable KEY=VALUE (repeatable)")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+	if *rootfs == "" {
+		cmd.usageError("--rootfs flag is required")
+	}
+
+	command := fs.Args()[1:]
+	if len(command) == 0 {
+		command = []string{"/bin/sh"}
+	}
+	var env []string
+	for _, value := range envFlags {
+		if kv, ok := parseEnvFlag(value); ok {
+			env = append(env, kv)
+		}
+	}
+
+	req := api.ContainerCreateRequest{
+		Image:          absRootfs(*rootfs),
+		Rootfs:         absRootfs(*rootfs),
+		Command:        command,
+		Env:            env,
+		Labels:         map[string]string{api.DebugTargetLabel: fs.Arg(0)},
+		NamespacesFrom: fs.Arg(0),
+		AutoRemove:     true,
+	}
+
+	// Create client
+	cli := newClient()
+
+	id, stream, err := cli.ContainerCreateAttach(context.Background(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating debug container: %v\n", err)
+		os.Exit(1)
+	}
+	defer stream.Close()
+
+	if err := streamTerminal(stream); err != nil {
+		fmt.Fprintf(os.Stderr, "Error attaching to debug container %s: %v\n", shortID(id), err)
+		os.Exit(1)
+	}
+}
+
+func psCommand(cmd *command, args []string) {
+	psFlags := cmd.flagSet()
+	format := psFlags.String("format", "", "Format output using a Go template or 'json'")
+	size := psFlags.Bool("size", false, "Display rootfs sizes")
+	psFlags.BoolVar(size, "s", false, "Display rootfs sizes")
+	noTrunc := psFlags.Bool("no-trunc", false, "Don't truncate container IDs")
+	last := psFlags.Int("last", 0, "Show only the n most recently created containers")
+	psFlags.IntVar(last, "n", 0, "Show only the n most recently created containers")
+	offset := psFlags.Int("offset", 0, "Skip the n most recently created containers (use with --last to page)")
+	cmd.parseFlags(psFlags, args)
+	out := newFormatter(*format)
+
+	opts := client.ContainerListOptions{Size: *size, Limit: *last, Offset: *offset}
+	if globalOpts.allContexts {
+		psAllContexts(opts, out, *size, *noTrunc)
+		return
+	}
+
+	// Create client
+	cli := newClient()
+
+	// List containers
+	containers, err := cli.ContainerList(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing containers: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Custom formats render each container on its own line
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, containers); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Print containers in a table format
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, psHeader(*size))
+	for _, container := range containers {
+		fmt.Fprintln(w, psRow(container, *size, *noTrunc))
+	}
+
+	w.Flush()
+}
+
+// psHeader returns the header of the ps table
+func psHeader(size bool) string {
+	header := "CONTAINER ID\tIMAGE\tCOMMAND\tSTATUS\tCREATED\tPID"
+	if size {
+		header += "\tSIZE"
+	}
+	return header
+}
+
+// psRow formats a container as a row of the ps table
+func psRow(container api.ContainerInfo, size, noTrunc bool) string {
+	// Format created time
+	created := time.Unix(container.Created, 0)
+	createdStr := formatTimeSince(created)
+
+	id := container.ID
+	if !noTrunc {
+		id = shortID(id)
+	}
+
+	row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%d",
+		id,
+		container.Image,
+		container.Command,
+		container.Status,
+		createdStr,
+		container.PID,
+	)
+	if size {
+		row += fmt.Sprintf("\t(virtual %s)", formatSize(container.SizeRootFs))
+	}
+	return row
+}
+
+// contextContainer is a container listed by ps --all-contexts, with the context it runs under
+type contextContainer struct {
+	Context string
+	api.ContainerInfo
+}
+
+// psAllContexts lists the containers of every configured context concurrently, adding a HOST column
+// Contexts that can't be reached are reported and left out; the command fails if any were
+func psAllContexts(opts client.ContainerListOptions, out *formatter.Formatter, size, noTrunc bool) {
+	clients, err := allContextClients()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	results := make([][]api.ContainerInfo, len(clients))
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = c.cli.ContainerList(context.Background(), opts)
+		}()
+	}
+	wg.Wait()
+
+	failed := false
+	containers := []contextContainer{}
+	for i, c := range clients {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "Error listing containers on %s: %v\n", c.name, errs[i])
+			failed = true
+			continue
+		}
+		for _, container := range results[i] {
+			containers = append(containers, contextContainer{Context: c.name, ContainerInfo: container})
+		}
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, containers); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HOST\t"+psHeader(size))
+		for _, container := range containers {
+			fmt.Fprintln(w, container.Context+"\t"+psRow(container.ContainerInfo, size, noTrunc))
+		}
+		w.Flush()
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func startCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	noDeps := fs.Bool("no-deps", false, "Don't start the containers it depends on")
+	attach := fs.Bool("attach", false, "Attach the terminal to the container's PTY")
+	fs.BoolVar(attach, "a", false, "Attach the terminal to the container's PTY")
+	interactive := fs.Bool("interactive", false, "Attach the terminal to the container's PTY, including stdin")
+	fs.BoolVar(interactive, "i", false, "Attach the terminal to the container's PTY, including stdin")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+
+	containerID := fs.Arg(0)
+
+	// Create client
+	cli := newClient()
+	opts := client.ContainerStartOptions{NoDeps: *noDeps}
+
+	// Attached starts stream the PTY to the local terminal; stdin always travels with it
+	if *attach || *interactive {
+		started, stream, err := cli.ContainerStartAttach(context.Background(), containerID, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting container: %v\n", err)
+			os.Exit(1)
+		}
+		defer stream.Close()
+
+		for _, id := range started[:len(started)-1] {
+			fmt.Printf("Container %s started\n", shortID(id))
+		}
+
+		if err := streamAttached(stream, true); err != nil {
+			fmt.Fprintf(os.Stderr, "Error attaching to container: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Start container and, unless --no-deps, its dependencies
+	resp, err := cli.ContainerStart(context.Background(), containerID, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting container: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, id := range resp.Started {
+		fmt.Printf("Container %s started\n", shortID(id))
+	}
+	for _, id := range resp.Queued {
+		fmt.Printf("Container %s queued until it is admitted (see 'mydocker system queue')\n", shortID(id))
+	}
+}
+
+func stopCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	noDeps := fs.Bool("no-deps", false, "Don't stop the containers that depend on it")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+
+	containerID := fs.Arg(0)
+
+	// Create client
+	cli := newClient()
+
+	// Stop container and, unless --no-deps, its dependents first
+	stopped, err := cli.ContainerStop(context.Background(), containerID, client.ContainerStopOptions{NoDeps: *noDeps})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error stopping container: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, id := range stopped {
+		fmt.Printf("Container %s stopped\n", shortID(id))
+	}
+}
+
+func inspectCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	history := fs.Bool("history", false, "Show the container's state transitions: when, who and why")
+	diff := fs.Bool("diff", false, "Compare the configuration of two containers, showing only the fields that differ")
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	if *diff {
+		if fs.NArg() != 2 {
+			cmd.usageError("--diff needs two container IDs")
+		}
+		if *history {
+			cmd.usageError("--diff and --history can't be combined")
+		}
+		diffCommand(cli, fs.Arg(0), fs.Arg(1), out)
+		return
+	}
+
+	inspect, err := cli.ContainerInspect(context.Background(), fs.Arg(0), *history)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inspecting container: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !*history {
+		// Details are printed as indented JSON unless a format is given
+		if out.IsTable() {
+			data, err := json.MarshalIndent(inspect, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding container details: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+		if err := out.Write(os.Stdout, inspect); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, inspect.History); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tFROM\tTO\tACTOR\tREASON")
+	for _, t := range inspect.History {
+		from := t.From
+		if from == "" {
+			from = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Time.Format(time.RFC3339), from, t.To, t.Actor, t.Reason)
+	}
+	w.Flush()
+}
+
+func statsCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	noStream := fs.Bool("no-stream", false, "Print a single sample and exit")
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	since := fs.String("since", "", "Show peak usage recorded since a time or duration ago (e.g. 1h), instead of live samples")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+
+	containerID := fs.Arg(0)
+
+	// Create client
+	cli := newClient()
+
+	if *since != "" {
+		statsHistoryCommand(cli, containerID, *since, out)
+		return
+	}
+
+	body, err := cli.ContainerStats(context.Background(), containerID, !*noStream)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting stats: %v\n", err)
+		os.Exit(1)
+	}
+	defer body.Close()
+
+	const row = "%-14s %-8s %-22s %-8s %-22s %-16s %-12s %-22s %-6s %s\n"
+	if out.IsTable() {
+		fmt.Printf(row, "CONTAINER ID", "CPU %", "MEM USAGE / LIMIT", "MEM %", "NET I/O", "NET PACKETS", "NET DROPS", "BLOCK I/O", "PIDS", "LOG DROPPED")
+	}
+
+	// Print each sample as it arrives until the daemon ends the stream
+	decoder := json.NewDecoder(body)
+	for {
+		var stats api.ContainerStats
+		if err := decoder.Decode(&stats); err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "Error reading stats: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if !out.IsTable() {
+			if err := out.Write(os.Stdout, stats); err != nil {
+				fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		fmt.Printf(row,
+			shortID(stats.ID),
+			fmt.Sprintf("%.2f%%", stats.CPU.Percent),
+			formatSize(int64(stats.Memory.Usage))+" / "+formatSize(int64(stats.Memory.Limit)),
+			fmt.Sprintf("%.2f%%", stats.Memory.Percent),
+			formatSize(int64(stats.Network.RxBytes))+" / "+formatSize(int64(stats.Network.TxBytes)),
+			fmt.Sprintf("%d / %d", stats.Network.RxPackets, stats.Network.TxPackets),
+			fmt.Sprintf("%d / %d", stats.Network.RxDropped, stats.Network.TxDropped),
+			formatSize(int64(stats.BlockIO.ReadBytes))+" / "+formatSize(int64(stats.BlockIO.WriteBytes)),
+			strconv.FormatUint(stats.Pids.Current, 10),
+			strconv.FormatUint(stats.Log.DroppedLines, 10),
+		)
+	}
+}
+
+// statsHistoryCommand prints the peaks of a container's recorded stats; custom formats get the full history
+func statsHistoryCommand(cli client.APIClient, containerID, since string, out *formatter.Formatter) {
+	history, err := cli.ContainerStatsHistory(context.Background(), containerID, since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting stats history: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, history); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	const row = "%-14s %-8s %-12s %-16s %-10s %s\n"
+	fmt.Printf(row, "CONTAINER ID", "SAMPLES", "PEAK CPU %", "PEAK MEM USAGE", "PEAK MEM %", "PEAK PIDS")
+	fmt.Printf(row,
+		shortID(history.ID),
+		strconv.Itoa(len(history.Samples)),
+		fmt.Sprintf("%.2f%%", history.Peak.CPUPercent),
+		formatSize(int64(history.Peak.MemoryUsage)),
+		fmt.Sprintf("%.2f%%", history.Peak.MemoryPercent),
+		strconv.FormatUint(history.Peak.Pids, 10),
+	)
+}
+
+// logLine is a line rendered by `mydocker logs --format`, labelled with the container that wrote it
+type logLine struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Stream      string    `json:"stream"`
+	Message     string    `json:"message"`
+	ContainerID string    `json:"container_id"`
+}
+
+func logsCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	tail := fs.String("tail", "all", "Number of lines to show from the end of the output")
+	since := fs.String("since", "", "Show lines written since this time (duration like 10m, RFC 3339 time or Unix timestamp)")
+	until := fs.String("until", "", "Show lines written before this time (same formats as --since)")
+	timestamps := fs.Bool("timestamps", false, "Prefix each line with the time it was written")
+	fs.BoolVar(timestamps, "t", false, "Prefix each line with the time it was written")
+	follow := fs.Bool("follow", false, "Keep printing lines as a running container writes them")
+	fs.BoolVar(follow, "f", false, "Keep printing lines as a running container writes them")
+	format := fs.String("format", "", "Format each line using a Go template or 'json'")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+
+	containerID := fs.Arg(0)
+
+	// Create client
+	cli := newClient()
+
+	// Formatted lines carry the full ID, not the prefix that was typed
+	if !out.IsTable() {
+		inspect, err := cli.ContainerInspect(context.Background(), containerID, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting logs: %v\n", err)
+			os.Exit(1)
+		}
+		containerID = inspect.ID
+	}
+
+	opts := client.ContainerLogsOptions{Tail: *tail, Since: *since, Until: *until, Follow: *follow}
+	body, err := cli.ContainerLogs(context.Background(), containerID, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting logs: %v\n", err)
+		os.Exit(1)
+	}
+	defer body.Close()
+
+	decoder := json.NewDecoder(body)
+	for {
+		var entry api.LogEntry
+		if err := decoder.Decode(&entry); err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "Error reading logs: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// Formatted lines all go to stdout, labelled with their stream
+		if !out.IsTable() {
+			line := logLine{Timestamp: entry.Time, Stream: entry.Stream, Message: entry.Line, ContainerID: containerID}
+			if err := out.Write(os.Stdout, line); err != nil {
+				fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		// Lines go back to the stream the container wrote them to
+		w := os.Stdout
+		if entry.Stream == "stderr" {
+			w = os.Stderr
+		}
+		if *timestamps {
+			fmt.Fprintf(w, "%s %s\n", entry.Time.Format(time.RFC3339Nano), entry.Line)
+		} else {
+			fmt.Fprintln(w, entry.Line)
+		}
+	}
+}
+
+func attachCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	var streamFlags stringSlice
+	fs.Var(&streamFlags, "stream", "Attach only this stream: stdin, stdout or stderr (repeatable, default those chosen at run)")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+	streams, err := parseStreams(streamFlags)
+	if err != nil {
+		cmd.usageError("%v", err)
+	}
+
+	containerID := fs.Arg(0)
+
+	// Create client
+	cli := newClient()
+
+	// Attach to the container's PTY or stdio
+	stream, err := cli.ContainerAttach(context.Background(), containerID, streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error attaching to container: %v\n", err)
+		os.Exit(1)
+	}
+	defer stream.Close()
+
+	if err := streamAttached(stream, len(streamFlags) == 0 || streams.Stdin); err != nil {
+		fmt.Fprintf(os.Stderr, "Error attaching to container: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseStreams turns --stream values into the streams to attach
+func parseStreams(values []string) (client.ContainerAttachOptions, error) {
+	var streams client.ContainerAttachOptions
+	for _, value := range values {
+		switch value {
+		case "stdin":
+			streams.Stdin = true
+		case "stdout":
+			streams.Stdout = true
+		case "stderr":
+			streams.Stderr = true
+		default:
+			return streams, fmt.Errorf("invalid --stream %q, expected stdin, stdout or stderr", value)
+		}
+	}
+	return streams, nil
+}
+
+// shortID truncates a container ID for display; any unique prefix is accepted wherever an ID is
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// formatSize formats a byte count in human-readable units
+func formatSize(bytes int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	size := float64(bytes)
+	i := 0
+	for size >= 1000 && i < len(units)-1 {
+		size /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d%s", bytes, units[0])
+	}
+	return fmt.Sprintf("%.3g%s", size, units[i])
+}
+
+// formatTimeSince formats the time since a given time in a human-readable format
+func formatTimeSince(t time.Time) string {
+	duration := time.Since(t)
+
+	seconds := int(duration.Seconds())
+	minutes := seconds / 60
+	hours := minutes / 60
+	days := hours / 24
+
+	if days > 0 {
+		return fmt.Sprintf("%d days ago", days)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%d hours ago", hours)
+	}
+	if minutes > 0 {
+		return fmt.Sprintf("%d minutes ago", minutes)
+	}
+	return fmt.Sprintf("%d seconds ago", seconds)
+}
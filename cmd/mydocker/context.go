@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/AbhishekGY/mydocker/pkg/client"
+)
+
+// contextNamePattern is what context names may look like
+var contextNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// defaultContextName selects the daemon on --socket rather than a configured context
+const defaultContextName = "default"
+
+// daemonContext is a named daemon the CLI can talk to
+type daemonContext struct {
+	Host        string `json:"host"`
+	Description string `json:"description,omitempty"`
+}
+
+// contextConfig is the contexts file: the configured daemons and which one commands use by default
+type contextConfig struct {
+	Current  string                   `json:"current,omitempty"` // Empty to use --socket
+	Contexts map[string]daemonContext `json:"contexts"`
+}
+
+// contextConfigPath returns the contexts file, under MYDOCKER_CONFIG if set
+func contextConfigPath() (string, error) {
+	dir := os.Getenv("MYDOCKER_CONFIG")
+	if dir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find config directory: %v", err)
+		}
+		dir = filepath.Join(configDir, "mydocker")
+	}
+	return filepath.Join(dir, "contexts.json"), nil
+}
+
+// loadContexts reads the contexts file; a missing file means no contexts
+func loadContexts() (*contextConfig, error) {
+	cfg := &contextConfig{Contexts: map[string]daemonContext{}}
+
+	path, err := contextConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contexts: %v", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if cfg.Contexts == nil {
+		cfg.Contexts = map[string]daemonContext{}
+	}
+	return cfg, nil
+}
+
+// save writes the contexts file
+func (cfg *contextConfig) save() error {
+	path, err := contextConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal contexts: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write contexts: %v", err)
+	}
+	return nil
+}
+
+// names returns the context names in sorted order
+func (cfg *contextConfig) names() []string {
+	names := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectedHost returns the daemon address the global flags and current context select, empty for --socket
+// In order of precedence: --host, --context, MYDOCKER_HOST, MYDOCKER_CONTEXT, then the current context
+func selectedHost() (string, error) {
+	if globalOpts.host != "" {
+		return globalOpts.host, nil
+	}
+	name := globalOpts.context
+	if name == "" {
+		if host := os.Getenv("MYDOCKER_HOST"); host != "" {
+			return host, nil
+		}
+		name = os.Getenv("MYDOCKER_CONTEXT")
+	}
+
+	cfg, err := loadContexts()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = cfg.Current
+	}
+	if name == "" || name == defaultContextName {
+		return "", nil
+	}
+
+	ctx, ok := cfg.Contexts[name]
+	if !ok {
+		return "", fmt.Errorf("context %q does not exist", name)
+	}
+	return ctx.Host, nil
+}
+
+func contextCreateCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	host := fs.String("host", "", "Daemon address, e.g. ssh://user@host or unix:///var/run/mydocker.sock")
+	description := fs.String("description", "", "Description of the context")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() != 1 {
+		cmd.usageError("Context name required")
+	}
+	name := fs.Arg(0)
+	if !contextNamePattern.MatchString(name) {
+		cmd.usageError("invalid context name %q, only [a-zA-Z0-9][a-zA-Z0-9_.-] are allowed", name)
+	}
+	if name == defaultContextName {
+		cmd.usageError("%q is reserved for the --socket daemon", name)
+	}
+	if *host == "" {
+		cmd.usageError("--host flag is required")
+	}
+	if _, err := client.NewClient(client.WithHost(*host)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := loadContexts()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading contexts: %v\n", err)
+		os.Exit(1)
+	}
+	if _, exists := cfg.Contexts[name]; exists {
+		fmt.Fprintf(os.Stderr, "Error: context %q already exists\n", name)
+		os.Exit(1)
+	}
+	cfg.Contexts[name] = daemonContext{Host: *host, Description: *description}
+	if err := cfg.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving contexts: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(name)
+}
+
+func contextListCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	cfg, err := loadContexts()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading contexts: %v\n", err)
+		os.Exit(1)
+	}
+
+	type contextInfo struct {
+		Name        string
+		Current     bool
+		Host        string
+		Description string
+	}
+	contexts := []contextInfo{{
+		Name:        defaultContextName,
+		Current:     cfg.Current == "",
+		Host:        "unix://" + globalOpts.socket,
+		Description: "Daemon on --socket",
+	}}
+	for _, name := range cfg.names() {
+		ctx := cfg.Contexts[name]
+		contexts = append(contexts, contextInfo{Name: name, Current: name == cfg.Current, Host: ctx.Host, Description: ctx.Description})
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, contexts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tDESCRIPTION")
+	for _, ctx := range contexts {
+		name := ctx.Name
+		if ctx.Current {
+			name += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, ctx.Host, ctx.Description)
+	}
+	w.Flush()
+}
+
+func contextUseCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() != 1 {
+		cmd.usageError("Context name required")
+	}
+	name := fs.Arg(0)
+
+	cfg, err := loadContexts()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading contexts: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The default context goes back to the socket from --socket or MYDOCKER_SOCKET
+	if name == defaultContextName {
+		name = ""
+	} else if _, ok := cfg.Contexts[name]; !ok {
+		fmt.Fprintf(os.Stderr, "Error: context %q does not exist\n", name)
+		os.Exit(1)
+	}
+	cfg.Current = name
+	if err := cfg.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving contexts: %v\n", err)
+		os.Exit(1)
+	}
+
+	if name == "" {
+		fmt.Println("Using the default socket")
+	} else {
+		fmt.Printf("Current context is now %q\n", name)
+	}
+}
+
+func contextRemoveCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Context name required")
+	}
+
+	cfg, err := loadContexts()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading contexts: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, name := range fs.Args() {
+		if _, ok := cfg.Contexts[name]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: context %q does not exist\n", name)
+			failed = true
+			continue
+		}
+		delete(cfg.Contexts, name)
+		if cfg.Current == name {
+			cfg.Current = ""
+		}
+		fmt.Println(name)
+	}
+	if err := cfg.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving contexts: %v\n", err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// contextClient is a client for one configured context
+type contextClient struct {
+	name string
+	cli  *client.Client
+}
+
+// allContextClients returns a client for every configured context, in name order
+func allContextClients() ([]contextClient, error) {
+	cfg, err := loadContexts()
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Contexts) == 0 {
+		return nil, fmt.Errorf("no contexts configured, add some with 'mydocker context create'")
+	}
+
+	clients := make([]contextClient, 0, len(cfg.Contexts))
+	for _, name := range cfg.names() {
+		cli, err := client.NewClient(client.WithHost(cfg.Contexts[name].Host))
+		if err != nil {
+			return nil, fmt.Errorf("context %s: %v", name, err)
+		}
+		clients = append(clients, contextClient{name: name, cli: cli})
+	}
+	return clients, nil
+}
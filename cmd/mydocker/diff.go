@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/client"
+	"github.com/AbhishekGY/mydocker/pkg/formatter"
+)
+
+// diffIgnoredFields differ between any two containers, so comparing them says nothing about configuration
+var diffIgnoredFields = map[string]bool{
+	"id":                true,
+	"pid":               true,
+	"created":           true,
+	"started":           true,
+	"exited":            true,
+	"exit_code":         true,
+	"network_namespace": true,
+	"network":           true,
+	"history":           true,
+}
+
+// configDifference is a field whose value differs between two containers; a nil value means it is unset
+type configDifference struct {
+	Field  string `json:"field"`
+	First  any    `json:"first"`
+	Second any    `json:"second"`
+}
+
+// diffInspect compares the details of two containers field by field, sorted by field
+// Nested objects are compared per key and environment variables per name, so a single changed setting
+// shows up on its own; other lists are compared as a whole
+func diffInspect(first, second *api.ContainerInspect) ([]configDifference, error) {
+	a, err := flattenInspect(first)
+	if err != nil {
+		return nil, err
+	}
+	b, err := flattenInspect(second)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool, len(a)+len(b))
+	for field := range a {
+		fields[field] = true
+	}
+	for field := range b {
+		fields[field] = true
+	}
+
+	diffs := []configDifference{}
+	for field := range fields {
+		if !reflect.DeepEqual(a[field], b[field]) {
+			diffs = append(diffs, configDifference{Field: field, First: a[field], Second: b[field]})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs, nil
+}
+
+// flattenInspect maps the dotted JSON path of every set field of a container's details to its value
+func flattenInspect(inspect *api.ContainerInspect) (map[string]any, error) {
+	data, err := json.Marshal(inspect)
+	if err != nil {
+		return nil, err
+	}
+	var details map[string]any
+	if err := json.Unmarshal(data, &details); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]any)
+	for key, value := range details {
+		if diffIgnoredFields[key] {
+			continue
+		}
+		if env, ok := value.([]any); ok && key == "env" {
+			for _, kv := range env {
+				name, val, _ := strings.Cut(fmt.Sprint(kv), "=")
+				fields["env."+name] = val
+			}
+			continue
+		}
+		flattenValue(fields, key, value)
+	}
+	return fields, nil
+}
+
+// flattenValue adds value to fields under path, descending into objects
+func flattenValue(fields map[string]any, path string, value any) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		fields[path] = value
+		return
+	}
+	for key, v := range object {
+		flattenValue(fields, path+"."+key, v)
+	}
+}
+
+// formatDiffValue renders a value of a configDifference for the table, "-" when unset
+func formatDiffValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// diffCommand prints the configuration fields that differ between two containers
+func diffCommand(cli client.APIClient, first, second string, out *formatter.Formatter) {
+	var details [2]*api.ContainerInspect
+	for i, ref := range []string{first, second} {
+		inspect, err := cli.ContainerInspect(context.Background(), ref, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error inspecting container: %v\n", err)
+			os.Exit(1)
+		}
+		details[i] = inspect
+	}
+
+	diffs, err := diffInspect(details[0], details[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error comparing containers: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, diffs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "FIELD\t%s\t%s\n", shortID(details[0].ID), shortID(details[1].ID))
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Field, formatDiffValue(d.First), formatDiffValue(d.Second))
+	}
+	w.Flush()
+}
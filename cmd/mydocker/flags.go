@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// stringSlice is a repeatable string flag
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// parseEnvFile reads KEY=VALUE lines from an env file
+// Blank lines and lines starting with # are skipped; a bare KEY takes its value from the client's environment
+func parseEnvFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %v", err)
+	}
+	defer file.Close()
+
+	var env []string
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: invalid variable name %q", path, lineNum, key)
+		}
+
+		if !hasValue {
+			// Pass through from the client's environment if set
+			if v, ok := os.LookupEnv(key); ok {
+				env = append(env, key+"="+v)
+			}
+			continue
+		}
+		env = append(env, key+"="+value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %v", err)
+	}
+
+	return env, nil
+}
+
+// parseEnvFlag expands a -e value: KEY=VALUE is used as is, a bare KEY takes the client's value
+func parseEnvFlag(value string) (string, bool) {
+	if strings.Contains(value, "=") {
+		return value, true
+	}
+	if v, ok := os.LookupEnv(value); ok {
+		return value + "=" + v, true
+	}
+	return "", false
+}
+
+// parseSecretFlag parses "src=/path[,target=name]" (or just "/path") into a secret
+func parseSecretFlag(value string) (api.Secret, error) {
+	var secret api.Secret
+
+	for _, field := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			if secret.Source != "" {
+				return secret, fmt.Errorf("invalid secret field %q", field)
+			}
+			secret.Source = field
+			continue
+		}
+
+		switch key {
+		case "src", "source":
+			secret.Source = val
+		case "target", "dst", "destination":
+			secret.Target = val
+		default:
+			return secret, fmt.Errorf("unknown secret option %q", key)
+		}
+	}
+
+	if secret.Source == "" {
+		return secret, fmt.Errorf("secret source is required")
+	}
+
+	// The daemon reads the file, so send an absolute path
+	if !strings.HasPrefix(secret.Source, "/") {
+		wd, err := os.Getwd()
+		if err != nil {
+			return secret, err
+		}
+		secret.Source = wd + "/" + secret.Source
+	}
+
+	return secret, nil
+}
+
+// parseMountFlag parses a --mount value: type=TYPE,src=/path,dst=/path followed by options such as ro or size=64m
+func parseMountFlag(value string) (api.Mount, error) {
+	mount := api.Mount{Type: "bind"}
+
+	for _, field := range strings.Split(value, ",") {
+		key, val, _ := strings.Cut(field, "=")
+		switch key {
+		case "type":
+			mount.Type = val
+		case "src", "source":
+			mount.Source = val
+		case "dst", "destination", "target":
+			mount.Destination = val
+		case "readonly":
+			mount.Options = append(mount.Options, "ro")
+		default:
+			mount.Options = append(mount.Options, field)
+		}
+	}
+
+	if mount.Destination == "" {
+		return mount, fmt.Errorf("mount destination is required")
+	}
+	if mount.Type == "bind" && mount.Source == "" {
+		return mount, fmt.Errorf("bind mount source is required")
+	}
+	if mount.Type != "bind" && mount.Source == "" {
+		mount.Source = mount.Type
+	}
+
+	// The daemon resolves bind sources, so send an absolute path
+	if mount.Type == "bind" && !strings.HasPrefix(mount.Source, "/") {
+		wd, err := os.Getwd()
+		if err != nil {
+			return mount, err
+		}
+		mount.Source = wd + "/" + mount.Source
+	}
+
+	return mount, nil
+}
+
+// parseLabels converts KEY=VALUE label flags into a map
+func parseLabels(values []string) (map[string]string, error) {
+	labels := make(map[string]string, len(values))
+	for _, value := range values {
+		key, val, _ := strings.Cut(value, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid --label %q, expected KEY=VALUE", value)
+		}
+		labels[key] = val
+	}
+	return labels, nil
+}
+
+// parseLogOpts converts KEY=VALUE --log-opt flags into a map
+func parseLogOpts(values []string) (map[string]string, error) {
+	opts := make(map[string]string, len(values))
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --log-opt %q, expected KEY=VALUE", value)
+		}
+		opts[key] = val
+	}
+	return opts, nil
+}
+
+// parsePressureThreshold parses a --pressure-threshold value like "memory.some=10"
+func parsePressureThreshold(value string) (string, float64, error) {
+	key, percent, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return "", 0, fmt.Errorf("expected <cpu|memory|io>.<some|full>=PERCENT")
+	}
+
+	threshold, err := strconv.ParseFloat(strings.TrimSuffix(percent, "%"), 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid percentage %q", percent)
+	}
+	return key, threshold, nil
+}
+
+// parseHugetlbLimit parses a --hugetlb-limit value like "2MB=1073741824"
+func parseHugetlbLimit(value string) (string, uint64, error) {
+	size, limit, ok := strings.Cut(value, "=")
+	if !ok || size == "" {
+		return "", 0, fmt.Errorf("expected PAGESIZE=BYTES, e.g. 2MB=1073741824")
+	}
+
+	bytes, err := strconv.ParseUint(limit, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid byte count %q", limit)
+	}
+	return strings.ToUpper(size), bytes, nil
+}
@@ -4,193 +4,641 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"text/tabwriter"
-	"time"
 
-	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/client"
+	"github.com/AbhishekGY/mydocker/pkg/formatter"
 )
 
-const defaultSocketPath = "/var/run/mydocker.sock"
+// globalOpts holds the parsed global flags
+var globalOpts globalOptions
 
 func main() {
-	if len(os.Args) < 2 {
-		printUsage()
+	root := newRootCommand()
+
+	// Parse global flags that come before the command
+	// Their usage is the root help, so --help lists the commands along with the global flags
+	globalFlags := globalFlagSet(&globalOpts)
+	globalFlags.Usage = func() {
+		root.printHelp(nil)
+	}
+	if err := globalFlags.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
 		os.Exit(1)
 	}
 
-	// Get subcommand
-	subcommand := os.Args[1]
+	root.execute(globalFlags.Args())
+}
 
-	switch subcommand {
-	case "run":
-		runCommand()
-	case "ps":
-		psCommand()
-	case "stop":
-		stopCommand()
-	default:
-		fmt.Printf("Unknown command: %s\n", subcommand)
-		printUsage()
-		os.Exit(1)
+// newRootCommand builds the full CLI command tree
+func newRootCommand() *command {
+	root := &command{
+		name:  "mydocker",
+		short: "A minimal container runtime client",
+		examples: []string{
+			"mydocker run --rootfs /tmp/mydocker-rootfs /bin/sh",
+			"mydocker run -d --memory 536870912 --rootfs /tmp/mydocker-rootfs /bin/sleep 300",
+			"mydocker ps --format '{{.ID}}\\t{{.Status}}'",
+			"mydocker container stop <container-id>",
+			"mydocker system debug --output ./debug",
+			"mydocker -H ssh://admin@build-01 ps",
+			"mydocker --all-contexts ps",
+			"source <(mydocker completion bash)",
+		},
 	}
-}
 
-func printUsage() {
-	fmt.Println("Usage: mydocker [command] [args...]")
-	fmt.Println("Commands:")
-	fmt.Println("  run     Create and run a new container")
-	fmt.Println("  ps      List containers")
-	fmt.Println("  stop    Stop a running container")
-	fmt.Println("\nResource limit flags for 'run' command:")
-	fmt.Println("  --memory BYTES         Memory limit in bytes (e.g., 536870912 for 512MB)")
-	fmt.Println("  --memory-swap BYTES    Memory + Swap limit in bytes")
-	fmt.Println("  --cpu-shares NUM       CPU shares (relative weight, default 1024)")
-	fmt.Println("  --cpu-quota MICROS     CPU quota in microseconds (-1 for unlimited)")
-	fmt.Println("  --cpu-period MICROS    CPU period in microseconds (default 100000)")
-	fmt.Println("  --pids-limit NUM       Maximum number of PIDs/processes")
-	fmt.Println("  --rootfs PATH          Path to the rootfs directory (required)")
-	fmt.Println("  -d, --detach           Run container in detached mode (background)")
-	fmt.Println("\nExamples:")
-	fmt.Println("  mydocker run --rootfs /tmp/mydocker-rootfs /bin/sh")
-	fmt.Println("  mydocker run -d --memory 536870912 --rootfs /tmp/mydocker-rootfs /bin/sleep 300")
-	fmt.Println("  mydocker ps")
-	fmt.Println("  mydocker stop <container-id>")
-}
+	containerCmd := &command{
+		name:  "container",
+		short: "Manage containers",
+	}
+	containerCmd.addCommands(containerCommands(false)...)
 
-func runCommand() {
-	// Create a new FlagSet for the run command
-	runFlags := flag.NewFlagSet("run", flag.ExitOnError)
-
-	// Define resource limit flags
-	memory := runFlags.Uint64("memory", 0, "Memory limit in bytes")
-	memorySwap := runFlags.Uint64("memory-swap", 0, "Memory + Swap limit in bytes")
-	cpuShares := runFlags.Uint64("cpu-shares", 1024, "CPU shares (relative weight)")
-	cpuQuota := runFlags.Int64("cpu-quota", -1, "CPU quota in microseconds")
-	cpuPeriod := runFlags.Uint64("cpu-period", 100000, "CPU period in microseconds")
-	pidsLimit := runFlags.Int64("pids-limit", 0, "Maximum number of PIDs/processes")
-	rootfs := runFlags.String("rootfs", "", "Path to the rootfs directory")
-	detach := runFlags.Bool("d", false, "Run container in detached mode (background)")
-	runFlags.Bool("detach", false, "Run container in detached mode (background)")
-
-	// Parse flags (skip "mydocker" and "run")
-	if err := runFlags.Parse(os.Args[2:]); err != nil {
-		fmt.Printf("Error parsing flags: %v\n", err)
-		os.Exit(1)
+	systemCmd := &command{
+		name:  "system",
+		short: "Manage the daemon",
 	}
+	systemCmd.addCommands(
+		&command{
+			name:  "debug",
+			usage: "[flags]",
+			short: "Capture goroutine/heap profiles and a daemon state dump (daemon must run with --debug)",
+			run:   systemDebugCommand,
+		},
+		newEventsCommand(),
+		&command{
+			name:  "reload",
+			short: "Reload the daemon configuration file",
+			run:   systemReloadCommand,
+		},
+		&command{
+			name:     "reconcile",
+			usage:    "[flags]",
+			short:    "Clean up cgroups and mounts left behind by crashed containers",
+			examples: []string{"mydocker system reconcile --dry-run"},
+			run:      systemReconcileCommand,
+		},
+		&command{
+			name:  "queue",
+			usage: "[flags]",
+			short: "Show host utilization and container starts waiting for admission",
+			run:   systemQueueCommand,
+		},
+		&command{
+			name:  "info",
+			usage: "[flags]",
+			short: "Show the daemon's API mode and how many containers, pods, services and schedules it manages",
+			run:   systemInfoCommand,
+		},
+		&command{
+			name:  "mode",
+			usage: "[flags] [normal|read-only|maintenance]",
+			short: "Show or switch the API mode; read-only accepts only reads, maintenance rejects new containers but allows stops",
+			examples: []string{
+				"mydocker system mode maintenance --reason 'kernel upgrade'",
+				"mydocker system mode read-only",
+				"mydocker system mode normal",
+			},
+			run: systemModeCommand,
+		},
+		&command{
+			name:  "drain",
+			usage: "[flags]",
+			short: "Stop accepting new containers and stop the running ones, dependents first, e.g. before a host reboot",
+			examples: []string{
+				"mydocker system drain --parallelism 4 --timeout 30s --deadline 5m",
+				"mydocker system drain --reason 'kernel upgrade' --format json",
+			},
+			run: systemDrainCommand,
+		},
+		&command{
+			name:  "dial-stdio",
+			short: "Proxy stdin and stdout to the daemon's socket (used by the CLI on the other end of an ssh:// host)",
+			run:   systemDialStdioCommand,
+		},
+	)
 
-	// Get the remaining arguments (command and args)
-	remainingArgs := runFlags.Args()
-	if len(remainingArgs) < 1 {
-		fmt.Println("Error: No command specified")
-		fmt.Println("Usage: mydocker run [flags] <command> [args...]")
-		runFlags.PrintDefaults()
-		os.Exit(1)
+	completionCmd := &command{
+		name:  "completion",
+		short: "Generate shell completion scripts",
 	}
+	completionCmd.addCommands(
+		&command{
+			name:     "bash",
+			short:    "Generate the bash completion script",
+			examples: []string{"source <(mydocker completion bash)"},
+			run:      completionBashCommand,
+		},
+		&command{
+			name:     "zsh",
+			short:    "Generate the zsh completion script",
+			examples: []string{"source <(mydocker completion zsh)"},
+			run:      completionZshCommand,
+		},
+	)
 
-	if *rootfs == "" {
-		fmt.Println("Error: --rootfs flag is required")
-		os.Exit(1)
+	sessionCmd := &command{
+		name:  "session",
+		short: "Inspect recorded PTY sessions (daemon must run with record-sessions)",
 	}
+	sessionCmd.addCommands(
+		&command{
+			name:  "ls",
+			usage: "<container-id>",
+			short: "List the recorded sessions of a container",
+			run:   sessionListCommand,
+		},
+		&command{
+			name:  "replay",
+			usage: "[flags] <container-id> [session]",
+			short: "Replay a recorded session, the most recent one by default",
+			examples: []string{
+				"mydocker session replay <container-id>",
+				"mydocker session replay --speed 2 --idle-limit 1s <container-id> <session>",
+				"mydocker session replay --raw <container-id> <session> > session.cast",
+			},
+			run: sessionReplayCommand,
+		},
+	)
 
-	// Create client
-	client := api.NewClient(defaultSocketPath)
+	scheduleCmd := &command{
+		name:  "schedule",
+		short: "Run containers on cron schedules",
+	}
+	scheduleCmd.addCommands(
+		&command{
+			name:  "create",
+			usage: "--cron <expr> [flags] <command> [args...]",
+			short: "Create a schedule that runs a container whenever the cron expression matches",
+			examples: []string{
+				"mydocker schedule create --cron '0 * * * *' --rootfs /srv/rootfs/alpine /bin/backup.sh",
+				"mydocker schedule create --cron '*/15 9-17 * * mon-fri' --rootfs alpine --memory 67108864 /bin/poll",
+				"mydocker schedule create --cron @daily --disabled --rootfs alpine /bin/report",
+			},
+			run: scheduleCreateCommand,
+		},
+		&command{
+			name:    "ls",
+			aliases: []string{"list"},
+			usage:   "[flags]",
+			short:   "List schedules with their next and last runs",
+			run:     scheduleListCommand,
+		},
+		&command{
+			name:  "inspect",
+			usage: "[flags] <schedule-id>",
+			short: "Show a schedule's details, or its recent runs with --runs",
+			run:   scheduleInspectCommand,
+		},
+		&command{
+			name:  "enable",
+			usage: "<schedule-id>...",
+			short: "Enable schedules",
+			run:   scheduleEnableCommand(true),
+		},
+		&command{
+			name:  "disable",
+			usage: "<schedule-id>...",
+			short: "Disable schedules; runs already started keep running",
+			run:   scheduleEnableCommand(false),
+		},
+		&command{
+			name:  "rm",
+			usage: "<schedule-id>...",
+			short: "Remove schedules; containers they started are kept",
+			run:   scheduleRemoveCommand,
+		},
+	)
 
-	// Build request
-	req := api.ContainerCreateRequest{
-		Image:      *rootfs, // Using rootfs as image for now
-		Command:    remainingArgs,
-		Rootfs:     *rootfs,
-		Memory:     *memory,
-		MemorySwap: *memorySwap,
-		CpuShares:  *cpuShares,
-		CpuQuota:   *cpuQuota,
-		CpuPeriod:  *cpuPeriod,
-		PidsLimit:  *pidsLimit,
-		Detach:     *detach,
+	serviceCmd := &command{
+		name:  "service",
+		short: "Keep a number of replicas of a container running",
 	}
+	serviceCmd.addCommands(
+		&command{
+			name:  "create",
+			usage: "--name <name> [flags] <command> [args...]",
+			short: "Create a service that keeps replicas of a container running",
+			examples: []string{
+				"mydocker service create --name web --replicas 3 --rootfs alpine /bin/httpd -f",
+				"mydocker service create --name worker --rootfs alpine --memory 67108864 /bin/worker",
+			},
+			run: serviceCreateCommand,
+		},
+		&command{
+			name:    "ls",
+			aliases: []string{"list"},
+			usage:   "[flags]",
+			short:   "List services with their running and desired replicas",
+			run:     serviceListCommand,
+		},
+		&command{
+			name:  "ps",
+			usage: "[flags] <service>",
+			short: "List a service's replica containers",
+			run:   servicePsCommand,
+		},
+		&command{
+			name:  "inspect",
+			usage: "[flags] <service>",
+			short: "Show a service's details",
+			run:   serviceInspectCommand,
+		},
+		&command{
+			name:  "scale",
+			usage: "<service> <replicas> | <service>=<replicas>...",
+			short: "Change the number of replicas services keep running",
+			examples: []string{
+				"mydocker service scale web 5",
+				"mydocker service scale web=5 worker=2",
+			},
+			run: serviceScaleCommand,
+		},
+		&command{
+			name:  "update",
+			usage: "--image <rootfs> [flags] <service>",
+			short: "Roll a service's replicas over to a new rootfs, rolling back if a new replica fails",
+			examples: []string{
+				"mydocker service update --image alpine-3.20 web",
+				"mydocker service update --image /srv/rootfs/web-v2 --parallelism 2 --monitor 30s web",
+				"mydocker service update --image web-v2 --parallelism 0 web",
+			},
+			run: serviceUpdateCommand,
+		},
+		&command{
+			name:  "rm",
+			usage: "<service>...",
+			short: "Remove services and their replicas",
+			run:   serviceRemoveCommand,
+		},
+	)
 
-	// Create container
-	id, err := client.CreateContainer(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating container: %v\n", err)
-		os.Exit(1)
+	podCmd := &command{
+		name:  "pod",
+		short: "Group containers that share network, PID, IPC and UTS namespaces and a lifecycle",
 	}
+	podCmd.addCommands(
+		&command{
+			name:  "create",
+			usage: "--name <name> --rootfs <rootfs> [flags] [infra-command [args...]]",
+			short: "Create a pod and start its infra container, which holds the namespaces (default command: sleep infinity)",
+			examples: []string{
+				"mydocker pod create --name web --rootfs /tmp/mydocker-rootfs",
+				"mydocker pod create --name web --label tier=frontend --rootfs alpine /bin/sleep infinity",
+			},
+			run: podCreateCommand,
+		},
+		&command{
+			name:  "add",
+			usage: "[flags] <pod> <command> [args...]",
+			short: "Create and start a container in a pod, sharing its namespaces",
+			examples: []string{
+				"mydocker pod add --rootfs /tmp/mydocker-rootfs web /bin/httpd -f -p 8080",
+				"mydocker pod add --rootfs /tmp/mydocker-rootfs --log-driver fluentd web /bin/log-shipper",
+				"mydocker pod add --init --rootfs /tmp/mydocker-rootfs web /bin/migrate-db",
+			},
+			run: podAddCommand,
+		},
+		&command{
+			name:    "ls",
+			aliases: []string{"list"},
+			usage:   "[flags]",
+			short:   "List pods with their running and total containers",
+			run:     podListCommand,
+		},
+		&command{
+			name:  "ps",
+			usage: "[flags] <pod>",
+			short: "List a pod's containers",
+			run:   podPsCommand,
+		},
+		&command{
+			name:  "inspect",
+			usage: "[flags] <pod>",
+			short: "Show a pod's details",
+			run:   podInspectCommand,
+		},
+		&command{
+			name:  "start",
+			usage: "<pod>...",
+			short: "Start pods: the infra container first, then the init containers in order, then the others",
+			run:   podStartCommand,
+		},
+		&command{
+			name:  "stop",
+			usage: "<pod>...",
+			short: "Stop pods: their containers first, then the infra container",
+			run:   podStopCommand,
+		},
+		&command{
+			name:  "rm",
+			usage: "[flags] <pod>...",
+			short: "Remove pods and their containers",
+			examples: []string{
+				"mydocker pod rm web",
+				"mydocker pod rm -f web",
+			},
+			run: podRemoveCommand,
+		},
+	)
 
-	fmt.Println(id)
-}
+	presetCmd := &command{
+		name:  "preset",
+		short: "Manage container templates that run --template starts from",
+	}
+	presetCmd.addCommands(
+		&command{
+			name:  "create",
+			usage: "--name <name> [flags] [command] [args...]",
+			short: "Create a preset; anything left out is up to the containers started from it",
+			examples: []string{
+				"mydocker preset create --name webapp --rootfs alpine --memory 268435456 --mount src=/srv/www,dst=/www,ro /bin/httpd -f",
+				"mydocker preset create --name sandbox --pids-limit 64 --cpu-quota 50000 --rootfs alpine",
+			},
+			run: presetCreateCommand,
+		},
+		&command{
+			name:    "ls",
+			aliases: []string{"list"},
+			usage:   "[flags]",
+			short:   "List presets from the daemon config and the API",
+			run:     presetListCommand,
+		},
+		&command{
+			name:  "inspect",
+			usage: "[flags] <preset>",
+			short: "Show a preset's template",
+			run:   presetInspectCommand,
+		},
+		&command{
+			name:  "rm",
+			usage: "<preset>...",
+			short: "Remove presets created with 'preset create'; containers started from them are kept",
+			run:   presetRemoveCommand,
+		},
+	)
 
-func psCommand() {
-	// Create client
-	client := api.NewClient(defaultSocketPath)
+	netnsCmd := &command{
+		name:  "netns",
+		short: "Reach containers' network namespaces from the host",
+	}
+	netnsCmd.addCommands(
+		&command{
+			name:     "path",
+			usage:    "<container-id>",
+			short:    "Print the path of a running container's network namespace on the daemon's host",
+			examples: []string{"nsenter --net=$(mydocker netns path <container-id>) ss -tlnp"},
+			run:      netnsPathCommand,
+		},
+		&command{
+			name:  "exec",
+			usage: "<container-id> <command> [args...]",
+			short: "Run a host command in a container's network namespace (on the daemon's host, as root)",
+			examples: []string{
+				"mydocker netns exec <container-id> ip addr",
+				"mydocker netns exec <container-id> tcpdump -i lo",
+			},
+			run: netnsExecCommand,
+		},
+	)
 
-	// List containers
-	containers, err := client.ListContainers()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error listing containers: %v\n", err)
-		os.Exit(1)
+	contextCmd := &command{
+		name:  "context",
+		short: "Manage the daemons the CLI can talk to",
 	}
+	contextCmd.addCommands(
+		&command{
+			name:  "create",
+			usage: "--host <address> [flags] <name>",
+			short: "Create a context for a daemon",
+			examples: []string{
+				"mydocker context create --host ssh://admin@build-01 build-01",
+				"mydocker context create --host unix:///run/user/1000/mydocker.sock --description 'rootless' local",
+			},
+			run: contextCreateCommand,
+		},
+		&command{
+			name:    "ls",
+			aliases: []string{"list"},
+			usage:   "[flags]",
+			short:   "List contexts; the current one is marked with *",
+			run:     contextListCommand,
+		},
+		&command{
+			name:     "use",
+			usage:    "<name>",
+			short:    "Make a context the default for later commands ('default' for --socket)",
+			examples: []string{"mydocker context use build-01", "mydocker --all-contexts ps"},
+			run:      contextUseCommand,
+		},
+		&command{
+			name:  "rm",
+			usage: "<name>...",
+			short: "Remove contexts",
+			run:   contextRemoveCommand,
+		},
+	)
 
-	// Print containers in a table format
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "CONTAINER ID\tIMAGE\tCOMMAND\tSTATUS\tCREATED\tPID")
+	root.addCommands(containerCmd, systemCmd, sessionCmd, scheduleCmd, serviceCmd, podCmd, presetCmd, netnsCmd, contextCmd, completionCmd)
 
-	for _, container := range containers {
-		// Format created time
-		created := time.Unix(container.Created, 0)
-		createdStr := formatTimeSince(created)
+	// Top-level shortcuts for the most common container commands
+	root.addCommands(containerCommands(true)...)
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n",
-			container.ID,
-			container.Image,
-			container.Command,
-			container.Status,
-			createdStr,
-			container.PID,
-		)
-	}
+	root.addCommands(
+		newEventsCommand(),
+		&command{
+			name:  "debug",
+			usage: "--rootfs <rootfs> [flags] <container-id> [command...]",
+			short: "Start a throwaway shell from a debug rootfs in a running container's network, PID and IPC namespaces",
+			examples: []string{
+				"mydocker debug --rootfs /srv/rootfs/debug-tools <container-id>",
+				"mydocker debug --rootfs debug-tools <container-id> strace -p 1",
+			},
+			run: debugCommand,
+		},
+		&command{
+			name:  "adopt",
+			usage: "[flags] <pid>",
+			short: "Manage a running process started outside the daemon as a container",
+			examples: []string{
+				"mydocker adopt $(pgrep -f myservice)",
+				"mydocker adopt --memory 268435456 --label app=myservice <pid>",
+			},
+			run: adoptCommand,
+		},
+		&command{
+			name:  "version",
+			usage: "[flags]",
+			short: "Show client and daemon version information",
+			run:   versionCommand,
+		},
+		&command{
+			name:  "help",
+			usage: "[command...]",
+			short: "Show help for a command",
+			run:   helpCommand,
+		},
+	)
 
-	w.Flush()
+	return root
 }
 
-func stopCommand() {
-	if len(os.Args) < 3 {
-		fmt.Println("Error: Container ID required")
-		fmt.Println("Usage: mydocker stop <container-id>")
-		os.Exit(1)
+// newEventsCommand returns the events command, registered under "system" and at the top level
+func newEventsCommand() *command {
+	return &command{
+		name:  "events",
+		usage: "[flags]",
+		short: "Stream real-time events from the daemon",
+		examples: []string{
+			"mydocker events",
+			"mydocker events --format '{{.Action}} {{.ID}}'",
+			"mydocker events --since 2h --until 1h",
+			"mydocker events --filter container=3f2a --filter event=die",
+			"mydocker events --filter label=tier=web --filter image=/srv/rootfs/alpine",
+		},
+		run: eventsCommand,
 	}
+}
 
-	containerID := os.Args[2]
+// containerCommands returns the container subcommands
+// They are registered under "container" and as top-level shortcuts, where listing is called "ps"
+func containerCommands(topLevel bool) []*command {
+	listName, listAliases := "ls", []string{"ps", "list"}
+	if topLevel {
+		listName, listAliases = "ps", []string{"ls"}
+	}
 
-	// Create client
-	client := api.NewClient(defaultSocketPath)
+	return []*command{
+		{
+			name:  "run",
+			usage: "[flags] <command> [args...]",
+			short: "Create and run a new container",
+			examples: []string{
+				"mydocker run --rootfs /tmp/mydocker-rootfs /bin/sh",
+				"mydocker run -d --memory 536870912 --pids-limit 64 --rootfs /tmp/mydocker-rootfs /bin/sleep 300",
+				"mydocker run --env-file ./app.env -e DEBUG=1 --secret src=./db_password,target=db --rootfs /tmp/mydocker-rootfs /bin/sh",
+				"mydocker run -d --depends-on <db-container-id> --rootfs /tmp/mydocker-rootfs /bin/sleep 300",
+				"mydocker run -d --wait-for-path /dev/ttyUSB0 --wait-for-unit nfs.mount --rootfs /tmp/mydocker-rootfs /bin/logger",
+				"mydocker run -d --device-hotplug '/dev/ttyUSB*' --rootfs /tmp/mydocker-rootfs /bin/logger",
+				"mydocker run -d --template webapp -e PORT=8081",
+				"mydocker run -d --log-driver fluentd --log-opt fluentd-address=localhost:24224 --rootfs /tmp/mydocker-rootfs /bin/myservice",
+				"mydocker run -d --log-driver gelf --log-opt gelf-address=udp://localhost:12201 --log-opt mode=non-blocking --log-opt max-buffer-size=4194304 --rootfs /tmp/mydocker-rootfs /bin/myservice",
+				"mydocker run --daemonless --memory 268435456 --rootfs /tmp/mydocker-rootfs /bin/sh -c 'echo hello'",
+			},
+			run: runCommand,
+		},
+		{
+			name:    listName,
+			aliases: listAliases,
+			usage:   "[flags]",
+			short:   "List containers",
+			run:     psCommand,
+		},
+		{
+			name:  "start",
+			usage: "[flags] <container-id>",
+			short: "Start a stopped container and its dependencies",
+			examples: []string{
+				"mydocker start <container-id>",
+				"mydocker start -a <container-id>",
+			},
+			run: startCommand,
+		},
+		{
+			name:  "stop",
+			usage: "[flags] <container-id>",
+			short: "Stop a running container and its dependents",
+			examples: []string{
+				"mydocker stop <container-id>",
+				"mydocker stop --no-deps <container-id>",
+			},
+			run: stopCommand,
+		},
+		{
+			name:  "inspect",
+			usage: "[flags] <container-id>",
+			short: "Display detailed information about a container",
+			examples: []string{
+				"mydocker inspect <container-id>",
+				"mydocker inspect --history <container-id>",
+				"mydocker inspect --diff <container-id> <other-container-id>",
+			},
+			run: inspectCommand,
+		},
+		{
+			name:  "clone",
+			usage: "[flags] <container-id>",
+			short: "Create a stopped container with the configuration of an existing one",
+			examples: []string{
+				"mydocker clone <container-id>",
+				"mydocker clone --label env=staging <container-id> && mydocker start <new-container-id>",
+			},
+			run: cloneCommand,
+		},
+		{
+			name:  "stats",
+			usage: "[flags] <container-id>",
+			short: "Display a live stream of a container's resource usage",
+			examples: []string{
+				"mydocker stats <container-id>",
+				"mydocker stats --no-stream --format '{{.CPU.Percent}} {{.Memory.Usage}}' <container-id>",
+				"mydocker stats --since 1h <container-id>",
+			},
+			run: statsCommand,
+		},
+		{
+			name:  "logs",
+			usage: "[flags] <container-id>",
+			short: "Show the output a container has written",
+			examples: []string{
+				"mydocker logs <container-id>",
+				"mydocker logs --tail 20 --timestamps <container-id>",
+				"mydocker logs --since 10m <container-id>",
+				"mydocker logs -f <container-id>",
+				"mydocker logs --format json <container-id>",
+				"mydocker logs --format '{{.Stream}}: {{.Message}}' <container-id>",
+			},
+			run: logsCommand,
+		},
+		{
+			name:  "attach",
+			usage: "<container-id>",
+			short: "Attach to a running container's terminal",
+			run:   attachCommand,
+		},
+	}
+}
 
-	// Stop container
-	err := client.StopContainer(containerID)
+// newFormatter parses a --format flag, exiting on an invalid template
+func newFormatter(format string) *formatter.Formatter {
+	out, err := formatter.New(format)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error stopping container: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	fmt.Printf("Container %s stopped\n", containerID)
+	return out
 }
 
-// formatTimeSince formats the time since a given time in a human-readable format
-func formatTimeSince(t time.Time) string {
-	duration := time.Since(t)
-
-	seconds := int(duration.Seconds())
-	minutes := seconds / 60
-	hours := minutes / 60
-	days := hours / 24
-
-	if days > 0 {
-		return fmt.Sprintf("%d days ago", days)
+// newClient creates a daemon client for the socket, host or context selected by the global flags
+func newClient() *client.Client {
+	if globalOpts.allContexts {
+		fmt.Fprintln(os.Stderr, "Error: --all-contexts is only supported by ps")
+		os.Exit(1)
+	}
+	host, err := selectedHost()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-	if hours > 0 {
-		return fmt.Sprintf("%d hours ago", hours)
+
+	opts := []client.Opt{client.WithSocketPath(globalOpts.socket)}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
 	}
-	if minutes > 0 {
-		return fmt.Sprintf("%d minutes ago", minutes)
+	cli, err := client.NewClient(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
+		os.Exit(1)
 	}
-	return fmt.Sprintf("%d seconds ago", seconds)
+	return cli
 }
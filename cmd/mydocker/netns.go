@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+)
+
+func netnsPathCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() != 1 {
+		cmd.usageError("Container ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	netns, err := cli.ContainerNetns(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting network namespace: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(netns.Path)
+}
+
+func netnsExecCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 2 {
+		cmd.usageError("Container ID and command required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	netns, err := cli.ContainerNetns(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting network namespace: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The handle lives on the daemon's host, so this only works there
+	if _, err := os.Stat(netns.Path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s is not on this host; netns exec must run on the daemon's host\n", netns.Path)
+		os.Exit(1)
+	}
+
+	err = namespace.ExecInNetns(netns.Path, fs.Args()[1:])
+	fmt.Fprintf(os.Stderr, "Error running command: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/client"
+)
+
+func podCreateCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	name := fs.String("name", "", "Pod name, used to refer to the pod in other commands")
+	rootfs := fs.String("rootfs", "", "Rootfs of the infra container, as a path or the name of one in the daemon's rootfs directory")
+	var labelFlags stringSlice
+	fs.Var(&labelFlags, "label", "Set a pod label KEY=VALUE, also set on its infra container (repeatable)")
+	cmd.parseFlags(fs, args)
+
+	if *name == "" {
+		cmd.usageError("--name flag is required")
+	}
+	if *rootfs == "" {
+		cmd.usageError("--rootfs flag is required")
+	}
+	labels, err := parseLabels(labelFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	req := api.PodCreateRequest{Name: *name, Rootfs: absRootfs(*rootfs), Command: fs.Args(), Labels: labels}
+
+	// Create client
+	cli := newClient()
+
+	pod, err := cli.PodCreate(context.Background(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating pod: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(pod.ID)
+}
+
+func podAddCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	buildRequest := containerFlags(cmd, fs)
+	init := fs.Bool("init", false, "Add an init container, which runs to completion before the pod's other containers start (add init containers first)")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Pod name or ID required")
+	}
+
+	// The pod comes before the command, which the container flags take from the remaining arguments
+	req := buildRequest()
+	ref := req.Command[0]
+	req.Command = req.Command[1:]
+	if len(req.Command) == 0 && req.Preset == "" {
+		cmd.usageError("No command specified")
+	}
+	if *init {
+		req.Labels[api.InitLabel] = "true"
+	}
+
+	// Create client
+	cli := newClient()
+
+	resp, err := cli.PodAdd(context.Background(), ref, req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error adding container to pod: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(resp.ID)
+	if resp.Queued {
+		fmt.Fprintln(os.Stderr, "The container is queued and will start once it is admitted (see 'mydocker system queue')")
+	}
+}
+
+func podListCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	noTrunc := fs.Bool("no-trunc", false, "Don't truncate pod IDs")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	// Create client
+	cli := newClient()
+
+	pods, err := cli.PodList(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing pods: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, pods); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POD ID\tNAME\tSTATUS\tCONTAINERS\tINFRA ID\tCREATED")
+	for _, p := range pods {
+		id, infra := p.ID, p.Infra
+		if !*noTrunc {
+			id, infra = shortID(id), shortID(infra)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%s\n", id, p.Name, p.Status, p.Running, len(p.Containers), infra, formatTimeSince(p.Created))
+	}
+	w.Flush()
+}
+
+func podPsCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	noTrunc := fs.Bool("no-trunc", false, "Don't truncate container IDs")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Pod name or ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	pod, err := cli.PodInspect(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inspecting pod: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, pod.Containers); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER ID\tINIT\tCOMMAND\tSTATUS\tCREATED")
+	for _, c := range pod.Containers {
+		id := c.ID
+		if !*noTrunc {
+			id = shortID(id)
+		}
+		init, status := "-", c.Status
+		if c.Init {
+			init = "yes"
+		}
+		if c.ExitCode != nil && c.Status == "exited" {
+			status = fmt.Sprintf("exited (%d)", *c.ExitCode)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, init, strings.Join(c.Command, " "), status, formatTimeSince(c.Created))
+	}
+	w.Flush()
+}
+
+func podInspectCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Pod name or ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	pod, err := cli.PodInspect(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inspecting pod: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Details are printed as indented JSON unless a format is given
+	if out.IsTable() {
+		data, err := json.MarshalIndent(pod, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding pod details: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if err := out.Write(os.Stdout, pod); err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func podStartCommand(cmd *command, args []string) {
+	podLifecycleCommand(cmd, args, "starting", "started", func(cli client.APIClient, ref string) (*api.Pod, error) {
+		return cli.PodStart(context.Background(), ref)
+	})
+}
+
+func podStopCommand(cmd *command, args []string) {
+	podLifecycleCommand(cmd, args, "stopping", "stopped", func(cli client.APIClient, ref string) (*api.Pod, error) {
+		return cli.PodStop(context.Background(), ref)
+	})
+}
+
+// podLifecycleCommand applies a start or stop to every pod named in args
+func podLifecycleCommand(cmd *command, args []string, doing, done string, apply func(cli client.APIClient, ref string) (*api.Pod, error)) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Pod name or ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	failed := false
+	for _, ref := range fs.Args() {
+		pod, err := apply(cli, ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %s pod %s: %v\n", doing, ref, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Pod %s %s\n", pod.Name, done)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func podRemoveCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	force := fs.Bool("force", false, "Stop the pod's running containers before removing it")
+	fs.BoolVar(force, "f", false, "Stop the pod's running containers before removing it")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Pod name or ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	failed := false
+	for _, ref := range fs.Args() {
+		id, err := cli.PodRemove(context.Background(), ref, *force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing pod %s: %v\n", ref, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Pod %s removed\n", shortID(id))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+func presetCreateCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	name := fs.String("name", "", "Preset name, given to --template to start from it")
+	buildTemplate := containerTemplateFlags(cmd, fs)
+	cmd.parseFlags(fs, args)
+
+	if *name == "" {
+		cmd.usageError("--name flag is required")
+	}
+	req := api.PresetCreateRequest{Name: *name, Template: buildTemplate(true)}
+
+	// Create client
+	cli := newClient()
+
+	preset, err := cli.PresetCreate(context.Background(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating preset: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(preset.Name)
+}
+
+func presetListCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	// Create client
+	cli := newClient()
+
+	presets, err := cli.PresetList(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing presets: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, presets); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tROOTFS\tCOMMAND\tCREATED")
+	for _, p := range presets {
+		rootfs, command, created := "-", "-", "-"
+		if p.Template.Rootfs != "" {
+			rootfs = p.Template.Rootfs
+		}
+		if len(p.Template.Command) > 0 {
+			command = strings.Join(p.Template.Command, " ")
+		}
+		if !p.Created.IsZero() {
+			created = formatTimeSince(p.Created)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Name, p.Source, rootfs, command, created)
+	}
+	w.Flush()
+}
+
+func presetInspectCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Preset name required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	preset, err := cli.PresetInspect(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inspecting preset: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Details are printed as indented JSON unless a format is given
+	if out.IsTable() {
+		data, err := json.MarshalIndent(preset, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding preset details: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if err := out.Write(os.Stdout, preset); err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func presetRemoveCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Preset name required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	failed := false
+	for _, name := range fs.Args() {
+		if err := cli.PresetRemove(context.Background(), name); err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing preset %s: %v\n", name, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Preset %s removed\n", name)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+func scheduleCreateCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cron := fs.String("cron", "", "When to run, as a cron expression (\"0 * * * *\") or a macro such as @daily, in the daemon's time zone")
+	disabled := fs.Bool("disabled", false, "Create the schedule without enabling it")
+	buildRequest := containerFlags(cmd, fs)
+	cmd.parseFlags(fs, args)
+
+	if *cron == "" {
+		cmd.usageError("--cron flag is required")
+	}
+	req := api.ScheduleCreateRequest{Cron: *cron, Template: buildRequest(), Disabled: *disabled}
+
+	// Create client
+	cli := newClient()
+
+	schedule, err := cli.ScheduleCreate(context.Background(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating schedule: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(schedule.ID)
+}
+
+func scheduleListCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	noTrunc := fs.Bool("no-trunc", false, "Don't truncate schedule IDs")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	// Create client
+	cli := newClient()
+
+	schedules, err := cli.ScheduleList(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing schedules: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, schedules); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCHEDULE ID\tCRON\tCOMMAND\tSTATUS\tNEXT RUN\tLAST RUN")
+	for _, s := range schedules {
+		id := s.ID
+		if !*noTrunc {
+			id = shortID(id)
+		}
+
+		status, next := "enabled", "-"
+		if !s.Enabled {
+			status = "disabled"
+		}
+		if !s.NextRun.IsZero() {
+			next = s.NextRun.Format("2006-01-02 15:04")
+		}
+		last := "-"
+		if len(s.Runs) > 0 {
+			last = describeRun(s.Runs[len(s.Runs)-1])
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", id, s.Cron, strings.Join(s.Template.Command, " "), status, next, last)
+	}
+	w.Flush()
+}
+
+func scheduleInspectCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	runs := fs.Bool("runs", false, "Show the schedule's recent runs and their exit codes")
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Schedule ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	schedule, err := cli.ScheduleInspect(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inspecting schedule: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !*runs {
+		// Details are printed as indented JSON unless a format is given
+		if out.IsTable() {
+			data, err := json.MarshalIndent(schedule, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding schedule details: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+		if err := out.Write(os.Stdout, schedule); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, schedule.Runs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tCONTAINER ID\tEXIT CODE\tDURATION\tERROR")
+	for _, run := range schedule.Runs {
+		containerID, code, duration := "-", "-", "-"
+		if run.ContainerID != "" {
+			containerID = shortID(run.ContainerID)
+		}
+		if run.ExitCode != nil {
+			code = fmt.Sprint(*run.ExitCode)
+		}
+		if !run.Finished.IsZero() && run.ContainerID != "" {
+			duration = run.Finished.Sub(run.Started).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", run.Started.Format(time.RFC3339), containerID, code, duration, run.Error)
+	}
+	w.Flush()
+}
+
+// describeRun summarizes a schedule run for the LAST RUN column
+func describeRun(run api.ScheduleRun) string {
+	switch {
+	case run.ExitCode != nil:
+		return fmt.Sprintf("Exited (%d) %s", *run.ExitCode, formatTimeSince(run.Finished))
+	case run.ContainerID == "" && strings.HasPrefix(run.Error, "skipped"):
+		return fmt.Sprintf("Skipped %s", formatTimeSince(run.Started))
+	case run.Error != "":
+		return fmt.Sprintf("Failed %s", formatTimeSince(run.Started))
+	default:
+		return fmt.Sprintf("Started %s", formatTimeSince(run.Started))
+	}
+}
+
+// scheduleEnableCommand returns the run function of "schedule enable" or "schedule disable"
+func scheduleEnableCommand(enabled bool) func(cmd *command, args []string) {
+	return func(cmd *command, args []string) {
+		fs := cmd.flagSet()
+		cmd.parseFlags(fs, args)
+
+		if fs.NArg() < 1 {
+			cmd.usageError("Schedule ID required")
+		}
+
+		// Create client
+		cli := newClient()
+
+		failed := false
+		for _, id := range fs.Args() {
+			schedule, err := cli.ScheduleEnable(context.Background(), id, enabled)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error updating schedule %s: %v\n", id, err)
+				failed = true
+				continue
+			}
+			if enabled {
+				fmt.Printf("Schedule %s enabled\n", shortID(schedule.ID))
+			} else {
+				fmt.Printf("Schedule %s disabled\n", shortID(schedule.ID))
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	}
+}
+
+func scheduleRemoveCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Schedule ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	failed := false
+	for _, ref := range fs.Args() {
+		id, err := cli.ScheduleRemove(context.Background(), ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing schedule %s: %v\n", ref, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Schedule %s removed\n", shortID(id))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+func serviceCreateCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	name := fs.String("name", "", "Service name, used to refer to the service in other commands")
+	replicas := fs.Int("replicas", 1, "Number of containers to keep running")
+	buildRequest := containerFlags(cmd, fs)
+	cmd.parseFlags(fs, args)
+
+	if *name == "" {
+		cmd.usageError("--name flag is required")
+	}
+	req := api.ServiceCreateRequest{Name: *name, Replicas: *replicas, Template: buildRequest()}
+
+	// Create client
+	cli := newClient()
+
+	service, err := cli.ServiceCreate(context.Background(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating service: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(service.ID)
+}
+
+func serviceListCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	noTrunc := fs.Bool("no-trunc", false, "Don't truncate service IDs")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	// Create client
+	cli := newClient()
+
+	services, err := cli.ServiceList(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing services: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, services); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE ID\tNAME\tREPLICAS\tCOMMAND\tCREATED")
+	for _, s := range services {
+		id := s.ID
+		if !*noTrunc {
+			id = shortID(id)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n", id, s.Name, s.Running, s.Replicas, strings.Join(s.Template.Command, " "), formatTimeSince(s.Created))
+	}
+	w.Flush()
+}
+
+func servicePsCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	noTrunc := fs.Bool("no-trunc", false, "Don't truncate container IDs")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Service name or ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	service, err := cli.ServiceInspect(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inspecting service: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, service.Containers); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER ID\tVERSION\tSTATUS\tCREATED")
+	for _, replica := range service.Containers {
+		id := replica.ID
+		if !*noTrunc {
+			id = shortID(id)
+		}
+		version := fmt.Sprint(replica.Version)
+		if replica.Version != service.Version {
+			version += " (outdated)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", id, version, replica.Status, formatTimeSince(replica.Created))
+	}
+	w.Flush()
+}
+
+func serviceInspectCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Service name or ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	service, err := cli.ServiceInspect(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inspecting service: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Details are printed as indented JSON unless a format is given
+	if out.IsTable() {
+		data, err := json.MarshalIndent(service, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding service details: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if err := out.Write(os.Stdout, service); err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func serviceScaleCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	// Accept "scale web 3" as well as "scale web=3 db=1"
+	var targets [][2]string
+	switch {
+	case fs.NArg() == 2 && !strings.Contains(fs.Arg(0), "="):
+		targets = append(targets, [2]string{fs.Arg(0), fs.Arg(1)})
+	case fs.NArg() > 0:
+		for _, arg := range fs.Args() {
+			ref, replicas, ok := strings.Cut(arg, "=")
+			if !ok {
+				cmd.usageError("invalid scale %q: expected SERVICE=REPLICAS", arg)
+			}
+			targets = append(targets, [2]string{ref, replicas})
+		}
+	default:
+		cmd.usageError("Service name and replica count required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	failed := false
+	for _, target := range targets {
+		replicas, err := strconv.Atoi(target[1])
+		if err != nil || replicas < 0 {
+			fmt.Fprintf(os.Stderr, "Error scaling service %s: invalid replica count %q\n", target[0], target[1])
+			failed = true
+			continue
+		}
+		service, err := cli.ServiceScale(context.Background(), target[0], replicas)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scaling service %s: %v\n", target[0], err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Service %s scaled to %d\n", service.Name, service.Replicas)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func serviceUpdateCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	image := fs.String("image", "", "Rootfs to roll the replicas over to, as a path or the name of one in the daemon's rootfs directory")
+	fs.StringVar(image, "rootfs", "", "Alias for --image")
+	parallelism := fs.Int("parallelism", 1, "Replicas replaced at a time, 0 for all at once (blue/green)")
+	monitor := fs.Duration("monitor", 5*time.Second, "How long new replicas must keep running to count as healthy")
+	detach := fs.Bool("detach", false, "Return once the update has started instead of waiting for it to finish")
+	fs.BoolVar(detach, "d", false, "Return once the update has started instead of waiting for it to finish")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Service name or ID required")
+	}
+	if *image == "" {
+		cmd.usageError("--image flag is required")
+	}
+	if *parallelism < 0 {
+		cmd.usageError("--parallelism cannot be negative")
+	}
+	req := api.ServiceUpdateRequest{Rootfs: *image, Parallelism: *parallelism, Monitor: *monitor}
+
+	// Create client
+	cli := newClient()
+
+	service, err := cli.ServiceUpdate(context.Background(), fs.Arg(0), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error updating service: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Updating service %s to version %d\n", service.Name, service.Version)
+	if *detach {
+		return
+	}
+
+	// Follow the update until it settles, reporting replicas as they are replaced
+	state := api.ServiceUpdating
+	seen := make(map[string]bool)
+	for _, replica := range service.Containers {
+		seen[replica.ID] = true
+	}
+	for {
+		time.Sleep(time.Second)
+		service, err = cli.ServiceInspect(context.Background(), service.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error inspecting service: %v\n", err)
+			os.Exit(1)
+		}
+		for _, replica := range service.Containers {
+			if !seen[replica.ID] {
+				seen[replica.ID] = true
+				fmt.Printf("Started replica %s (version %d)\n", shortID(replica.ID), replica.Version)
+			}
+		}
+
+		status := service.UpdateStatus
+		if status.State != state {
+			state = status.State
+			switch state {
+			case api.ServiceRollingBack:
+				fmt.Printf("Rolling back: %s\n", status.Message)
+			case api.ServiceUpdateCompleted:
+				fmt.Println("Update completed")
+			case api.ServiceRollbackCompleted:
+				fmt.Printf("Rollback completed: %s\n", status.Message)
+			case api.ServiceUpdatePaused:
+				fmt.Printf("Update paused: %s\n", status.Message)
+			}
+		}
+
+		switch state {
+		case api.ServiceUpdateCompleted:
+			return
+		case api.ServiceRollbackCompleted, api.ServiceUpdatePaused:
+			os.Exit(1)
+		}
+	}
+}
+
+func serviceRemoveCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Service name or ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	failed := false
+	for _, ref := range fs.Args() {
+		id, err := cli.ServiceRemove(context.Background(), ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing service %s: %v\n", ref, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Service %s removed\n", shortID(id))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func sessionListCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+
+	// Create client
+	cli := newClient()
+
+	sessions, err := cli.ContainerSessions(context.Background(), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tSTARTED\tSIZE")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, formatTimeSince(s.Started), formatSize(s.Size))
+	}
+	w.Flush()
+}
+
+func sessionReplayCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	speed := fs.Float64("speed", 1, "Playback speed multiplier")
+	idleLimit := fs.Duration("idle-limit", 0, "Cap pauses between outputs to this duration (0 for no cap)")
+	raw := fs.Bool("raw", false, "Print the asciicast recording instead of playing it")
+	cmd.parseFlags(fs, args)
+
+	if fs.NArg() < 1 {
+		cmd.usageError("Container ID required")
+	}
+	if *speed <= 0 {
+		cmd.usageError("--speed must be greater than 0")
+	}
+
+	containerID := fs.Arg(0)
+
+	// Create client
+	cli := newClient()
+	ctx := context.Background()
+
+	// Without a session name, replay the most recent one
+	name := fs.Arg(1)
+	if name == "" {
+		sessions, err := cli.ContainerSessions(ctx, containerID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing sessions: %v\n", err)
+			os.Exit(1)
+		}
+		if len(sessions) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: container %s has no recorded sessions\n", containerID)
+			os.Exit(1)
+		}
+		name = sessions[len(sessions)-1].Name
+	}
+
+	recording, err := cli.ContainerSession(ctx, containerID, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching session: %v\n", err)
+		os.Exit(1)
+	}
+	defer recording.Close()
+
+	if *raw {
+		io.Copy(os.Stdout, recording)
+		return
+	}
+
+	if err := replaySession(recording, *speed, *idleLimit); err != nil {
+		fmt.Fprintf(os.Stderr, "Error replaying session: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// replaySession writes the output events of an asciicast v2 recording to stdout with their original timing
+// Input events are skipped; whatever the user typed shows up in the output through the PTY's echo
+func replaySession(recording io.Reader, speed float64, idleLimit time.Duration) error {
+	scanner := bufio.NewScanner(recording)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	// The first line is the header
+	if !scanner.Scan() {
+		return fmt.Errorf("empty recording")
+	}
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version != 2 {
+		return fmt.Errorf("not an asciicast v2 recording")
+	}
+
+	var last float64
+	for scanner.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || len(event) != 3 {
+			return fmt.Errorf("malformed event: %s", scanner.Text())
+		}
+		at, _ := event[0].(float64)
+		kind, _ := event[1].(string)
+		data, _ := event[2].(string)
+		if kind != "o" {
+			continue
+		}
+
+		delay := time.Duration((at - last) / speed * float64(time.Second))
+		if idleLimit > 0 && delay > idleLimit {
+			delay = idleLimit
+		}
+		time.Sleep(delay)
+		last = at
+
+		os.Stdout.WriteString(data)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/client"
+	"github.com/AbhishekGY/mydocker/pkg/version"
+)
+
+func systemDebugCommand(cmd *command, args []string) {
+	debugFlags := cmd.flagSet()
+	output := debugFlags.String("output", "", "Directory to write profiles and state dump to")
+	cmd.parseFlags(debugFlags, args)
+
+	dir := *output
+	if dir == "" {
+		dir = fmt.Sprintf("mydocker-debug-%s", time.Now().Format("20060102-150405"))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Create client
+	cli := newClient()
+	ctx := context.Background()
+
+	// Capture profiles: goroutine stacks as text, heap in pprof format
+	profiles := []struct {
+		name  string
+		level int
+		file  string
+	}{
+		{"goroutine", 2, "goroutine.txt"},
+		{"heap", 0, "heap.pprof"},
+	}
+
+	for _, p := range profiles {
+		path := filepath.Join(dir, p.file)
+		f, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", path, err)
+			os.Exit(1)
+		}
+
+		err = cli.DebugProfile(ctx, p.name, p.level, f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error capturing %s profile (is mydockerd running with --debug?): %v\n", p.name, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+
+	// Capture daemon state dump
+	state, err := cli.DebugState(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error capturing daemon state: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding daemon state: %v\n", err)
+		os.Exit(1)
+	}
+
+	statePath := filepath.Join(dir, "state.json")
+	if err := os.WriteFile(statePath, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", statePath, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s\n", statePath)
+
+	if state.DaemonLock == "held" {
+		fmt.Println("Warning: daemon lock was held while capturing state; container and runner lists were skipped")
+	}
+}
+
+func systemReloadCommand(cmd *command, args []string) {
+	cmd.parseFlags(cmd.flagSet(), args)
+
+	// Create client
+	cli := newClient()
+
+	changed, err := cli.SystemReload(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reloading daemon configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(changed) == 0 {
+		fmt.Println("Configuration reloaded, no settings changed")
+		return
+	}
+	fmt.Printf("Configuration reloaded, changed: %s\n", strings.Join(changed, ", "))
+}
+
+func systemReconcileCommand(cmd *command, args []string) {
+	reconcileFlags := cmd.flagSet()
+	dryRun := reconcileFlags.Bool("dry-run", false, "Only report what would be cleaned up")
+	cmd.parseFlags(reconcileFlags, args)
+
+	// Create client
+	cli := newClient()
+
+	resp, err := cli.SystemReconcile(context.Background(), *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reconciling daemon state: %v\n", err)
+		os.Exit(1)
+	}
+
+	verb := "Removed"
+	if resp.DryRun {
+		verb = "Would remove"
+	}
+	for _, id := range resp.Cgroups {
+		fmt.Printf("%s cgroup of container %s\n", verb, shortID(id))
+	}
+	for _, path := range resp.Mounts {
+		fmt.Printf("%s mount %s\n", verb, path)
+	}
+	if len(resp.Cgroups)+len(resp.Mounts) == 0 {
+		fmt.Println("No orphaned resources found")
+	}
+
+	for _, msg := range resp.Errors {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
+	}
+	if len(resp.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+func systemQueueCommand(cmd *command, args []string) {
+	queueFlags := cmd.flagSet()
+	format := queueFlags.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(queueFlags, args)
+	out := newFormatter(*format)
+
+	// Create client
+	cli := newClient()
+
+	queue, err := cli.AdmissionQueue(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting admission queue: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, queue); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !queue.Enabled {
+		fmt.Println("Admission control is disabled, containers only queue for host resources they wait for")
+	}
+	fmt.Printf("Host CPU:    %.1f%%%s\n", queue.CPUPercent, thresholdSuffix(queue.MaxCPUPercent))
+	fmt.Printf("Host memory: %.1f%%%s\n", queue.MemoryPercent, thresholdSuffix(queue.MaxMemoryPercent))
+	if queue.Saturated != "" {
+		fmt.Printf("Saturated:   %s\n", queue.Saturated)
+	}
+	if len(queue.Queued) == 0 {
+		return
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER ID\tQUEUED\tREASON")
+	for _, c := range queue.Queued {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", shortID(c.ID), formatTimeSince(c.Queued), c.Reason)
+	}
+	w.Flush()
+}
+
+func systemInfoCommand(cmd *command, args []string) {
+	infoFlags := cmd.flagSet()
+	format := infoFlags.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(infoFlags, args)
+	out := newFormatter(*format)
+
+	// Create client
+	cli := newClient()
+
+	info, err := cli.SystemInfo(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting daemon information: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, info); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Printf("Server version: %s\n", info.ServerVersion)
+	fmt.Printf("API mode:       %s%s\n", info.Mode, modeSuffix(info))
+	fmt.Printf("Containers:     %d\n", info.Containers)
+	fmt.Printf("  Running:      %d\n", info.ContainersRunning)
+	fmt.Printf("  Queued:       %d\n", info.ContainersQueued)
+	fmt.Printf("  Stopped:      %d\n", info.ContainersStopped)
+	fmt.Printf("Pods:           %d\n", info.Pods)
+	fmt.Printf("Services:       %d\n", info.Services)
+	fmt.Printf("Schedules:      %d\n", info.Schedules)
+	fmt.Printf("Data directory: %s\n", info.DataDir)
+	fmt.Printf("Cgroup version: v%s\n", info.CgroupVersion)
+	fmt.Printf("Debug:          %t\n", info.Debug)
+}
+
+func systemModeCommand(cmd *command, args []string) {
+	modeFlags := cmd.flagSet()
+	reason := modeFlags.String("reason", "", "Why the mode is set, shown to clients whose requests it rejects")
+	cmd.parseFlags(modeFlags, args)
+
+	// Create client
+	cli := newClient()
+
+	var info *api.SystemInfo
+	var err error
+	switch modeFlags.NArg() {
+	case 0:
+		info, err = cli.SystemInfo(context.Background())
+	case 1:
+		info, err = cli.SystemMode(context.Background(), modeFlags.Arg(0), *reason)
+	default:
+		cmd.usageError("Too many arguments")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting API mode: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s%s\n", info.Mode, modeSuffix(info))
+}
+
+func systemDrainCommand(cmd *command, args []string) {
+	drainFlags := cmd.flagSet()
+	parallelism := drainFlags.Int("parallelism", 1, "Number of containers to stop at once")
+	timeout := drainFlags.Duration("timeout", 5*time.Second, "How long each container has to exit after SIGTERM before it is killed")
+	deadline := drainFlags.Duration("deadline", 0, "How long the whole drain may take before remaining containers are killed (0 for no limit)")
+	reason := drainFlags.String("reason", "", "Why the host is drained, shown to clients whose requests maintenance mode rejects")
+	format := drainFlags.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(drainFlags, args)
+	out := newFormatter(*format)
+
+	if *parallelism < 1 {
+		cmd.usageError("--parallelism must be at least 1")
+	}
+	if *timeout < 0 || *deadline < 0 {
+		cmd.usageError("--timeout and --deadline must not be negative")
+	}
+
+	// Create client
+	cli := newClient()
+
+	resp, err := cli.SystemDrain(context.Background(), api.SystemDrainRequest{
+		Parallelism: *parallelism,
+		Timeout:     *timeout,
+		Deadline:    *deadline,
+		Reason:      *reason,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error draining daemon: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, resp); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		if len(resp.Containers) > 0 {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CONTAINER ID\tRESULT\tDURATION\tERROR")
+			for _, c := range resp.Containers {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", shortID(c.ID), c.Result, c.Duration.Round(time.Millisecond), c.Error)
+			}
+			w.Flush()
+			fmt.Println()
+		}
+		fmt.Printf("Drained in %s: %d stopped, %d killed, %d cancelled, %d failed\n",
+			resp.Duration.Round(time.Millisecond), resp.Stopped, resp.Killed, resp.Cancelled, resp.Failed)
+		if resp.DeadlineExceeded {
+			fmt.Println("The deadline passed before every container had stopped")
+		}
+		fmt.Printf("The daemon is in %s mode; run 'mydocker system mode normal' to accept new containers again\n", resp.Mode)
+	}
+
+	if resp.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// modeSuffix describes why and when the daemon was put in a restricted API mode
+func modeSuffix(info *api.SystemInfo) string {
+	if info.Mode == api.ModeNormal {
+		return ""
+	}
+	if info.ModeReason == "" {
+		return fmt.Sprintf(" (set %s)", formatTimeSince(info.ModeSince))
+	}
+	return fmt.Sprintf(" (%s, set %s)", info.ModeReason, formatTimeSince(info.ModeSince))
+}
+
+// thresholdSuffix describes an admission threshold, which isn't checked when zero
+func thresholdSuffix(max float64) string {
+	if max == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (queue above %.1f%%)", max)
+}
+
+// versionInfo is the value rendered by `mydocker version --format`
+type versionInfo struct {
+	Client api.VersionResponse
+	Server *api.VersionResponse
+}
+
+func versionCommand(cmd *command, args []string) {
+	versionFlags := cmd.flagSet()
+	format := versionFlags.String("format", "", "Format output using a Go template or 'json'")
+	cmd.parseFlags(versionFlags, args)
+	out := newFormatter(*format)
+
+	info := versionInfo{
+		Client: api.VersionResponse{
+			Version:       version.Version,
+			GitCommit:     version.GitCommit,
+			APIVersion:    version.APIVersion,
+			MinAPIVersion: version.MinAPIVersion,
+			GoVersion:     runtime.Version(),
+			Os:            runtime.GOOS,
+			Arch:          runtime.GOARCH,
+		},
+	}
+
+	// Create client
+	cli := newClient()
+
+	server, err := cli.ServerVersion(context.Background())
+	if err != nil && out.IsTable() {
+		printClientVersion(info.Client)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting daemon version: %v\n", err)
+		os.Exit(1)
+	}
+	info.Server = server
+
+	if !out.IsTable() {
+		if err := out.Write(os.Stdout, info); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		printClientVersion(info.Client)
+
+		fmt.Println("\nServer:")
+		fmt.Printf("  Version:      %s\n", server.Version)
+		fmt.Printf("  API version:  %s (minimum version %s)\n", server.APIVersion, server.MinAPIVersion)
+		fmt.Printf("  Go version:   %s\n", server.GoVersion)
+		fmt.Printf("  Git commit:   %s\n", server.GitCommit)
+		fmt.Printf("  OS/Arch:      %s/%s\n", server.Os, server.Arch)
+		fmt.Printf("  Kernel:       %s\n", server.KernelVersion)
+		fmt.Printf("  Cgroup:       %s (v%s)\n", server.CgroupDriver, server.CgroupVersion)
+	}
+
+	if err := version.CheckCompatibility(server.APIVersion, server.MinAPIVersion); err != nil {
+		fmt.Fprintf(os.Stderr, "\nWarning: client and daemon API versions are incompatible: %v\n", err)
+	}
+}
+
+// printClientVersion prints the client section of `mydocker version`
+func printClientVersion(client api.VersionResponse) {
+	fmt.Println("Client:")
+	fmt.Printf("  Version:      %s\n", client.Version)
+	fmt.Printf("  API version:  %s\n", client.APIVersion)
+	fmt.Printf("  Go version:   %s\n", client.GoVersion)
+	fmt.Printf("  Git commit:   %s\n", client.GitCommit)
+	fmt.Printf("  OS/Arch:      %s/%s\n", client.Os, client.Arch)
+}
+
+func eventsCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", "", "Format output using a Go template or 'json'")
+	since := fs.String("since", "", "Show logged events since this time (duration like 2h, RFC 3339 time or Unix timestamp)")
+	until := fs.String("until", "", "Stop streaming at this time (same formats as --since)")
+	var filters stringSlice
+	fs.Var(&filters, "filter", "Filter events by container, type, event, image or label key=value (repeatable)")
+	fs.Var(&filters, "f", "Filter events by container, type, event, image or label key=value (repeatable)")
+	cmd.parseFlags(fs, args)
+	out := newFormatter(*format)
+
+	filter, err := api.ParseEventFilter(filters)
+	if err != nil {
+		cmd.usageError("%v", err)
+	}
+
+	// Create client
+	cli := newClient()
+
+	opts := client.EventsOptions{Since: *since, Until: *until, Filters: filters}
+	body, err := cli.Events(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting events: %v\n", err)
+		os.Exit(1)
+	}
+	defer body.Close()
+
+	// Print events as they arrive until the daemon closes the stream or the user interrupts
+	decoder := json.NewDecoder(body)
+	for {
+		var event api.Event
+		if err := decoder.Decode(&event); err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "Error reading events: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// The daemon filters too; this keeps the output right against daemons that predate filters
+		if !filter.Match(event) {
+			continue
+		}
+
+		if !out.IsTable() {
+			if err := out.Write(os.Stdout, event); err != nil {
+				fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		fmt.Println(formatEvent(event))
+	}
+}
+
+// formatEvent renders an event as "<time> <type> <action> <id> (key=value, ...)"
+func formatEvent(event api.Event) string {
+	line := fmt.Sprintf("%s %s %s %s",
+		time.Unix(0, event.TimeNano).Format(time.RFC3339Nano),
+		event.Type,
+		event.Action,
+		event.ID,
+	)
+
+	if len(event.Attributes) > 0 {
+		keys := make([]string, 0, len(event.Attributes))
+		for key := range event.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		attrs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			attrs = append(attrs, key+"="+event.Attributes[key])
+		}
+		line += " (" + strings.Join(attrs, ", ") + ")"
+	}
+
+	return line
+}
+
+// systemDialStdioCommand connects stdin and stdout to the daemon's socket until either side closes
+// A client using an ssh:// host runs this on the remote machine and speaks the API through it
+func systemDialStdioCommand(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parseFlags(fs, args)
+
+	conn, err := net.Dial("unix", globalOpts.socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to daemon: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	// Pass on EOF from stdin as a half-close so the daemon can still answer
+	go func() {
+		io.Copy(conn, os.Stdin)
+		conn.(*net.UnixConn).CloseWrite()
+	}()
+
+	// The client closing its end is the normal way for this to finish, so copy errors aren't reported
+	io.Copy(os.Stdout, conn)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/AbhishekGY/mydocker/pkg/client"
+	"golang.org/x/term"
+)
+
+// streamAttached streams an attached container to the local terminal
+// Containers with a PTY take over the terminal; those without one get stdin copied in and stdout and stderr split out
+func streamAttached(stream *client.HijackedResponse, stdin bool) error {
+	if stream.Multiplexed {
+		return streamMultiplexed(stream, stdin)
+	}
+	return streamTerminal(stream)
+}
+
+// streamTerminal puts the local terminal in raw mode and copies I/O between it and the stream
+// It returns when either side closes or a termination signal is received
+func streamTerminal(stream io.ReadWriter) error {
+	// Put terminal in raw mode
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set terminal to raw mode: %v", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	// Handle signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	// Copy I/O bidirectionally
+	done := make(chan error, 2)
+
+	// Copy stdin to the stream
+	go func() {
+		_, err := io.Copy(stream, os.Stdin)
+		done <- err
+	}()
+
+	// Copy the stream to stdout
+	go func() {
+		_, err := io.Copy(os.Stdout, stream)
+		done <- err
+	}()
+
+	// Wait for signals or I/O completion
+	select {
+	case <-sigChan:
+		// Signal received, stream will be closed by the caller
+	case <-done:
+		// I/O completed
+	}
+
+	return nil
+}
+
+// streamMultiplexed copies stdin to a container without a TTY and its output to stdout and stderr
+// It returns once the container's output ends or a termination signal is received
+func streamMultiplexed(stream *client.HijackedResponse, stdin bool) error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	// The end of stdin is passed on so programs reading it until EOF can finish
+	if stdin {
+		go func() {
+			io.Copy(stream, os.Stdin)
+			stream.CloseWrite()
+		}()
+	} else {
+		stream.CloseWrite()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.DemuxStream(stream, os.Stdout, os.Stderr)
+	}()
+
+	select {
+	case <-sigChan:
+		return nil
+	case err := <-done:
+		return err
+	}
+}
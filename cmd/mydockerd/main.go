@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/AbhishekGY/mydocker/pkg/config"
 	"github.com/AbhishekGY/mydocker/pkg/daemon"
 )
 
@@ -20,6 +21,9 @@ func main() {
 	// Parse command-line flags
 	socketPath := flag.String("socket", "/var/run/mydocker.sock", "Path to Unix socket")
 	dataDir := flag.String("data-dir", "/var/lib/mydocker", "Path to data directory")
+	configFile := flag.String("config-file", config.DefaultConfigPath, "Path to daemon configuration file")
+	debug := flag.Bool("debug", false, "Expose /debug/pprof and /debug/state endpoints")
+	apiMode := flag.String("api-mode", "normal", "API mode to start in: normal, read-only or maintenance")
 	flag.Parse()
 
 	// Create daemon instance
@@ -28,10 +32,21 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Failed to create daemon: %v\n", err)
 		os.Exit(1)
 	}
+	if *debug {
+		d.EnableDebug()
+	}
+	if err := d.LoadConfig(*configFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if err := d.SetMode(*apiMode, "", ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --api-mode: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	// Start daemon in a goroutine
 	errChan := make(chan error, 1)
@@ -41,17 +56,27 @@ func main() {
 		}
 	}()
 
-	// Wait for signal or error
-	select {
-	case sig := <-sigChan:
-		fmt.Printf("\nReceived signal: %v\n", sig)
-		if err := d.Stop(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error stopping daemon: %v\n", err)
+	// Wait for a shutdown signal or error, reloading the configuration on SIGHUP
+	for running := true; running; {
+		select {
+		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				if _, err := d.Reload(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to reload configuration: %v\n", err)
+				}
+				continue
+			}
+
+			fmt.Printf("\nReceived signal: %v\n", sig)
+			if err := d.Stop(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error stopping daemon: %v\n", err)
+				os.Exit(1)
+			}
+			running = false
+		case err := <-errChan:
+			fmt.Fprintf(os.Stderr, "Daemon error: %v\n", err)
 			os.Exit(1)
 		}
-	case err := <-errChan:
-		fmt.Fprintf(os.Stderr, "Daemon error: %v\n", err)
-		os.Exit(1)
 	}
 
 	fmt.Println("Daemon stopped")
@@ -6,7 +6,6 @@ toolchain go1.24.7
 
 require (
 	github.com/creack/pty v1.1.18
+	golang.org/x/sys v0.39.0
 	golang.org/x/term v0.38.0
 )
-
-require golang.org/x/sys v0.39.0 // indirect
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EventFilter selects events by key: container (ID prefix), type, event (action), image and label (key or key=value)
+// Values of the same key match if any of them does; every key present must match
+type EventFilter map[string][]string
+
+// eventFilterKeys are the keys an EventFilter accepts
+var eventFilterKeys = []string{"container", "type", "event", "image", "label"}
+
+// ParseEventFilter parses "key=value" filter arguments
+func ParseEventFilter(args []string) (EventFilter, error) {
+	filter := EventFilter{}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid filter %q: expected key=value", arg)
+		}
+		valid := false
+		for _, k := range eventFilterKeys {
+			valid = valid || k == key
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid filter key %q: must be one of %s", key, strings.Join(eventFilterKeys, ", "))
+		}
+		filter[key] = append(filter[key], value)
+	}
+	return filter, nil
+}
+
+// Match reports whether an event passes the filter
+// Images and labels are read from the "image" and "label.<key>" attributes the daemon adds to container events
+func (f EventFilter) Match(event Event) bool {
+	for key, values := range f {
+		matched := false
+		for _, value := range values {
+			switch key {
+			case "container":
+				matched = strings.HasPrefix(event.ID, value)
+			case "type":
+				matched = event.Type == value
+			case "event":
+				matched = event.Action == value
+			case "image":
+				matched = event.Attributes["image"] == value
+			case "label":
+				name, want, hasValue := strings.Cut(value, "=")
+				got, exists := event.Attributes["label."+name]
+				matched = exists && (!hasValue || got == want)
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
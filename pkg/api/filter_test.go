@@ -0,0 +1,130 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEventFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    EventFilter
+		wantErr bool
+	}{
+		{name: "none", args: nil, want: EventFilter{}},
+		{
+			name: "every key",
+			args: []string{"container=abc", "type=container", "event=start", "image=/tmp/rootfs", "label=env=prod"},
+			want: EventFilter{
+				"container": {"abc"},
+				"type":      {"container"},
+				"event":     {"start"},
+				"image":     {"/tmp/rootfs"},
+				"label":     {"env=prod"},
+			},
+		},
+		{
+			name: "repeated key",
+			args: []string{"event=start", "event=die"},
+			want: EventFilter{"event": {"start", "die"}},
+		},
+		{name: "unknown key", args: []string{"name=web"}, wantErr: true},
+		{name: "key only", args: []string{"container"}, wantErr: true},
+		{name: "empty value", args: []string{"container="}, wantErr: true},
+		{name: "empty key", args: []string{"=abc"}, wantErr: true},
+		{name: "invalid after valid", args: []string{"event=start", "status=running"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEventFilter(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseEventFilter(%q) = %v, want an error", tt.args, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEventFilter(%q) failed: %v", tt.args, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEventFilter(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventFilterMatch(t *testing.T) {
+	event := Event{
+		Type:   "container",
+		Action: "start",
+		ID:     "3f2a9c1b7d",
+		Attributes: map[string]string{
+			"image":     "/tmp/rootfs",
+			"label.env": "prod",
+			"label.app": "",
+		},
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{name: "no filter", want: true},
+		{name: "full ID", args: []string{"container=3f2a9c1b7d"}, want: true},
+		{name: "ID prefix", args: []string{"container=3f2a"}, want: true},
+		{name: "other ID", args: []string{"container=9c1b"}, want: false},
+		{name: "type", args: []string{"type=container"}, want: true},
+		{name: "other type", args: []string{"type=daemon"}, want: false},
+		{name: "event", args: []string{"event=start"}, want: true},
+		{name: "other event", args: []string{"event=die"}, want: false},
+		{name: "image", args: []string{"image=/tmp/rootfs"}, want: true},
+		{name: "image prefix", args: []string{"image=/tmp"}, want: false},
+		{name: "label key", args: []string{"label=env"}, want: true},
+		{name: "label with empty value", args: []string{"label=app"}, want: true},
+		{name: "label value", args: []string{"label=env=prod"}, want: true},
+		{name: "other label value", args: []string{"label=env=dev"}, want: false},
+		{name: "empty label value", args: []string{"label=env="}, want: false},
+		{name: "missing label", args: []string{"label=tier"}, want: false},
+
+		// Values of one key are ORed
+		{name: "repeated key, one matches", args: []string{"event=die", "event=start"}, want: true},
+		{name: "repeated key, none match", args: []string{"event=die", "event=stop"}, want: false},
+		{name: "repeated label, one matches", args: []string{"label=tier", "label=env=prod"}, want: true},
+
+		// Keys are ANDed
+		{name: "keys all match", args: []string{"container=3f2a", "event=start", "label=env=prod"}, want: true},
+		{name: "one key fails", args: []string{"container=3f2a", "event=die"}, want: false},
+		{name: "ORed key fails", args: []string{"event=start", "event=die", "image=/other"}, want: false},
+		{name: "ORed keys match", args: []string{"event=die", "event=start", "type=daemon", "type=container"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseEventFilter(tt.args)
+			if err != nil {
+				t.Fatalf("ParseEventFilter(%q) failed: %v", tt.args, err)
+			}
+			if got := filter.Match(event); got != tt.want {
+				t.Errorf("Match with %q = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventFilterMatchMissingAttributes(t *testing.T) {
+	// Daemon events carry no image or label attributes
+	event := Event{Type: "daemon", Action: "reload"}
+
+	for _, args := range [][]string{{"image=/tmp/rootfs"}, {"label=env"}, {"container=3f"}} {
+		filter, err := ParseEventFilter(args)
+		if err != nil {
+			t.Fatalf("ParseEventFilter(%q) failed: %v", args, err)
+		}
+		if filter.Match(event) {
+			t.Errorf("Match with %q = true for an event without the attribute", args)
+		}
+	}
+}
@@ -1,22 +1,212 @@
 package api
 
+import "time"
+
+// ServiceLabel holds the ID of the service a container is a replica of
+const ServiceLabel = "mydocker.service"
+
+// ServiceVersionLabel holds the version of the service template a replica was created from
+const ServiceVersionLabel = "mydocker.service.version"
+
+// ScheduleLabel holds the ID of the schedule that started a container
+const ScheduleLabel = "mydocker.schedule"
+
+// DependsOnLabel lists the IDs of containers that must be running before a container, comma separated
+const DependsOnLabel = "mydocker.depends_on"
+
+// DebugTargetLabel holds the ID of the container a debug container was started to inspect
+const DebugTargetLabel = "mydocker.debug.target"
+
+// PresetLabel holds the name of the preset a container was created from
+const PresetLabel = "mydocker.preset"
+
+// PodLabel holds the ID of the pod a container belongs to
+const PodLabel = "mydocker.pod"
+
+// InitLabel marks an init container when set to "true": containers depending on it start only once it has
+// run to completion and exited with code 0
+const InitLabel = "mydocker.init"
+
+// Error codes returned in ErrorResponse.Code
+const (
+	ErrCodeInvalidRequest      = "INVALID_REQUEST"
+	ErrCodeMethodNotAllowed    = "METHOD_NOT_ALLOWED"
+	ErrCodeContainerNotFound   = "CONTAINER_NOT_FOUND"
+	ErrCodeAmbiguousID         = "AMBIGUOUS_ID"
+	ErrCodeContainerRunning    = "CONTAINER_RUNNING"
+	ErrCodeContainerNotRunning = "CONTAINER_NOT_RUNNING"
+	ErrCodeAttachConflict      = "ATTACH_CONFLICT"
+	ErrCodeSessionNotFound     = "SESSION_NOT_FOUND"
+	ErrCodeStatsDisabled       = "STATS_DISABLED"
+	ErrCodeQuotaExceeded       = "QUOTA_EXCEEDED"
+	ErrCodeScheduleNotFound    = "SCHEDULE_NOT_FOUND"
+	ErrCodeServiceNotFound     = "SERVICE_NOT_FOUND"
+	ErrCodeNameInUse           = "NAME_IN_USE"
+	ErrCodeServiceUpdating     = "SERVICE_UPDATING"
+	ErrCodePresetNotFound      = "PRESET_NOT_FOUND"
+	ErrCodePodNotFound         = "POD_NOT_FOUND"
+	ErrCodeModeRestricted      = "MODE_RESTRICTED"
+	ErrCodeDrainInProgress     = "DRAIN_IN_PROGRESS"
+	ErrCodePermissionDenied    = "PERMISSION_DENIED"
+	ErrCodeInternal            = "INTERNAL_ERROR"
+)
+
+// ErrorResponse is the body of every non-2xx response from the daemon
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
 // ContainerCreateRequest represents a request to create a new container
 type ContainerCreateRequest struct {
-	Image      string   `json:"image"`
-	Command    []string `json:"command"`
-	Rootfs     string   `json:"rootfs"`
-	Memory     uint64   `json:"memory"`
-	MemorySwap uint64   `json:"memory_swap"`
-	CpuShares  uint64   `json:"cpu_shares"`
-	CpuQuota   int64    `json:"cpu_quota"`
-	CpuPeriod  uint64   `json:"cpu_period"`
-	PidsLimit  int64    `json:"pids_limit"`
-	Detach     bool     `json:"detach"`
+	Image      string            `json:"image"`
+	Command    []string          `json:"command"`
+	Rootfs     string            `json:"rootfs"`
+	Platform   string            `json:"platform,omitempty"` // os/arch[/variant] the rootfs was built for, defaults to the host's
+	Env        []string          `json:"env,omitempty"`
+	Secrets    []Secret          `json:"secrets,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Memory     uint64            `json:"memory"`
+	MemorySwap int64             `json:"memory_swap"` // -1 for unlimited swap, 0 for twice Memory
+	CpuShares  uint64            `json:"cpu_shares"`
+	CpuQuota   int64             `json:"cpu_quota"`
+	CpuPeriod  uint64            `json:"cpu_period"`
+	CpuBurst   uint64            `json:"cpu_burst,omitempty"`
+	PidsLimit  int64             `json:"pids_limit"`
+	Profile    string            `json:"profile,omitempty"` // Resource profile from the daemon config filling in limits left unset
+	Detach     bool              `json:"detach"`
+	NoDeps     bool              `json:"no_deps,omitempty"` // Don't start containers listed in the depends_on label
+
+	// HugetlbLimits maps a huge page size (e.g. "2MB") to the maximum bytes of huge pages of that size
+	HugetlbLimits map[string]uint64 `json:"hugetlb_limits,omitempty"`
+
+	// PressureThresholds maps "<cpu|memory|io>.<some|full>" to the avg10 stall percentage that triggers a pressure event
+	PressureThresholds map[string]float64 `json:"pressure_thresholds,omitempty"`
+
+	// SecurityOpt holds security options such as "apparmor=<profile|unconfined>"
+	SecurityOpt []string `json:"security_opt,omitempty"`
+
+	// Mounts are made inside the container in addition to its rootfs, subject to the daemon's allowed mount types
+	Mounts []Mount `json:"mounts,omitempty"`
+	// MaskedPaths are hidden from the container on top of the daemon's default masked paths
+	MaskedPaths []string `json:"masked_paths,omitempty"`
+
+	// TimeOffsets runs the container in its own time namespace with shifted clocks (nil to share the host's)
+	TimeOffsets *TimeOffsets `json:"time_offsets,omitempty"`
+
+	// WaitFor holds every start of the container in the admission queue until host resources exist
+	WaitFor *WaitConditions `json:"wait_for,omitempty"`
+
+	// NamespacesFrom joins the network, PID and IPC namespaces of this running container instead of creating new ones
+	NamespacesFrom string `json:"namespaces_from,omitempty"`
+	// AutoRemove removes the container once it exits
+	AutoRemove bool `json:"auto_remove,omitempty"`
+
+	// ResourceViews shows the container its own memory and CPU limits in /proc/meminfo, /proc/cpuinfo,
+	// /sys/devices/system/cpu/online and /sys/fs/cgroup instead of the host's
+	ResourceViews bool `json:"resource_views,omitempty"`
+
+	// Autoscale raises the container's memory limit and CPU quota while it runs into them, up to a ceiling
+	Autoscale *AutoscalePolicy `json:"autoscale,omitempty"`
+
+	// NoTTY gives an attached container plain stdin, stdout and stderr pipes instead of a PTY,
+	// streamed as a multiplexed stream so stdout and stderr stay apart
+	NoTTY bool `json:"no_tty,omitempty"`
+	// AttachStdin, AttachStdout and AttachStderr select the streams an attach carries when it doesn't choose; none set means all
+	AttachStdin  bool `json:"attach_stdin,omitempty"`
+	AttachStdout bool `json:"attach_stdout,omitempty"`
+	AttachStderr bool `json:"attach_stderr,omitempty"`
+	// StdinOnce closes the container's stdin once the first attached client's stdin ends (needs NoTTY)
+	StdinOnce bool `json:"stdin_once,omitempty"`
+
+	// LogConfig ships the container's output to a log collector instead of the daemon's own output
+	LogConfig *LogConfig `json:"log_config,omitempty"`
+
+	// DeviceHotplug lists globs such as /dev/ttyUSB* for host devices whose nodes follow them being plugged
+	// in and removed while the container runs
+	DeviceHotplug []string `json:"device_hotplug,omitempty"`
+
+	// Preset names a container template from the daemon config or the presets API to start from;
+	// fields the request leaves unset are taken from the preset
+	Preset string `json:"preset,omitempty"`
+}
+
+// ContainerAdoptRequest asks the daemon to manage a running process it didn't start as a container
+// The process must run in namespaces of its own, e.g. one started with unshare or nsenter by another tool
+type ContainerAdoptRequest struct {
+	PID       int               `json:"pid"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Memory    uint64            `json:"memory"`
+	CpuShares uint64            `json:"cpu_shares"`
+	CpuQuota  int64             `json:"cpu_quota"`
+	CpuPeriod uint64            `json:"cpu_period"`
+	PidsLimit int64             `json:"pids_limit"`
+	Profile   string            `json:"profile,omitempty"` // Resource profile from the daemon config filling in limits left unset
+}
+
+// ContainerCloneRequest adjusts the copy made of a container's configuration
+type ContainerCloneRequest struct {
+	Labels map[string]string `json:"labels,omitempty"` // Added to the labels copied from the container, replacing any with the same key
+}
+
+// LogConfig selects a log driver and its options, e.g. "fluentd" with "fluentd-address"
+// Every driver takes a "tag" option, a template over the container's .ID, .FullID, .Command and .Labels,
+// and "mode=non-blocking" with "max-buffer-size" to drop lines rather than stall the container
+type LogConfig struct {
+	Driver  string            `json:"driver"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// AutoscalePolicy grows a running container's limits when it hits them, so bursty workloads aren't OOM killed
+// or throttled; each resource is only adjusted if its step is set
+type AutoscalePolicy struct {
+	MemoryStep    float64       `json:"memory_step,omitempty"`    // Percent the memory limit grows by each time the container hits it
+	MemoryCeiling uint64        `json:"memory_ceiling,omitempty"` // Memory limit in bytes the policy never raises it above
+	CpuStep       float64       `json:"cpu_step,omitempty"`       // Percent the CPU quota grows by each time the container is throttled
+	CpuCeiling    int64         `json:"cpu_ceiling,omitempty"`    // CPU quota in microseconds per period the policy never raises it above
+	Cooldown      time.Duration `json:"cooldown,omitempty"`       // Minimum time between adjustments of a resource, 0 for the default
+}
+
+// AutoscaleAdjustment is one limit change made by an autoscale policy
+type AutoscaleAdjustment struct {
+	Resource string    `json:"resource"` // "memory" (bytes) or "cpu" (quota in microseconds per period)
+	From     int64     `json:"from"`
+	To       int64     `json:"to"`
+	Reason   string    `json:"reason"`
+	Time     time.Time `json:"time"`
+}
+
+// Secret is a host file exposed read-only to the container at /run/secrets/<target>
+type Secret struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Mount is an extra mount inside a container
+type Mount struct {
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`
+	Type        string   `json:"type"`              // "bind" or a filesystem type such as "tmpfs"
+	Options     []string `json:"options,omitempty"` // e.g. "ro", "nosuid", "size=64m"
+}
+
+// TimeOffsets shifts the monotonic and boot-time clocks inside a container
+type TimeOffsets struct {
+	Monotonic time.Duration `json:"monotonic"`
+	Boottime  time.Duration `json:"boottime"`
+}
+
+// WaitConditions are host resources a container waits for before it starts, e.g. devices that appear late in boot
+type WaitConditions struct {
+	Paths   []string      `json:"paths,omitempty"`   // Files or devices that must exist, e.g. /dev/ttyUSB0
+	Units   []string      `json:"units,omitempty"`   // systemd units that must be active, e.g. nfs.mount
+	Timeout time.Duration `json:"timeout,omitempty"` // How long a start waits before giving up, 0 for no limit
 }
 
 // ContainerCreateResponse represents the response after creating a container
 type ContainerCreateResponse struct {
-	ID string `json:"id"`
+	ID     string `json:"id"`
+	Queued bool   `json:"queued,omitempty"` // The container waits in the admission queue instead of running yet
 }
 
 // ContainerInfo represents information about a container
@@ -27,19 +217,534 @@ type ContainerInfo struct {
 	Status  string `json:"status"`
 	Created int64  `json:"created"`
 	PID     int    `json:"pid"`
+
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// SizeRootFs is the size of the container's rootfs, only filled in when sizes are requested
+	// Containers share their rootfs directory, so this is a virtual size rather than per-container usage
+	SizeRootFs int64 `json:"size_root_fs,omitempty"`
+}
+
+// ContainerInspect is the detailed view of a single container
+type ContainerInspect struct {
+	ID       string            `json:"id"`
+	Status   string            `json:"status"`
+	PID      int               `json:"pid"`
+	Created  time.Time         `json:"created"`
+	Started  time.Time         `json:"started"`
+	Exited   time.Time         `json:"exited"`
+	ExitCode *int              `json:"exit_code,omitempty"` // nil until the container exits
+	Command  []string          `json:"command"`
+	Rootfs   string            `json:"rootfs"`
+	Platform string            `json:"platform,omitempty"`
+	Env      []string          `json:"env,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Mounts   []Mount           `json:"mounts,omitempty"`
+	Profile  string            `json:"profile,omitempty"`
+
+	WaitFor *WaitConditions `json:"wait_for,omitempty"`
+
+	NetworkNamespace string `json:"network_namespace,omitempty"` // Bind mount of the network namespace, set while running
+	NamespacesFrom   string `json:"namespaces_from,omitempty"`
+	AutoRemove       bool   `json:"auto_remove,omitempty"`
+
+	ResourceViews bool `json:"resource_views,omitempty"`
+	NoTTY         bool `json:"no_tty,omitempty"`
+	StdinOnce     bool `json:"stdin_once,omitempty"`
+	Adopted       bool `json:"adopted,omitempty"` // Started outside the daemon; it can't be started again once it exits
+
+	LogConfig *LogConfig `json:"log_config,omitempty"`
+
+	DeviceHotplug []string `json:"device_hotplug,omitempty"`
+
+	Network *NetworkStats `json:"network,omitempty"` // Traffic counters of the container's interfaces while running, without rates
+
+	// Memory and CpuQuota are the limits in effect, which an autoscale policy may have raised since create
+	Memory    uint64           `json:"memory,omitempty"`
+	CpuQuota  int64            `json:"cpu_quota,omitempty"`
+	Autoscale *AutoscalePolicy `json:"autoscale,omitempty"`
+	// AutoscaleAdjustments lists the most recent limit changes made by the autoscale policy, oldest first
+	AutoscaleAdjustments []AutoscaleAdjustment `json:"autoscale_adjustments,omitempty"`
+
+	// History lists the container's status transitions, oldest first; only filled in when requested
+	History []StateTransition `json:"history,omitempty"`
+}
+
+// ContainerNetns locates the network namespace of a running container on the daemon's host
+type ContainerNetns struct {
+	ID   string `json:"id"`
+	PID  int    `json:"pid"`
+	Path string `json:"path"` // Bind mount of /proc/<pid>/ns/net that stays valid while the container runs
+}
+
+// StateTransition is one change of a container's status
+type StateTransition struct {
+	From   string    `json:"from"` // Empty for the transition that created the container
+	To     string    `json:"to"`
+	Reason string    `json:"reason"`
+	Actor  string    `json:"actor"` // "uid=<uid> pid=<pid>" of the API client, or "daemon"
+	Time   time.Time `json:"time"`
 }
 
 // ContainerListResponse represents the response for listing containers
 type ContainerListResponse struct {
 	Containers []ContainerInfo `json:"containers"`
+	Total      int             `json:"total"` // Number of containers before limit and offset were applied
+}
+
+// Stream identifiers of a multiplexed attach stream
+// Each frame has an 8 byte header, the stream identifier followed by three zero bytes and the big-endian uint32
+// length of the payload, the same framing Docker uses for containers without a TTY
+const (
+	StreamStdout byte = 1
+	StreamStderr byte = 2
+)
+
+// StreamHeaderSize is the size of the header of each frame of a multiplexed attach stream
+const StreamHeaderSize = 8
+
+// ContainerStartRequest represents a request to start a created or exited container
+type ContainerStartRequest struct {
+	ID     string `json:"id"`
+	NoDeps bool   `json:"no_deps,omitempty"` // Don't start the container's dependencies first
+
+	// Attach starts the container with a PTY and streams it over the connection, like an attached create
+	Attach bool `json:"attach,omitempty"`
+}
+
+// ContainerStartResponse represents the response after starting a container
+type ContainerStartResponse struct {
+	Started []string `json:"started"`          // IDs started by the request, dependencies first
+	Queued  []string `json:"queued,omitempty"` // IDs waiting in the admission queue for the host to have room
 }
 
 // ContainerStopRequest represents a request to stop a container
 type ContainerStopRequest struct {
-	ID string `json:"id"`
+	ID     string `json:"id"`
+	NoDeps bool   `json:"no_deps,omitempty"` // Don't stop containers that depend on this one first
 }
 
 // ContainerStopResponse represents the response after stopping a container
 type ContainerStopResponse struct {
-	Success bool `json:"success"`
+	Success bool     `json:"success"`
+	Stopped []string `json:"stopped,omitempty"` // IDs stopped by the request, dependents first
+}
+
+// SessionInfo describes a recorded PTY session of a container
+type SessionInfo struct {
+	Name    string    `json:"name"` // File name of the asciicast v2 recording
+	Started time.Time `json:"started"`
+	Size    int64     `json:"size"`
+}
+
+// SessionListResponse lists a container's recorded sessions, oldest first
+type SessionListResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// ContainerStats is one resource usage sample of a running container
+// Rates and percentages are computed against the previous sample, so the first sample of a stream reports them as zero
+type ContainerStats struct {
+	ID   string    `json:"id"`
+	Read time.Time `json:"read"`
+
+	CPU     CPUStats     `json:"cpu"`
+	Memory  MemoryStats  `json:"memory"`
+	Pids    PidsStats    `json:"pids"`
+	BlockIO BlockIOStats `json:"block_io"`
+	Network NetworkStats `json:"network"`
+
+	Log LogStats `json:"log"`
+}
+
+// ContainerStatsHistory is the sampled resource usage of a container over a time window
+type ContainerStatsHistory struct {
+	ID      string           `json:"id"`
+	Since   time.Time        `json:"since"`
+	Samples []ContainerStats `json:"samples"` // Oldest first
+	Peak    StatsPeak        `json:"peak"`
+}
+
+// StatsPeak holds the highest values seen across a ContainerStatsHistory's samples
+type StatsPeak struct {
+	CPUPercent    float64 `json:"cpu_percent"`
+	MemoryUsage   uint64  `json:"memory_usage"`
+	MemoryPercent float64 `json:"memory_percent"`
+	Pids          uint64  `json:"pids"`
+}
+
+// CPUStats reports CPU time consumed by the container
+type CPUStats struct {
+	TotalUsage  uint64  `json:"total_usage"`  // Container CPU time in nanoseconds
+	SystemUsage uint64  `json:"system_usage"` // Host CPU time in nanoseconds, across all CPUs
+	OnlineCPUs  int     `json:"online_cpus"`
+	Percent     float64 `json:"percent"` // Share of one CPU, so up to OnlineCPUs*100
+}
+
+// MemoryStats reports the container's memory usage
+type MemoryStats struct {
+	Usage   uint64  `json:"usage"` // Bytes in use, excluding inactive page cache
+	Cache   uint64  `json:"cache"` // Inactive page cache in bytes
+	Limit   uint64  `json:"limit"` // Memory limit in bytes, or host memory if unlimited
+	Percent float64 `json:"percent"`
+}
+
+// PidsStats reports the number of processes in the container
+type PidsStats struct {
+	Current uint64 `json:"current"`
+	Limit   uint64 `json:"limit,omitempty"` // 0 if unlimited
+}
+
+// BlockIOStats reports bytes transferred to and from block devices
+type BlockIOStats struct {
+	ReadBytes      uint64  `json:"read_bytes"`
+	WriteBytes     uint64  `json:"write_bytes"`
+	ReadBytesRate  float64 `json:"read_bytes_rate"` // Bytes per second since the previous sample
+	WriteBytesRate float64 `json:"write_bytes_rate"`
+}
+
+// NetworkStats reports traffic on the container's network interfaces, loopback excluded
+type NetworkStats struct {
+	RxBytes     uint64  `json:"rx_bytes"`
+	TxBytes     uint64  `json:"tx_bytes"`
+	RxBytesRate float64 `json:"rx_bytes_rate"` // Bytes per second since the previous sample
+	TxBytesRate float64 `json:"tx_bytes_rate"`
+
+	RxPackets uint64 `json:"rx_packets"`
+	TxPackets uint64 `json:"tx_packets"`
+	RxDropped uint64 `json:"rx_dropped"`
+	TxDropped uint64 `json:"tx_dropped"`
+
+	Interfaces []InterfaceStats `json:"interfaces,omitempty"` // The counters of each interface, which the totals add up
+}
+
+// InterfaceStats holds the counters of one of the container's network interfaces
+type InterfaceStats struct {
+	Name      string `json:"name"`
+	RxBytes   uint64 `json:"rx_bytes"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	TxPackets uint64 `json:"tx_packets"`
+	RxDropped uint64 `json:"rx_dropped"`
+	TxDropped uint64 `json:"tx_dropped"`
+}
+
+// LogStats reports on the shipping of the container's output to its log driver
+type LogStats struct {
+	DroppedLines uint64 `json:"dropped_lines"` // Lines a non-blocking log driver dropped because its buffer was full
+}
+
+// LogEntry is one line of a container's stored output, as the logs endpoint streams it
+type LogEntry struct {
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream"` // "stdout" or "stderr"; a container with a TTY only has stdout
+	Line   string    `json:"log"`    // Without the trailing newline
+}
+
+// Event types
+const (
+	ContainerEventType = "container"
+	ScheduleEventType  = "schedule"
+	ServiceEventType   = "service"
+	PresetEventType    = "preset"
+	PodEventType       = "pod"
+	DaemonEventType    = "daemon"
+)
+
+// Event is something that happened in the daemon, such as a container starting or exiting
+type Event struct {
+	Type       string            `json:"type"`
+	Action     string            `json:"action"` // e.g. "create", "start", "die", "stop", "pressure"
+	ID         string            `json:"id"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Time       int64             `json:"time"`
+	TimeNano   int64             `json:"time_nano"`
+}
+
+// RunnerInfo describes a live container runner held by the daemon
+type RunnerInfo struct {
+	ID       string `json:"id"`
+	PID      int    `json:"pid"`
+	Detach   bool   `json:"detach"`
+	Attached bool   `json:"attached"`
+}
+
+// DebugStateResponse is a snapshot of the daemon's internal state for diagnosing hangs
+type DebugStateResponse struct {
+	Goroutines int             `json:"goroutines"`
+	DaemonLock string          `json:"daemon_lock"` // "free" or "held"
+	Containers []ContainerInfo `json:"containers"`
+	Runners    []RunnerInfo    `json:"runners"`
+}
+
+// SystemReloadResponse lists the daemon.json settings that changed on reload
+type SystemReloadResponse struct {
+	Changed []string `json:"changed"`
+}
+
+// SystemReconcileRequest asks the daemon to clean up resources left behind by containers
+type SystemReconcileRequest struct {
+	DryRun bool `json:"dry_run,omitempty"` // Report what would be cleaned up without changing anything
+}
+
+// SystemReconcileResponse lists the orphaned resources found by a reconcile pass
+type SystemReconcileResponse struct {
+	DryRun  bool     `json:"dry_run"`
+	Cgroups []string `json:"cgroups"` // IDs of container cgroups with no running container
+	Mounts  []string `json:"mounts"`  // Container mounts and network namespace handles found in the daemon's mount namespace
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// API modes, which restrict the requests the daemon accepts
+const (
+	ModeNormal      = "normal"      // Every request is accepted
+	ModeReadOnly    = "read-only"   // Only requests that read state: lists, inspects, logs, stats and events
+	ModeMaintenance = "maintenance" // Requests that create or start containers are rejected; stops and removals are accepted
+)
+
+// SystemModeRequest switches the daemon's API mode
+type SystemModeRequest struct {
+	Mode   string `json:"mode"`
+	Reason string `json:"reason,omitempty"` // Included in the errors of rejected requests, e.g. "host drain"
+}
+
+// SystemInfo summarizes the daemon and the objects it manages
+type SystemInfo struct {
+	ServerVersion string    `json:"server_version"`
+	Mode          string    `json:"mode"`
+	ModeReason    string    `json:"mode_reason,omitempty"`
+	ModeSince     time.Time `json:"mode_since"`
+
+	Containers        int `json:"containers"`
+	ContainersRunning int `json:"containers_running"`
+	ContainersQueued  int `json:"containers_queued"`
+	ContainersStopped int `json:"containers_stopped"`
+	Pods              int `json:"pods"`
+	Services          int `json:"services"`
+	Schedules         int `json:"schedules"`
+
+	DataDir       string `json:"data_dir"`
+	CgroupVersion string `json:"cgroup_version"`
+	Debug         bool   `json:"debug"`
+}
+
+// SystemDrainRequest asks the daemon to stop accepting new containers and stop every running one
+type SystemDrainRequest struct {
+	Parallelism int           `json:"parallelism,omitempty"` // Containers stopped at once, 1 when unset
+	Timeout     time.Duration `json:"timeout,omitempty"`     // How long each container has to exit after SIGTERM before it is killed, 5s when unset
+	Deadline    time.Duration `json:"deadline,omitempty"`    // How long the whole drain may take before remaining containers are killed, 0 for no limit
+	Reason      string        `json:"reason,omitempty"`      // Reason of the maintenance mode the drain puts the daemon in
+}
+
+// SystemDrainResponse summarizes a drain
+type SystemDrainResponse struct {
+	Mode     string        `json:"mode"` // The daemon's API mode after the drain
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+
+	Stopped          int  `json:"stopped"`   // Exited after SIGTERM, or before the drain got to them
+	Killed           int  `json:"killed"`    // Killed after their timeout or the deadline passed
+	Cancelled        int  `json:"cancelled"` // Taken out of the admission queue
+	Failed           int  `json:"failed"`
+	DeadlineExceeded bool `json:"deadline_exceeded"`
+
+	Containers []DrainedContainer `json:"containers"` // In the order their stops completed
+}
+
+// DrainedContainer is the outcome of stopping one container during a drain
+type DrainedContainer struct {
+	ID       string        `json:"id"`
+	Result   string        `json:"result"` // "stopped", "killed", "cancelled" or "failed"
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// AdmissionQueueResponse reports host utilization against the admission thresholds and the starts waiting on it
+type AdmissionQueueResponse struct {
+	Enabled          bool              `json:"enabled"`
+	CPUPercent       float64           `json:"cpu_percent"`
+	MemoryPercent    float64           `json:"memory_percent"`
+	MaxCPUPercent    float64           `json:"max_cpu_percent,omitempty"`
+	MaxMemoryPercent float64           `json:"max_memory_percent,omitempty"`
+	Saturated        string            `json:"saturated,omitempty"` // Why new starts are queued, empty when they aren't
+	Queued           []QueuedContainer `json:"queued"`              // In admission order
+}
+
+// QueuedContainer is a container start waiting in the admission queue
+type QueuedContainer struct {
+	ID     string    `json:"id"`
+	Queued time.Time `json:"queued"`
+	Reason string    `json:"reason"`
+}
+
+// ScheduleCreateRequest asks the daemon to run a container from Template whenever Cron matches
+type ScheduleCreateRequest struct {
+	Cron     string                 `json:"cron"` // Five-field cron expression or a macro such as "@hourly", in the daemon's time zone
+	Template ContainerCreateRequest `json:"template"`
+	Disabled bool                   `json:"disabled,omitempty"` // Create the schedule without enabling it
+}
+
+// Schedule is a container the daemon runs on a cron schedule
+type Schedule struct {
+	ID       string                 `json:"id"`
+	Cron     string                 `json:"cron"`
+	Template ContainerCreateRequest `json:"template"`
+	Enabled  bool                   `json:"enabled"`
+	Created  time.Time              `json:"created"`
+	NextRun  time.Time              `json:"next_run"` // Zero when disabled
+	Runs     []ScheduleRun          `json:"runs"`     // Most recent last
+}
+
+// ScheduleRun is one run of a schedule
+type ScheduleRun struct {
+	ContainerID string    `json:"container_id,omitempty"` // Empty if no container was started
+	Started     time.Time `json:"started"`
+	Finished    time.Time `json:"finished"`
+	ExitCode    *int      `json:"exit_code,omitempty"` // nil until the container exits
+	Error       string    `json:"error,omitempty"`     // Why the run failed to start or was skipped
+}
+
+// ScheduleListResponse is the response of GET /schedules
+type ScheduleListResponse struct {
+	Schedules []Schedule `json:"schedules"`
+}
+
+// ServiceCreateRequest asks the daemon to keep Replicas containers created from Template running
+type ServiceCreateRequest struct {
+	Name     string                 `json:"name"`
+	Replicas int                    `json:"replicas"`
+	Template ContainerCreateRequest `json:"template"`
+}
+
+// ServiceScaleRequest changes the desired replica count of a service
+type ServiceScaleRequest struct {
+	Replicas int `json:"replicas"`
+}
+
+// ServiceUpdateRequest rolls the replicas of a service over to a new rootfs
+// New replicas are started before old ones are stopped; if one fails the service is rolled back
+type ServiceUpdateRequest struct {
+	Rootfs      string        `json:"rootfs"`
+	Parallelism int           `json:"parallelism"` // Replicas replaced at a time, 0 for all at once (blue/green)
+	Monitor     time.Duration `json:"monitor"`     // How long new replicas must keep running to count as healthy, 0 for the default
+}
+
+// Service update states
+const (
+	ServiceUpdating          = "updating"
+	ServiceUpdateCompleted   = "completed"
+	ServiceRollingBack       = "rolling_back"
+	ServiceRollbackCompleted = "rollback_completed"
+	ServiceUpdatePaused      = "paused" // Interrupted, or the rollback failed too; replicas of both versions may be running
+)
+
+// ServiceUpdateStatus describes the last update of a service
+type ServiceUpdateStatus struct {
+	State     string    `json:"state"`
+	Started   time.Time `json:"started"`
+	Completed time.Time `json:"completed,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// Service is a named group of identical containers kept at a desired count
+type Service struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Replicas   int                    `json:"replicas"` // Desired count
+	Running    int                    `json:"running"`
+	Template   ContainerCreateRequest `json:"template"`
+	Created    time.Time              `json:"created"`
+	Containers []ServiceReplica       `json:"containers"` // Replicas, oldest first
+
+	Version      int                  `json:"version"` // Version of Template, bumped by every update
+	UpdateStatus *ServiceUpdateStatus `json:"update_status,omitempty"`
+}
+
+// ServiceReplica is one container of a service
+type ServiceReplica struct {
+	ID      string    `json:"id"`
+	Status  string    `json:"status"`
+	Created time.Time `json:"created"`
+	Version int       `json:"version"` // Version of the service template it was created from
+}
+
+// ServiceListResponse is the response of GET /services
+type ServiceListResponse struct {
+	Services []Service `json:"services"`
+}
+
+// PodCreateRequest creates a pod and starts its infra container, which holds the namespaces the pod's
+// containers share
+type PodCreateRequest struct {
+	Name    string            `json:"name"`
+	Rootfs  string            `json:"rootfs"`            // Rootfs of the infra container
+	Command []string          `json:"command,omitempty"` // Command of the infra container, which must keep running (default sleep infinity)
+	Labels  map[string]string `json:"labels,omitempty"`  // Labels of the pod, also set on its infra container
+}
+
+// Pod is a group of containers that share the network, PID, IPC and UTS namespaces of an infra container
+// and are started, stopped and removed together
+type Pod struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Infra      string            `json:"infra"`  // ID of the infra container
+	Status     string            `json:"status"` // Status of the infra container
+	Labels     map[string]string `json:"labels,omitempty"`
+	Created    time.Time         `json:"created"`
+	Running    int               `json:"running"`    // Containers running, not counting the infra container
+	Containers []PodContainer    `json:"containers"` // Containers added to the pod, oldest first
+}
+
+// PodContainer is one container of a pod
+type PodContainer struct {
+	ID       string    `json:"id"`
+	Status   string    `json:"status"`
+	ExitCode *int      `json:"exit_code,omitempty"` // nil until the container exits
+	Init     bool      `json:"init,omitempty"`      // Runs to completion before the pod's other containers start
+	Command  []string  `json:"command"`
+	Created  time.Time `json:"created"`
+}
+
+// PodListResponse is the response of GET /pods
+type PodListResponse struct {
+	Pods []Pod `json:"pods"`
+}
+
+// Preset sources
+const (
+	PresetSourceConfig = "config" // Defined in daemon.json; changed by editing it and reloading
+	PresetSourceAPI    = "api"    // Created through the presets API
+)
+
+// PresetCreateRequest defines a named container template that create requests can start from
+type PresetCreateRequest struct {
+	Name     string                 `json:"name"`
+	Template ContainerCreateRequest `json:"template"`
+}
+
+// Preset is a named container template; create requests naming it only need to set what differs
+type Preset struct {
+	Name     string                 `json:"name"`
+	Source   string                 `json:"source"`
+	Template ContainerCreateRequest `json:"template"`
+	Created  time.Time              `json:"created,omitempty"` // Zero for presets from the daemon config
+}
+
+// PresetListResponse is the response of GET /presets
+type PresetListResponse struct {
+	Presets []Preset `json:"presets"`
+}
+
+// VersionResponse describes the daemon build and host
+type VersionResponse struct {
+	Version       string `json:"version"`
+	GitCommit     string `json:"git_commit"`
+	APIVersion    string `json:"api_version"`
+	MinAPIVersion string `json:"min_api_version"`
+	GoVersion     string `json:"go_version"`
+	Os            string `json:"os"`
+	Arch          string `json:"arch"`
+	KernelVersion string `json:"kernel_version"`
+	CgroupDriver  string `json:"cgroup_driver"`
+	CgroupVersion string `json:"cgroup_version"`
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 )
 
@@ -13,19 +12,18 @@ type Controller string
 
 // Available cgroup controllers
 const (
-	Cpu    Controller = "cpu"
-	Memory Controller = "memory"
-	CpuSet Controller = "cpuset"
-	Pids   Controller = "pids"
-	BlkIO  Controller = "blkio"
+	Cpu     Controller = "cpu"
+	CpuAcct Controller = "cpuacct" // cgroups v1 only, CPU accounting lives in cpu.stat on v2
+	Memory  Controller = "memory"
+	CpuSet  Controller = "cpuset"
+	Pids    Controller = "pids"
+	BlkIO   Controller = "blkio" // cgroups v1 name of the block I/O controller
+	IO      Controller = "io"    // cgroups v2 name of the block I/O controller
+	Hugetlb Controller = "hugetlb"
 )
 
-// Cgroup represents a control group
-type Cgroup struct {
-	Name        string
-	Controllers []Controller
-	Path        string
-}
+// cgroupRoot is where the cgroup hierarchies are mounted
+const cgroupRoot = "/sys/fs/cgroup"
 
 // ResourceLimits defines resource constraints for a container
 type ResourceLimits struct {
@@ -33,15 +31,26 @@ type ResourceLimits struct {
 	CpuShares uint64 // CPU shares (relative weight)
 	CpuQuota  int64  // CPU quota in microseconds (-1 for no limit)
 	CpuPeriod uint64 // CPU period in microseconds
+	CpuBurst  uint64 // Unused quota that may accumulate for bursts, in microseconds (0 for none)
 
 	// Memory limits
 	MemoryLimit     uint64 // Memory limit in bytes
-	MemorySwapLimit uint64 // Memory+Swap limit in bytes
+	MemorySwapLimit int64  // Memory+Swap limit in bytes, MemorySwapUnlimited for no swap limit, 0 for the default
+	MemoryHigh      uint64 // Usage in bytes above which the cgroup is throttled and reclaimed (cgroups v2 only, 0 for none)
 
 	// Process limits
 	PidsLimit int64 // Maximum number of processes
+
+	// Huge page limits in bytes, keyed by page size (e.g. "2MB", "1GB")
+	HugetlbLimits map[string]uint64
 }
 
+// MinMemoryLimit is the smallest memory limit a container can start with
+const MinMemoryLimit = 6 * 1024 * 1024
+
+// MemorySwapUnlimited lets a memory-limited container use as much swap as the host has
+const MemorySwapUnlimited = -1
+
 // DefaultResourceLimits returns default resource limits
 func DefaultResourceLimits() ResourceLimits {
 	return ResourceLimits{
@@ -54,278 +63,163 @@ func DefaultResourceLimits() ResourceLimits {
 	}
 }
 
-func NewCgroup(name string, controllers []Controller) (*Cgroup, error) {
-	// Prepare the cgroup name - sanitize it for use in filesystem
-	cgroupName := fmt.Sprintf("mydocker-%s", strings.Replace(name, "/", "_", -1))
-
-	cg := &Cgroup{
-		Name:        cgroupName,
-		Controllers: controllers,
+// Validate checks combinations of limits that the kernel would reject or ignore
+func (l ResourceLimits) Validate() error {
+	if l.MemoryLimit > 0 && l.MemoryLimit < MinMemoryLimit {
+		return fmt.Errorf("memory limit must be at least %d bytes (6MB)", MinMemoryLimit)
 	}
-
-	// Detect cgroups v2 unified hierarchy
-	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err == nil {
-		// We're using cgroups v2
-		cg.Path = filepath.Join("/sys/fs/cgroup", cgroupName)
-		return cg, nil
+	if l.MemorySwapLimit < MemorySwapUnlimited {
+		return fmt.Errorf("memory-swap must be -1 (unlimited) or a byte count, got %d", l.MemorySwapLimit)
 	}
-
-	// Fallback to cgroups v1
-	cg.Path = "" // We'll set individual paths per controller
-	return cg, nil
-}
-
-// Create creates the cgroup directories for all specified controllers
-func (cg *Cgroup) Create() error {
-	// Check if we're using cgroups v2
-	if cg.Path != "" {
-		// Create the unified cgroup directory
-		if err := os.MkdirAll(cg.Path, 0755); err != nil {
-			return fmt.Errorf("failed to create unified cgroup %s: %v", cg.Path, err)
+	if l.MemorySwapLimit > 0 {
+		if l.MemoryLimit == 0 {
+			return fmt.Errorf("memory-swap requires a memory limit")
 		}
-
-		// Enable controllers in the unified hierarchy
-		controllerList := []string{}
-		for _, ctrl := range cg.Controllers {
-			controllerList = append(controllerList, string(ctrl))
+		if uint64(l.MemorySwapLimit) < l.MemoryLimit {
+			return fmt.Errorf("memory-swap (%d) must be greater than or equal to memory (%d)", l.MemorySwapLimit, l.MemoryLimit)
 		}
-
-		// Try to enable controllers (may fail if we don't have permissions)
-		enablePath := filepath.Join(cg.Path, "cgroup.subtree_control")
-		_ = os.WriteFile(enablePath, []byte("+"+strings.Join(controllerList, " +")), 0644)
-
-		return nil
 	}
 
-	// For cgroups v1, create a directory for each controller
-	for _, ctrl := range cg.Controllers {
-		cgPath := filepath.Join("/sys/fs/cgroup", string(ctrl), cg.Name)
-		if err := os.MkdirAll(cgPath, 0755); err != nil {
-			return fmt.Errorf("failed to create cgroup %s: %v", cgPath, err)
-		}
+	if l.CpuShares > 0 && (l.CpuShares < 2 || l.CpuShares > 262144) {
+		return fmt.Errorf("cpu shares must be between 2 and 262144, got %d", l.CpuShares)
 	}
-
-	return nil
-}
-
-// Delete removes the cgroup
-func (cg *Cgroup) Delete() error {
-	// Check if we're using cgroups v2
-	if cg.Path != "" {
-		return os.RemoveAll(cg.Path)
+	if l.CpuPeriod > 0 && (l.CpuPeriod < 1000 || l.CpuPeriod > 1000000) {
+		return fmt.Errorf("cpu period must be between 1000 and 1000000 microseconds, got %d", l.CpuPeriod)
 	}
-
-	// For cgroups v1, remove directories for each controller
-	var lastErr error
-	for _, ctrl := range cg.Controllers {
-		cgPath := filepath.Join("/sys/fs/cgroup", string(ctrl), cg.Name)
-		if err := os.RemoveAll(cgPath); err != nil {
-			lastErr = err
-		}
+	if l.CpuQuota != -1 && l.CpuQuota != 0 && l.CpuQuota < 1000 {
+		return fmt.Errorf("cpu quota must be -1 (unlimited) or at least 1000 microseconds, got %d", l.CpuQuota)
 	}
-
-	return lastErr
-}
-
-// AddProcess adds a process to the cgroup
-func (cg *Cgroup) AddProcess(pid int) error {
-	// Check if we're using cgroups v2
-	if cg.Path != "" {
-		procsFile := filepath.Join(cg.Path, "cgroup.procs")
-		return os.WriteFile(procsFile, []byte(strconv.Itoa(pid)), 0644)
+	if l.PidsLimit < -1 {
+		return fmt.Errorf("pids limit must be -1 (unlimited) or a process count, got %d", l.PidsLimit)
 	}
 
-	// For cgroups v1, add process to each controller
-	var lastErr error
-	for _, ctrl := range cg.Controllers {
-		cgPath := filepath.Join("/sys/fs/cgroup", string(ctrl), cg.Name)
-		procsFile := filepath.Join(cgPath, "cgroup.procs")
-		if err := os.WriteFile(procsFile, []byte(strconv.Itoa(pid)), 0644); err != nil {
-			lastErr = err
+	if l.CpuBurst > 0 {
+		if l.CpuQuota <= 0 {
+			return fmt.Errorf("cpu burst requires a cpu quota")
+		}
+		if l.CpuBurst > uint64(l.CpuQuota) {
+			return fmt.Errorf("cpu burst (%d) must not exceed cpu quota (%d)", l.CpuBurst, l.CpuQuota)
 		}
 	}
-
-	return lastErr
+	return nil
 }
 
-// SetResourceLimits applies the specified resource limits to the cgroup
-func (cg *Cgroup) SetResourceLimits(limits ResourceLimits) error {
-	// Apply CPU limits
-	if err := cg.applyCpuLimits(limits); err != nil {
-		return err
-	}
-
-	// Apply memory limits
-	if err := cg.applyMemoryLimits(limits); err != nil {
-		return err
+// ResolveMemorySwap fills in the default swap allowance: a memory-limited container
+// without an explicit memory-swap may use as much swap as memory (memory-swap = 2 x memory)
+func (l *ResourceLimits) ResolveMemorySwap() {
+	if l.MemoryLimit > 0 && l.MemorySwapLimit == 0 {
+		l.MemorySwapLimit = int64(2 * l.MemoryLimit)
 	}
+}
 
-	// Apply pids limits
-	if limits.PidsLimit > 0 {
-		if err := cg.applyPidsLimits(limits); err != nil {
-			return err
-		}
+// SwapLimit returns the swap allowance on its own, as cgroups v2 limits it
+// Returns -1 when swap is unlimited and 0 when swap is disabled or no memory limit is set
+func (l ResourceLimits) SwapLimit() int64 {
+	switch {
+	case l.MemorySwapLimit == MemorySwapUnlimited:
+		return -1
+	case l.MemoryLimit == 0 || l.MemorySwapLimit <= 0:
+		return 0
+	default:
+		return l.MemorySwapLimit - int64(l.MemoryLimit)
 	}
-
-	return nil
 }
 
-func (cg *Cgroup) applyCpuLimits(limits ResourceLimits) error {
-	// Check if we're using cgroups v2
-	if cg.Path != "" {
-		// Set CPU weight (shares equivalent in cgroups v2)
-		if limits.CpuShares > 0 {
-			// Convert from shares to weight (1-10000)
-			weight := 1 + ((limits.CpuShares-2)*9999)/262142
-			if weight == 0 {
-				weight = 1
-			}
-			if err := os.WriteFile(
-				filepath.Join(cg.Path, "cpu.weight"),
-				[]byte(strconv.FormatUint(weight, 10)),
-				0644,
-			); err != nil {
-				return fmt.Errorf("failed to set cpu weight: %v", err)
-			}
-		}
-
-		// Set CPU quota and period
-		if limits.CpuQuota > 0 {
-			maxVal := limits.CpuQuota
-			periodVal := limits.CpuPeriod
-
-			if periodVal == 0 {
-				periodVal = 100000 // 100ms default
-			}
-
-			// Format: "max quota period"
-			maxStr := fmt.Sprintf("%d %d", maxVal, periodVal)
-			if err := os.WriteFile(
-				filepath.Join(cg.Path, "cpu.max"),
-				[]byte(maxStr),
-				0644,
-			); err != nil {
-				return fmt.Errorf("failed to set cpu.max: %v", err)
-			}
-		}
+// Manager controls the cgroup of a single container
+type Manager interface {
+	// Create creates the cgroup and enables the controllers it needs
+	Create() error
+	// Delete removes the cgroup; it must not contain any processes
+	Delete() error
+	// AddProcess moves a process into the cgroup
+	AddProcess(pid int) error
+	// SetResourceLimits writes the limits to the cgroup's controller files
+	SetResourceLimits(limits ResourceLimits) error
+	// Stats reads the cgroup's resource usage counters
+	Stats() (*Stats, error)
+	// Pressure reads the cgroup's pressure stall information for cpu, memory and io
+	Pressure() (map[string]PSIStats, error)
+}
 
-		return nil
-	}
+// Version identifies the cgroup hierarchy layout of the host
+type Version int
 
-	// For cgroups v1
-	cpuCgPath := filepath.Join("/sys/fs/cgroup", "cpu", cg.Name)
+// Supported cgroup versions
+const (
+	V1 Version = 1 // One hierarchy per controller under /sys/fs/cgroup/<controller>
+	V2 Version = 2 // Single unified hierarchy at /sys/fs/cgroup
+)
 
-	// Set CPU shares
-	if limits.CpuShares > 0 {
-		if err := os.WriteFile(
-			filepath.Join(cpuCgPath, "cpu.shares"),
-			[]byte(strconv.FormatUint(limits.CpuShares, 10)),
-			0644,
-		); err != nil {
-			return fmt.Errorf("failed to set cpu shares: %v", err)
-		}
+// DetectVersion reports which cgroup hierarchy the host uses
+// Hybrid hosts that mount v2 only at /sys/fs/cgroup/unified are treated as v1
+func DetectVersion() Version {
+	if _, err := os.Stat(cgroupRoot + "/cgroup.controllers"); err == nil {
+		return V2
 	}
+	return V1
+}
 
-	// Set CPU quota
-	if limits.CpuQuota >= 0 {
-		if err := os.WriteFile(
-			filepath.Join(cpuCgPath, "cpu.cfs_quota_us"),
-			[]byte(strconv.FormatInt(limits.CpuQuota, 10)),
-			0644,
-		); err != nil {
-			return fmt.Errorf("failed to set cpu quota: %v", err)
-		}
+// Controllers returns the controllers a container cgroup needs to enforce limits on this hierarchy
+func (v Version) Controllers(limits ResourceLimits) []Controller {
+	var controllers []Controller
+	if v == V2 {
+		controllers = []Controller{Cpu, Memory, Pids, IO}
+	} else {
+		controllers = []Controller{Cpu, CpuAcct, Memory, Pids, BlkIO}
 	}
 
-	// Set CPU period
-	if limits.CpuPeriod > 0 {
-		if err := os.WriteFile(
-			filepath.Join(cpuCgPath, "cpu.cfs_period_us"),
-			[]byte(strconv.FormatUint(limits.CpuPeriod, 10)),
-			0644,
-		); err != nil {
-			return fmt.Errorf("failed to set cpu period: %v", err)
-		}
+	if len(limits.HugetlbLimits) > 0 {
+		controllers = append(controllers, Hugetlb)
 	}
-
-	return nil
+	return controllers
 }
 
-// applyMemoryLimits applies memory-specific limits
-func (cg *Cgroup) applyMemoryLimits(limits ResourceLimits) error {
-	// Check if we're using cgroups v2
-	if cg.Path != "" {
-		// Set memory limit
-		if limits.MemoryLimit > 0 {
-			if err := os.WriteFile(
-				filepath.Join(cg.Path, "memory.max"),
-				[]byte(strconv.FormatUint(limits.MemoryLimit, 10)),
-				0644,
-			); err != nil {
-				return fmt.Errorf("failed to set memory.max: %v", err)
-			}
-		}
+// AllControllers returns every controller a container cgroup may use on this hierarchy
+func (v Version) AllControllers() []Controller {
+	return append(v.Controllers(ResourceLimits{}), Hugetlb)
+}
 
-		// Set memory+swap limit
-		if limits.MemorySwapLimit > 0 {
-			if err := os.WriteFile(
-				filepath.Join(cg.Path, "memory.swap.max"),
-				[]byte(strconv.FormatUint(limits.MemorySwapLimit-limits.MemoryLimit, 10)),
-				0644,
-			); err != nil {
-				// Swap limit may not be supported, ignore errors
-				fmt.Printf("Warning: failed to set swap limit: %v\n", err)
-			}
+// ContainerIDs returns the IDs of the container cgroups present on the host, whether or not the daemon knows them
+func (v Version) ContainerIDs() ([]string, error) {
+	var dirs []string
+	if v == V2 {
+		dirs = []string{filepath.Join(cgroupRoot, SliceName)}
+	} else {
+		for _, ctrl := range v.AllControllers() {
+			dirs = append(dirs, filepath.Join(cgroupRoot, string(ctrl)))
 		}
-
-		return nil
 	}
 
-	// For cgroups v1
-	memCgPath := filepath.Join("/sys/fs/cgroup", "memory", cg.Name)
-
-	// Set memory limit
-	if limits.MemoryLimit > 0 {
-		if err := os.WriteFile(
-			filepath.Join(memCgPath, "memory.limit_in_bytes"),
-			[]byte(strconv.FormatUint(limits.MemoryLimit, 10)),
-			0644,
-		); err != nil {
-			return fmt.Errorf("failed to set memory limit: %v", err)
+	// On v1 the same container shows up once per hierarchy
+	seen := make(map[string]bool)
+	var ids []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
 		}
-	}
-
-	// Set memory+swap limit
-	if limits.MemorySwapLimit > 0 {
-		if err := os.WriteFile(
-			filepath.Join(memCgPath, "memory.memsw.limit_in_bytes"),
-			[]byte(strconv.FormatUint(limits.MemorySwapLimit, 10)),
-			0644,
-		); err != nil {
-			// Swap limit may not be supported, ignore errors
-			fmt.Printf("Warning: failed to set swap limit: %v\n", err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cgroups in %s: %v", dir, err)
+		}
+		for _, entry := range entries {
+			id, ok := strings.CutPrefix(entry.Name(), "mydocker-")
+			if !ok || !entry.IsDir() || seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
 		}
 	}
-
-	return nil
+	return ids, nil
 }
 
-// applyPidsLimits applies process count limits
-func (cg *Cgroup) applyPidsLimits(limits ResourceLimits) error {
-	// Check if we're using cgroups v2
-	if cg.Path != "" {
-		return os.WriteFile(
-			filepath.Join(cg.Path, "pids.max"),
-			[]byte(strconv.FormatInt(limits.PidsLimit, 10)),
-			0644,
-		)
+// NewManager returns the manager for a container's cgroup on the given hierarchy
+// Nothing is created until Create is called
+func NewManager(version Version, id string, controllers []Controller) Manager {
+	// Sanitize the name for use in the filesystem
+	name := fmt.Sprintf("mydocker-%s", strings.ReplaceAll(id, "/", "_"))
+	if version == V2 {
+		return newV2Manager(name, controllers)
 	}
-
-	// For cgroups v1
-	pidsCgPath := filepath.Join("/sys/fs/cgroup", "pids", cg.Name)
-	return os.WriteFile(
-		filepath.Join(pidsCgPath, "pids.max"),
-		[]byte(strconv.FormatInt(limits.PidsLimit, 10)),
-		0644,
-	)
+	return newV1Manager(name, controllers)
 }
@@ -0,0 +1,51 @@
+package cgroups
+
+import "testing"
+
+func TestMemorySwapSemantics(t *testing.T) {
+	const mb = 1024 * 1024
+
+	tests := []struct {
+		name         string
+		memory       uint64
+		memorySwap   int64
+		wantErr      bool
+		wantResolved int64 // Memory+swap after ResolveMemorySwap
+		wantSwap     int64 // Swap on its own, as cgroups v2 limits it
+	}{
+		{name: "no limits"},
+		{name: "default is as much swap as memory", memory: 64 * mb, wantResolved: 128 * mb, wantSwap: 64 * mb},
+		{name: "memory+swap above memory", memory: 64 * mb, memorySwap: 100 * mb, wantResolved: 100 * mb, wantSwap: 36 * mb},
+		{name: "memory+swap equal to memory disables swap", memory: 64 * mb, memorySwap: 64 * mb, wantResolved: 64 * mb, wantSwap: 0},
+		{name: "unlimited swap", memory: 64 * mb, memorySwap: MemorySwapUnlimited, wantResolved: MemorySwapUnlimited, wantSwap: -1},
+		{name: "unlimited swap without memory", memorySwap: MemorySwapUnlimited, wantResolved: MemorySwapUnlimited, wantSwap: -1},
+		{name: "swap smaller than memory", memory: 64 * mb, memorySwap: 32 * mb, wantErr: true},
+		{name: "swap without memory", memorySwap: 64 * mb, wantErr: true},
+		{name: "below unlimited", memory: 64 * mb, memorySwap: -2, wantErr: true},
+		{name: "memory below the minimum", memory: 1 * mb, memorySwap: 2 * mb, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := ResourceLimits{MemoryLimit: tt.memory, MemorySwapLimit: tt.memorySwap}
+			err := limits.Validate()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Validate accepted memory %d with memory-swap %d", tt.memory, tt.memorySwap)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			limits.ResolveMemorySwap()
+			if limits.MemorySwapLimit != tt.wantResolved {
+				t.Errorf("ResolveMemorySwap set memory-swap %d, want %d", limits.MemorySwapLimit, tt.wantResolved)
+			}
+			if got := limits.SwapLimit(); got != tt.wantSwap {
+				t.Errorf("SwapLimit = %d, want %d", got, tt.wantSwap)
+			}
+		})
+	}
+}
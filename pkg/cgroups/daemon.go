@@ -0,0 +1,54 @@
+package cgroups
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// defaultCpuShares is the CPU weight of a cgroup that doesn't set one
+const defaultCpuShares = 1024
+
+// ProtectDaemon moves the daemon into its own cgroup and reserves CPU and memory for it there
+// A zero memoryReservation removes the reservation and zero cpuShares restores the default weight
+// Memory can only be reserved on cgroups v2 (memory.min); v1 has no equivalent
+func (v Version) ProtectDaemon(memoryReservation, cpuShares uint64) error {
+	if cpuShares == 0 {
+		cpuShares = defaultCpuShares
+	}
+	pid := strconv.Itoa(os.Getpid())
+
+	if v == V1 {
+		if memoryReservation > 0 {
+			return fmt.Errorf("reserving memory for the daemon requires cgroups v2")
+		}
+		dir := filepath.Join(cgroupRoot, string(Cpu), daemonCgroup)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create cgroup %s: %v", dir, err)
+		}
+		if err := writeValue(dir, "cpu.shares", strconv.FormatUint(cpuShares, 10)); err != nil {
+			return err
+		}
+		return writeValue(dir, "cgroup.procs", pid)
+	}
+
+	// The daemon cgroup sits next to the container cgroups, so the slice has to pass the reservation down
+	slice := filepath.Join(cgroupRoot, SliceName)
+	leaf := filepath.Join(slice, daemonCgroup)
+	if err := os.MkdirAll(leaf, 0755); err != nil {
+		return fmt.Errorf("failed to create cgroup %s: %v", leaf, err)
+	}
+	if err := writeValue(leaf, "cgroup.procs", pid); err != nil {
+		return err
+	}
+	if err := writeValue(leaf, "cpu.weight", strconv.FormatUint(sharesToWeight(cpuShares), 10)); err != nil {
+		return err
+	}
+	for _, dir := range []string{slice, leaf} {
+		if err := writeValue(dir, "memory.min", strconv.FormatUint(memoryReservation, 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cgroups
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// defaultCpuPeriod is the CFS period the kernel uses when none is set, in microseconds
+const defaultCpuPeriod = 100000
+
+// CheckHost verifies that this host can enforce limits before any cgroup is created
+// Limits the kernel would reject, or that a missing controller would leave unenforced, are reported with the reason
+func (v Version) CheckHost(limits ResourceLimits) error {
+	for _, ctrl := range v.Controllers(limits) {
+		if !v.controllerAvailable(ctrl) {
+			return fmt.Errorf("cgroup controller %s is not available on this host (cgroups v%d)", ctrl, v)
+		}
+	}
+
+	if limits.MemoryLimit > 0 {
+		total, err := HostMemoryTotal()
+		if err != nil {
+			return err
+		}
+		if limits.MemoryLimit > total {
+			return fmt.Errorf("memory limit %d exceeds the host's total memory of %d bytes", limits.MemoryLimit, total)
+		}
+	}
+
+	if limits.CpuQuota > 0 {
+		period := limits.CpuPeriod
+		if period == 0 {
+			period = defaultCpuPeriod
+		}
+		cpus := float64(limits.CpuQuota) / float64(period)
+		if cpus > float64(runtime.NumCPU()) {
+			return fmt.Errorf("cpu quota %d over period %d allows %.2f CPUs, but the host only has %d", limits.CpuQuota, period, cpus, runtime.NumCPU())
+		}
+	}
+
+	// CFS burst needs Linux 5.14 or newer; older kernels don't have the file at all
+	if limits.CpuBurst > 0 {
+		burstFile := filepath.Join(cgroupRoot, string(Cpu), "cpu.cfs_burst_us")
+		if v == V2 {
+			burstFile = filepath.Join(cgroupRoot, SliceName, "cpu.max.burst")
+		}
+		if _, err := os.Stat(burstFile); err != nil {
+			return fmt.Errorf("the kernel does not support cpu burst (requires Linux 5.14 or newer)")
+		}
+	}
+
+	return nil
+}
+
+// controllerAvailable reports whether containers can be placed in the controller on this hierarchy
+func (v Version) controllerAvailable(ctrl Controller) bool {
+	if v == V2 {
+		available, err := readControllers(filepath.Join(cgroupRoot, "cgroup.controllers"))
+		return err == nil && available[string(ctrl)]
+	}
+
+	_, err := os.Stat(filepath.Join(cgroupRoot, string(ctrl)))
+	return err == nil
+}
+
+// HostMemoryTotal returns the host's total memory in bytes
+func HostMemoryTotal() (uint64, error) {
+	return readMeminfo("MemTotal")
+}
+
+// HostMemoryAvailable returns the memory the host can give to new allocations without swapping, in bytes
+func HostMemoryAvailable() (uint64, error) {
+	return readMeminfo("MemAvailable")
+}
+
+// readMeminfo returns a /proc/meminfo value in bytes
+func readMeminfo(key string) (uint64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == key+":" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, fmt.Errorf("%s not found in /proc/meminfo", key)
+}
@@ -0,0 +1,77 @@
+package cgroups
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// hugepagesDir lists one directory per huge page size supported by the kernel, e.g. "hugepages-2048kB"
+const hugepagesDir = "/sys/kernel/mm/hugepages"
+
+// HugePageSizes returns the huge page sizes supported by the host in cgroup notation, e.g. "2MB", "1GB"
+func HugePageSizes() ([]string, error) {
+	entries, err := os.ReadDir(hugepagesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", hugepagesDir, err)
+	}
+
+	var sizes []string
+	for _, entry := range entries {
+		kb, ok := strings.CutPrefix(entry.Name(), "hugepages-")
+		if !ok {
+			continue
+		}
+		kb, ok = strings.CutSuffix(kb, "kB")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(kb, 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes = append(sizes, hugePageSizeName(n))
+	}
+	return sizes, nil
+}
+
+// hugePageSizeName converts a page size in kB to the name cgroup files use, e.g. 2048 -> "2MB"
+func hugePageSizeName(kb uint64) string {
+	units := []string{"KB", "MB", "GB"}
+	i := 0
+	for kb >= 1024 && kb%1024 == 0 && i < len(units)-1 {
+		kb /= 1024
+		i++
+	}
+	return fmt.Sprintf("%d%s", kb, units[i])
+}
+
+// ValidateHugetlbLimits checks that every page size in limits is supported by the host
+func ValidateHugetlbLimits(limits map[string]uint64) error {
+	if len(limits) == 0 {
+		return nil
+	}
+
+	sizes, err := HugePageSizes()
+	if err != nil {
+		return err
+	}
+
+	for size, limit := range limits {
+		supported := false
+		for _, s := range sizes {
+			if s == size {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("huge page size %s is not supported by this host (supported: %s)", size, strings.Join(sizes, ", "))
+		}
+		if limit == 0 {
+			return fmt.Errorf("hugetlb limit for %s must be greater than 0", size)
+		}
+	}
+	return nil
+}
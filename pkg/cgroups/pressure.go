@@ -0,0 +1,72 @@
+package cgroups
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Resources that report pressure stall information
+var PressureResources = []string{"cpu", "memory", "io"}
+
+// PSIData holds one line of a pressure file
+type PSIData struct {
+	Avg10  float64 // Percentage of time stalled over the last 10 seconds
+	Avg60  float64
+	Avg300 float64
+	Total  uint64 // Total stall time in microseconds
+}
+
+// PSIStats holds the "some" and "full" lines of a pressure file
+// some: at least one task was stalled, full: all non-idle tasks were stalled at the same time
+type PSIStats struct {
+	Some PSIData
+	Full PSIData
+}
+
+// readPSI parses a pressure file with lines like "some avg10=0.00 avg60=0.00 avg300=0.00 total=0"
+func readPSI(path string) (PSIStats, error) {
+	var stats PSIStats
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var psi *PSIData
+		switch fields[0] {
+		case "some":
+			psi = &stats.Some
+		case "full":
+			psi = &stats.Full
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "avg10":
+				psi.Avg10, _ = strconv.ParseFloat(value, 64)
+			case "avg60":
+				psi.Avg60, _ = strconv.ParseFloat(value, 64)
+			case "avg300":
+				psi.Avg300, _ = strconv.ParseFloat(value, 64)
+			case "total":
+				psi.Total, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,77 @@
+package cgroups
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Stats holds the resource usage counters of a cgroup
+type Stats struct {
+	CpuUsage    uint64 // Total CPU time consumed in nanoseconds
+	MemoryUsage uint64 // Current memory usage in bytes, including page cache
+	MemoryCache uint64 // Inactive page cache in bytes, reclaimable under pressure
+	MemoryLimit uint64 // Memory limit in bytes (0 if unlimited)
+	PidsCurrent uint64 // Number of processes in the cgroup
+	PidsLimit   uint64 // Maximum number of processes (0 if unlimited)
+	IoRead      uint64 // Bytes read from block devices
+	IoWrite     uint64 // Bytes written to block devices
+
+	// Counters of the cgroup running into its limits, which only ever grow
+	CpuThrottled     uint64 // CPU periods in which the quota throttled the cgroup
+	MemoryHighEvents uint64 // Times usage went over memory.high and was throttled (cgroups v2 only)
+	MemoryMaxEvents  uint64 // Times usage hit the memory limit and forced reclaim
+}
+
+// readUint reads a single unsigned integer from a cgroup file
+// The value "max" is returned as 0, meaning unlimited
+func readUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return n, nil
+}
+
+// readKeyValues parses a flat-keyed cgroup file with "key value" lines, such as cpu.stat or memory.stat
+func readKeyValues(path string) (map[string]uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if n, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = n
+		}
+	}
+	return values, scanner.Err()
+}
+
+// writeValue writes a value to a cgroup control file
+func writeValue(dir, file, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to set %s: %v", file, err)
+	}
+	return nil
+}
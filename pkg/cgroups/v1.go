@@ -0,0 +1,219 @@
+package cgroups
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// unlimitedV1 is the smallest value cgroups v1 reports for "no limit" (page-aligned LLONG_MAX)
+const unlimitedV1 = 1 << 62
+
+// v1Manager manages a cgroup with one directory per controller hierarchy
+type v1Manager struct {
+	name        string
+	controllers []Controller
+}
+
+func newV1Manager(name string, controllers []Controller) *v1Manager {
+	return &v1Manager{name: name, controllers: controllers}
+}
+
+// path returns the cgroup directory in the given controller's hierarchy
+func (m *v1Manager) path(ctrl Controller) string {
+	return filepath.Join(cgroupRoot, string(ctrl), m.name)
+}
+
+// Create creates the cgroup directory in each controller hierarchy
+func (m *v1Manager) Create() error {
+	for _, ctrl := range m.controllers {
+		// The controller hierarchy must be mounted, otherwise MkdirAll would create plain directories on the tmpfs
+		if _, err := os.Stat(filepath.Join(cgroupRoot, string(ctrl))); err != nil {
+			return fmt.Errorf("cgroup controller %s is not mounted", ctrl)
+		}
+
+		if err := os.MkdirAll(m.path(ctrl), 0755); err != nil {
+			return fmt.Errorf("failed to create cgroup %s: %v", m.path(ctrl), err)
+		}
+	}
+	return nil
+}
+
+// Delete removes the cgroup directory from every hierarchy, reporting all failures
+func (m *v1Manager) Delete() error {
+	var errs []error
+	for _, ctrl := range m.controllers {
+		// cgroup directories are removed with rmdir; their control files can't be deleted
+		if err := os.Remove(m.path(ctrl)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("failed to remove cgroup %s: %v", m.path(ctrl), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddProcess moves a process into the cgroup of every controller
+func (m *v1Manager) AddProcess(pid int) error {
+	for _, ctrl := range m.controllers {
+		if err := writeValue(m.path(ctrl), "cgroup.procs", strconv.Itoa(pid)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetResourceLimits applies the specified resource limits to the cgroup
+func (m *v1Manager) SetResourceLimits(limits ResourceLimits) error {
+	cpuPath := m.path(Cpu)
+	if limits.CpuShares > 0 {
+		if err := writeValue(cpuPath, "cpu.shares", strconv.FormatUint(limits.CpuShares, 10)); err != nil {
+			return err
+		}
+	}
+	if limits.CpuQuota != 0 {
+		if err := writeValue(cpuPath, "cpu.cfs_quota_us", strconv.FormatInt(limits.CpuQuota, 10)); err != nil {
+			return err
+		}
+	}
+	if limits.CpuPeriod > 0 {
+		if err := writeValue(cpuPath, "cpu.cfs_period_us", strconv.FormatUint(limits.CpuPeriod, 10)); err != nil {
+			return err
+		}
+	}
+	// Written after the quota and period, which bound it
+	if limits.CpuBurst > 0 {
+		if err := writeValue(cpuPath, "cpu.cfs_burst_us", strconv.FormatUint(limits.CpuBurst, 10)); err != nil {
+			return err
+		}
+	}
+
+	if err := m.setMemoryLimits(limits); err != nil {
+		return err
+	}
+
+	if limits.PidsLimit > 0 {
+		if err := writeValue(m.path(Pids), "pids.max", strconv.FormatInt(limits.PidsLimit, 10)); err != nil {
+			return err
+		}
+	}
+
+	for size, limit := range limits.HugetlbLimits {
+		if err := writeValue(m.path(Hugetlb), fmt.Sprintf("hugetlb.%s.limit_in_bytes", size), strconv.FormatUint(limit, 10)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setMemoryLimits writes the memory and memory+swap limits
+// The kernel keeps memsw at or above the memory limit, so raising the limits of a running cgroup writes memsw first
+func (m *v1Manager) setMemoryLimits(limits ResourceLimits) error {
+	if limits.MemoryLimit == 0 {
+		return nil
+	}
+	memPath := m.path(Memory)
+
+	writeMemory := func() error {
+		return writeValue(memPath, "memory.limit_in_bytes", strconv.FormatUint(limits.MemoryLimit, 10))
+	}
+	// memory.memsw.limit_in_bytes limits memory plus swap; -1 lifts the limit
+	writeSwap := func() error {
+		if limits.MemorySwapLimit == 0 {
+			return nil
+		}
+		// memsw files only exist when the kernel accounts swap (swapaccount=1)
+		if _, err := os.Stat(filepath.Join(memPath, "memory.memsw.limit_in_bytes")); os.IsNotExist(err) {
+			if limits.MemorySwapLimit != MemorySwapUnlimited {
+				fmt.Printf("Warning: kernel does not support swap limits, memory-swap ignored\n")
+			}
+			return nil
+		}
+		return writeValue(memPath, "memory.memsw.limit_in_bytes", strconv.FormatInt(limits.MemorySwapLimit, 10))
+	}
+
+	current, err := readUint(filepath.Join(memPath, "memory.limit_in_bytes"))
+	if err != nil {
+		return err
+	}
+	if limits.MemoryLimit > current {
+		if err := writeSwap(); err != nil {
+			return err
+		}
+		return writeMemory()
+	}
+	if err := writeMemory(); err != nil {
+		return err
+	}
+	return writeSwap()
+}
+
+// Stats reads the resource usage counters from each controller hierarchy
+func (m *v1Manager) Stats() (*Stats, error) {
+	stats := &Stats{}
+	var err error
+
+	if stats.CpuUsage, err = readUint(filepath.Join(m.path(CpuAcct), "cpuacct.usage")); err != nil {
+		return nil, err
+	}
+	cpuStat, err := readKeyValues(filepath.Join(m.path(Cpu), "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stats.CpuThrottled = cpuStat["nr_throttled"]
+
+	memPath := m.path(Memory)
+	if stats.MemoryUsage, err = readUint(filepath.Join(memPath, "memory.usage_in_bytes")); err != nil {
+		return nil, err
+	}
+	if stats.MemoryLimit, err = readUint(filepath.Join(memPath, "memory.limit_in_bytes")); err != nil {
+		return nil, err
+	}
+	if stats.MemoryLimit >= unlimitedV1 {
+		stats.MemoryLimit = 0
+	}
+	memStat, err := readKeyValues(filepath.Join(memPath, "memory.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stats.MemoryCache = memStat["total_inactive_file"]
+	if stats.MemoryMaxEvents, err = readUint(filepath.Join(memPath, "memory.failcnt")); err != nil {
+		return nil, err
+	}
+
+	pidsPath := m.path(Pids)
+	if stats.PidsCurrent, err = readUint(filepath.Join(pidsPath, "pids.current")); err != nil {
+		return nil, err
+	}
+	if stats.PidsLimit, err = readUint(filepath.Join(pidsPath, "pids.max")); err != nil {
+		return nil, err
+	}
+
+	// blkio.throttle.io_service_bytes has lines like "8:0 Read 1234" plus a "Total" line
+	data, err := os.ReadFile(filepath.Join(m.path(BlkIO), "blkio.throttle.io_service_bytes"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read blkio stats: %v", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		n, _ := strconv.ParseUint(fields[2], 10, 64)
+		switch fields[1] {
+		case "Read":
+			stats.IoRead += n
+		case "Write":
+			stats.IoWrite += n
+		}
+	}
+
+	return stats, nil
+}
+
+// Pressure is not available per cgroup on v1 hierarchies
+func (m *v1Manager) Pressure() (map[string]PSIStats, error) {
+	return nil, fmt.Errorf("pressure stall information requires cgroups v2")
+}
@@ -0,0 +1,309 @@
+package cgroups
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// SliceName is the cgroup that holds all container cgroups on cgroups v2
+// Keeping containers out of the daemon's own cgroup avoids the no-internal-process rule,
+// which forbids enabling controllers for children of a cgroup that has processes of its own
+const SliceName = "mydocker.slice"
+
+// daemonCgroup is the leaf cgroup inside SliceName the daemon moves to when it starts in the hierarchy root
+const daemonCgroup = "mydockerd"
+
+// v2Manager manages a cgroup in the unified hierarchy
+type v2Manager struct {
+	path        string
+	controllers []Controller
+}
+
+func newV2Manager(name string, controllers []Controller) *v2Manager {
+	return &v2Manager{
+		path:        filepath.Join(cgroupRoot, SliceName, name),
+		controllers: controllers,
+	}
+}
+
+// UnifiedPath returns the directory of a container's cgroup in the unified hierarchy, or "" on cgroups v1
+func UnifiedPath(m Manager) string {
+	if v2, ok := m.(*v2Manager); ok {
+		return v2.path
+	}
+	return ""
+}
+
+// SetupV2 prepares the unified hierarchy for container cgroups
+// It creates SliceName, moves the daemon out of the hierarchy root into a leaf cgroup if needed,
+// and delegates the default controllers down to SliceName so problems surface at startup
+func SetupV2() error {
+	slice := filepath.Join(cgroupRoot, SliceName)
+	if err := os.MkdirAll(slice, 0755); err != nil {
+		return fmt.Errorf("failed to create cgroup %s: %v", slice, err)
+	}
+
+	// A daemon in the root of a namespaced hierarchy would keep the root from delegating controllers
+	current, err := ownCgroup()
+	if err != nil {
+		return err
+	}
+	if current == "/" {
+		leaf := filepath.Join(slice, daemonCgroup)
+		if err := os.MkdirAll(leaf, 0755); err != nil {
+			return fmt.Errorf("failed to create cgroup %s: %v", leaf, err)
+		}
+		if err := writeValue(leaf, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+			return fmt.Errorf("failed to move daemon into %s: %v", leaf, err)
+		}
+	}
+
+	return delegate(V2.Controllers(ResourceLimits{}))
+}
+
+// ownCgroup returns the daemon's cgroup path relative to the unified hierarchy root
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("failed to read /proc/self/cgroup: %v", err)
+	}
+
+	// The unified hierarchy is the "0::<path>" entry
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("unified hierarchy not found in /proc/self/cgroup")
+}
+
+// delegate enables controllers on the hierarchy root and on SliceName so container cgroups receive them
+func delegate(controllers []Controller) error {
+	if err := enableControllers(cgroupRoot, controllers); err != nil {
+		if errors.Is(err, syscall.EBUSY) {
+			return fmt.Errorf("%v (processes in the root cgroup %s must be moved to a leaf cgroup first)", err, cgroupRoot)
+		}
+		return err
+	}
+	return enableControllers(filepath.Join(cgroupRoot, SliceName), controllers)
+}
+
+// Create enables the needed controllers down to SliceName and creates the cgroup
+// Controllers only show up in a child cgroup once the parent lists them in cgroup.subtree_control
+func (m *v2Manager) Create() error {
+	if err := delegate(m.controllers); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.path, 0755); err != nil {
+		return fmt.Errorf("failed to create cgroup %s: %v", m.path, err)
+	}
+	return nil
+}
+
+// enableControllers makes sure the parent cgroup delegates each controller to its children
+func enableControllers(parent string, controllers []Controller) error {
+	available, err := readControllers(filepath.Join(parent, "cgroup.controllers"))
+	if err != nil {
+		return err
+	}
+	enabled, err := readControllers(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+
+	for _, ctrl := range controllers {
+		if enabled[string(ctrl)] {
+			continue
+		}
+		if !available[string(ctrl)] {
+			return fmt.Errorf("cgroup controller %s is not available in %s", ctrl, parent)
+		}
+		// Enable one controller per write so a failure names the controller
+		if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+"+string(ctrl)), 0644); err != nil {
+			return fmt.Errorf("failed to enable cgroup controller %s in %s: %w", ctrl, parent, err)
+		}
+	}
+	return nil
+}
+
+// readControllers parses a space-separated controller list such as cgroup.controllers
+func readControllers(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	controllers := make(map[string]bool)
+	for _, name := range strings.Fields(string(data)) {
+		controllers[name] = true
+	}
+	return controllers, nil
+}
+
+// Delete removes the cgroup
+func (m *v2Manager) Delete() error {
+	// cgroup directories are removed with rmdir; their control files can't be deleted
+	if err := os.Remove(m.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cgroup %s: %v", m.path, err)
+	}
+	return nil
+}
+
+// AddProcess moves a process into the cgroup
+func (m *v2Manager) AddProcess(pid int) error {
+	return writeValue(m.path, "cgroup.procs", strconv.Itoa(pid))
+}
+
+// SetResourceLimits applies the specified resource limits to the cgroup
+func (m *v2Manager) SetResourceLimits(limits ResourceLimits) error {
+	if limits.CpuShares > 0 {
+		if err := writeValue(m.path, "cpu.weight", strconv.FormatUint(sharesToWeight(limits.CpuShares), 10)); err != nil {
+			return err
+		}
+	}
+	if limits.CpuQuota > 0 {
+		period := limits.CpuPeriod
+		if period == 0 {
+			period = 100000 // 100ms default
+		}
+		// Format: "quota period"
+		if err := writeValue(m.path, "cpu.max", fmt.Sprintf("%d %d", limits.CpuQuota, period)); err != nil {
+			return err
+		}
+	}
+	// Only has an effect together with a quota
+	if limits.CpuBurst > 0 {
+		if err := writeValue(m.path, "cpu.max.burst", strconv.FormatUint(limits.CpuBurst, 10)); err != nil {
+			return err
+		}
+	}
+
+	if limits.MemoryLimit > 0 {
+		if err := writeValue(m.path, "memory.max", strconv.FormatUint(limits.MemoryLimit, 10)); err != nil {
+			return err
+		}
+	}
+	if limits.MemoryHigh > 0 {
+		if err := writeValue(m.path, "memory.high", strconv.FormatUint(limits.MemoryHigh, 10)); err != nil {
+			return err
+		}
+	}
+	// memory.swap.max limits swap on its own rather than memory plus swap
+	if limits.MemoryLimit > 0 && limits.MemorySwapLimit != 0 {
+		swapMax := "max"
+		if swap := limits.SwapLimit(); swap >= 0 {
+			swapMax = strconv.FormatInt(swap, 10)
+		}
+
+		if _, err := os.Stat(filepath.Join(m.path, "memory.swap.max")); os.IsNotExist(err) {
+			if swapMax != "max" {
+				fmt.Printf("Warning: kernel does not support swap limits, memory-swap ignored\n")
+			}
+		} else if err := writeValue(m.path, "memory.swap.max", swapMax); err != nil {
+			return err
+		}
+	}
+
+	if limits.PidsLimit > 0 {
+		if err := writeValue(m.path, "pids.max", strconv.FormatInt(limits.PidsLimit, 10)); err != nil {
+			return err
+		}
+	}
+
+	for size, limit := range limits.HugetlbLimits {
+		if err := writeValue(m.path, fmt.Sprintf("hugetlb.%s.max", size), strconv.FormatUint(limit, 10)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sharesToWeight converts v1 CPU shares (2-262144) to a v2 weight (1-10000)
+func sharesToWeight(shares uint64) uint64 {
+	weight := 1 + ((shares-2)*9999)/262142
+	if weight == 0 {
+		weight = 1
+	}
+	return weight
+}
+
+// Stats reads the resource usage counters of the cgroup
+func (m *v2Manager) Stats() (*Stats, error) {
+	stats := &Stats{}
+
+	// CPU usage is reported in microseconds
+	cpuStat, err := readKeyValues(filepath.Join(m.path, "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stats.CpuUsage = cpuStat["usage_usec"] * 1000
+	stats.CpuThrottled = cpuStat["nr_throttled"]
+
+	if stats.MemoryUsage, err = readUint(filepath.Join(m.path, "memory.current")); err != nil {
+		return nil, err
+	}
+	if stats.MemoryLimit, err = readUint(filepath.Join(m.path, "memory.max")); err != nil {
+		return nil, err
+	}
+	memStat, err := readKeyValues(filepath.Join(m.path, "memory.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stats.MemoryCache = memStat["inactive_file"]
+	memEvents, err := readKeyValues(filepath.Join(m.path, "memory.events"))
+	if err != nil {
+		return nil, err
+	}
+	stats.MemoryHighEvents = memEvents["high"]
+	stats.MemoryMaxEvents = memEvents["max"]
+
+	if stats.PidsCurrent, err = readUint(filepath.Join(m.path, "pids.current")); err != nil {
+		return nil, err
+	}
+	if stats.PidsLimit, err = readUint(filepath.Join(m.path, "pids.max")); err != nil {
+		return nil, err
+	}
+
+	// io.stat has one line per device: "8:0 rbytes=1 wbytes=2 rios=3 ..."
+	data, err := os.ReadFile(filepath.Join(m.path, "io.stat"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read io.stat: %v", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		for _, field := range strings.Fields(line) {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			n, _ := strconv.ParseUint(value, 10, 64)
+			switch key {
+			case "rbytes":
+				stats.IoRead += n
+			case "wbytes":
+				stats.IoWrite += n
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// Pressure reads the pressure stall information of the cgroup for cpu, memory and io
+func (m *v2Manager) Pressure() (map[string]PSIStats, error) {
+	pressure := make(map[string]PSIStats, len(PressureResources))
+	for _, resource := range PressureResources {
+		stats, err := readPSI(filepath.Join(m.path, resource+".pressure"))
+		if err != nil {
+			return nil, err
+		}
+		pressure[resource] = stats
+	}
+	return pressure, nil
+}
@@ -0,0 +1,284 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/version"
+)
+
+// DefaultSocketPath is the Unix socket the daemon listens on by default
+const DefaultSocketPath = "/var/run/mydocker.sock"
+
+// Client is a typed client for the mydocker daemon API
+type Client struct {
+	socketPath string
+	ssh        *sshTarget // Set when the daemon is reached over ssh instead of a local socket
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// Opt configures a Client
+type Opt func(*Client) error
+
+// WithSocketPath sets the Unix socket used to reach the daemon
+func WithSocketPath(path string) Opt {
+	return func(c *Client) error {
+		if path == "" {
+			return fmt.Errorf("socket path cannot be empty")
+		}
+		c.socketPath = path
+		return nil
+	}
+}
+
+// WithHost sets the daemon address as a URL: unix:///path/to/socket, or
+// ssh://[user@]host[:port][/path/to/socket] to reach a remote daemon through the ssh command
+// The remote host needs mydocker on its PATH; without a path, its default socket is used
+func WithHost(host string) Opt {
+	return func(c *Client) error {
+		u, err := url.Parse(host)
+		if err != nil {
+			return fmt.Errorf("invalid host %q: %v", host, err)
+		}
+
+		switch u.Scheme {
+		case "unix":
+			c.ssh = nil
+			return WithSocketPath(u.Path)(c)
+		case "ssh":
+			target, err := parseSSHHost(u)
+			if err != nil {
+				return err
+			}
+			c.ssh = target
+			return nil
+		default:
+			return fmt.Errorf("unsupported host %q, expected unix:// or ssh://", host)
+		}
+	}
+}
+
+// WithTimeout sets the timeout for non-streaming requests
+func WithTimeout(timeout time.Duration) Opt {
+	return func(c *Client) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithHTTPClient replaces the HTTP client used for non-streaming requests
+func WithHTTPClient(httpClient *http.Client) Opt {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return fmt.Errorf("http client cannot be nil")
+		}
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// NewClient creates a new client, applying the given options on top of the defaults
+func NewClient(opts ...Opt) (*Client, error) {
+	c := &Client{
+		socketPath: DefaultSocketPath,
+		timeout:    30 * time.Second,
+	}
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{
+			Transport: &http.Transport{
+				DialContext: c.dial,
+			},
+			Timeout: c.timeout,
+		}
+	}
+
+	return c, nil
+}
+
+// SocketPath returns the Unix socket the client connects to
+func (c *Client) SocketPath() string {
+	return c.socketPath
+}
+
+// dial opens a connection to the daemon's Unix socket, tunneled over ssh for ssh:// hosts
+func (c *Client) dial(ctx context.Context, _, _ string) (net.Conn, error) {
+	if c.ssh != nil {
+		return c.ssh.dial(ctx)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", c.socketPath)
+}
+
+// HijackedResponse is a raw bidirectional stream taken over from an HTTP connection
+type HijackedResponse struct {
+	Conn   net.Conn
+	Reader *bufio.Reader
+
+	// Multiplexed is set for containers without a TTY, whose stdout and stderr arrive framed; see DemuxStream
+	Multiplexed bool
+}
+
+// Read reads from the stream, including any data buffered while parsing headers
+func (h *HijackedResponse) Read(p []byte) (int, error) {
+	return h.Reader.Read(p)
+}
+
+// Write writes to the stream
+func (h *HijackedResponse) Write(p []byte) (int, error) {
+	return h.Conn.Write(p)
+}
+
+// CloseWrite tells the daemon there is no more input, leaving the output flowing
+func (h *HijackedResponse) CloseWrite() error {
+	if conn, ok := h.Conn.(interface{ CloseWrite() error }); ok {
+		return conn.CloseWrite()
+	}
+	return nil
+}
+
+// Close closes the underlying connection
+func (h *HijackedResponse) Close() error {
+	return h.Conn.Close()
+}
+
+// do sends a request and decodes a JSON response into out (if out is non-nil)
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, "http://unix"+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// hijack sends a request over a fresh connection and returns the stream that follows the response headers
+// The response body (if any) is decoded into out before the stream is handed back
+func (c *Client) hijack(ctx context.Context, method, path string, body io.Reader, out interface{}) (*HijackedResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, "http://unix"+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	conn, err := c.dial(ctx, "unix", c.socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %v", err)
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if err := checkResponse(resp); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to decode response: %v", err)
+		}
+	}
+
+	return &HijackedResponse{Conn: conn, Reader: reader, Multiplexed: resp.Header.Get("Mydocker-Stream") == "multiplexed"}, nil
+}
+
+// stream sends a GET request for a long-lived response and returns its body
+// The client-wide request timeout doesn't apply; the stream ends when ctx is cancelled or the daemon closes it
+func (c *Client) stream(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix"+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// checkResponse turns a non-200 response into an error, an *APIError when the daemon sent a JSON error body
+// Unknown endpoints on a daemon with a different API version get a hint instead of a bare 404
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+
+	// Handlers report errors as JSON; anything else came from the router or an older daemon
+	var errResp api.ErrorResponse
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") && json.Unmarshal(bodyBytes, &errResp) == nil && errResp.Error != "" {
+		return &APIError{StatusCode: resp.StatusCode, Code: errResp.Code, Message: errResp.Error}
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		serverAPI := resp.Header.Get("Api-Version")
+		if serverAPI == "" {
+			return fmt.Errorf("endpoint not supported by daemon (daemon predates API versioning, client API version %s)", version.APIVersion)
+		}
+		if version.CompareAPIVersions(serverAPI, version.APIVersion) != 0 {
+			return fmt.Errorf("endpoint not supported by daemon (daemon API version %s, client API version %s): %s",
+				serverAPI, version.APIVersion, strings.TrimSpace(string(bodyBytes)))
+		}
+	}
+
+	return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+}
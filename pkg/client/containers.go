@@ -0,0 +1,313 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// ContainerCreate creates and starts a detached container
+// The response has the container's ID and whether its start is waiting in the admission queue
+func (c *Client) ContainerCreate(ctx context.Context, req api.ContainerCreateRequest) (*api.ContainerCreateResponse, error) {
+	req.Detach = true
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var createResp api.ContainerCreateResponse
+	if err := c.do(ctx, http.MethodPost, "/containers/create", bytes.NewReader(body), &createResp); err != nil {
+		return nil, err
+	}
+
+	return &createResp, nil
+}
+
+// ContainerAdopt turns a running process started outside the daemon into a container
+func (c *Client) ContainerAdopt(ctx context.Context, req api.ContainerAdoptRequest) (*api.ContainerCreateResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var adoptResp api.ContainerCreateResponse
+	if err := c.do(ctx, http.MethodPost, "/containers/adopt", bytes.NewReader(body), &adoptResp); err != nil {
+		return nil, err
+	}
+
+	return &adoptResp, nil
+}
+
+// ContainerClone creates a stopped container with the configuration of an existing one
+func (c *Client) ContainerClone(ctx context.Context, id string, req api.ContainerCloneRequest) (*api.ContainerCreateResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var cloneResp api.ContainerCreateResponse
+	path := fmt.Sprintf("/containers/%s/clone", url.PathEscape(id))
+	if err := c.do(ctx, http.MethodPost, path, bytes.NewReader(body), &cloneResp); err != nil {
+		return nil, err
+	}
+
+	return &cloneResp, nil
+}
+
+// ContainerCreateAttach creates and starts a container with a PTY and returns the attached stream
+// The caller must close the returned stream
+func (c *Client) ContainerCreateAttach(ctx context.Context, req api.ContainerCreateRequest) (string, *HijackedResponse, error) {
+	req.Detach = false
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var createResp api.ContainerCreateResponse
+	stream, err := c.hijack(ctx, http.MethodPost, "/containers/create", bytes.NewReader(body), &createResp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return createResp.ID, stream, nil
+}
+
+// ContainerListOptions controls what ContainerList returns
+type ContainerListOptions struct {
+	// Size requests rootfs sizes, which can be slow to compute
+	Size bool
+
+	// Limit caps the number of containers returned (0 for all); Offset skips that many first
+	Limit  int
+	Offset int
+	// Oldest sorts by creation time oldest first instead of newest first
+	Oldest bool
+	// Fields selects the ContainerInfo JSON fields to return (nil for all); the ID is always included
+	Fields []string
+}
+
+// ContainerList returns the containers known to the daemon, newest first unless opts say otherwise
+func (c *Client) ContainerList(ctx context.Context, opts ContainerListOptions) ([]api.ContainerInfo, error) {
+	query := url.Values{}
+	if opts.Size {
+		query.Set("size", "true")
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if opts.Oldest {
+		query.Set("sort", "created")
+	}
+	if len(opts.Fields) > 0 {
+		query.Set("fields", strings.Join(opts.Fields, ","))
+	}
+
+	path := "/containers/list"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var listResp api.ContainerListResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &listResp); err != nil {
+		return nil, err
+	}
+
+	return listResp.Containers, nil
+}
+
+// ContainerStartOptions controls how ContainerStart handles dependencies
+type ContainerStartOptions struct {
+	// NoDeps starts only the container, not the containers it depends on
+	NoDeps bool
+}
+
+// ContainerStart starts a created or exited container in detached mode
+// The response lists the IDs that were started, dependencies first, and any left waiting in the admission queue
+func (c *Client) ContainerStart(ctx context.Context, id string, opts ContainerStartOptions) (*api.ContainerStartResponse, error) {
+	body, err := json.Marshal(api.ContainerStartRequest{ID: id, NoDeps: opts.NoDeps})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var startResp api.ContainerStartResponse
+	if err := c.do(ctx, http.MethodPost, "/containers/start", bytes.NewReader(body), &startResp); err != nil {
+		return nil, err
+	}
+
+	return &startResp, nil
+}
+
+// ContainerStartAttach starts a created or exited container with a PTY and returns the attached stream
+// Dependencies are started detached first, as in ContainerStart; the caller must close the returned stream
+func (c *Client) ContainerStartAttach(ctx context.Context, id string, opts ContainerStartOptions) ([]string, *HijackedResponse, error) {
+	body, err := json.Marshal(api.ContainerStartRequest{ID: id, NoDeps: opts.NoDeps, Attach: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var startResp api.ContainerStartResponse
+	stream, err := c.hijack(ctx, http.MethodPost, "/containers/start", bytes.NewReader(body), &startResp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return startResp.Started, stream, nil
+}
+
+// ContainerStopOptions controls how ContainerStop handles dependents
+type ContainerStopOptions struct {
+	// NoDeps stops only the container, not the containers that depend on it
+	NoDeps bool
+}
+
+// ContainerStop stops a running container
+// Returns the IDs that were stopped, dependents first
+func (c *Client) ContainerStop(ctx context.Context, id string, opts ContainerStopOptions) ([]string, error) {
+	body, err := json.Marshal(api.ContainerStopRequest{ID: id, NoDeps: opts.NoDeps})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var stopResp api.ContainerStopResponse
+	if err := c.do(ctx, http.MethodPost, "/containers/stop", bytes.NewReader(body), &stopResp); err != nil {
+		return nil, err
+	}
+
+	if !stopResp.Success {
+		return nil, fmt.Errorf("failed to stop container")
+	}
+
+	return stopResp.Stopped, nil
+}
+
+// ContainerStats returns a stream of JSON-encoded api.ContainerStats samples, one per line
+// With stream set the daemon pushes a sample every second until ctx is cancelled or the container exits;
+// otherwise a single sample is returned. The caller must close the returned reader
+func (c *Client) ContainerStats(ctx context.Context, id string, stream bool) (io.ReadCloser, error) {
+	path := fmt.Sprintf("/containers/%s/stats?stream=%t", url.PathEscape(id), stream)
+
+	return c.stream(ctx, path)
+}
+
+// ContainerLogsOptions selects the stored output ContainerLogs returns
+type ContainerLogsOptions struct {
+	// Tail returns only the last lines, a number or "all"; empty for all
+	Tail string
+
+	// Since and Until bound the lines by when they were written
+	// Both take a duration before now ("2h"), an RFC 3339 time or a Unix timestamp; empty for no bound
+	Since string
+	Until string
+
+	// Follow keeps the stream open, sending lines as a running container writes them until its output ends
+	Follow bool
+}
+
+// ContainerLogs returns a container's stored output as a stream of JSON-encoded api.LogEntry values, one per line
+// The caller must close the returned reader
+func (c *Client) ContainerLogs(ctx context.Context, id string, opts ContainerLogsOptions) (io.ReadCloser, error) {
+	query := url.Values{}
+	if opts.Tail != "" {
+		query.Set("tail", opts.Tail)
+	}
+	if opts.Since != "" {
+		query.Set("since", opts.Since)
+	}
+	if opts.Until != "" {
+		query.Set("until", opts.Until)
+	}
+	if opts.Follow {
+		query.Set("follow", "1")
+	}
+
+	path := fmt.Sprintf("/containers/%s/logs", url.PathEscape(id))
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.stream(ctx, path)
+}
+
+// ContainerStatsHistory returns the samples the daemon recorded for a container since the given time
+// since is a duration before now ("1h"), an RFC 3339 time or a Unix timestamp
+func (c *Client) ContainerStatsHistory(ctx context.Context, id, since string) (*api.ContainerStatsHistory, error) {
+	path := fmt.Sprintf("/containers/%s/stats?since=%s", url.PathEscape(id), url.QueryEscape(since))
+
+	var history api.ContainerStatsHistory
+	if err := c.do(ctx, http.MethodGet, path, nil, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// ContainerInspect returns the details of a container, with its state history if history is set
+func (c *Client) ContainerInspect(ctx context.Context, id string, history bool) (*api.ContainerInspect, error) {
+	path := fmt.Sprintf("/containers/%s/json?history=%t", url.PathEscape(id), history)
+
+	var inspect api.ContainerInspect
+	if err := c.do(ctx, http.MethodGet, path, nil, &inspect); err != nil {
+		return nil, err
+	}
+	return &inspect, nil
+}
+
+// ContainerNetns returns where a running container's network namespace is bind-mounted on the daemon's host
+func (c *Client) ContainerNetns(ctx context.Context, id string) (*api.ContainerNetns, error) {
+	var netns api.ContainerNetns
+	if err := c.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/netns", nil, &netns); err != nil {
+		return nil, err
+	}
+	return &netns, nil
+}
+
+// ContainerSessions lists the recorded PTY sessions of a container, oldest first
+func (c *Client) ContainerSessions(ctx context.Context, id string) ([]api.SessionInfo, error) {
+	path := fmt.Sprintf("/containers/%s/sessions", url.PathEscape(id))
+
+	var listResp api.SessionListResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &listResp); err != nil {
+		return nil, err
+	}
+	return listResp.Sessions, nil
+}
+
+// ContainerSession returns a recorded PTY session in asciicast v2 format
+// The caller must close the returned reader
+func (c *Client) ContainerSession(ctx context.Context, id, name string) (io.ReadCloser, error) {
+	path := fmt.Sprintf("/containers/%s/sessions/%s", url.PathEscape(id), url.PathEscape(name))
+
+	return c.stream(ctx, path)
+}
+
+// ContainerAttachOptions selects the streams an attach carries
+// Leaving all of them unset gets the streams chosen when the container was created
+type ContainerAttachOptions struct {
+	Stdin  bool
+	Stdout bool
+	Stderr bool
+}
+
+// ContainerAttach attaches to a running container's PTY, or its stdio pipes if it has no TTY
+// The caller must close the returned stream
+func (c *Client) ContainerAttach(ctx context.Context, id string, opts ContainerAttachOptions) (*HijackedResponse, error) {
+	query := url.Values{}
+	query.Set("id", id)
+	if opts.Stdin || opts.Stdout || opts.Stderr {
+		query.Set("stdin", strconv.FormatBool(opts.Stdin))
+		query.Set("stdout", strconv.FormatBool(opts.Stdout))
+		query.Set("stderr", strconv.FormatBool(opts.Stderr))
+	}
+	return c.hijack(ctx, http.MethodPost, "/containers/attach?"+query.Encode(), nil, nil)
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// DebugState fetches the daemon's internal state dump (requires the daemon to run with --debug)
+func (c *Client) DebugState(ctx context.Context) (*api.DebugStateResponse, error) {
+	var state api.DebugStateResponse
+	if err := c.do(ctx, http.MethodGet, "/debug/state", nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// DebugProfile writes the named pprof profile (e.g. "goroutine", "heap") to w
+// debugLevel follows pprof semantics: 0 for the binary format, 1 or 2 for text
+func (c *Client) DebugProfile(ctx context.Context, name string, debugLevel int, w io.Writer) error {
+	path := fmt.Sprintf("/debug/pprof/%s?debug=%d", url.PathEscape(name), debugLevel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix"+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read profile: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// APIError is an error reported by the daemon
+type APIError struct {
+	StatusCode int    // HTTP status of the response
+	Code       string // One of the api.ErrCode constants
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// IsErrNotFound reports whether err means the container doesn't exist
+func IsErrNotFound(err error) bool {
+	return hasCode(err, api.ErrCodeContainerNotFound)
+}
+
+// IsErrConflict reports whether err means the container's state didn't allow the request,
+// e.g. starting a running container or attaching to one that already has a client
+func IsErrConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// hasCode reports whether err is an APIError with the given code
+func hasCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"io"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// APIClient is the set of daemon operations exposed by Client
+// Code that talks to the daemon should depend on this interface so it can be mocked in tests
+type APIClient interface {
+	ContainerCreate(ctx context.Context, req api.ContainerCreateRequest) (*api.ContainerCreateResponse, error)
+	ContainerAdopt(ctx context.Context, req api.ContainerAdoptRequest) (*api.ContainerCreateResponse, error)
+	ContainerClone(ctx context.Context, id string, req api.ContainerCloneRequest) (*api.ContainerCreateResponse, error)
+	ContainerCreateAttach(ctx context.Context, req api.ContainerCreateRequest) (string, *HijackedResponse, error)
+	ContainerList(ctx context.Context, opts ContainerListOptions) ([]api.ContainerInfo, error)
+	ContainerStart(ctx context.Context, id string, opts ContainerStartOptions) (*api.ContainerStartResponse, error)
+	ContainerStartAttach(ctx context.Context, id string, opts ContainerStartOptions) ([]string, *HijackedResponse, error)
+	ContainerStop(ctx context.Context, id string, opts ContainerStopOptions) ([]string, error)
+	ContainerInspect(ctx context.Context, id string, history bool) (*api.ContainerInspect, error)
+	ContainerNetns(ctx context.Context, id string) (*api.ContainerNetns, error)
+	ContainerStats(ctx context.Context, id string, stream bool) (io.ReadCloser, error)
+	ContainerStatsHistory(ctx context.Context, id, since string) (*api.ContainerStatsHistory, error)
+	ContainerLogs(ctx context.Context, id string, opts ContainerLogsOptions) (io.ReadCloser, error)
+	ContainerAttach(ctx context.Context, id string, opts ContainerAttachOptions) (*HijackedResponse, error)
+	ContainerSessions(ctx context.Context, id string) ([]api.SessionInfo, error)
+	ContainerSession(ctx context.Context, id, name string) (io.ReadCloser, error)
+	ScheduleCreate(ctx context.Context, req api.ScheduleCreateRequest) (*api.Schedule, error)
+	ScheduleList(ctx context.Context) ([]api.Schedule, error)
+	ScheduleInspect(ctx context.Context, id string) (*api.Schedule, error)
+	ScheduleEnable(ctx context.Context, id string, enabled bool) (*api.Schedule, error)
+	ScheduleRemove(ctx context.Context, id string) (string, error)
+	ServiceCreate(ctx context.Context, req api.ServiceCreateRequest) (*api.Service, error)
+	ServiceList(ctx context.Context) ([]api.Service, error)
+	ServiceInspect(ctx context.Context, ref string) (*api.Service, error)
+	ServiceScale(ctx context.Context, ref string, replicas int) (*api.Service, error)
+	ServiceUpdate(ctx context.Context, ref string, req api.ServiceUpdateRequest) (*api.Service, error)
+	ServiceRemove(ctx context.Context, ref string) (string, error)
+	PresetCreate(ctx context.Context, req api.PresetCreateRequest) (*api.Preset, error)
+	PresetList(ctx context.Context) ([]api.Preset, error)
+	PresetInspect(ctx context.Context, name string) (*api.Preset, error)
+	PresetRemove(ctx context.Context, name string) error
+	PodCreate(ctx context.Context, req api.PodCreateRequest) (*api.Pod, error)
+	PodList(ctx context.Context) ([]api.Pod, error)
+	PodInspect(ctx context.Context, ref string) (*api.Pod, error)
+	PodAdd(ctx context.Context, ref string, req api.ContainerCreateRequest) (*api.ContainerCreateResponse, error)
+	PodStart(ctx context.Context, ref string) (*api.Pod, error)
+	PodStop(ctx context.Context, ref string) (*api.Pod, error)
+	PodRemove(ctx context.Context, ref string, force bool) (string, error)
+	ServerVersion(ctx context.Context) (*api.VersionResponse, error)
+	SystemReload(ctx context.Context) ([]string, error)
+	SystemReconcile(ctx context.Context, dryRun bool) (*api.SystemReconcileResponse, error)
+	AdmissionQueue(ctx context.Context) (*api.AdmissionQueueResponse, error)
+	SystemInfo(ctx context.Context) (*api.SystemInfo, error)
+	SystemMode(ctx context.Context, mode, reason string) (*api.SystemInfo, error)
+	SystemDrain(ctx context.Context, req api.SystemDrainRequest) (*api.SystemDrainResponse, error)
+	Events(ctx context.Context, opts EventsOptions) (io.ReadCloser, error)
+	DebugState(ctx context.Context) (*api.DebugStateResponse, error)
+	DebugProfile(ctx context.Context, name string, debugLevel int, w io.Writer) error
+}
+
+// Ensure Client implements APIClient
+var _ APIClient = (*Client)(nil)
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// PodCreate creates a pod and starts its infra container
+func (c *Client) PodCreate(ctx context.Context, req api.PodCreateRequest) (*api.Pod, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var pod api.Pod
+	if err := c.do(ctx, http.MethodPost, "/pods", bytes.NewReader(body), &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+// PodList returns every pod with its containers, oldest first
+func (c *Client) PodList(ctx context.Context) ([]api.Pod, error) {
+	var listResp api.PodListResponse
+	if err := c.do(ctx, http.MethodGet, "/pods", nil, &listResp); err != nil {
+		return nil, err
+	}
+	return listResp.Pods, nil
+}
+
+// PodInspect returns a pod, found by name or ID, with its containers
+func (c *Client) PodInspect(ctx context.Context, ref string) (*api.Pod, error) {
+	var pod api.Pod
+	if err := c.do(ctx, http.MethodGet, "/pods/"+url.PathEscape(ref), nil, &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+// PodAdd creates a container in a pod and starts it detached; req.NamespacesFrom must be empty
+func (c *Client) PodAdd(ctx context.Context, ref string, req api.ContainerCreateRequest) (*api.ContainerCreateResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var createResp api.ContainerCreateResponse
+	if err := c.do(ctx, http.MethodPost, "/pods/"+url.PathEscape(ref)+"/containers", bytes.NewReader(body), &createResp); err != nil {
+		return nil, err
+	}
+	return &createResp, nil
+}
+
+// PodStart starts a pod's infra container and then its other containers
+func (c *Client) PodStart(ctx context.Context, ref string) (*api.Pod, error) {
+	var pod api.Pod
+	if err := c.do(ctx, http.MethodPost, "/pods/"+url.PathEscape(ref)+"/start", nil, &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+// PodStop stops a pod's containers and then its infra container
+func (c *Client) PodStop(ctx context.Context, ref string) (*api.Pod, error) {
+	var pod api.Pod
+	if err := c.do(ctx, http.MethodPost, "/pods/"+url.PathEscape(ref)+"/stop", nil, &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+// PodRemove deletes a pod with its containers and returns its full ID
+// Without force a pod with running containers is refused
+func (c *Client) PodRemove(ctx context.Context, ref string, force bool) (string, error) {
+	path := fmt.Sprintf("/pods/%s?force=%t", url.PathEscape(ref), force)
+
+	var pod api.Pod
+	if err := c.do(ctx, http.MethodDelete, path, nil, &pod); err != nil {
+		return "", err
+	}
+	return pod.ID, nil
+}
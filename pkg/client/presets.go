@@ -0,0 +1,49 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// PresetCreate defines a named container template that create requests can start from
+func (c *Client) PresetCreate(ctx context.Context, req api.PresetCreateRequest) (*api.Preset, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var preset api.Preset
+	if err := c.do(ctx, http.MethodPost, "/presets", bytes.NewReader(body), &preset); err != nil {
+		return nil, err
+	}
+	return &preset, nil
+}
+
+// PresetList returns the presets from the daemon config and the API, by name
+func (c *Client) PresetList(ctx context.Context) ([]api.Preset, error) {
+	var listResp api.PresetListResponse
+	if err := c.do(ctx, http.MethodGet, "/presets", nil, &listResp); err != nil {
+		return nil, err
+	}
+	return listResp.Presets, nil
+}
+
+// PresetInspect returns a preset with its template
+func (c *Client) PresetInspect(ctx context.Context, name string) (*api.Preset, error) {
+	var preset api.Preset
+	if err := c.do(ctx, http.MethodGet, "/presets/"+url.PathEscape(name), nil, &preset); err != nil {
+		return nil, err
+	}
+	return &preset, nil
+}
+
+// PresetRemove deletes a preset created through the API; containers created from it are kept
+func (c *Client) PresetRemove(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/presets/"+url.PathEscape(name), nil, nil)
+}
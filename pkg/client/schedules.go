@@ -0,0 +1,67 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// ScheduleCreate creates a schedule that runs a container from req.Template whenever req.Cron matches
+func (c *Client) ScheduleCreate(ctx context.Context, req api.ScheduleCreateRequest) (*api.Schedule, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var schedule api.Schedule
+	if err := c.do(ctx, http.MethodPost, "/schedules", bytes.NewReader(body), &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ScheduleList returns every schedule, oldest first
+func (c *Client) ScheduleList(ctx context.Context) ([]api.Schedule, error) {
+	var listResp api.ScheduleListResponse
+	if err := c.do(ctx, http.MethodGet, "/schedules", nil, &listResp); err != nil {
+		return nil, err
+	}
+	return listResp.Schedules, nil
+}
+
+// ScheduleInspect returns a schedule with its run history
+func (c *Client) ScheduleInspect(ctx context.Context, id string) (*api.Schedule, error) {
+	var schedule api.Schedule
+	if err := c.do(ctx, http.MethodGet, "/schedules/"+url.PathEscape(id), nil, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ScheduleEnable enables or disables a schedule
+func (c *Client) ScheduleEnable(ctx context.Context, id string, enabled bool) (*api.Schedule, error) {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+
+	var schedule api.Schedule
+	if err := c.do(ctx, http.MethodPost, "/schedules/"+url.PathEscape(id)+"/"+action, nil, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ScheduleRemove deletes a schedule and returns its full ID; containers it started are kept
+func (c *Client) ScheduleRemove(ctx context.Context, id string) (string, error) {
+	var schedule api.Schedule
+	if err := c.do(ctx, http.MethodDelete, "/schedules/"+url.PathEscape(id), nil, &schedule); err != nil {
+		return "", err
+	}
+	return schedule.ID, nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// ServiceCreate creates a service that keeps req.Replicas containers from req.Template running
+func (c *Client) ServiceCreate(ctx context.Context, req api.ServiceCreateRequest) (*api.Service, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var service api.Service
+	if err := c.do(ctx, http.MethodPost, "/services", bytes.NewReader(body), &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// ServiceList returns every service with its replicas, oldest first
+func (c *Client) ServiceList(ctx context.Context) ([]api.Service, error) {
+	var listResp api.ServiceListResponse
+	if err := c.do(ctx, http.MethodGet, "/services", nil, &listResp); err != nil {
+		return nil, err
+	}
+	return listResp.Services, nil
+}
+
+// ServiceInspect returns a service, found by name or ID, with its replicas
+func (c *Client) ServiceInspect(ctx context.Context, ref string) (*api.Service, error) {
+	var service api.Service
+	if err := c.do(ctx, http.MethodGet, "/services/"+url.PathEscape(ref), nil, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// ServiceScale changes the number of replicas a service keeps running
+func (c *Client) ServiceScale(ctx context.Context, ref string, replicas int) (*api.Service, error) {
+	body, err := json.Marshal(api.ServiceScaleRequest{Replicas: replicas})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var service api.Service
+	if err := c.do(ctx, http.MethodPost, "/services/"+url.PathEscape(ref)+"/scale", bytes.NewReader(body), &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// ServiceUpdate starts rolling a service over to a new rootfs; poll ServiceInspect for its progress
+func (c *Client) ServiceUpdate(ctx context.Context, ref string, req api.ServiceUpdateRequest) (*api.Service, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var service api.Service
+	if err := c.do(ctx, http.MethodPost, "/services/"+url.PathEscape(ref)+"/update", bytes.NewReader(body), &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// ServiceRemove deletes a service and returns its full ID; the daemon stops and removes its replicas
+func (c *Client) ServiceRemove(ctx context.Context, ref string) (string, error) {
+	var service api.Service
+	if err := c.do(ctx, http.MethodDelete, "/services/"+url.PathEscape(ref), nil, &service); err != nil {
+		return "", err
+	}
+	return service.ID, nil
+}
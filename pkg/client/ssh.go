@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sshTarget is a remote daemon reached by running "mydocker system dial-stdio" over ssh
+type sshTarget struct {
+	user       string
+	host       string
+	port       string
+	socketPath string // Socket on the remote host, empty for the remote CLI's default
+}
+
+// parseSSHHost parses ssh://[user@]host[:port][/path/to/socket]
+func parseSSHHost(u *url.URL) (*sshTarget, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("ssh host %q has no host name", u.String())
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		return nil, fmt.Errorf("ssh host %q must not contain a password, use ssh keys or an agent", u.Redacted())
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("ssh host %q must not have a query or fragment", u.String())
+	}
+
+	target := &sshTarget{host: u.Hostname(), port: u.Port(), socketPath: u.Path}
+	if u.User != nil {
+		target.user = u.User.Username()
+	}
+	return target, nil
+}
+
+// String returns the target in ssh:// form
+func (t *sshTarget) String() string {
+	u := url.URL{Scheme: "ssh", Host: t.host, Path: t.socketPath}
+	if t.port != "" {
+		u.Host = net.JoinHostPort(t.host, t.port)
+	}
+	if t.user != "" {
+		u.User = url.User(t.user)
+	}
+	return u.String()
+}
+
+// args returns the ssh command line that connects stdin and stdout to the remote daemon socket
+func (t *sshTarget) args() []string {
+	var args []string
+	if t.user != "" {
+		args = append(args, "-l", t.user)
+	}
+	if t.port != "" {
+		args = append(args, "-p", t.port)
+	}
+	args = append(args, "--", t.host, "mydocker")
+	if t.socketPath != "" {
+		// The remote command is run by the user's shell, so the path must not be read as shell syntax
+		args = append(args, "--socket", shellQuote(t.socketPath))
+	}
+	return append(args, "system", "dial-stdio")
+}
+
+// shellQuote quotes s as a single word for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// dial starts ssh and returns a connection over its stdin and stdout
+// Every connection runs its own ssh process; ssh connection sharing (ControlMaster) makes that cheap
+func (t *sshTarget) dial(ctx context.Context) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// ctx only covers dialing, so the process isn't tied to it
+	cmd := exec.Command("ssh", t.args()...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ssh stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ssh stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run ssh: %v", err)
+	}
+
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout, addr: sshAddr(t.String())}, nil
+}
+
+// commandConn is a net.Conn over the stdin and stdout of a child process
+// Deadlines are not supported; requests are bounded by their context instead
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	addr   sshAddr
+
+	closeOnce sync.Once
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	return c.stdout.Read(p)
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// CloseWrite closes the process's stdin so the remote side sees EOF
+func (c *commandConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+// Close stops the process and reaps it
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr                { return c.addr }
+func (c *commandConn) RemoteAddr() net.Addr               { return c.addr }
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// sshAddr is the address of a connection tunneled over ssh
+type sshAddr string
+
+func (a sshAddr) Network() string { return "ssh" }
+func (a sshAddr) String() string  { return string(a) }
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// DemuxStream splits a multiplexed attach stream into stdout and stderr until the stream ends
+func DemuxStream(stream io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, api.StreamHeaderSize)
+	for {
+		if _, err := io.ReadFull(stream, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read stream header: %v", err)
+		}
+
+		var out io.Writer
+		switch header[0] {
+		case api.StreamStdout:
+			out = stdout
+		case api.StreamStderr:
+			out = stderr
+		default:
+			return fmt.Errorf("unknown stream %d in attach stream", header[0])
+		}
+		if out == nil {
+			out = io.Discard
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(out, stream, size); err != nil {
+			return fmt.Errorf("failed to copy stream: %v", err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// SystemReload asks the daemon to re-read daemon.json and returns the settings that changed
+func (c *Client) SystemReload(ctx context.Context) ([]string, error) {
+	var reloadResp api.SystemReloadResponse
+	if err := c.do(ctx, http.MethodPost, "/system/reload", nil, &reloadResp); err != nil {
+		return nil, err
+	}
+	return reloadResp.Changed, nil
+}
+
+// SystemReconcile asks the daemon to clean up cgroups and mounts no container owns
+// With dryRun the daemon only reports what it would remove
+func (c *Client) SystemReconcile(ctx context.Context, dryRun bool) (*api.SystemReconcileResponse, error) {
+	body, err := json.Marshal(api.SystemReconcileRequest{DryRun: dryRun})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var reconcileResp api.SystemReconcileResponse
+	if err := c.do(ctx, http.MethodPost, "/system/reconcile", bytes.NewReader(body), &reconcileResp); err != nil {
+		return nil, err
+	}
+	return &reconcileResp, nil
+}
+
+// AdmissionQueue returns host utilization against the admission thresholds and the container starts waiting on it
+func (c *Client) AdmissionQueue(ctx context.Context) (*api.AdmissionQueueResponse, error) {
+	var queueResp api.AdmissionQueueResponse
+	if err := c.do(ctx, http.MethodGet, "/system/queue", nil, &queueResp); err != nil {
+		return nil, err
+	}
+	return &queueResp, nil
+}
+
+// SystemInfo returns the daemon's API mode and counts of the objects it manages
+func (c *Client) SystemInfo(ctx context.Context) (*api.SystemInfo, error) {
+	var info api.SystemInfo
+	if err := c.do(ctx, http.MethodGet, "/system/info", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// SystemMode switches the daemon's API mode and returns its information afterwards
+func (c *Client) SystemMode(ctx context.Context, mode, reason string) (*api.SystemInfo, error) {
+	body, err := json.Marshal(api.SystemModeRequest{Mode: mode, Reason: reason})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var info api.SystemInfo
+	if err := c.do(ctx, http.MethodPost, "/system/mode", bytes.NewReader(body), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// SystemDrain puts the daemon in maintenance mode and stops every running container, returning once they have all stopped
+func (c *Client) SystemDrain(ctx context.Context, req api.SystemDrainRequest) (*api.SystemDrainResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	var drainResp api.SystemDrainResponse
+	if err := c.do(ctx, http.MethodPost, "/system/drain", bytes.NewReader(body), &drainResp); err != nil {
+		return nil, err
+	}
+	return &drainResp, nil
+}
+
+// ServerVersion returns the daemon's version information
+func (c *Client) ServerVersion(ctx context.Context) (*api.VersionResponse, error) {
+	var versionResp api.VersionResponse
+	if err := c.do(ctx, http.MethodGet, "/version", nil, &versionResp); err != nil {
+		return nil, err
+	}
+	return &versionResp, nil
+}
+
+// EventsOptions selects which events Events returns
+type EventsOptions struct {
+	// Since replays logged events from this time on, Until ends the stream at this time
+	// Both take a duration before now ("2h"), an RFC 3339 time or a Unix timestamp; empty for none
+	Since string
+	Until string
+
+	// Filters are "key=value" pairs, see api.EventFilter
+	Filters []string
+}
+
+// Events returns a stream of JSON-encoded api.Event values, one per line, until ctx is cancelled or Until passes
+// The caller must close the returned reader
+func (c *Client) Events(ctx context.Context, opts EventsOptions) (io.ReadCloser, error) {
+	query := url.Values{}
+	if opts.Since != "" {
+		query.Set("since", opts.Since)
+	}
+	if opts.Until != "" {
+		query.Set("until", opts.Until)
+	}
+	for _, filter := range opts.Filters {
+		query.Add("filter", filter)
+	}
+
+	path := "/events"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.stream(ctx, path)
+}
@@ -0,0 +1,376 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// DefaultConfigPath is where the daemon looks for its configuration file
+const DefaultConfigPath = "/etc/mydocker/daemon.json"
+
+// Log levels accepted in the "log-level" setting
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// DaemonConfig is the contents of daemon.json
+// Every field here can be reloaded at runtime without restarting containers
+type DaemonConfig struct {
+	LogLevel           string        `json:"log-level,omitempty"`
+	RegistryMirrors    []string      `json:"registry-mirrors,omitempty"`
+	InsecureRegistries []string      `json:"insecure-registries,omitempty"`
+	DefaultLimits      DefaultLimits `json:"default-limits,omitempty"`
+	GC                 GCPolicy      `json:"gc,omitempty"`
+
+	// StatsHistory controls the background sampling behind "stats --since"
+	StatsHistory StatsHistoryPolicy `json:"stats-history,omitempty"`
+
+	// RootfsRoots restricts container rootfs paths to these directories; empty allows any path
+	// Directories in the daemon's own rootfs directory (<data-dir>/rootfs) are always allowed
+	RootfsRoots []string `json:"rootfs-roots,omitempty"`
+
+	// AllowedMountTypes lists the mount types containers may request; empty allows DefaultMountTypes
+	AllowedMountTypes []string `json:"allowed-mount-types,omitempty"`
+	// BindMountRoots restricts bind mount and secret sources to these directories; empty falls back to RootfsRoots
+	// System directories such as / and /etc, and anything overlapping the data directory, are never allowed
+	BindMountRoots []string `json:"bind-mount-roots,omitempty"`
+
+	// ResourceProfiles are named sets of limits that create requests can select with "profile"
+	ResourceProfiles map[string]DefaultLimits `json:"resource-profiles,omitempty"`
+	// Presets are named container templates that create requests can start from with "preset"
+	// They take precedence over presets of the same name created through the API
+	Presets map[string]api.ContainerCreateRequest `json:"presets,omitempty"`
+
+	// UserQuotas limit what each client may run, keyed by UID or "*" for UIDs without an entry of their own
+	// Root (UID 0) is only limited by an explicit "0" entry
+	UserQuotas map[string]UserQuota `json:"user-quotas,omitempty"`
+
+	// Admission queues container starts while the host is saturated instead of starting them right away
+	Admission AdmissionPolicy `json:"admission,omitempty"`
+
+	// Protection keeps a runaway container from taking the daemon down with it
+	Protection DaemonProtection `json:"daemon-protection,omitempty"`
+
+	// RecordSessions saves every attached PTY session to <data-dir>/sessions in asciicast v2 format for auditing
+	RecordSessions bool `json:"record-sessions,omitempty"`
+
+	// Tracing exports spans of API requests and container starts to an OpenTelemetry collector
+	Tracing TracingPolicy `json:"tracing,omitempty"`
+}
+
+// PresetNamePattern matches the names presets may have
+var PresetNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// DefaultMountTypes are the mount types containers may request when allowed-mount-types is unset
+var DefaultMountTypes = []string{"bind", "tmpfs"}
+
+// MountTypes returns the mount types containers may request
+func (c *DaemonConfig) MountTypes() []string {
+	if len(c.AllowedMountTypes) == 0 {
+		return DefaultMountTypes
+	}
+	return c.AllowedMountTypes
+}
+
+// GCPolicy controls the daemon's background garbage collection job
+// A zero retention disables collection of that resource
+type GCPolicy struct {
+	Interval                 Duration `json:"interval,omitempty"`
+	ExitedContainerRetention Duration `json:"exited-container-retention,omitempty"`
+	HistoryRetention         Duration `json:"history-retention,omitempty"` // Age after which state transitions are dropped from container histories
+	EventRetention           Duration `json:"event-retention,omitempty"`   // Age after which events are dropped from the event log
+}
+
+// DefaultGCInterval is how often the GC job runs when no interval is configured
+const DefaultGCInterval = time.Hour
+
+// AdmissionPolicy sets the host utilization above which container starts wait in a queue
+// A zero threshold isn't checked; with both zero, containers always start right away
+type AdmissionPolicy struct {
+	MaxCPUPercent    float64  `json:"max-cpu-percent,omitempty"`
+	MaxMemoryPercent float64  `json:"max-memory-percent,omitempty"`
+	QueueTimeout     Duration `json:"queue-timeout,omitempty"` // How long a start may wait before it's dropped (0 for no limit)
+}
+
+// Enabled reports whether any threshold is set
+func (p AdmissionPolicy) Enabled() bool {
+	return p.MaxCPUPercent > 0 || p.MaxMemoryPercent > 0
+}
+
+// UserQuota limits the containers owned by one client UID
+// Containers count against the UID that created them, whoever starts them later
+type UserQuota struct {
+	MaxRunning     int      `json:"max-running,omitempty"`     // Running containers at once (0 for no limit)
+	MaxMemory      uint64   `json:"max-memory,omitempty"`      // Sum of the memory limits of running containers; containers must set one
+	RootfsPrefixes []string `json:"rootfs-prefixes,omitempty"` // Directories container rootfs paths and bind mount sources must be in (empty for any)
+}
+
+// QuotaFor returns the quota that applies to uid, or nil if it has none
+func (c *DaemonConfig) QuotaFor(uid uint32) *UserQuota {
+	if quota, ok := c.UserQuotas[strconv.FormatUint(uint64(uid), 10)]; ok {
+		return &quota
+	}
+	if quota, ok := c.UserQuotas["*"]; ok && uid != 0 {
+		return &quota
+	}
+	return nil
+}
+
+// DaemonProtection shields mydockerd from the OOM killer and from CPU and memory starvation
+type DaemonProtection struct {
+	OOMScoreAdj       int     `json:"oom-score-adj,omitempty"`      // -1000 (never kill) to 1000; containers still start at 0
+	MemoryReservation uint64  `json:"memory-reservation,omitempty"` // Bytes guaranteed to the daemon's cgroup (cgroups v2 only)
+	CpuShares         uint64  `json:"cpu-shares,omitempty"`         // CPU weight of the daemon's cgroup against containers (default 1024)
+	LowMemoryWarning  float64 `json:"low-memory-warning,omitempty"` // Warn when the host's available memory drops below this percentage
+}
+
+// TracingPolicy selects the OpenTelemetry collector spans are exported to over OTLP/HTTP
+// Tracing is disabled while no endpoint is set
+type TracingPolicy struct {
+	Endpoint    string `json:"otlp-endpoint,omitempty"` // Base URL of the collector, e.g. http://localhost:4318; spans go to <endpoint>/v1/traces
+	ServiceName string `json:"service-name,omitempty"`  // service.name of the spans, DefaultServiceName when unset
+}
+
+// DefaultServiceName is the service.name of exported spans when tracing sets none
+const DefaultServiceName = "mydockerd"
+
+// StatsHistoryPolicy controls how often container stats are sampled and how long samples are kept in memory
+// A zero retention disables sampling
+type StatsHistoryPolicy struct {
+	Interval  Duration `json:"interval,omitempty"`
+	Retention Duration `json:"retention,omitempty"`
+}
+
+// DefaultStatsInterval is how often container stats are sampled when no interval is configured
+const DefaultStatsInterval = 10 * time.Second
+
+// Duration is a time.Duration that is written as a string ("90m", "168h") in JSON
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a duration string such as "24h"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"24h\": %v", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultLimits are applied to containers whose create request leaves a limit unset (zero)
+// Resource profiles use the same fields and take precedence over the defaults
+type DefaultLimits struct {
+	Memory     uint64 `json:"memory,omitempty"`
+	MemorySwap int64  `json:"memory-swap,omitempty"` // -1 for unlimited swap
+	CpuShares  uint64 `json:"cpu-shares,omitempty"`
+	PidsLimit  int64  `json:"pids-limit,omitempty"`
+}
+
+// validate checks a set of limits; name says which one in error messages
+func (l DefaultLimits) validate(name string) error {
+	if l.MemorySwap < -1 {
+		return fmt.Errorf("%s memory-swap must be -1 (unlimited) or a byte count", name)
+	}
+	if l.MemorySwap > 0 && uint64(l.MemorySwap) < l.Memory {
+		return fmt.Errorf("%s memory-swap must be greater than or equal to %s memory", name, name)
+	}
+	if l.PidsLimit < 0 {
+		return fmt.Errorf("%s pids-limit cannot be negative", name)
+	}
+	return nil
+}
+
+// Default returns the configuration used when no daemon.json exists
+func Default() *DaemonConfig {
+	return &DaemonConfig{
+		LogLevel: LogLevelInfo,
+	}
+}
+
+// Load reads and validates a daemon.json file
+// A missing file is not an error; the defaults are returned instead
+func Load(path string) (*DaemonConfig, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration for invalid values
+func (c *DaemonConfig) Validate() error {
+	switch c.LogLevel {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		return fmt.Errorf("unknown log-level %q", c.LogLevel)
+	}
+
+	if err := c.DefaultLimits.validate("default"); err != nil {
+		return err
+	}
+	for name, profile := range c.ResourceProfiles {
+		if name == "" {
+			return fmt.Errorf("resource profile names cannot be empty")
+		}
+		if err := profile.validate(fmt.Sprintf("resource profile %q", name)); err != nil {
+			return err
+		}
+	}
+
+	for name, preset := range c.Presets {
+		if !PresetNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid preset name %q: use letters, digits, '_', '.' and '-'", name)
+		}
+		if preset.Preset != "" {
+			return fmt.Errorf("preset %q cannot start from another preset", name)
+		}
+	}
+
+	for _, mirror := range c.RegistryMirrors {
+		if err := validateMirror(mirror); err != nil {
+			return err
+		}
+	}
+	for _, registry := range c.InsecureRegistries {
+		if err := validateInsecureRegistry(registry); err != nil {
+			return err
+		}
+	}
+
+	for _, root := range c.RootfsRoots {
+		if !filepath.IsAbs(root) {
+			return fmt.Errorf("rootfs-roots must be absolute paths: %s", root)
+		}
+	}
+	for _, root := range c.BindMountRoots {
+		if !filepath.IsAbs(root) {
+			return fmt.Errorf("bind-mount-roots must be absolute paths: %s", root)
+		}
+	}
+
+	if c.GC.Interval < 0 || c.GC.ExitedContainerRetention < 0 || c.GC.HistoryRetention < 0 || c.GC.EventRetention < 0 {
+		return fmt.Errorf("gc durations cannot be negative")
+	}
+	for key, quota := range c.UserQuotas {
+		if _, err := strconv.ParseUint(key, 10, 32); err != nil && key != "*" {
+			return fmt.Errorf("user-quotas keys must be UIDs or \"*\": %q", key)
+		}
+		if quota.MaxRunning < 0 {
+			return fmt.Errorf("user-quotas %s max-running cannot be negative", key)
+		}
+		for _, prefix := range quota.RootfsPrefixes {
+			if !filepath.IsAbs(prefix) {
+				return fmt.Errorf("user-quotas %s rootfs-prefixes must be absolute paths: %s", key, prefix)
+			}
+		}
+	}
+
+	admission := c.Admission
+	if admission.MaxCPUPercent < 0 || admission.MaxCPUPercent > 100 || admission.MaxMemoryPercent < 0 || admission.MaxMemoryPercent > 100 {
+		return fmt.Errorf("admission thresholds must be percentages between 0 and 100")
+	}
+	if admission.QueueTimeout < 0 {
+		return fmt.Errorf("admission queue-timeout cannot be negative")
+	}
+
+	protection := c.Protection
+	if protection.OOMScoreAdj < -1000 || protection.OOMScoreAdj > 1000 {
+		return fmt.Errorf("daemon-protection oom-score-adj must be between -1000 and 1000")
+	}
+	if protection.CpuShares > 0 && (protection.CpuShares < 2 || protection.CpuShares > 262144) {
+		return fmt.Errorf("daemon-protection cpu-shares must be between 2 and 262144")
+	}
+	if protection.LowMemoryWarning < 0 || protection.LowMemoryWarning >= 100 {
+		return fmt.Errorf("daemon-protection low-memory-warning must be a percentage below 100")
+	}
+
+	if c.StatsHistory.Interval < 0 || c.StatsHistory.Retention < 0 {
+		return fmt.Errorf("stats-history durations cannot be negative")
+	}
+
+	if c.Tracing.Endpoint != "" {
+		u, err := url.Parse(c.Tracing.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("tracing otlp-endpoint must be an http:// or https:// URL: %q", c.Tracing.Endpoint)
+		}
+	}
+
+	return nil
+}
+
+// validateMirror checks that a registry mirror is an http or https URL without a query or fragment
+func validateMirror(mirror string) error {
+	u, err := url.Parse(mirror)
+	if err != nil {
+		return fmt.Errorf("invalid registry-mirrors entry %q: %v", mirror, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("registry-mirrors entries must be http:// or https:// URLs: %q", mirror)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("registry-mirrors entries cannot have a query or fragment: %q", mirror)
+	}
+	return nil
+}
+
+// validateInsecureRegistry checks that an insecure-registries entry is a host[:port] or a CIDR range
+func validateInsecureRegistry(registry string) error {
+	if strings.Contains(registry, "://") {
+		return fmt.Errorf("insecure-registries entries must not include a scheme: %q", registry)
+	}
+	if strings.Contains(registry, "/") {
+		if _, _, err := net.ParseCIDR(registry); err != nil {
+			return fmt.Errorf("invalid insecure-registries CIDR %q: %v", registry, err)
+		}
+		return nil
+	}
+
+	host := registry
+	if h, port, err := net.SplitHostPort(registry); err == nil {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port in insecure-registries entry %q", registry)
+		}
+		host = h
+	}
+	if host == "" {
+		return fmt.Errorf("insecure-registries entries need a host: %q", registry)
+	}
+	return nil
+}
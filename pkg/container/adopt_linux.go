@@ -0,0 +1,132 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrAdoptedExit is what Wait returns for an adopted process; only its parent can collect its exit status
+var ErrAdoptedExit = errors.New("exit status unknown, the process was adopted")
+
+// PidFD refers to a process through a pidfd, which keeps referring to it even if its PID is reused
+type PidFD struct {
+	pid int
+	fd  int
+}
+
+// OpenPidFD opens a pidfd for pid, so what is learned about the process afterwards can be tied to it with Alive
+func OpenPidFD(pid int) (*PidFD, error) {
+	fd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open process %d: %v", pid, err)
+	}
+	return &PidFD{pid: pid, fd: fd}, nil
+}
+
+// Alive reports an error once the process has exited, after which its PID may belong to another process
+func (p *PidFD) Alive() error {
+	if p.fd < 0 {
+		return fmt.Errorf("pidfd of process %d is closed", p.pid)
+	}
+	if err := unix.PidfdSendSignal(p.fd, 0, nil, 0); err != nil {
+		return fmt.Errorf("process %d has exited", p.pid)
+	}
+	return nil
+}
+
+// Close closes the pidfd unless Adopt has taken it over; it may be called more than once
+func (p *PidFD) Close() {
+	if p.fd >= 0 {
+		unix.Close(p.fd)
+		p.fd = -1
+	}
+}
+
+// Adopt makes the runner manage the process p refers to, started outside the daemon, instead of starting one of its own
+// The process and its descendants are moved into the runner's cgroup. The daemon isn't the process's parent,
+// so its exit is noticed through the pidfd, which Adopt takes over, and Wait returns ErrAdoptedExit
+func (r *Runner) Adopt(p *PidFD) error {
+	pid := p.pid
+	if err := p.Alive(); err != nil {
+		return err
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find process %d: %v", pid, err)
+	}
+
+	if r.Cgroup != nil {
+		if err := r.Cgroup.AddProcess(pid); err != nil {
+			return fmt.Errorf("failed to add process to cgroup: %v", err)
+		}
+		// Descendants that exit before they are moved are no loss
+		for _, child := range descendants(pid) {
+			r.Cgroup.AddProcess(child)
+		}
+		// The cgroup is joined by PID, so it must still have been the same process then
+		if err := p.Alive(); err != nil {
+			return err
+		}
+	}
+
+	pidfd := p.fd
+	p.fd = -1
+	r.Cmd = &exec.Cmd{Args: r.Command, Process: process}
+	r.exited = make(chan struct{})
+	go func() {
+		fds := []unix.PollFd{{Fd: int32(pidfd), Events: unix.POLLIN}}
+		for {
+			if _, err := unix.Poll(fds, -1); err != unix.EINTR {
+				break
+			}
+		}
+		unix.Close(pidfd)
+		r.waitErr = ErrAdoptedExit
+		close(r.exited)
+	}()
+
+	return nil
+}
+
+// descendants returns the PIDs of the children of pid, their children and so on
+func descendants(pid int) []int {
+	stats, _ := filepath.Glob("/proc/[0-9]*/stat")
+	children := make(map[int][]int)
+	for _, path := range stats {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// The command name in parentheses may contain spaces, so fields are counted after its closing parenthesis
+		end := strings.LastIndexByte(string(data), ')')
+		if end < 0 {
+			continue
+		}
+		fields := strings.Fields(string(data[end+1:]))
+		if len(fields) < 2 {
+			continue
+		}
+		child, err1 := strconv.Atoi(filepath.Base(filepath.Dir(path)))
+		parent, err2 := strconv.Atoi(fields[1])
+		if err1 == nil && err2 == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	var found []int
+	queue := children[pid]
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		found = append(found, next)
+		queue = append(queue, children[next]...)
+	}
+	return found
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+
+package container
+
+import "fmt"
+
+// PidFD refers to a process; there are no pidfds outside Linux
+type PidFD struct{}
+
+// OpenPidFD always fails outside Linux, where processes can't be adopted
+func OpenPidFD(pid int) (*PidFD, error) {
+	return nil, fmt.Errorf("adopting processes is only supported on Linux")
+}
+
+// Alive always fails outside Linux
+func (p *PidFD) Alive() error {
+	return fmt.Errorf("adopting processes is only supported on Linux")
+}
+
+// Close does nothing outside Linux
+func (p *PidFD) Close() {}
+
+// Adopt always fails outside Linux, where there is no cgroup to move the process into
+func (r *Runner) Adopt(p *PidFD) error {
+	return fmt.Errorf("adopting processes is only supported on Linux")
+}
@@ -1,3 +1,5 @@
+// Package container runs commands in containers: new namespaces, a pivoted rootfs and a cgroup with resource limits
+// The daemon drives containers through Runner; Run covers a whole single-shot run for programs embedding the runtime
 package container
 
 import (
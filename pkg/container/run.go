@@ -0,0 +1,124 @@
+package container
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"syscall"
+
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+)
+
+// Config describes a container for Run
+type Config struct {
+	ID      string   // Names the container's cgroup (empty for a random ID)
+	Command []string // Command and arguments, resolved inside the rootfs
+	Rootfs  string
+	Env     []string // Extra environment variables (KEY=VALUE)
+	Limits  cgroups.ResourceLimits
+
+	Mounts      []namespace.Mount      // Extra mounts made inside the container
+	MaskedPaths []string               // Paths hidden from the container on top of namespace.DefaultMaskedPaths
+	TimeOffsets *namespace.TimeOffsets // Clock offsets of a private time namespace (nil to share the host's)
+
+	InitPath string // container-init binary (empty to look next to the running executable)
+	Stdio    Stdio
+}
+
+// Run creates a container, runs its command to completion and removes its cgroup, all without a daemon
+// It must run as root and returns the command's exit code; cancelling ctx kills the container
+// A daemon running on the same host doesn't know the container and its reconcile removes the cgroup
+func Run(ctx context.Context, cfg Config) (int, error) {
+	limits := cfg.Limits
+	if err := limits.Validate(); err != nil {
+		return -1, err
+	}
+	limits.ResolveMemorySwap()
+
+	version := cgroups.DetectVersion()
+	if version == cgroups.V2 {
+		if err := cgroups.SetupV2(); err != nil {
+			return -1, fmt.Errorf("failed to set up cgroups: %v", err)
+		}
+	}
+	if err := version.CheckHost(limits); err != nil {
+		return -1, err
+	}
+
+	masked := append([]string(nil), namespace.DefaultMaskedPaths...)
+	for _, path := range cfg.MaskedPaths {
+		if err := namespace.ValidateMaskedPath(path); err != nil {
+			return -1, err
+		}
+		if !slices.Contains(masked, path) {
+			masked = append(masked, path)
+		}
+	}
+	if cfg.TimeOffsets != nil && !namespace.TimeNamespaceSupported() {
+		return -1, fmt.Errorf("time namespaces are not supported by the kernel")
+	}
+
+	id := cfg.ID
+	if id == "" {
+		bytes := make([]byte, 32)
+		if _, err := rand.Read(bytes); err != nil {
+			return -1, fmt.Errorf("failed to generate container ID: %v", err)
+		}
+		id = hex.EncodeToString(bytes)
+	}
+	rootfs, err := filepath.Abs(cfg.Rootfs)
+	if err != nil {
+		return -1, fmt.Errorf("invalid rootfs %s: %v", cfg.Rootfs, err)
+	}
+
+	cg := cgroups.NewManager(version, id, version.Controllers(limits))
+	runner, err := NewRunner(id, cfg.Command, rootfs, cfg.Env, nil, cg, limits, false)
+	if err != nil {
+		return -1, err
+	}
+	runner.Mounts = cfg.Mounts
+	runner.MaskedPaths = masked
+	runner.TimeOffsets = cfg.TimeOffsets
+	runner.InitPath = cfg.InitPath
+	runner.Stdio = &cfg.Stdio
+
+	if err := runner.Start(); err != nil {
+		runner.Cleanup()
+		return -1, err
+	}
+
+	select {
+	case <-runner.Exited():
+	case <-ctx.Done():
+		runner.Kill()
+	}
+	waitErr := runner.Wait()
+
+	if err := runner.Cleanup(); err != nil {
+		return ExitCode(waitErr), err
+	}
+	return ExitCode(waitErr), nil
+}
+
+// ExitCode converts the result of waiting for a container into a shell-style exit code
+// Containers killed by a signal report 128 plus the signal number
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return -1
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
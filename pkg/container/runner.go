@@ -1,11 +1,14 @@
 package container
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,14 +22,53 @@ type Runner struct {
 	ID      string
 	Command []string
 	Rootfs  string
-	Cgroup  *cgroups.Cgroup
+	Env     []string           // Extra environment variables (KEY=VALUE)
+	Secrets []namespace.Secret // Files exposed read-only under /run/secrets
+	Cgroup  cgroups.Manager
 	Cmd     *exec.Cmd
 	Detach  bool
 	PtyFile *os.File // PTY master file (for attached mode)
+
+	// Attached containers with NoTTY get pipes instead of a PTY; the daemon holds the other ends
+	NoTTY     bool
+	OpenStdin bool     // Give a NoTTY container a stdin pipe rather than /dev/null
+	Stdin     *os.File // Write end of the container's stdin, nil once closed
+	Stdout    *os.File // Read end of the container's stdout
+	Stderr    *os.File // Read end of the container's stderr
+	stdinMu   sync.Mutex
+
+	AppArmorProfile string // Profile applied by container-init before exec (empty for none)
+	ProcessLabel    string // SELinux context of the container command (empty for none)
+	MountLabel      string // SELinux context of mounts container-init creates (empty for none)
+
+	TimeOffsets *namespace.TimeOffsets // Clock offsets of a private time namespace (nil to share the host's)
+
+	Mounts      []namespace.Mount // Extra mounts made by container-init
+	MaskedPaths []string          // Paths hidden from the container
+
+	NamespacesOf int  // PID of a process whose network, PID and IPC namespaces the container joins (0 for new ones)
+	ShareUTS     bool // Join the UTS namespace of NamespacesOf as well, so the hostname is shared
+
+	InitPath string // container-init binary (empty to look next to the running executable)
+	Stdio    *Stdio // Streams the process uses as they are, instead of a PTY or pipes (nil for neither)
+
+	attachMu sync.Mutex
+	attached bool // Whether a client is currently streaming the PTY
+
+	exited  chan struct{} // Closed once the process has been reaped
+	waitErr error         // Result of reaping the process, valid after exited is closed
 }
 
-// NewRunner creates a new container runner and sets up its cgroup
-func NewRunner(id string, command []string, rootfs string, limits cgroups.ResourceLimits, detach bool) (*Runner, error) {
+// Stdio holds streams a container process uses, such as the caller's terminal or a log driver
+// Files are handed to the process directly; other readers and writers are copied through pipes by os/exec
+type Stdio struct {
+	Stdin  io.Reader // nil for /dev/null
+	Stdout io.Writer // nil to discard
+	Stderr io.Writer // nil to discard
+}
+
+// NewRunner creates a new container runner and sets up its cgroup with the given limits
+func NewRunner(id string, command []string, rootfs string, env []string, secrets []namespace.Secret, cg cgroups.Manager, limits cgroups.ResourceLimits, detach bool) (*Runner, error) {
 	// Validate inputs
 	if len(command) == 0 {
 		return nil, fmt.Errorf("command cannot be empty")
@@ -40,14 +82,23 @@ func NewRunner(id string, command []string, rootfs string, limits cgroups.Resour
 		return nil, fmt.Errorf("rootfs directory doesn't exist: %s", rootfs)
 	}
 
-	// TODO: Cgroups disabled for now - will implement later
-	// For now, just create the runner without cgroup
+	// Create the cgroup and apply resource limits
+	if err := cg.Create(); err != nil {
+		cg.Delete()
+		return nil, fmt.Errorf("failed to create cgroup: %v", err)
+	}
+	if err := cg.SetResourceLimits(limits); err != nil {
+		cg.Delete()
+		return nil, fmt.Errorf("failed to set resource limits: %v", err)
+	}
 
 	return &Runner{
 		ID:      id,
 		Command: command,
 		Rootfs:  rootfs,
-		Cgroup:  nil, // No cgroup for now
+		Env:     env,
+		Secrets: secrets,
+		Cgroup:  cg,
 		Detach:  detach,
 	}, nil
 }
@@ -55,13 +106,15 @@ func NewRunner(id string, command []string, rootfs string, limits cgroups.Resour
 // Start prepares and starts the container process in the background
 func (r *Runner) Start() error {
 	// Find the path to container-init binary
-	// It should be in the same directory as the mydockerd binary
-	execPath, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to get executable path: %v", err)
+	// Unless given, it should be in the same directory as the mydockerd binary
+	initPath := r.InitPath
+	if initPath == "" {
+		execPath, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to get executable path: %v", err)
+		}
+		initPath = filepath.Join(filepath.Dir(execPath), "container-init")
 	}
-	execDir := filepath.Dir(execPath)
-	initPath := filepath.Join(execDir, "container-init")
 
 	// Check if container-init exists
 	if _, err := os.Stat(initPath); os.IsNotExist(err) {
@@ -73,43 +126,267 @@ func (r *Runner) Start() error {
 	args := append([]string{initPath}, r.Command...)
 	r.Cmd = exec.Command(args[0], args[1:]...)
 
-	// Pass the rootfs path via environment variable
-	r.Cmd.Env = append(os.Environ(), fmt.Sprintf("CONTAINER_ROOTFS=%s", r.Rootfs))
+	// container-init blocks on this pipe until the process has been added to the cgroup
+	// so that everything the container allocates is accounted to it
+	configRead, configWrite, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create config pipe: %v", err)
+	}
+	defer configRead.Close()
+	defer configWrite.Close()
+
+	syncRead, syncWrite, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create sync pipe: %v", err)
+	}
+	defer syncRead.Close()
+	defer syncWrite.Close()
+
+	// container-init reports setup failures on this pipe; it closes without data once the command has been exec'd
+	errRead, errWrite, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create error pipe: %v", err)
+	}
+	defer errRead.Close()
+	defer errWrite.Close()
+	r.Cmd.ExtraFiles = []*os.File{configRead, syncRead, errWrite}
+
+	// The init configuration is sent over the config pipe once the process has started
+	initCfg := namespace.InitConfig{
+		Rootfs:  r.Rootfs,
+		Secrets: r.Secrets,
+		SyncFD:  namespace.ConfigFD + 1, // Second of ExtraFiles
+		ErrorFD: namespace.ConfigFD + 2,
+
+		AppArmorProfile: r.AppArmorProfile,
+		ProcessLabel:    r.ProcessLabel,
+		MountLabel:      r.MountLabel,
+		TimeOffsets:     r.TimeOffsets,
+		Mounts:          r.Mounts,
+		MaskedPaths:     r.MaskedPaths,
+	}
+	// The container gets a clean environment rather than inheriting the daemon's
+	r.Cmd.Env = mergeEnv(namespace.DefaultEnv, r.Env)
 
 	// Configure namespaces
 	namespace.PrepareNamespaces(r.Cmd)
 
 	// Set up stdin/stdout/stderr based on detach mode
-	if r.Detach {
+	var start func() error
+	if r.Stdio != nil {
+		// The caller's streams are used as they are
+		r.Cmd.Stdin = r.Stdio.Stdin
+		r.Cmd.Stdout = r.Stdio.Stdout
+		r.Cmd.Stderr = r.Stdio.Stderr
+
+		start = func() error {
+			if err := r.Cmd.Start(); err != nil {
+				return fmt.Errorf("failed to start container process: %v", err)
+			}
+			return nil
+		}
+	} else if r.Detach {
 		// Detached mode: no stdin, log to daemon's stdout/stderr
 		r.Cmd.Stdin = nil
 		r.Cmd.Stdout = os.Stdout
 		r.Cmd.Stderr = os.Stderr
 
 		// Start the process in the background
-		if err := r.Cmd.Start(); err != nil {
-			return fmt.Errorf("failed to start container process: %v", err)
+		start = func() error {
+			if err := r.Cmd.Start(); err != nil {
+				return fmt.Errorf("failed to start container process: %v", err)
+			}
+			return nil
+		}
+	} else if r.NoTTY {
+		// Attached mode without a TTY: pipes keep stdout and stderr apart
+		childFiles, err := r.openPipes()
+		if err != nil {
+			return err
+		}
+		start = func() error {
+			err := r.Cmd.Start()
+			// The container has its own copies now
+			for _, f := range childFiles {
+				f.Close()
+			}
+			if err != nil {
+				r.closePipes()
+				return fmt.Errorf("failed to start container process: %v", err)
+			}
+			return nil
 		}
 	} else {
 		// Attached mode: allocate a PTY
-		ptyFile, err := pty.Start(r.Cmd)
-		if err != nil {
-			return fmt.Errorf("failed to start container with PTY: %v", err)
+		start = func() error {
+			ptyFile, err := pty.Start(r.Cmd)
+			if err != nil {
+				return fmt.Errorf("failed to start container with PTY: %v", err)
+			}
+			r.PtyFile = ptyFile
+			return nil
+		}
+	}
+	if r.NamespacesOf != 0 {
+		err = namespace.StartInNamespaces(r.Cmd, r.NamespacesOf, r.ShareUTS, start)
+	} else {
+		err = start()
+	}
+	if err != nil {
+		return err
+	}
+
+	err = initCfg.Send(configWrite)
+	configWrite.Close()
+	if err != nil {
+		r.Cmd.Process.Kill()
+		r.Cmd.Wait()
+		return err
+	}
+
+	// Move the process into the cgroup while container-init waits, then let it continue
+	if r.Cgroup != nil {
+		if err := r.Cgroup.AddProcess(r.Cmd.Process.Pid); err != nil {
+			r.Cmd.Process.Kill()
+			r.Cmd.Wait()
+			return fmt.Errorf("failed to add process to cgroup: %v", err)
 		}
-		r.PtyFile = ptyFile
+	}
+	// The workload gets the default OOM killer priority rather than inheriting the daemon's protection
+	if err := os.WriteFile(fmt.Sprintf("/proc/%d/oom_score_adj", r.Cmd.Process.Pid), []byte("0"), 0644); err != nil {
+		r.Cmd.Process.Kill()
+		r.Cmd.Wait()
+		return fmt.Errorf("failed to reset oom_score_adj: %v", err)
+	}
+	if _, err := syncWrite.Write([]byte{0}); err != nil {
+		r.Cmd.Process.Kill()
+		r.Cmd.Wait()
+		return fmt.Errorf("failed to signal container-init: %v", err)
 	}
 
-	// TODO: Cgroup support disabled - skip AddProcess for now
+	// Wait for the exec; our copy of the write end must be closed to see EOF
+	errWrite.Close()
+	if err := namespace.ReadInitError(errRead); err != nil {
+		r.Cmd.Wait()
+		return err
+	}
+
+	// Reap the process exactly once; Wait and WaitWithTimeout observe the result
+	r.exited = make(chan struct{})
+	go func() {
+		r.waitErr = r.Cmd.Wait()
+		close(r.exited)
+	}()
 
 	return nil
 }
 
+// openPipes creates the stdio pipes of a NoTTY container, connects their container ends to Cmd and
+// returns those ends for the caller to close once the process has started
+func (r *Runner) openPipes() ([]*os.File, error) {
+	var childFiles []*os.File
+	fail := func(err error) ([]*os.File, error) {
+		for _, f := range childFiles {
+			f.Close()
+		}
+		r.closePipes()
+		return nil, fmt.Errorf("failed to create stdio pipes: %v", err)
+	}
+
+	if r.OpenStdin {
+		read, write, err := os.Pipe()
+		if err != nil {
+			return fail(err)
+		}
+		r.Cmd.Stdin, r.Stdin = read, write
+		childFiles = append(childFiles, read)
+	}
+	stdoutRead, stdoutWrite, err := os.Pipe()
+	if err != nil {
+		return fail(err)
+	}
+	r.Cmd.Stdout, r.Stdout = stdoutWrite, stdoutRead
+	childFiles = append(childFiles, stdoutWrite)
+	stderrRead, stderrWrite, err := os.Pipe()
+	if err != nil {
+		return fail(err)
+	}
+	r.Cmd.Stderr, r.Stderr = stderrWrite, stderrRead
+	childFiles = append(childFiles, stderrWrite)
+
+	return childFiles, nil
+}
+
+// closePipes closes the daemon's ends of the stdio pipes
+func (r *Runner) closePipes() {
+	r.CloseStdin()
+	if r.Stdout != nil {
+		r.Stdout.Close()
+		r.Stdout = nil
+	}
+	if r.Stderr != nil {
+		r.Stderr.Close()
+		r.Stderr = nil
+	}
+}
+
+// CloseStdin closes the container's stdin pipe so it reads EOF; later calls do nothing
+func (r *Runner) CloseStdin() {
+	r.stdinMu.Lock()
+	defer r.stdinMu.Unlock()
+
+	if r.Stdin != nil {
+		r.Stdin.Close()
+		r.Stdin = nil
+	}
+}
+
+// StdinPipe returns the write end of the container's stdin, nil if it has none or it was closed
+func (r *Runner) StdinPipe() *os.File {
+	r.stdinMu.Lock()
+	defer r.stdinMu.Unlock()
+
+	return r.Stdin
+}
+
+// Attachable reports whether clients can attach to the container's streams
+func (r *Runner) Attachable() bool {
+	return r.PtyFile != nil || r.Stdout != nil
+}
+
+// mergeEnv returns base with entries overridden or extended by the KEY=VALUE pairs in overrides
+func mergeEnv(base, overrides []string) []string {
+	merged := append([]string{}, base...)
+	for _, kv := range overrides {
+		key := strings.SplitN(kv, "=", 2)[0]
+
+		replaced := false
+		for i, existing := range merged {
+			if strings.SplitN(existing, "=", 2)[0] == key {
+				merged[i] = kv
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, kv)
+		}
+	}
+	return merged
+}
+
 // Wait blocks until the container process exits
 func (r *Runner) Wait() error {
 	if r.Cmd == nil || r.Cmd.Process == nil {
 		return fmt.Errorf("container not started")
 	}
-	return r.Cmd.Wait()
+	<-r.exited
+	return r.waitErr
+}
+
+// Exited returns a channel that is closed once the container process has exited
+func (r *Runner) Exited() <-chan struct{} {
+	return r.exited
 }
 
 // Stop sends SIGTERM to the container process
@@ -125,7 +402,10 @@ func (r *Runner) Kill() error {
 	if r.Cmd == nil || r.Cmd.Process == nil {
 		return fmt.Errorf("container not started")
 	}
-	return r.Cmd.Process.Kill()
+	if err := r.Cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
 }
 
 // PID returns the process ID of the container
@@ -143,15 +423,72 @@ func (r *Runner) Cleanup() error {
 		r.PtyFile.Close()
 		r.PtyFile = nil
 	}
-	// TODO: Cgroup cleanup disabled for now
+
+	// An attached client may still be reading what the container wrote before it exited; ReleaseAttach closes the pipes then
+	r.attachMu.Lock()
+	if r.attached {
+		r.CloseStdin()
+	} else {
+		r.closePipes()
+	}
+	r.attachMu.Unlock()
+	if r.Cgroup != nil {
+		if err := r.Cgroup.Delete(); err != nil {
+			return fmt.Errorf("failed to delete cgroup: %v", err)
+		}
+	}
 	return nil
 }
 
+// Stats reads the resource usage of the container's cgroup
+func (r *Runner) Stats() (*cgroups.Stats, error) {
+	if r.Cgroup == nil {
+		return nil, fmt.Errorf("container has no cgroup")
+	}
+	return r.Cgroup.Stats()
+}
+
 // GetPtyFile returns the PTY file for attached mode
 func (r *Runner) GetPtyFile() *os.File {
 	return r.PtyFile
 }
 
+// AcquireAttach marks the PTY as in use by a client
+// Returns false if another client is already attached
+func (r *Runner) AcquireAttach() bool {
+	r.attachMu.Lock()
+	defer r.attachMu.Unlock()
+
+	if r.attached {
+		return false
+	}
+	r.attached = true
+	return true
+}
+
+// ReleaseAttach marks the PTY as free for another client to attach
+func (r *Runner) ReleaseAttach() {
+	r.attachMu.Lock()
+	defer r.attachMu.Unlock()
+
+	r.attached = false
+
+	// Cleanup left the pipes to this client if the container has exited
+	select {
+	case <-r.exited:
+		r.closePipes()
+	default:
+	}
+}
+
+// IsAttached reports whether a client is currently streaming the PTY
+func (r *Runner) IsAttached() bool {
+	r.attachMu.Lock()
+	defer r.attachMu.Unlock()
+
+	return r.attached
+}
+
 // CopyIO copies data between the PTY and provided reader/writer
 func (r *Runner) CopyIO(stdin io.Reader, stdout, stderr io.Writer) error {
 	if r.PtyFile == nil {
@@ -180,14 +517,9 @@ func (r *Runner) WaitWithTimeout(timeout time.Duration) error {
 		return fmt.Errorf("container not started")
 	}
 
-	done := make(chan error, 1)
-	go func() {
-		done <- r.Wait()
-	}()
-
 	select {
-	case err := <-done:
-		return err
+	case <-r.exited:
+		return r.waitErr
 	case <-time.After(timeout):
 		return fmt.Errorf("timeout waiting for container to exit")
 	}
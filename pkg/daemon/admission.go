@@ -0,0 +1,363 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/config"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+)
+
+// admissionInterval is how often host utilization is sampled and the head of the admission queue reconsidered
+const admissionInterval = time.Second
+
+// admission queues container starts while the host is busier than the configured thresholds
+type admission struct {
+	mu    sync.Mutex
+	queue []*admissionEntry
+
+	// Host utilization over the last interval, in percent
+	cpu    float64
+	memory float64
+
+	prevBusy, prevTotal uint64 // /proc/stat counters from the previous sample
+}
+
+// admissionEntry is a container start waiting for the host to have room
+type admissionEntry struct {
+	id         string
+	queued     time.Time
+	reason     string
+	prevStatus string     // Status restored if the container leaves the queue without starting
+	ready      chan error // Receives nil once admitted, or the reason the start was dropped
+
+	waitFor  *api.WaitConditions // Host resources the container waits for, nil for none
+	deadline time.Time           // When waiting for them times out, zero for never
+	hostWait bool                // Still waiting for waitFor, so it doesn't hold up the containers behind it
+}
+
+// saturation returns why the host is too busy to start a container under policy, or "" if it isn't
+// The caller must hold a.mu
+func (a *admission) saturation(policy config.AdmissionPolicy) string {
+	if policy.MaxCPUPercent > 0 && a.cpu >= policy.MaxCPUPercent {
+		return fmt.Sprintf("host cpu at %.1f%%, limit %.1f%%", a.cpu, policy.MaxCPUPercent)
+	}
+	if policy.MaxMemoryPercent > 0 && a.memory >= policy.MaxMemoryPercent {
+		return fmt.Sprintf("host memory at %.1f%%, limit %.1f%%", a.memory, policy.MaxMemoryPercent)
+	}
+	return ""
+}
+
+// sample refreshes the host utilization figures
+func (a *admission) sample() {
+	busy, total, err := hostCPUTimes()
+	if err != nil {
+		return
+	}
+	memTotal, err := cgroups.HostMemoryTotal()
+	if err != nil {
+		return
+	}
+	memAvailable, err := cgroups.HostMemoryAvailable()
+	if err != nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.prevTotal > 0 && total > a.prevTotal {
+		a.cpu = float64(busy-a.prevBusy) / float64(total-a.prevTotal) * 100
+	}
+	a.prevBusy, a.prevTotal = busy, total
+	a.memory = float64(memTotal-memAvailable) / float64(memTotal) * 100
+}
+
+// hostCPUTimes returns the busy and total CPU ticks of the host from the "cpu" line of /proc/stat
+func hostCPUTimes() (busy, total uint64, err error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read /proc/stat: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[0] != "cpu" {
+			continue
+		}
+
+		// user nice system idle iowait irq softirq steal; guest time is already counted in user
+		var idle uint64
+		for i, field := range fields[1:min(len(fields), 9)] {
+			n, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to parse /proc/stat: %v", err)
+			}
+			total += n
+			if i == 3 || i == 4 {
+				idle += n
+			}
+		}
+		return total - idle, total, nil
+	}
+	return 0, 0, fmt.Errorf("cpu line not found in /proc/stat")
+}
+
+// admitAndStart starts a container, or queues it when the host is saturated or other starts are already waiting
+// Detached starts return right away with a nil runner when queued; attached starts wait for their turn
+func (d *Daemon) admitAndStart(ctx context.Context, id string, detach bool, cause transitionCause) (*container.Runner, error) {
+	entry, err := d.enqueue(id, cause)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return d.StartContainerWithRunner(ctx, id, detach, cause)
+	}
+
+	if detach {
+		go d.startWhenAdmitted(ctx, entry, cause)
+		return nil, nil
+	}
+
+	if err := traceStage(ctx, "container.admission", func() error { return <-entry.ready }); err != nil {
+		return nil, err
+	}
+	return d.StartContainerWithRunner(ctx, id, false, cause)
+}
+
+// enqueue puts a container start in the admission queue if it has to wait, returning nil if it can start now
+// Containers also wait there, whatever the policy, until the host resources they declare exist
+func (d *Daemon) enqueue(id string, cause transitionCause) (*admissionEntry, error) {
+	policy := d.currentConfig().Admission
+
+	containerState, err := d.getContainer(id)
+	if err != nil {
+		return nil, err
+	}
+	waiting := unmetWaitConditions(containerState.WaitFor)
+	if !policy.Enabled() && waiting == "" {
+		return nil, nil
+	}
+	if containerState.Status == "running" || containerState.Status == "queued" {
+		return nil, errConflict(api.ErrCodeContainerRunning, "container is already %s: %s", containerState.Status, id)
+	}
+
+	d.admission.mu.Lock()
+	defer d.admission.mu.Unlock()
+
+	// Starts that arrive while others are waiting for admission line up behind them even if the host has room
+	reason := waiting
+	if reason == "" {
+		reason = d.admission.saturation(policy)
+	}
+	if reason == "" {
+		if !d.admission.waitingForAdmission() {
+			return nil, nil
+		}
+		reason = "waiting behind queued containers"
+	}
+
+	entry := &admissionEntry{id: id, queued: time.Now(), reason: reason, prevStatus: containerState.Status, ready: make(chan error, 1)}
+	if containerState.WaitFor != nil {
+		entry.waitFor = containerState.WaitFor
+		entry.hostWait = waiting != ""
+		if timeout := containerState.WaitFor.Timeout; timeout > 0 {
+			entry.deadline = entry.queued.Add(timeout)
+		}
+	}
+	d.admission.queue = append(d.admission.queue, entry)
+
+	d.setStatus(containerState, "queued", transitionCause{cause.actor, "queued for admission: " + reason})
+	d.updateContainer(containerState)
+
+	fmt.Printf("Queued start of container %s: %s\n", id, reason)
+	d.logEvent("queued", id, map[string]string{"reason": reason})
+	return entry, nil
+}
+
+// waitingForAdmission reports whether any queued container is waiting for the host to have room
+// The caller must hold a.mu
+func (a *admission) waitingForAdmission() bool {
+	for _, entry := range a.queue {
+		if !entry.hostWait {
+			return true
+		}
+	}
+	return false
+}
+
+// startWhenAdmitted starts a queued detached container once the admission loop lets it through
+func (d *Daemon) startWhenAdmitted(ctx context.Context, entry *admissionEntry, cause transitionCause) {
+	if err := traceStage(ctx, "container.admission", func() error { return <-entry.ready }); err != nil {
+		return
+	}
+
+	if _, err := d.StartContainerWithRunner(ctx, entry.id, true, cause); err != nil {
+		fmt.Printf("Failed to start admitted container %s: %v\n", entry.id, err)
+		if containerState, getErr := d.getContainer(entry.id); getErr == nil {
+			d.setStatus(containerState, "exited", transitionCause{daemonActor, fmt.Sprintf("start failed: %v", err)})
+			containerState.Exited = time.Now()
+			d.updateContainer(containerState)
+		}
+	}
+}
+
+// runAdmission samples host utilization and admits queued containers until stop is closed
+// At most one container is admitted per interval so the utilization it adds shows up before the next
+func (d *Daemon) runAdmission(stop <-chan struct{}) {
+	ticker := time.NewTicker(admissionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		policy := d.currentConfig().Admission
+		d.admission.sample()
+
+		// Host resources are checked without the lock since unit checks run systemctl
+		d.admission.mu.Lock()
+		pending := slices.Clone(d.admission.queue)
+		d.admission.mu.Unlock()
+		waiting := make(map[*admissionEntry]string)
+		for _, entry := range pending {
+			if entry.waitFor != nil {
+				waiting[entry] = unmetWaitConditions(entry.waitFor)
+			}
+		}
+
+		d.admission.mu.Lock()
+		now := time.Now()
+		timeout := time.Duration(policy.QueueTimeout)
+		var expired []*admissionEntry
+		var expiredReasons []string
+		kept := d.admission.queue[:0]
+		for _, entry := range d.admission.queue {
+			switch {
+			case timeout > 0 && now.Sub(entry.queued) > timeout:
+				expired = append(expired, entry)
+				expiredReasons = append(expiredReasons, fmt.Sprintf("timed out after %s in the admission queue", timeout))
+			case entry.hostWait && !entry.deadline.IsZero() && now.After(entry.deadline):
+				expired = append(expired, entry)
+				expiredReasons = append(expiredReasons, fmt.Sprintf("timed out after %s %s", entry.waitFor.Timeout, entry.reason))
+			default:
+				kept = append(kept, entry)
+			}
+		}
+		d.admission.queue = kept
+
+		// Containers still waiting for host resources are passed over; the first of the rest is admitted once
+		// the host has room, and lifting the thresholds with a reload lets the queue through one per interval
+		saturated := ""
+		if policy.Enabled() {
+			saturated = d.admission.saturation(policy)
+		}
+		var admitted *admissionEntry
+		for _, entry := range d.admission.queue {
+			reason, checked := waiting[entry]
+			switch {
+			case entry.waitFor != nil && !checked:
+				// Queued after the check, so it is looked at on the next interval
+			case reason != "":
+				entry.reason, entry.hostWait = reason, true
+			case saturated != "":
+				entry.reason, entry.hostWait = saturated, false
+			case admitted == nil:
+				admitted = entry
+			default:
+				entry.reason, entry.hostWait = "waiting behind queued containers", false
+			}
+		}
+		if admitted != nil {
+			d.admission.queue = slices.DeleteFunc(d.admission.queue, func(e *admissionEntry) bool { return e == admitted })
+		}
+		d.admission.mu.Unlock()
+
+		for i, entry := range expired {
+			d.leaveQueue(entry, transitionCause{daemonActor, expiredReasons[i]})
+		}
+		if admitted != nil {
+			fmt.Printf("Admitted container %s after %s in the queue\n", admitted.id, time.Since(admitted.queued).Round(time.Second))
+			d.logEvent("dequeued", admitted.id, map[string]string{"reason": "admitted"})
+			admitted.ready <- nil
+		}
+	}
+}
+
+// cancelQueued takes a container out of the admission queue without starting it
+func (d *Daemon) cancelQueued(id string, cause transitionCause) error {
+	d.admission.mu.Lock()
+	var entry *admissionEntry
+	for i, e := range d.admission.queue {
+		if e.id == id {
+			entry = e
+			d.admission.queue = append(d.admission.queue[:i], d.admission.queue[i+1:]...)
+			break
+		}
+	}
+	d.admission.mu.Unlock()
+
+	if entry == nil {
+		return errConflict(api.ErrCodeContainerNotRunning, "container is not queued: %s", id)
+	}
+	d.leaveQueue(entry, cause)
+	return nil
+}
+
+// leaveQueue restores the status of a container dropped from the queue and fails whoever waits on its start
+func (d *Daemon) leaveQueue(entry *admissionEntry, cause transitionCause) {
+	if containerState, err := d.getContainer(entry.id); err == nil {
+		d.setStatus(containerState, entry.prevStatus, cause)
+		d.updateContainer(containerState)
+	}
+
+	fmt.Printf("Container %s left the admission queue: %s\n", entry.id, cause.reason)
+	d.logEvent("dequeued", entry.id, map[string]string{"reason": cause.reason})
+	entry.ready <- fmt.Errorf("container %s was not started: %s", entry.id, cause.reason)
+}
+
+// AdmissionQueue reports host utilization and the containers waiting to start
+func (d *Daemon) AdmissionQueue() *api.AdmissionQueueResponse {
+	policy := d.currentConfig().Admission
+
+	d.admission.mu.Lock()
+	defer d.admission.mu.Unlock()
+
+	resp := &api.AdmissionQueueResponse{
+		Enabled:          policy.Enabled(),
+		CPUPercent:       d.admission.cpu,
+		MemoryPercent:    d.admission.memory,
+		MaxCPUPercent:    policy.MaxCPUPercent,
+		MaxMemoryPercent: policy.MaxMemoryPercent,
+		Queued:           []api.QueuedContainer{},
+	}
+	if resp.Enabled {
+		resp.Saturated = d.admission.saturation(policy)
+	}
+	for _, entry := range d.admission.queue {
+		resp.Queued = append(resp.Queued, api.QueuedContainer{ID: entry.id, Queued: entry.queued, Reason: entry.reason})
+	}
+	return resp
+}
+
+// handleAdmissionQueue reports the admission queue
+func (d *Daemon) handleAdmissionQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.AdmissionQueue())
+}
@@ -0,0 +1,218 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// adoptedProcess is what the daemon learns about a process from /proc before adopting it
+type adoptedProcess struct {
+	command []string
+	root    string // The process's root directory as seen from the daemon
+	uid     uint32 // Real UID
+}
+
+// inspectProcess reads a process's command line, root and owner, and checks that it can be adopted
+func inspectProcess(pid int) (*adoptedProcess, error) {
+	if pid <= 1 || pid == os.Getpid() {
+		return nil, errInvalidRequest(fmt.Errorf("process %d cannot be adopted", pid))
+	}
+	dir := fmt.Sprintf("/proc/%d", pid)
+
+	cmdline, err := os.ReadFile(dir + "/cmdline")
+	if err != nil {
+		return nil, errInvalidRequest(fmt.Errorf("process %d not found", pid))
+	}
+	if len(cmdline) == 0 {
+		// Kernel threads and zombies have no command line
+		return nil, errInvalidRequest(fmt.Errorf("process %d has no command line, it is a kernel thread or has exited", pid))
+	}
+	command := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+
+	// A process in the daemon's own mount and PID namespaces isn't containerized, adopting it would only limit it
+	shared := true
+	for _, ns := range []string{"mnt", "pid"} {
+		own, err1 := os.Readlink("/proc/self/ns/" + ns)
+		theirs, err2 := os.Readlink(dir + "/ns/" + ns)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("failed to read namespaces of process %d", pid)
+		}
+		if own != theirs {
+			shared = false
+		}
+	}
+	if shared {
+		return nil, errInvalidRequest(fmt.Errorf("process %d runs in the daemon's mount and PID namespaces, only processes in namespaces of their own can be adopted", pid))
+	}
+
+	// Processes of containers are already managed
+	cgroupFile, err := os.ReadFile(dir + "/cgroup")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cgroup of process %d: %v", pid, err)
+	}
+	if bytes.Contains(cgroupFile, []byte("/mydocker-")) {
+		return nil, errConflict(api.ErrCodeNameInUse, "process %d already belongs to a container", pid)
+	}
+
+	root, err := os.Readlink(dir + "/root")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read root of process %d: %v", pid, err)
+	}
+
+	status, err := os.ReadFile(dir + "/status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status of process %d: %v", pid, err)
+	}
+	var uid uint64
+	for _, line := range strings.Split(string(status), "\n") {
+		if rest, ok := strings.CutPrefix(line, "Uid:"); ok {
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				uid, err = strconv.ParseUint(fields[0], 10, 32)
+			}
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse UID of process %d: %v", pid, err)
+	}
+
+	return &adoptedProcess{command: command, root: root, uid: uint32(uid)}, nil
+}
+
+// AdoptContainer turns a running process started outside the daemon into a running container owned by caller
+// Clients other than root may only adopt their own processes
+func (d *Daemon) AdoptContainer(req api.ContainerAdoptRequest, caller peer) (string, error) {
+	actor := caller.String()
+	var owner *uint32
+	if caller.known {
+		owner = &caller.uid
+	}
+
+	// Adoptions are serialized so two requests can't both find the process outside any container
+	d.adoptMu.Lock()
+	defer d.adoptMu.Unlock()
+
+	// The pidfd is opened before /proc is read, so a PID reused in between can be told apart from the process inspected
+	pidfd, err := container.OpenPidFD(req.PID)
+	if err != nil {
+		return "", errInvalidRequest(err)
+	}
+	defer pidfd.Close()
+
+	process, err := inspectProcess(req.PID)
+	if err != nil {
+		return "", err
+	}
+	if err := pidfd.Alive(); err != nil {
+		return "", errInvalidRequest(fmt.Errorf("%v while it was being inspected", err))
+	}
+	if caller.known && caller.uid != 0 && caller.uid != process.uid {
+		return "", &apiError{status: http.StatusForbidden, code: api.ErrCodePermissionDenied, err: fmt.Errorf("process %d belongs to uid %d", req.PID, process.uid)}
+	}
+
+	limits := cgroups.ResourceLimits{
+		MemoryLimit: req.Memory,
+		CpuShares:   req.CpuShares,
+		CpuQuota:    req.CpuQuota,
+		CpuPeriod:   req.CpuPeriod,
+		PidsLimit:   req.PidsLimit,
+	}
+	if err := d.applyDefaultLimits(&limits, req.Profile); err != nil {
+		return "", err
+	}
+	if err := limits.Validate(); err != nil {
+		return "", errInvalidRequest(err)
+	}
+	limits.ResolveMemorySwap()
+	if err := d.cgroupVersion.CheckHost(limits); err != nil {
+		return "", errInvalidRequest(err)
+	}
+
+	if d.currentConfig().UserQuotas != nil {
+		d.quotaMu.Lock()
+		defer d.quotaMu.Unlock()
+	}
+	if err := d.checkQuota(owner, "", limits, process.root, nil, nil); err != nil {
+		return "", err
+	}
+
+	id, err := d.generateContainerID()
+	if err != nil {
+		return "", err
+	}
+
+	cg := cgroups.NewManager(d.cgroupVersion, id, d.cgroupVersion.Controllers(limits))
+	runner, err := container.NewRunner(id, process.command, process.root, nil, nil, cg, limits, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to create runner: %v", err)
+	}
+	if err := runner.Adopt(pidfd); err != nil {
+		runner.Cleanup()
+		return "", errInvalidRequest(err)
+	}
+
+	containerState := &state.ContainerState{
+		SchemaVersion: state.SchemaVersion,
+		ID:            id,
+		PID:           req.PID,
+		Created:       time.Now(),
+		ContainerConfig: state.ContainerConfig{
+			Command: process.command,
+			Labels:  req.Labels,
+		},
+		HostConfig: state.HostConfig{
+			Rootfs:  process.root,
+			Limits:  limits,
+			Profile: req.Profile,
+			Owner:   owner,
+
+			Adopted: true,
+		},
+	}
+	d.setStatus(containerState, "running", transitionCause{actor, fmt.Sprintf("adopted process %d", req.PID)})
+	if err := d.addContainer(containerState); err != nil {
+		// The process keeps running under the new cgroup's limits; only the daemon's record of it is missing
+		return "", fmt.Errorf("failed to add container: %v", err)
+	}
+	d.addRunner(id, runner)
+
+	fmt.Printf("Adopted process %d as container %s\n", req.PID, id)
+	d.logEvent("adopt", id, map[string]string{"pid": strconv.Itoa(req.PID)})
+
+	if err := namespace.BindNetns(id, req.PID); err != nil {
+		fmt.Printf("Warning: failed to expose network namespace of container %s: %v\n", id, err)
+	}
+
+	go d.monitorContainer(id, runner)
+	return id, nil
+}
+
+// handleContainerAdopt adopts a running process as a container
+func (d *Daemon) handleContainerAdopt(w http.ResponseWriter, r *http.Request) {
+	var req api.ContainerAdoptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	id, err := d.AdoptContainer(req, requestPeer(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.ContainerCreateResponse{ID: id})
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// streamHeader is the response header telling hijacked clients how the attached stream is framed
+const streamHeader = "Mydocker-Stream"
+
+// attachStreams selects the container streams an attached client gets
+// A PTY merges stdout and stderr, so selecting either one carries the PTY's output
+type attachStreams struct {
+	stdin, stdout, stderr bool
+}
+
+// defaultAttachStreams returns the streams selected when the container was created, all of them if none were
+func defaultAttachStreams(c *state.ContainerState) attachStreams {
+	if !c.AttachStdin && !c.AttachStdout && !c.AttachStderr {
+		return attachStreams{stdin: true, stdout: true, stderr: true}
+	}
+	return attachStreams{stdin: c.AttachStdin, stdout: c.AttachStdout, stderr: c.AttachStderr}
+}
+
+// parseAttachStreams reads the stdin, stdout and stderr query parameters of an attach
+// A request that sets none of them gets defaults
+func parseAttachStreams(query url.Values, defaults attachStreams) (attachStreams, error) {
+	if !query.Has("stdin") && !query.Has("stdout") && !query.Has("stderr") {
+		return defaults, nil
+	}
+
+	var streams attachStreams
+	for name, selected := range map[string]*bool{"stdin": &streams.stdin, "stdout": &streams.stdout, "stderr": &streams.stderr} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return attachStreams{}, fmt.Errorf("invalid %s value %q", name, value)
+		}
+		*selected = b
+	}
+	return streams, nil
+}
+
+// streamKind names how a runner's streams are framed for the streamHeader response header
+func streamKind(runner *container.Runner) string {
+	if runner.GetPtyFile() != nil {
+		return "raw"
+	}
+	return "multiplexed"
+}
+
+// streamContainer streams the selected container streams to a client until the client or the container is done
+func (d *Daemon) streamContainer(r *http.Request, id string, stream io.ReadWriter, runner *container.Runner, streams attachStreams, stdinOnce bool) {
+	if runner.GetPtyFile() != nil {
+		d.streamPty(r, id, stream, runner, streams)
+		return
+	}
+	d.streamPipes(id, stream, runner, streams, stdinOnce)
+}
+
+// streamPipes streams the stdio pipes of a container without a TTY, framing stdout and stderr so the client can
+// tell them apart; it returns once both have reached EOF or the client goes away
+// Output of unselected streams is drained and dropped so the container doesn't block writing it
+func (d *Daemon) streamPipes(id string, stream io.ReadWriter, runner *container.Runner, streams attachStreams, stdinOnce bool) {
+	clientGone := make(chan struct{})
+	var goneOnce sync.Once
+
+	// Copy from client to stdin; EOF means the client has no more input, not that it left
+	go func() {
+		stdin := runner.StdinPipe()
+		var err error
+		if streams.stdin && stdin != nil {
+			_, err = io.Copy(stdin, stream)
+		} else {
+			_, err = io.Copy(io.Discard, stream)
+		}
+		if err != nil {
+			goneOnce.Do(func() { close(clientGone) })
+			return
+		}
+		if stdinOnce && streams.stdin {
+			d.debugf("Closing stdin of container %s\n", id)
+			runner.CloseStdin()
+		}
+	}()
+
+	var writeMu sync.Mutex
+	writeFrame := func(kind byte, p []byte) error {
+		frame := make([]byte, api.StreamHeaderSize, api.StreamHeaderSize+len(p))
+		frame[0] = kind
+		binary.BigEndian.PutUint32(frame[4:api.StreamHeaderSize], uint32(len(p)))
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err := stream.Write(append(frame, p...))
+		return err
+	}
+	selected := map[byte]bool{api.StreamStdout: streams.stdout, api.StreamStderr: streams.stderr}
+
+	// The broadcaster reads the pipes for the log subsystem and passes the output on
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		output := d.getOutput(id)
+		if output == nil {
+			return
+		}
+		err := output.follow(func(kind byte, p []byte) error {
+			if !selected[kind] {
+				return nil
+			}
+			return writeFrame(kind, p)
+		})
+		if err != nil {
+			goneOnce.Do(func() { close(clientGone) })
+		}
+	}()
+
+	select {
+	case <-outputDone:
+	case <-clientGone:
+	}
+}
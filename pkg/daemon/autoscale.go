@@ -0,0 +1,192 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+)
+
+// autoscaleInterval is how often a container's limit counters are checked against its autoscale policy
+const autoscaleInterval = 2 * time.Second
+
+// defaultAutoscaleCooldown is the minimum time between adjustments of a resource when the policy doesn't say
+const defaultAutoscaleCooldown = 30 * time.Second
+
+// maxAutoscaleAdjustments is how many adjustments are kept in a container's state
+const maxAutoscaleAdjustments = 20
+
+// validateAutoscalePolicy checks that each resource the policy scales has a limit to start from and a ceiling above it
+func validateAutoscalePolicy(policy *api.AutoscalePolicy, limits cgroups.ResourceLimits) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MemoryStep < 0 || policy.CpuStep < 0 {
+		return fmt.Errorf("autoscale steps cannot be negative")
+	}
+	if policy.MemoryStep == 0 && policy.CpuStep == 0 {
+		return fmt.Errorf("autoscale policy needs a memory or cpu step")
+	}
+	if policy.Cooldown < 0 {
+		return fmt.Errorf("autoscale cooldown cannot be negative")
+	}
+
+	if policy.MemoryStep > 0 {
+		if limits.MemoryLimit == 0 {
+			return fmt.Errorf("memory autoscaling needs a memory limit")
+		}
+		if policy.MemoryCeiling <= limits.MemoryLimit {
+			return fmt.Errorf("autoscale memory ceiling %d must be above the memory limit %d", policy.MemoryCeiling, limits.MemoryLimit)
+		}
+	}
+	if policy.CpuStep > 0 {
+		if limits.CpuQuota <= 0 {
+			return fmt.Errorf("cpu autoscaling needs a cpu quota")
+		}
+		if policy.CpuCeiling <= limits.CpuQuota {
+			return fmt.Errorf("autoscale cpu ceiling %d must be above the cpu quota %d", policy.CpuCeiling, limits.CpuQuota)
+		}
+	}
+	return nil
+}
+
+// autoscaleHighRatio is where memory.high sits relative to the memory limit under a memory autoscale policy
+// Throttling above it slows a growing container down enough for the daemon to raise the limit before it OOMs
+const autoscaleHighRatio = 0.9
+
+// autoscaleCeilingLimits returns limits with every resource the policy scales at its ceiling
+func autoscaleCeilingLimits(policy *api.AutoscalePolicy, limits cgroups.ResourceLimits) cgroups.ResourceLimits {
+	if policy.MemoryStep > 0 {
+		limits.MemoryLimit = policy.MemoryCeiling
+	}
+	if policy.CpuStep > 0 {
+		limits.CpuQuota = policy.CpuCeiling
+	}
+	return limits
+}
+
+// monitorAutoscale raises a container's limits whenever its cgroup reports running into them
+// Memory is raised on new memory.high or memory.max events (failcnt on cgroups v1), the CPU quota on new throttled periods
+func (d *Daemon) monitorAutoscale(id string, runner *container.Runner, policy api.AutoscalePolicy) {
+	if runner.Cgroup == nil {
+		return
+	}
+	last, err := runner.Cgroup.Stats()
+	if err != nil {
+		fmt.Printf("Warning: autoscale policy for container %s is ignored: %v\n", id, err)
+		return
+	}
+
+	cooldown := policy.Cooldown
+	if cooldown == 0 {
+		cooldown = defaultAutoscaleCooldown
+	}
+	var lastMemory, lastCpu time.Time
+
+	ticker := time.NewTicker(autoscaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-runner.Exited():
+			return
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		stats, err := runner.Cgroup.Stats()
+		if err != nil {
+			// The cgroup goes away when the container exits
+			d.debugf("Autoscale monitor for container %s stopped: %v\n", id, err)
+			return
+		}
+
+		memoryEvents := (stats.MemoryHighEvents - last.MemoryHighEvents) + (stats.MemoryMaxEvents - last.MemoryMaxEvents)
+		if policy.MemoryStep > 0 && memoryEvents > 0 && time.Since(lastMemory) >= cooldown {
+			reason := fmt.Sprintf("%d memory limit event(s)", memoryEvents)
+			if d.raiseLimit(id, runner, policy, "memory", reason) {
+				lastMemory = time.Now()
+			}
+		}
+
+		throttled := stats.CpuThrottled - last.CpuThrottled
+		if policy.CpuStep > 0 && throttled > 0 && time.Since(lastCpu) >= cooldown {
+			reason := fmt.Sprintf("throttled in %d period(s)", throttled)
+			if d.raiseLimit(id, runner, policy, "cpu", reason) {
+				lastCpu = time.Now()
+			}
+		}
+
+		last = stats
+	}
+}
+
+// raiseLimit grows one resource limit of a running container by the policy's step, capped at its ceiling,
+// and records the change; it returns false if nothing was changed
+func (d *Daemon) raiseLimit(id string, runner *container.Runner, policy api.AutoscalePolicy, resource, reason string) bool {
+	containerState, err := d.getContainer(id)
+	if err != nil {
+		return false
+	}
+
+	limits := containerState.Limits
+	var from, to int64
+	if resource == "memory" {
+		from = int64(limits.MemoryLimit)
+		to = min(from+int64(float64(from)*policy.MemoryStep/100), int64(policy.MemoryCeiling))
+		limits.MemoryLimit = uint64(to)
+		if limits.MemoryHigh > 0 {
+			limits.MemoryHigh = uint64(float64(to) * autoscaleHighRatio)
+		}
+		// Keep the swap allowance the same size
+		if limits.MemorySwapLimit > 0 {
+			limits.MemorySwapLimit += to - from
+		}
+	} else {
+		from = limits.CpuQuota
+		to = min(from+int64(float64(from)*policy.CpuStep/100), policy.CpuCeiling)
+		limits.CpuQuota = to
+	}
+	if to <= from {
+		d.debugf("Container %s: %s limit is at its autoscale ceiling\n", id, resource)
+		return false
+	}
+
+	// A raised memory limit counts against the owner's quota like any other
+	if d.currentConfig().UserQuotas != nil {
+		d.quotaMu.Lock()
+		defer d.quotaMu.Unlock()
+	}
+	if err := d.checkQuota(containerState.Owner, id, limits, containerState.Rootfs, containerState.Mounts, containerState.Secrets); err != nil {
+		fmt.Printf("Container %s: not autoscaling %s: %v\n", id, resource, err)
+		return false
+	}
+
+	if err := runner.Cgroup.SetResourceLimits(limits); err != nil {
+		fmt.Printf("Warning: failed to autoscale %s of container %s: %v\n", resource, id, err)
+		return false
+	}
+
+	adjustment := api.AutoscaleAdjustment{Resource: resource, From: from, To: to, Reason: reason, Time: time.Now()}
+	containerState.Limits = limits
+	containerState.AutoscaleAdjustments = append(containerState.AutoscaleAdjustments, adjustment)
+	if n := len(containerState.AutoscaleAdjustments); n > maxAutoscaleAdjustments {
+		containerState.AutoscaleAdjustments = containerState.AutoscaleAdjustments[n-maxAutoscaleAdjustments:]
+	}
+	if err := d.updateContainer(containerState); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	fmt.Printf("Container %s: autoscaled %s limit from %d to %d (%s)\n", id, resource, from, to, reason)
+	d.logEvent("autoscale", id, map[string]string{
+		"resource": resource,
+		"from":     strconv.FormatInt(from, 10),
+		"to":       strconv.FormatInt(to, 10),
+		"reason":   reason,
+	})
+	return true
+}
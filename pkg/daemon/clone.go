@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/selinux"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// managedLabels tie a container to the service or schedule that runs it; clones are left out of both
+var managedLabels = []string{api.ServiceLabel, api.ServiceVersionLabel, api.ScheduleLabel}
+
+// handleContainerClone creates a stopped copy of a container's configuration
+func (d *Daemon) handleContainerClone(w http.ResponseWriter, r *http.Request) {
+	var req api.ContainerCloneRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+			return
+		}
+	}
+
+	id, err := d.CloneContainer(r.PathValue("id"), req, requestPeer(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.ContainerCreateResponse{ID: id})
+}
+
+// CloneContainer creates a container with the configuration of ref on behalf of caller, who becomes its owner
+// The clone is left in the created state; it shares the rootfs directory and takes the limits currently in
+// effect, including any an autoscale policy raised. Labels in req are added to the copied ones
+func (d *Daemon) CloneContainer(ref string, req api.ContainerCloneRequest, caller peer) (string, error) {
+	sourceID, err := d.resolveID(ref)
+	if err != nil {
+		return "", err
+	}
+	source, err := d.getContainer(sourceID)
+	if err != nil {
+		return "", err
+	}
+
+	// Copy the configuration through JSON so the clone shares no maps or slices with the source
+	d.mu.RLock()
+	adopted := source.Adopted
+	config, configErr := json.Marshal(source.ContainerConfig)
+	hostConfig, hostConfigErr := json.Marshal(source.HostConfig)
+	d.mu.RUnlock()
+	if adopted {
+		return "", errInvalidRequest(fmt.Errorf("container %s was adopted and has no configuration to start a clone from", sourceID))
+	}
+	if configErr != nil || hostConfigErr != nil {
+		return "", fmt.Errorf("failed to copy configuration of container %s", sourceID)
+	}
+
+	id, err := d.generateContainerID()
+	if err != nil {
+		return "", err
+	}
+	clone := &state.ContainerState{
+		SchemaVersion: state.SchemaVersion,
+		ID:            id,
+		Created:       time.Now(),
+	}
+	if err := json.Unmarshal(config, &clone.ContainerConfig); err != nil {
+		return "", fmt.Errorf("failed to copy configuration of container %s: %v", sourceID, err)
+	}
+	if err := json.Unmarshal(hostConfig, &clone.HostConfig); err != nil {
+		return "", fmt.Errorf("failed to copy configuration of container %s: %v", sourceID, err)
+	}
+
+	if len(req.Labels) > 0 {
+		if clone.Labels == nil {
+			clone.Labels = make(map[string]string, len(req.Labels))
+		}
+		maps.Copy(clone.Labels, req.Labels)
+	}
+	for _, label := range managedLabels {
+		delete(clone.Labels, label)
+	}
+	if err := d.resolveDeps(clone.Labels); err != nil {
+		return "", errInvalidRequest(err)
+	}
+
+	clone.Owner = nil
+	if caller.known {
+		clone.Owner = &caller.uid
+	}
+	if err := d.checkQuota(clone.Owner, "", clone.Limits, clone.Rootfs, clone.Mounts, clone.Secrets); err != nil {
+		return "", err
+	}
+
+	// The clone gets an MCS level of its own, so the two containers stay apart under SELinux
+	if clone.ProcessLabel != "" {
+		level, err := selinux.NewLevel(d.selinuxLevelsInUse())
+		if err != nil {
+			return "", err
+		}
+		clone.ProcessLabel, clone.MountLabel = selinux.ProcessLabel(level), selinux.FileLabel(level)
+	}
+
+	actor := caller.String()
+	d.setStatus(clone, "created", transitionCause{actor, "clone of " + sourceID})
+	if err := d.addContainer(clone); err != nil {
+		return "", fmt.Errorf("failed to add container: %v", err)
+	}
+
+	fmt.Printf("Created container %s as a clone of %s (status: created)\n", id, sourceID)
+	d.logEvent("create", id, map[string]string{"cloned_from": sourceID})
+	return id, nil
+}
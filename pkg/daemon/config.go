@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/config"
+	"github.com/AbhishekGY/mydocker/pkg/version"
+)
+
+// LoadConfig loads daemon.json from path and remembers the path for later reloads
+// Must be called before Start
+func (d *Daemon) LoadConfig(path string) error {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	d.configMu.Lock()
+	defer d.configMu.Unlock()
+
+	d.configPath = path
+	d.config = cfg
+
+	d.applyProtection(config.DaemonProtection{}, cfg.Protection)
+	d.applyTracing(config.TracingPolicy{}, cfg.Tracing)
+	return nil
+}
+
+// Reload re-reads daemon.json and applies it without touching running containers
+// Returns the names of the settings that changed
+func (d *Daemon) Reload() ([]string, error) {
+	d.configMu.RLock()
+	path := d.configPath
+	d.configMu.RUnlock()
+
+	if path == "" {
+		return nil, fmt.Errorf("daemon was started without a config file")
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		// Keep running with the previous configuration
+		return nil, err
+	}
+
+	d.configMu.Lock()
+	old := d.config
+	d.config = cfg
+	d.configMu.Unlock()
+
+	changed := []string{}
+	if old.LogLevel != cfg.LogLevel {
+		changed = append(changed, "log-level")
+	}
+	if !reflect.DeepEqual(old.RegistryMirrors, cfg.RegistryMirrors) {
+		changed = append(changed, "registry-mirrors")
+	}
+	if !reflect.DeepEqual(old.InsecureRegistries, cfg.InsecureRegistries) {
+		changed = append(changed, "insecure-registries")
+	}
+	if old.DefaultLimits != cfg.DefaultLimits {
+		changed = append(changed, "default-limits")
+	}
+	if !reflect.DeepEqual(old.ResourceProfiles, cfg.ResourceProfiles) {
+		changed = append(changed, "resource-profiles")
+	}
+	if !reflect.DeepEqual(old.Presets, cfg.Presets) {
+		changed = append(changed, "presets")
+	}
+	if old.GC != cfg.GC {
+		changed = append(changed, "gc")
+	}
+	if old.StatsHistory != cfg.StatsHistory {
+		changed = append(changed, "stats-history")
+	}
+	if !reflect.DeepEqual(old.RootfsRoots, cfg.RootfsRoots) {
+		changed = append(changed, "rootfs-roots")
+	}
+	if !reflect.DeepEqual(old.AllowedMountTypes, cfg.AllowedMountTypes) {
+		changed = append(changed, "allowed-mount-types")
+	}
+	if !reflect.DeepEqual(old.BindMountRoots, cfg.BindMountRoots) {
+		changed = append(changed, "bind-mount-roots")
+	}
+	if !reflect.DeepEqual(old.UserQuotas, cfg.UserQuotas) {
+		changed = append(changed, "user-quotas")
+	}
+	if old.Admission != cfg.Admission {
+		changed = append(changed, "admission")
+	}
+	if old.Protection != cfg.Protection {
+		changed = append(changed, "daemon-protection")
+		d.applyProtection(old.Protection, cfg.Protection)
+	}
+	if old.RecordSessions != cfg.RecordSessions {
+		changed = append(changed, "record-sessions")
+	}
+	if old.Tracing != cfg.Tracing {
+		changed = append(changed, "tracing")
+		d.applyTracing(old.Tracing, cfg.Tracing)
+	}
+
+	fmt.Printf("Reloaded configuration from %s (changed: %v)\n", path, changed)
+	return changed, nil
+}
+
+// currentConfig returns the active configuration (thread-safe)
+// The returned value must not be modified
+func (d *Daemon) currentConfig() *config.DaemonConfig {
+	d.configMu.RLock()
+	defer d.configMu.RUnlock()
+
+	return d.config
+}
+
+// debugf prints a message only when the log level is "debug"
+func (d *Daemon) debugf(format string, args ...interface{}) {
+	if d.currentConfig().LogLevel == config.LogLevelDebug {
+		fmt.Printf(format, args...)
+	}
+}
+
+// applyDefaultLimits fills in limits the create request left unset from the named resource profile, if any,
+// and then from the configured defaults
+func (d *Daemon) applyDefaultLimits(limits *cgroups.ResourceLimits, profile string) error {
+	cfg := d.currentConfig()
+	if profile != "" {
+		profileLimits, ok := cfg.ResourceProfiles[profile]
+		if !ok {
+			return errInvalidRequest(fmt.Errorf("unknown resource profile %q", profile))
+		}
+		fillLimits(limits, profileLimits)
+	}
+	fillLimits(limits, cfg.DefaultLimits)
+	return nil
+}
+
+// fillLimits copies the limits that are still unset from defaults
+func fillLimits(limits *cgroups.ResourceLimits, defaults config.DefaultLimits) {
+	// The default swap only goes with the default memory limit, it could conflict with an explicit one
+	if limits.MemoryLimit == 0 {
+		limits.MemoryLimit = defaults.Memory
+		if limits.MemorySwapLimit == 0 {
+			limits.MemorySwapLimit = defaults.MemorySwap
+		}
+	}
+	if limits.CpuShares == 0 {
+		limits.CpuShares = defaults.CpuShares
+	}
+	if limits.PidsLimit == 0 {
+		limits.PidsLimit = defaults.PidsLimit
+	}
+}
+
+// logRequests wraps a handler and logs every API request at debug level
+// It also advertises the daemon's API version on every response
+func (d *Daemon) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.debugf("API request: %s %s\n", r.Method, r.URL.RequestURI())
+		w.Header().Set(apiVersionHeader, version.APIVersion)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,19 +1,61 @@
 package daemon
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/AbhishekGY/mydocker/pkg/api"
 	"github.com/AbhishekGY/mydocker/pkg/cgroups"
 	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/logger"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+	"github.com/AbhishekGY/mydocker/pkg/platform"
 	"github.com/AbhishekGY/mydocker/pkg/state"
+	"github.com/AbhishekGY/mydocker/pkg/tracing"
 )
 
-// CreateContainer creates and starts a new container
-func (d *Daemon) CreateContainer(req api.ContainerCreateRequest) (string, *container.Runner, error) {
+// defaultStopTimeout is how long a stopped container has to exit after SIGTERM before it is killed
+const defaultStopTimeout = 5 * time.Second
+
+// CreateContainer creates and starts a new container on behalf of caller, who becomes its owner
+// The runner is nil when a detached start was queued for admission; ctx carries the trace the create is recorded in
+func (d *Daemon) CreateContainer(ctx context.Context, req api.ContainerCreateRequest, caller peer) (string, *container.Runner, error) {
+	ctx, span := tracing.Start(ctx, "container.create", tracing.String("container.rootfs", req.Rootfs))
+	defer span.End()
+
+	id, runner, err := d.createContainer(ctx, req, caller)
+	if id != "" {
+		span.SetAttributes(tracing.String("container.id", id))
+	}
+	span.RecordError(err)
+	return id, runner, err
+}
+
+// createContainer does the work of CreateContainer, with the spans of its stages as children of ctx's
+func (d *Daemon) createContainer(ctx context.Context, req api.ContainerCreateRequest, caller peer) (string, *container.Runner, error) {
+	actor := caller.String()
+	var owner *uint32
+	if caller.known {
+		owner = &caller.uid
+	}
+
+	if err := d.expandPreset(&req); err != nil {
+		return "", nil, err
+	}
+	if err := d.validateCreateRequest(&req); err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+
 	// Generate a unique container ID
-	id := d.generateContainerID()
+	id, err := d.generateContainerID()
+	if err != nil {
+		return "", nil, err
+	}
 
 	// Create resource limits from request
 	limits := cgroups.ResourceLimits{
@@ -22,47 +64,323 @@ func (d *Daemon) CreateContainer(req api.ContainerCreateRequest) (string, *conta
 		CpuShares:       req.CpuShares,
 		CpuQuota:        req.CpuQuota,
 		CpuPeriod:       req.CpuPeriod,
+		CpuBurst:        req.CpuBurst,
 		PidsLimit:       req.PidsLimit,
+		HugetlbLimits:   req.HugetlbLimits,
+	}
+	if err := d.applyDefaultLimits(&limits, req.Profile); err != nil {
+		return "", nil, err
+	}
+	if err := limits.Validate(); err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+	limits.ResolveMemorySwap()
+	if err := d.cgroupVersion.CheckHost(limits); err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+	if err := cgroups.ValidateHugetlbLimits(limits.HugetlbLimits); err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+
+	// Validate environment variables
+	for _, kv := range req.Env {
+		if !strings.Contains(kv, "=") || strings.HasPrefix(kv, "=") {
+			return "", nil, errInvalidRequest(fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", kv))
+		}
+		if key, _, _ := strings.Cut(kv, "="); namespace.ReservedEnv(key) {
+			return "", nil, errInvalidRequest(fmt.Errorf("environment variable %s is reserved for container-init", key))
+		}
+	}
+
+	// Validate secrets, defaulting the target to the source file name
+	secrets := make([]namespace.Secret, 0, len(req.Secrets))
+	targets := make(map[string]bool)
+	for _, s := range req.Secrets {
+		secret := namespace.Secret{Source: s.Source, Target: s.Target}
+		if secret.Target == "" {
+			secret.Target = filepath.Base(secret.Source)
+		}
+		if err := namespace.ValidateSecret(secret); err != nil {
+			return "", nil, errInvalidRequest(err)
+		}
+		if secret.Source, err = d.validateSecretSource(secret.Source); err != nil {
+			return "", nil, errInvalidRequest(err)
+		}
+		if targets[secret.Target] {
+			return "", nil, errInvalidRequest(fmt.Errorf("duplicate secret target: %s", secret.Target))
+		}
+		targets[secret.Target] = true
+		secrets = append(secrets, secret)
+	}
+
+	mounts, relabels, err := d.validateMounts(req.Mounts)
+	if err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+	masked, err := maskedPaths(req.MaskedPaths)
+	if err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+
+	if err := validatePressureThresholds(req.PressureThresholds); err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+
+	// A PTY has no stdin of its own to close; its reader gets EOF from ^D instead
+	if req.StdinOnce && !req.NoTTY {
+		return "", nil, errInvalidRequest(fmt.Errorf("stdin-once needs a container without a TTY"))
+	}
+
+	if err := validateAutoscalePolicy(req.Autoscale, limits); err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+	if req.Autoscale != nil {
+		// The ceilings have to fit the host just like the limits they grow from
+		if err := d.cgroupVersion.CheckHost(autoscaleCeilingLimits(req.Autoscale, limits)); err != nil {
+			return "", nil, errInvalidRequest(fmt.Errorf("autoscale ceiling: %v", err))
+		}
+		// memory.high events are what tell the policy the container is outgrowing its limit
+		if req.Autoscale.MemoryStep > 0 && d.cgroupVersion == cgroups.V2 {
+			limits.MemoryHigh = uint64(float64(limits.MemoryLimit) * autoscaleHighRatio)
+		}
+	}
+
+	if err := validateDeviceHotplug(req.DeviceHotplug); err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+
+	if req.LogConfig != nil {
+		if err := logger.ValidateOptions(req.LogConfig.Driver, req.LogConfig.Options); err != nil {
+			return "", nil, errInvalidRequest(err)
+		}
+	}
+
+	// Quotas are checked again when the container starts; this just turns away requests that can't run,
+	// before anything below touches the host on the caller's behalf
+	if err := d.checkQuota(owner, "", limits, req.Rootfs, mounts, secrets); err != nil {
+		return "", nil, err
+	}
+
+	// Dependencies must already exist; ID prefixes are expanded so the label stays valid as containers come and go
+	if err := d.resolveDeps(req.Labels); err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+
+	// The container whose namespaces are joined must exist now and be running whenever this one starts
+	var namespacesFrom string
+	if req.NamespacesFrom != "" {
+		if namespacesFrom, err = d.resolveID(req.NamespacesFrom); err != nil {
+			return "", nil, errInvalidRequest(fmt.Errorf("namespaces-from: %v", err))
+		}
+	}
+
+	// Foreign-architecture rootfs directories run under qemu user emulation
+	var containerPlatform string
+	if req.Platform != "" {
+		p, err := platform.Parse(req.Platform)
+		if err != nil {
+			return "", nil, errInvalidRequest(err)
+		}
+		if err := platform.EnsureEmulation(p); err != nil {
+			return "", nil, errInvalidRequest(err)
+		}
+		containerPlatform = p.String()
+	}
+
+	var timeOffsets *namespace.TimeOffsets
+	if req.TimeOffsets != nil {
+		if !namespace.TimeNamespaceSupported() {
+			return "", nil, errInvalidRequest(fmt.Errorf("time namespaces are not supported by the kernel"))
+		}
+		timeOffsets = &namespace.TimeOffsets{Monotonic: req.TimeOffsets.Monotonic, Boottime: req.TimeOffsets.Boottime}
+	}
+
+	// Resolve security options to the profile and labels the container runs with
+	securityOpts, err := parseSecurityOpts(req.SecurityOpt)
+	if err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+	apparmorProfile, err := d.resolveAppArmorProfile(securityOpts.apparmorProfile)
+	if err != nil {
+		return "", nil, errInvalidRequest(err)
+	}
+	processLabel, mountLabel, err := d.selinuxLabels(securityOpts, req.Rootfs)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := relabelBindSources(relabels, mountLabel); err != nil {
+		return "", nil, err
 	}
 
 	// Create container state
 	containerState := &state.ContainerState{
-		ID:      id,
-		PID:     0, // Not started yet
-		Status:  "created",
-		Command: req.Command,
-		Rootfs:  req.Rootfs,
-		Created: time.Now(),
-		Limits:  limits,
+		SchemaVersion: state.SchemaVersion,
+		ID:            id,
+		PID:           0, // Not started yet
+		Created:       time.Now(),
+		ContainerConfig: state.ContainerConfig{
+			Command:  req.Command,
+			Env:      req.Env,
+			Labels:   req.Labels,
+			Platform: containerPlatform,
+
+			NoTTY:     req.NoTTY,
+			StdinOnce: req.StdinOnce,
+
+			AttachStdin:  req.AttachStdin,
+			AttachStdout: req.AttachStdout,
+			AttachStderr: req.AttachStderr,
+		},
+		HostConfig: state.HostConfig{
+			Rootfs:      req.Rootfs,
+			Limits:      limits,
+			Secrets:     secrets,
+			Mounts:      mounts,
+			MaskedPaths: masked,
+			Profile:     req.Profile,
+			Owner:       owner,
+
+			PressureThresholds: req.PressureThresholds,
+			AppArmorProfile:    apparmorProfile,
+			ProcessLabel:       processLabel,
+			MountLabel:         mountLabel,
+			TimeOffsets:        timeOffsets,
+
+			WaitFor: req.WaitFor,
+
+			NamespacesFrom: namespacesFrom,
+			AutoRemove:     req.AutoRemove,
+
+			ResourceViews: req.ResourceViews,
+			Autoscale:     req.Autoscale,
+
+			LogConfig: req.LogConfig,
+
+			DeviceHotplug: req.DeviceHotplug,
+		},
 	}
 
 	// Add container to daemon state
-	if err := d.addContainer(containerState); err != nil {
+	d.setStatus(containerState, "created", transitionCause{actor, "create"})
+	err = d.addContainer(containerState)
+	if err != nil {
 		return "", nil, fmt.Errorf("failed to add container: %v", err)
 	}
 
 	fmt.Printf("Created container %s (status: created)\n", id)
+	d.logEvent("create", id, nil)
+
+	// Bring up dependencies before the container itself
+	if !req.NoDeps {
+		err = traceStage(ctx, "container.dependencies", func() error {
+			_, err := d.startDeps(ctx, id, actor)
+			return err
+		})
+	}
 
-	// Start the container immediately
-	runner, err := d.StartContainerWithRunner(id, req.Detach)
+	// Start the container now, or once the host has room for it
+	var runner *container.Runner
+	if err == nil {
+		runner, err = d.admitAndStart(ctx, id, req.Detach, transitionCause{actor, "start after create"})
+	}
 	if err != nil {
 		// If start fails, update state to reflect failure
-		containerState.Status = "exited"
-		d.updateContainer(containerState)
+		d.setStatus(containerState, "exited", transitionCause{daemonActor, fmt.Sprintf("start failed: %v", err)})
+		containerState.Exited = time.Now()
+		if req.AutoRemove {
+			d.removeContainer(id)
+		} else {
+			d.updateContainer(containerState)
+		}
 		return "", nil, fmt.Errorf("failed to start container: %v", err)
 	}
 
 	return id, runner, nil
 }
 
-// StartContainer starts a created container (with detach=true by default for backward compatibility)
-func (d *Daemon) StartContainer(id string) error {
-	_, err := d.StartContainerWithRunner(id, true)
-	return err
+// StartContainer starts a created or exited container on behalf of actor, with a PTY if attach is set
+// Unless noDeps is set, the containers it depends on are started first
+// Returns the IDs that were started, in start order, and the container's runner (nil if its start was queued)
+func (d *Daemon) StartContainer(ctx context.Context, ref string, noDeps, attach bool, actor string) ([]string, *container.Runner, error) {
+	id, err := d.resolveID(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var started []string
+	if !noDeps {
+		err = traceStage(ctx, "container.dependencies", func() (err error) {
+			started, err = d.startDeps(ctx, id, actor)
+			return err
+		})
+		if err != nil {
+			return started, nil, err
+		}
+	}
+
+	// Dependencies always run detached and skip the admission queue; only the requested container gets a PTY
+	runner, err := d.admitAndStart(ctx, id, !attach, transitionCause{actor, "start"})
+	if err != nil {
+		return started, nil, err
+	}
+	return append(started, id), runner, nil
+}
+
+// startDeps starts the transitive dependencies of id that aren't running, dependencies first
+func (d *Daemon) startDeps(ctx context.Context, id, actor string) ([]string, error) {
+	order, err := d.startOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var started []string
+	for _, dep := range order[:len(order)-1] {
+		depState, err := d.getContainer(dep)
+		if err != nil {
+			return started, fmt.Errorf("dependency %s of %s: %v", dep, id, err)
+		}
+		if isInitContainer(depState) {
+			ran, err := d.runInitContainer(ctx, dep, transitionCause{actor, "init container of " + id})
+			if err != nil {
+				return started, err
+			}
+			if ran {
+				started = append(started, dep)
+			}
+			continue
+		}
+		if depState.Status == "running" {
+			continue
+		}
+
+		fmt.Printf("Starting dependency %s of container %s\n", dep, id)
+		if _, err := d.StartContainerWithRunner(ctx, dep, true, transitionCause{actor, "dependency of " + id}); err != nil {
+			return started, fmt.Errorf("failed to start dependency %s: %v", dep, err)
+		}
+		started = append(started, dep)
+	}
+	return started, nil
 }
 
 // StartContainerWithRunner starts a created container and returns the runner
-func (d *Daemon) StartContainerWithRunner(id string, detach bool) (*container.Runner, error) {
+// The start and its stages are recorded as spans under the one in ctx
+func (d *Daemon) StartContainerWithRunner(ctx context.Context, id string, detach bool, cause transitionCause) (*container.Runner, error) {
+	ctx, span := tracing.Start(ctx, "container.start", tracing.String("container.id", id), tracing.String("reason", cause.reason))
+	defer span.End()
+
+	runner, err := d.startContainerWithRunner(ctx, id, detach, cause)
+	if runner != nil {
+		span.SetAttributes(tracing.Int("container.pid", runner.PID()))
+	}
+	span.RecordError(err)
+	return runner, err
+}
+
+// startContainerWithRunner does the work of StartContainerWithRunner, with the spans of its stages as children of ctx's
+func (d *Daemon) startContainerWithRunner(ctx context.Context, id string, detach bool, cause transitionCause) (*container.Runner, error) {
+	timings := startTimings{}
+	begin := time.Now()
+
 	// Get container state
 	containerState, err := d.getContainer(id)
 	if err != nil {
@@ -71,29 +389,159 @@ func (d *Daemon) StartContainerWithRunner(id string, detach bool) (*container.Ru
 
 	// Check if container is already running
 	if containerState.Status == "running" {
-		return nil, fmt.Errorf("container is already running")
+		return nil, errConflict(api.ErrCodeContainerRunning, "container is already running: %s", id)
+	}
+	if containerState.Adopted {
+		return nil, errInvalidRequest(fmt.Errorf("container %s was adopted from a process started outside the daemon and can't be started again", id))
+	}
+
+	// The rootfs and bind mount sources were canonicalized at create time, so a symlink swapped in since then shows up as a different path
+	var rootfs string
+	err = timings.run(ctx, "prepare_rootfs", func() (err error) {
+		if rootfs, err = d.validateRootfs(containerState.Rootfs); err != nil {
+			return fmt.Errorf("invalid rootfs: %v", err)
+		}
+		if rootfs != containerState.Rootfs {
+			return fmt.Errorf("rootfs %s now resolves to %s", containerState.Rootfs, rootfs)
+		}
+		for _, m := range containerState.Mounts {
+			if m.Type != "bind" {
+				continue
+			}
+			source, err := d.validateBindSource(m.Source)
+			if err != nil {
+				return err
+			}
+			if source != m.Source {
+				return fmt.Errorf("bind mount source %s now resolves to %s", m.Source, source)
+			}
+		}
+
+		// The binfmt_misc handler may have gone away since the container was created, e.g. after a reboot
+		if containerState.Platform != "" {
+			p, err := platform.Parse(containerState.Platform)
+			if err != nil {
+				return err
+			}
+			return platform.EnsureEmulation(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The check and the start it allows happen under quotaMu so concurrent starts can't both fit under a quota
+	if d.currentConfig().UserQuotas != nil {
+		d.quotaMu.Lock()
+		defer d.quotaMu.Unlock()
+	}
+	if err := d.checkQuota(containerState.Owner, id, containerState.Limits, rootfs, containerState.Mounts, containerState.Secrets); err != nil {
+		return nil, err
+	}
+
+	// Joined namespaces belong to the process running in the other container right now
+	namespacesOf := 0
+	if containerState.NamespacesFrom != "" {
+		target, err := d.getContainer(containerState.NamespacesFrom)
+		if err != nil {
+			return nil, fmt.Errorf("container whose namespaces it joins: %v", err)
+		}
+		if target.Status != "running" || target.PID == 0 {
+			return nil, errConflict(api.ErrCodeContainerNotRunning, "container %s whose namespaces it joins is not running", target.ID)
+		}
+		namespacesOf = target.PID
 	}
 
 	// Create the runner
-	runner, err := container.NewRunner(id, containerState.Command, containerState.Rootfs, containerState.Limits, detach)
+	cg := cgroups.NewManager(d.cgroupVersion, id, d.cgroupVersion.Controllers(containerState.Limits))
+	var runner *container.Runner
+	err = timings.run(ctx, "create_cgroup", func() (err error) {
+		runner, err = container.NewRunner(id, containerState.Command, containerState.Rootfs, containerState.Env, containerState.Secrets, cg, containerState.Limits, detach)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create runner: %v", err)
 	}
+	runner.AppArmorProfile = containerState.AppArmorProfile
+	runner.ProcessLabel = containerState.ProcessLabel
+	runner.MountLabel = containerState.MountLabel
+	runner.TimeOffsets = containerState.TimeOffsets
+	runner.Mounts = containerState.Mounts
+	runner.MaskedPaths = containerState.MaskedPaths
+	runner.NamespacesOf = namespacesOf
+	runner.ShareUTS = namespacesOf != 0 && containerState.Labels[api.PodLabel] != ""
+	runner.NoTTY = containerState.NoTTY
+	runner.OpenStdin = defaultAttachStreams(containerState).stdin
+
+	// The views are generated before the process starts so they are in place when its runtime sizes itself
+	if containerState.ResourceViews {
+		var views []namespace.Mount
+		err := timings.run(ctx, "resource_views", func() (err error) {
+			views, err = d.setupResourceViews(id, runner, containerState.Limits)
+			return err
+		})
+		if err != nil {
+			runner.Cleanup()
+			return nil, err
+		}
+		runner.Mounts = append(append([]namespace.Mount(nil), runner.Mounts...), views...)
+	}
+
+	// All output passes through the log subsystem: the container's local store, its log driver if it has one,
+	// and the clients following it. It is set up before the process execs, so no output is missed
+	var local *logger.Local
+	var logDriver, logSink logger.Driver
+	err = timings.run(ctx, "open_log_driver", func() (err error) {
+		if local, err = logger.OpenLocal(d.logPath(id)); err != nil {
+			return err
+		}
+		logSink = local
+		if containerState.LogConfig != nil {
+			if logDriver, err = openLogDriver(containerState); err != nil {
+				local.Close()
+				return err
+			}
+			logSink = logger.Tee(local, logDriver)
+		}
+		return nil
+	})
+	if err != nil {
+		runner.Cleanup()
+		d.removeResourceViews(id)
+		return nil, err
+	}
+	output := newOutputBroadcaster(id, runner, local, logSink)
 
 	// Start the container process
-	if err := runner.Start(); err != nil {
+	if err := timings.run(ctx, "start_process", runner.Start); err != nil {
 		// Clean up cgroup on failure
 		runner.Cleanup()
+		d.removeResourceViews(id)
+		closeLogDriver(id, logSink)
 		return nil, fmt.Errorf("failed to start container process: %v", err)
 	}
+	if logDriver != nil {
+		d.addLogDriver(id, logDriver)
+	}
+	// Detached output is read from the start; attached output once a client follows it, and at the latest
+	// when monitorContainer drains it
+	d.addOutput(output)
+	if detach {
+		output.start()
+	}
 
 	// Update container state
 	containerState.PID = runner.PID()
-	containerState.Status = "running"
-	if err := d.updateContainer(containerState); err != nil {
+	containerState.Started = time.Now()
+	containerState.ExitCode = nil
+	d.setStatus(containerState, "running", cause)
+	if err := timings.run(ctx, "save_state", func() error { return d.updateContainer(containerState) }); err != nil {
 		// If we can't save state, kill the container
 		runner.Kill()
+		d.drainOutput(id)
 		runner.Cleanup()
+		d.removeResourceViews(id)
 		return nil, fmt.Errorf("failed to update container state: %v", err)
 	}
 
@@ -101,9 +549,27 @@ func (d *Daemon) StartContainerWithRunner(id string, detach bool) (*container.Ru
 	d.addRunner(id, runner)
 
 	fmt.Printf("Started container %s with PID %d\n", id, runner.PID())
+	d.logEvent("start", id, timings.attributes(time.Since(begin)))
+
+	// Give tools outside the daemon a stable path to the container's network namespace
+	if err := traceStage(ctx, "container.bind_netns", func() error { return namespace.BindNetns(id, runner.PID()) }); err != nil {
+		fmt.Printf("Warning: failed to expose network namespace of container %s: %v\n", id, err)
+	}
 
 	// Launch goroutine to monitor container
 	go d.monitorContainer(id, runner)
+	if len(containerState.PressureThresholds) > 0 {
+		go d.monitorPressure(id, runner, containerState.PressureThresholds)
+	}
+	if containerState.ResourceViews {
+		go d.refreshResourceViews(id, runner, containerState.Limits)
+	}
+	if containerState.Autoscale != nil {
+		go d.monitorAutoscale(id, runner, *containerState.Autoscale)
+	}
+	if len(containerState.DeviceHotplug) > 0 {
+		go d.monitorDeviceHotplug(id, runner, containerState.DeviceHotplug)
+	}
 
 	return runner, nil
 }
@@ -112,6 +578,8 @@ func (d *Daemon) StartContainerWithRunner(id string, detach bool) (*container.Ru
 func (d *Daemon) monitorContainer(id string, runner *container.Runner) {
 	// Wait for container to exit (blocks until exit)
 	err := runner.Wait()
+	code := container.ExitCode(err)
+	d.logEvent("die", id, map[string]string{"exitCode": strconv.Itoa(code)})
 
 	fmt.Printf("Container %s exited", id)
 	if err != nil {
@@ -127,13 +595,26 @@ func (d *Daemon) monitorContainer(id string, runner *container.Runner) {
 		return
 	}
 
-	// Update state to exited
-	containerState.Status = "exited"
+	// Update state to exited, crediting whoever asked for the stop
+	cause := transitionCause{daemonActor, fmt.Sprintf("process exited with code %d", code)}
+	if stop, ok := d.takeStopCause(id); ok {
+		cause = stop
+	}
+	d.setStatus(containerState, "exited", cause)
+	containerState.Exited = time.Now()
+	containerState.ExitCode = &code
 	containerState.PID = 0
 	if err := d.updateContainer(containerState); err != nil {
 		fmt.Printf("Error updating container state for %s: %v\n", id, err)
 	}
 
+	if err := namespace.ReleaseNetns(id); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	d.removeResourceViews(id)
+	d.drainOutput(id)
+	d.removeLogDriver(id)
+
 	// Cleanup cgroup
 	if err := runner.Cleanup(); err != nil {
 		fmt.Printf("Error cleaning up container %s: %v\n", id, err)
@@ -141,44 +622,203 @@ func (d *Daemon) monitorContainer(id string, runner *container.Runner) {
 
 	// Remove runner from daemon
 	d.removeRunner(id)
+
+	if containerState.AutoRemove {
+		if err := d.removeContainer(id); err != nil {
+			fmt.Printf("Error removing container %s: %v\n", id, err)
+			return
+		}
+		d.logEvent("destroy", id, eventAttributes(containerState, nil))
+	}
 }
 
-// StopContainer stops a running container
-func (d *Daemon) StopContainer(id string) error {
+// StopContainer stops a running container on behalf of actor
+// Unless noDeps is set, running containers that depend on it are stopped first
+// Returns the IDs that were stopped, in stop order
+func (d *Daemon) StopContainer(ref string, noDeps bool, actor string) ([]string, error) {
+	id, err := d.resolveID(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	order := []string{id}
+	if !noDeps {
+		if order, err = d.stopOrder(id); err != nil {
+			return nil, err
+		}
+	}
+
+	var stopped []string
+	for _, cid := range order[:len(order)-1] {
+		dependent, err := d.getContainer(cid)
+		if err != nil || dependent.Status != "running" {
+			continue
+		}
+
+		fmt.Printf("Stopping dependent %s of container %s\n", cid, id)
+		if err := d.stopContainer(cid, transitionCause{actor, "dependency " + id + " stopped"}); err != nil {
+			return stopped, fmt.Errorf("failed to stop dependent %s: %v", cid, err)
+		}
+		stopped = append(stopped, cid)
+	}
+
+	if err := d.stopContainer(id, transitionCause{actor, "stop"}); err != nil {
+		return stopped, err
+	}
+	return append(stopped, id), nil
+}
+
+// stopContainer stops a single running container and waits for it to exit
+// The exit is recorded in the container's history with the given cause
+func (d *Daemon) stopContainer(id string, cause transitionCause) error {
+	_, err := d.stopContainerWithin(id, cause, defaultStopTimeout)
+	return err
+}
+
+// stopContainerWithin stops a single running container, killing it if it hasn't exited grace after SIGTERM
+// Returns whether the container had to be killed
+func (d *Daemon) stopContainerWithin(id string, cause transitionCause, grace time.Duration) (bool, error) {
 	// Get container state
 	containerState, err := d.getContainer(id)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	// Queued containers are simply taken out of the queue
+	if containerState.Status == "queued" {
+		return false, d.cancelQueued(id, cause)
 	}
 
 	// Check if container is running
 	if containerState.Status != "running" {
-		return fmt.Errorf("container is not running (status: %s)", containerState.Status)
+		return false, errConflict(api.ErrCodeContainerNotRunning, "container is not running (status: %s)", containerState.Status)
 	}
 
 	// Get runner
 	runner, err := d.getRunner(id)
 	if err != nil {
-		return fmt.Errorf("runner not found for container %s", id)
+		return false, fmt.Errorf("runner not found for container %s", id)
 	}
 
+	d.setStopCause(id, cause)
+
 	// Send SIGTERM
 	fmt.Printf("Sending SIGTERM to container %s (PID %d)\n", id, runner.PID())
 	if err := runner.Stop(); err != nil {
-		return fmt.Errorf("failed to send SIGTERM: %v", err)
+		return false, fmt.Errorf("failed to send SIGTERM: %v", err)
 	}
 
-	// Wait with timeout (5 seconds)
-	if err := runner.WaitWithTimeout(5 * time.Second); err != nil {
-		// Still running after timeout, force kill
+	// Wait for the grace period, then force kill
+	killed := false
+	select {
+	case <-runner.Exited():
+	case <-time.After(grace):
 		fmt.Printf("Container %s did not stop gracefully, sending SIGKILL\n", id)
 		if err := runner.Kill(); err != nil {
-			return fmt.Errorf("failed to kill container: %v", err)
+			return false, fmt.Errorf("failed to kill container: %v", err)
 		}
+		runner.Wait()
+		killed = true
 	}
 
+	d.logEvent("stop", id, nil)
+
 	// The monitorContainer goroutine will handle cleanup and state update
-	return nil
+	return killed, nil
+}
+
+// InspectContainer returns the details of a container, including its state history if requested
+func (d *Daemon) InspectContainer(ref string, history bool) (*api.ContainerInspect, error) {
+	id, err := d.resolveID(ref)
+	if err != nil {
+		return nil, err
+	}
+	containerState, err := d.getContainer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	inspect := &api.ContainerInspect{
+		ID:       containerState.ID,
+		Status:   containerState.Status,
+		PID:      containerState.PID,
+		Created:  containerState.Created,
+		Started:  containerState.Started,
+		Exited:   containerState.Exited,
+		ExitCode: containerState.ExitCode,
+		Command:  containerState.Command,
+		Rootfs:   containerState.Rootfs,
+		Platform: containerState.Platform,
+		Env:      containerState.Env,
+		Labels:   containerState.Labels,
+		Profile:  containerState.Profile,
+
+		WaitFor: containerState.WaitFor,
+
+		NamespacesFrom: containerState.NamespacesFrom,
+		AutoRemove:     containerState.AutoRemove,
+
+		ResourceViews: containerState.ResourceViews,
+		NoTTY:         containerState.NoTTY,
+		StdinOnce:     containerState.StdinOnce,
+		Adopted:       containerState.Adopted,
+
+		LogConfig: containerState.LogConfig,
+
+		DeviceHotplug: containerState.DeviceHotplug,
+
+		Memory:               containerState.Limits.MemoryLimit,
+		CpuQuota:             containerState.Limits.CpuQuota,
+		Autoscale:            containerState.Autoscale,
+		AutoscaleAdjustments: containerState.AutoscaleAdjustments,
+	}
+	if containerState.Status == "running" {
+		inspect.NetworkNamespace = namespace.NetnsPath(id)
+		if network, err := netDevStats(containerState.PID); err == nil {
+			inspect.Network = &network
+		}
+	}
+	for _, m := range containerState.Mounts {
+		inspect.Mounts = append(inspect.Mounts, api.Mount{Source: m.Source, Destination: m.Destination, Type: m.Type, Options: m.Options})
+	}
+
+	if history {
+		transitions, err := d.store.LoadHistory(id)
+		if err != nil {
+			return nil, err
+		}
+		inspect.History = make([]api.StateTransition, 0, len(transitions))
+		for _, t := range transitions {
+			inspect.History = append(inspect.History, api.StateTransition{From: t.From, To: t.To, Reason: t.Reason, Actor: t.Actor, Time: t.Time})
+		}
+	}
+
+	return inspect, nil
+}
+
+// ContainerNetns returns the network namespace handle of a running container
+func (d *Daemon) ContainerNetns(ref string) (*api.ContainerNetns, error) {
+	id, err := d.resolveID(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	containerState, ok := d.containers[id]
+	if !ok {
+		return nil, errContainerNotFound(id)
+	}
+	if containerState.Status != "running" {
+		return nil, errConflict(api.ErrCodeContainerNotRunning, "container is not running (status: %s)", containerState.Status)
+	}
+	path := namespace.NetnsPath(id)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("network namespace of container %s is not exposed: %v", id, err)
+	}
+
+	return &api.ContainerNetns{ID: id, PID: containerState.PID, Path: path}, nil
 }
 
 // ListContainers returns information about all containers
@@ -206,6 +846,7 @@ func (d *Daemon) ListContainers() []api.ContainerInfo {
 			Status:  container.Status,
 			Created: container.Created.Unix(),
 			PID:     container.PID,
+			Labels:  container.Labels,
 		}
 		containers = append(containers, info)
 	}
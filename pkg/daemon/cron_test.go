@@ -0,0 +1,234 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    *cronSchedule
+		wantErr bool
+	}{
+		{
+			expr: "* * * * *",
+			want: &cronSchedule{minute: bits(0, 59), hour: bits(0, 23), dom: bits(1, 31), month: bits(1, 12), dow: bits(0, 7), domAny: true, dowAny: true},
+		},
+		{
+			expr: "@hourly",
+			want: &cronSchedule{minute: 1, hour: bits(0, 23), dom: bits(1, 31), month: bits(1, 12), dow: bits(0, 7), domAny: true, dowAny: true},
+		},
+		{
+			expr: " @Daily ",
+			want: &cronSchedule{minute: 1, hour: 1, dom: bits(1, 31), month: bits(1, 12), dow: bits(0, 7), domAny: true, dowAny: true},
+		},
+		{
+			expr: "@weekly",
+			want: &cronSchedule{minute: 1, hour: 1, dom: bits(1, 31), month: bits(1, 12), dow: 1, domAny: true},
+		},
+		{
+			expr: "@yearly",
+			want: &cronSchedule{minute: 1, hour: 1, dom: 1 << 1, month: 1 << 1, dow: bits(0, 7), dowAny: true},
+		},
+		{
+			expr: "*/15 9-17 * * mon-fri",
+			want: &cronSchedule{minute: 1 | 1<<15 | 1<<30 | 1<<45, hour: bits(9, 17), dom: bits(1, 31), month: bits(1, 12), dow: bits(1, 5), domAny: true},
+		},
+		{
+			expr: "5,10-20/5 0-6/3 1/10 JAN,jul *",
+			want: &cronSchedule{minute: 1<<5 | 1<<10 | 1<<15 | 1<<20, hour: 1 | 1<<3 | 1<<6, dom: 1<<1 | 1<<11 | 1<<21 | 1<<31, month: 1<<1 | 1<<7, dow: bits(0, 7), dowAny: true},
+		},
+		{
+			// Sunday written as 7 also sets 0
+			expr: "0 0 * * 7",
+			want: &cronSchedule{minute: 1, hour: 1, dom: bits(1, 31), month: bits(1, 12), dow: 1 | 1<<7, domAny: true},
+		},
+		{expr: "", wantErr: true},
+		{expr: "* * * *", wantErr: true},
+		{expr: "* * * * * *", wantErr: true},
+		{expr: "@fortnightly", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "* 24 * * *", wantErr: true},
+		{expr: "* * 0 * *", wantErr: true},
+		{expr: "* * * 13 *", wantErr: true},
+		{expr: "* * * * 8", wantErr: true},
+		{expr: "10-5 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "*/x * * * *", wantErr: true},
+		{expr: "* * * foo *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseCron(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCron(%q) = %+v, want an error", tt.expr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCron(%q) failed: %v", tt.expr, err)
+			}
+			if *got != *tt.want {
+				t.Errorf("parseCron(%q) = %+v, want %+v", tt.expr, *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "next minute",
+			expr: "* * * * *",
+			from: time.Date(2026, 1, 1, 10, 0, 30, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 10, 1, 0, 0, time.UTC),
+		},
+		{
+			name: "always after from",
+			expr: "0 10 * * *",
+			from: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "step",
+			expr: "*/15 * * * *",
+			from: time.Date(2026, 1, 1, 10, 16, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "hour rollover",
+			expr: "*/15 * * * *",
+			from: time.Date(2026, 1, 1, 10, 50, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays skip the weekend",
+			expr: "0 9 * * mon-fri",
+			from: time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC), // Friday
+			want: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "month rollover",
+			expr: "0 0 1 * *",
+			from: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "year rollover",
+			expr: "@monthly",
+			from: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "months without the day are skipped",
+			expr: "0 0 31 * *",
+			from: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never",
+			expr: "0 0 30 2 *",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+		{
+			// With both day fields restricted either one matching is enough: the 13th comes before a Friday
+			name: "day of month or day of week, day of month first",
+			expr: "0 0 13 * fri",
+			from: time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), // Saturday
+			want: time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week, day of week first",
+			expr: "0 0 13 * fri",
+			from: time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC), // Tuesday
+			want: time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// With day of month unrestricted, only the day of week counts
+			name: "day of week alone",
+			expr: "0 0 * * fri",
+			from: time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// With day of week unrestricted, only the day of month counts
+			name: "day of month alone",
+			expr: "0 0 13 * *",
+			from: time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 2, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// Clocks go from 02:00 EST to 03:00 EDT on 2026-03-08
+			name: "hourly across spring forward",
+			expr: "@hourly",
+			from: time.Date(2026, 3, 8, 1, 0, 0, 0, newYork),
+			want: time.Date(2026, 3, 8, 3, 0, 0, 0, newYork),
+		},
+		{
+			name: "time skipped by spring forward",
+			expr: "30 2 * * *",
+			from: time.Date(2026, 3, 8, 0, 0, 0, 0, newYork),
+			want: time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+		},
+		{
+			name: "time after spring forward",
+			expr: "0 3 * * *",
+			from: time.Date(2026, 3, 8, 0, 0, 0, 0, newYork),
+			want: time.Date(2026, 3, 8, 3, 0, 0, 0, newYork),
+		},
+		{
+			// Clocks go from 02:00 EDT back to 01:00 EST on 2026-11-01; an hourly job runs in both 01:00 hours
+			name: "hourly across fall back",
+			expr: "@hourly",
+			from: time.Date(2026, 11, 1, 1, 0, 0, 0, newYork),  // 01:00 EDT
+			want: time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC), // 01:00 EST
+		},
+		{
+			name: "time after fall back",
+			expr: "0 3 * * *",
+			from: time.Date(2026, 11, 1, 0, 0, 0, 0, newYork),
+			want: time.Date(2026, 11, 1, 3, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parseCron(%q) failed: %v", tt.expr, err)
+			}
+			if got := schedule.next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("next(%v) of %q = %v, want %v", tt.from, tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+// bits returns the set of values from low to high
+func bits(low, high int) uint64 {
+	var set uint64
+	for v := low; v <= high; v++ {
+		set |= 1 << uint(v)
+	}
+	return set
+}
@@ -4,11 +4,19 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
-	"syscall"
 	"time"
 
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/config"
 	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/logger"
+	"github.com/AbhishekGY/mydocker/pkg/selinux"
 	"github.com/AbhishekGY/mydocker/pkg/state"
 )
 
@@ -16,36 +24,134 @@ import (
 type Daemon struct {
 	socketPath string
 	dataDir    string
+	rootfsDir  string // Daemon-managed rootfs directories, referred to by name in create requests
 	store      *state.Store
+	pidFile    *pidFile
 	containers map[string]*state.ContainerState
 	runners    map[string]*container.Runner
-	mu         sync.RWMutex
+	stopCauses map[string]transitionCause    // Why running containers were asked to stop, until their exit is recorded
+	outputs    map[string]*outputBroadcaster // Output of running containers, read with or without clients
+	logDrivers map[string]logger.Driver      // Log drivers of running containers
+	sizes      sizeCache
+	events     events
+
+	statsHistory statsHistory // Samples taken by the stats sampler, kept for the configured retention
+	quotaMu      sync.Mutex   // Serializes quota checks with the starts they allow
+	adoptMu      sync.Mutex   // Serializes adoptions so a process can't be adopted twice
+	drainMu      sync.Mutex   // Held while a drain runs, so only one runs at a time
+	admission    admission    // Container starts waiting for the host to have room
+	schedules    schedules    // Containers run on cron schedules
+	services     services     // Services whose replicas are kept running
+	presets      presets      // Container templates created through the API
+	pods         pods         // Groups of containers sharing an infra container's namespaces
+	mode         apiMode      // Restricts the requests the API accepts, e.g. while the host is drained
+
+	cgroupVersion cgroups.Version // Detected once at startup
+	mu            sync.RWMutex
+	debug         bool          // Expose /debug endpoints (pprof and state dump)
+	stopCh        chan struct{} // Closed on shutdown to stop background jobs
+
+	apparmorProfile string // Default AppArmor profile for containers, empty if AppArmor is unavailable
+	selinuxEnabled  bool   // Give containers separate SELinux labels
+
+	configPath string
+	config     *config.DaemonConfig
+	configMu   sync.RWMutex
 }
 
 // NewDaemon creates a new daemon instance
 func NewDaemon(socketPath, dataDir string) (*Daemon, error) {
+	if err := checkHost(); err != nil {
+		return nil, err
+	}
+
 	// Initialize the state store
 	store, err := state.NewStore(dataDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create state store: %v", err)
 	}
 
+	// Make sure no other daemon is using the same data directory
+	pid, err := acquirePidFile(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
 	d := &Daemon{
 		socketPath: socketPath,
 		dataDir:    dataDir,
+		rootfsDir:  filepath.Join(dataDir, "rootfs"),
 		store:      store,
+		pidFile:    pid,
+		config:     config.Default(),
+		stopCh:     make(chan struct{}),
 		containers: make(map[string]*state.ContainerState),
 		runners:    make(map[string]*container.Runner),
+		stopCauses: make(map[string]transitionCause),
+		outputs:    make(map[string]*outputBroadcaster),
+		logDrivers: make(map[string]logger.Driver),
+	}
+	d.mode.since = time.Now()
+
+	// Detect the cgroup hierarchy once; every container cgroup uses the same backend
+	d.cgroupVersion = cgroups.DetectVersion()
+	fmt.Printf("Using cgroups v%d\n", d.cgroupVersion)
+	if d.cgroupVersion == cgroups.V2 {
+		if err := cgroups.SetupV2(); err != nil {
+			pid.release()
+			return nil, fmt.Errorf("failed to set up cgroups: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(d.rootfsDir, 0755); err != nil {
+		pid.release()
+		return nil, fmt.Errorf("failed to create rootfs directory: %v", err)
+	}
+
+	// Events are kept on disk so "events --since" reaches back past daemon restarts
+	if err := d.events.openLog(filepath.Join(dataDir, eventLogName)); err != nil {
+		fmt.Printf("Warning: %v, events will not be kept\n", err)
+	}
+
+	d.setupAppArmor()
+	if d.selinuxEnabled = selinux.IsEnabled(); d.selinuxEnabled {
+		fmt.Println("SELinux is enabled, containers get separate MCS labels")
 	}
 
 	// Load existing containers from disk
 	if err := d.loadContainers(); err != nil {
+		pid.release()
 		return nil, fmt.Errorf("failed to load containers: %v", err)
 	}
+	if err := d.loadSchedules(); err != nil {
+		pid.release()
+		return nil, fmt.Errorf("failed to load schedules: %v", err)
+	}
+	if err := d.loadServices(); err != nil {
+		pid.release()
+		return nil, fmt.Errorf("failed to load services: %v", err)
+	}
+	if err := d.loadPresets(); err != nil {
+		pid.release()
+		return nil, fmt.Errorf("failed to load presets: %v", err)
+	}
+	if err := d.loadPods(); err != nil {
+		pid.release()
+		return nil, fmt.Errorf("failed to load pods: %v", err)
+	}
+
+	// Clean up cgroups and mounts a previous daemon left behind
+	d.Reconcile(false)
 
 	return d, nil
 }
 
+// EnableDebug exposes the /debug/pprof and /debug/state endpoints
+// Must be called before Start
+func (d *Daemon) EnableDebug() {
+	d.debug = true
+}
+
 // loadContainers loads all existing containers from the state store
 func (d *Daemon) loadContainers() error {
 	containers, err := d.store.ListContainers()
@@ -60,11 +166,12 @@ func (d *Daemon) loadContainers() error {
 		// Check if container was running when daemon stopped
 		if container.Status == "running" && container.PID > 0 {
 			// Check if process still exists
-			if err := syscall.Kill(container.PID, 0); err != nil {
+			if !processAlive(container.PID) {
 				// Process is dead, update state
 				fmt.Printf("Container %s was running but process %d is dead, marking as exited\n",
 					container.ID, container.PID)
-				container.Status = "exited"
+				d.setStatus(container, "exited", transitionCause{daemonActor, "process died while the daemon was down"})
+				container.Exited = time.Now()
 				container.PID = 0
 				// Save updated state
 				if err := d.store.SaveContainer(container); err != nil {
@@ -75,7 +182,8 @@ func (d *Daemon) loadContainers() error {
 				// In a production system, we'd re-attach to the running process
 				fmt.Printf("Container %s process %d is still running, marking as exited (re-attach not implemented)\n",
 					container.ID, container.PID)
-				container.Status = "exited"
+				d.setStatus(container, "exited", transitionCause{daemonActor, "daemon restarted; re-attach not implemented"})
+				container.Exited = time.Now()
 				container.PID = 0
 				if err := d.store.SaveContainer(container); err != nil {
 					fmt.Printf("Warning: failed to update container state: %v\n", err)
@@ -83,6 +191,14 @@ func (d *Daemon) loadContainers() error {
 			}
 		}
 
+		// The request that queued the container is gone, so it goes back to waiting for a start
+		if container.Status == "queued" {
+			d.setStatus(container, "created", transitionCause{daemonActor, "daemon restarted before the container was admitted"})
+			if err := d.store.SaveContainer(container); err != nil {
+				fmt.Printf("Warning: failed to update container state: %v\n", err)
+			}
+		}
+
 		d.containers[container.ID] = container
 	}
 
@@ -90,11 +206,81 @@ func (d *Daemon) loadContainers() error {
 	return nil
 }
 
-// generateContainerID generates a random container ID
-func (d *Daemon) generateContainerID() string {
-	bytes := make([]byte, 6)
-	rand.Read(bytes)
-	return hex.EncodeToString(bytes)
+// containerIDBytes is the number of random bytes in a container ID, giving 64 hex characters like Docker
+const containerIDBytes = 32
+
+// shortIDLength is how many characters of an ID the CLI shows; new IDs are kept unique at this length
+const shortIDLength = 12
+
+// generateContainerID generates a random container ID that no existing container uses, even as a short ID
+func (d *Daemon) generateContainerID() (string, error) {
+	bytes := make([]byte, containerIDBytes)
+	for attempt := 0; attempt < 10; attempt++ {
+		if _, err := rand.Read(bytes); err != nil {
+			return "", fmt.Errorf("failed to generate container ID: %v", err)
+		}
+
+		id := hex.EncodeToString(bytes)
+		if !d.idInUse(id) {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a unique container ID")
+}
+
+// idInUse reports whether id or its short form collides with a known container
+func (d *Daemon) idInUse(id string) bool {
+	if d.store.HasContainer(id) {
+		return true
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for existing := range d.containers {
+		if strings.HasPrefix(existing, id[:shortIDLength]) || strings.HasPrefix(id, existing) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveID expands a full container ID or a unique prefix of one to the full ID
+func (d *Daemon) resolveID(ref string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.resolveIDLocked(ref)
+}
+
+// resolveIDLocked is resolveID for callers already holding d.mu
+func (d *Daemon) resolveIDLocked(ref string) (string, error) {
+	if ref == "" {
+		return "", errInvalidRequest(fmt.Errorf("container ID required"))
+	}
+	if _, ok := d.containers[ref]; ok {
+		return ref, nil
+	}
+
+	var matches []string
+	for id := range d.containers {
+		if strings.HasPrefix(id, ref) {
+			matches = append(matches, id)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", errContainerNotFound(ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", &apiError{
+			status: http.StatusBadRequest,
+			code:   api.ErrCodeAmbiguousID,
+			err:    fmt.Errorf("container ID prefix %s is ambiguous, it matches %d containers", ref, len(matches)),
+		}
+	}
 }
 
 // getContainer retrieves a container by ID (thread-safe)
@@ -104,7 +290,7 @@ func (d *Daemon) getContainer(id string) (*state.ContainerState, error) {
 
 	container, exists := d.containers[id]
 	if !exists {
-		return nil, fmt.Errorf("container not found: %s", id)
+		return nil, errContainerNotFound(id)
 	}
 
 	return container, nil
@@ -140,6 +326,7 @@ func (d *Daemon) removeContainer(id string) error {
 	if err := d.store.DeleteContainer(id); err != nil {
 		return fmt.Errorf("failed to delete container state: %v", err)
 	}
+	d.removeLog(id)
 
 	return nil
 }
@@ -199,8 +386,8 @@ func (d *Daemon) stopAllContainers() {
 	}
 	d.mu.RUnlock()
 
-	// Stop all running containers
-	for _, id := range runnerIDs {
+	// Stop dependents before the containers they depend on
+	for _, id := range d.shutdownOrder(runnerIDs) {
 		fmt.Printf("Stopping container %s...\n", id)
 		runner, err := d.getRunner(id)
 		if err != nil {
@@ -208,6 +395,7 @@ func (d *Daemon) stopAllContainers() {
 		}
 
 		// Try graceful stop with timeout
+		d.setStopCause(id, transitionCause{daemonActor, "daemon shutdown"})
 		if err := runner.Stop(); err != nil {
 			fmt.Printf("Warning: failed to send SIGTERM to container %s: %v\n", id, err)
 		}
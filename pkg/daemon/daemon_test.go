@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+func TestResolveIDLocked(t *testing.T) {
+	d := &Daemon{containers: make(map[string]*state.ContainerState)}
+	for _, id := range []string{"abc123", "abc456", "abc", "def789"} {
+		d.containers[id] = &state.ContainerState{ID: id}
+	}
+
+	tests := []struct {
+		name       string
+		ref        string
+		want       string
+		wantStatus int // HTTP status of the error, 0 for none
+		wantCode   string
+	}{
+		{name: "full ID", ref: "def789", want: "def789"},
+		{name: "unique prefix", ref: "abc1", want: "abc123"},
+		{name: "single character prefix", ref: "d", want: "def789"},
+		{name: "exact ID over a prefix of others", ref: "abc", want: "abc"},
+		{name: "ambiguous prefix", ref: "ab", wantStatus: http.StatusBadRequest, wantCode: api.ErrCodeAmbiguousID},
+		{name: "no match", ref: "fff", wantStatus: http.StatusNotFound, wantCode: api.ErrCodeContainerNotFound},
+		{name: "longer than any ID", ref: "def7890", wantStatus: http.StatusNotFound, wantCode: api.ErrCodeContainerNotFound},
+		{name: "empty ref", ref: "", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.resolveIDLocked(tt.ref)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("resolveIDLocked(%q) failed: %v", tt.ref, err)
+				}
+				if got != tt.want {
+					t.Errorf("resolveIDLocked(%q) = %q, want %q", tt.ref, got, tt.want)
+				}
+				return
+			}
+
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("resolveIDLocked(%q) = %q, %v, want an API error", tt.ref, got, err)
+			}
+			if apiErr.status != tt.wantStatus || (tt.wantCode != "" && apiErr.code != tt.wantCode) {
+				t.Errorf("resolveIDLocked(%q) error has status %d code %q, want %d %q", tt.ref, apiErr.status, apiErr.code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// registerDebugHandlers adds pprof and state dump endpoints to the mux
+func (d *Daemon) registerDebugHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/state", d.handleDebugState)
+}
+
+// DebugState returns a snapshot of the daemon's containers, runners and lock state
+func (d *Daemon) DebugState() api.DebugStateResponse {
+	state := api.DebugStateResponse{
+		Goroutines: runtime.NumGoroutine(),
+		DaemonLock: "free",
+	}
+
+	// Probe the lock without blocking so a stuck daemon can still be inspected
+	if !d.mu.TryLock() {
+		state.DaemonLock = "held"
+		return state
+	}
+	d.mu.Unlock()
+
+	state.Containers = d.ListContainers()
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	state.Runners = make([]api.RunnerInfo, 0, len(d.runners))
+	for id, runner := range d.runners {
+		state.Runners = append(state.Runners, api.RunnerInfo{
+			ID:       id,
+			PID:      runner.PID(),
+			Detach:   runner.Detach,
+			Attached: runner.IsAttached(),
+		})
+	}
+
+	return state
+}
+
+// handleDebugState handles daemon state dump requests
+func (d *Daemon) handleDebugState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.DebugState())
+}
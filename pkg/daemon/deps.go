@@ -0,0 +1,171 @@
+package daemon
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// containerDeps returns the IDs a container depends on according to its labels
+func containerDeps(cs *state.ContainerState) []string {
+	var deps []string
+	for _, id := range strings.Split(cs.Labels[api.DependsOnLabel], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			deps = append(deps, id)
+		}
+	}
+	return deps
+}
+
+// resolveDeps checks that every dependency in labels refers to a known container
+// and rewrites the depends_on label with full IDs. A new container can't close a cycle since nothing depends on it yet
+func (d *Daemon) resolveDeps(labels map[string]string) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	deps := containerDeps(&state.ContainerState{ContainerConfig: state.ContainerConfig{Labels: labels}})
+	for i, dep := range deps {
+		id, err := d.resolveIDLocked(dep)
+		if err != nil {
+			return fmt.Errorf("dependency %s: %v", dep, err)
+		}
+		deps[i] = id
+	}
+	if len(deps) > 0 {
+		labels[api.DependsOnLabel] = strings.Join(deps, ",")
+	}
+	return nil
+}
+
+// startOrder returns id and its transitive dependencies, dependencies first
+func (d *Daemon) startOrder(id string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	graph := make(map[string][]string, len(d.containers))
+	for cid, cs := range d.containers {
+		graph[cid] = containerDeps(cs)
+	}
+	if _, ok := graph[id]; !ok {
+		return nil, fmt.Errorf("container %s not found", id)
+	}
+
+	return topoSort(graph, []string{id})
+}
+
+// stopOrder returns id and every container that transitively depends on it, dependents first
+func (d *Daemon) stopOrder(id string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, ok := d.containers[id]; !ok {
+		return nil, fmt.Errorf("container %s not found", id)
+	}
+
+	// Walking the reversed graph dependencies-first yields dependents first
+	return topoSort(d.dependentsGraph(), []string{id})
+}
+
+// shutdownOrder orders ids so that dependents are stopped before their dependencies
+// Containers not in ids are skipped; a cycle falls back to the given order
+func (d *Daemon) shutdownOrder(ids []string) []string {
+	d.mu.RLock()
+	graph := d.dependentsGraph()
+	d.mu.RUnlock()
+
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+	order, err := topoSort(graph, sorted)
+	if err != nil {
+		fmt.Printf("Warning: %v, stopping containers in arbitrary order\n", err)
+		return ids
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	filtered := make([]string, 0, len(ids))
+	for _, id := range order {
+		if wanted[id] {
+			filtered = append(filtered, id)
+		}
+	}
+	return filtered
+}
+
+// hasDependentsLocked reports whether any container lists id in its depends_on label
+// The caller must hold d.mu
+func (d *Daemon) hasDependentsLocked(id string) bool {
+	for _, cs := range d.containers {
+		if slices.Contains(containerDeps(cs), id) {
+			return true
+		}
+	}
+	return false
+}
+
+// dependentsGraph maps each container to the containers that depend on it
+// The caller must hold d.mu
+func (d *Daemon) dependentsGraph() map[string][]string {
+	graph := make(map[string][]string, len(d.containers))
+	for cid, cs := range d.containers {
+		for _, dep := range containerDeps(cs) {
+			graph[dep] = append(graph[dep], cid)
+		}
+	}
+	for _, edges := range graph {
+		sort.Strings(edges)
+	}
+	return graph
+}
+
+// topoSort walks graph depth-first from roots and returns nodes with their edges before them
+// Edges to nodes missing from graph are followed but have no edges of their own
+func topoSort(graph map[string][]string, roots []string) ([]string, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	marks := make(map[string]int)
+	var order []string
+	var path []string
+
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch marks[id] {
+		case done:
+			return nil
+		case visiting:
+			// Report the cycle starting from the first occurrence of id on the path
+			for i, p := range path {
+				if p == id {
+					return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path[i:], id), " -> "))
+				}
+			}
+		}
+
+		marks[id] = visiting
+		path = append(path, id)
+		for _, next := range graph[id] {
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[id] = done
+		order = append(order, id)
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := visit(root); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
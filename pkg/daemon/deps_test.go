@@ -0,0 +1,176 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+func TestTopoSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		graph   map[string][]string
+		roots   []string
+		want    []string
+		wantErr string // Cycle the error reports, empty for none
+	}{
+		{name: "single node", graph: map[string][]string{"a": nil}, roots: []string{"a"}, want: []string{"a"}},
+		{name: "chain", graph: map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil}, roots: []string{"a"}, want: []string{"c", "b", "a"}},
+		{
+			name:  "diamond visits shared edge once",
+			graph: map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}, "d": nil},
+			roots: []string{"a"},
+			want:  []string{"d", "b", "c", "a"},
+		},
+		{
+			name:  "edges in the given order",
+			graph: map[string][]string{"a": {"c", "b"}, "b": nil, "c": nil},
+			roots: []string{"a"},
+			want:  []string{"c", "b", "a"},
+		},
+		{
+			name:  "several roots",
+			graph: map[string][]string{"a": {"c"}, "b": {"c"}, "c": nil},
+			roots: []string{"a", "b"},
+			want:  []string{"c", "a", "b"},
+		},
+		{name: "missing node has no edges", graph: map[string][]string{"a": {"gone"}}, roots: []string{"a"}, want: []string{"gone", "a"}},
+		{name: "unreachable nodes left out", graph: map[string][]string{"a": nil, "b": {"a"}}, roots: []string{"a"}, want: []string{"a"}},
+		{name: "self cycle", graph: map[string][]string{"a": {"a"}}, roots: []string{"a"}, wantErr: "a -> a"},
+		{name: "cycle", graph: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}}, roots: []string{"a"}, wantErr: "a -> b -> c -> a"},
+		{
+			name:    "cycle below the root",
+			graph:   map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"b"}},
+			roots:   []string{"a"},
+			wantErr: "b -> c -> b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := topoSort(tt.graph, tt.roots)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("topoSort = %q, want a cycle error", got)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("topoSort error %q doesn't report the cycle %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("topoSort failed: %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("topoSort = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// depsDaemon returns a daemon knowing a container for each key of deps, depending on the listed containers
+func depsDaemon(deps map[string][]string) *Daemon {
+	d := &Daemon{containers: make(map[string]*state.ContainerState)}
+	for id, on := range deps {
+		labels := map[string]string{}
+		if len(on) > 0 {
+			labels[api.DependsOnLabel] = strings.Join(on, ",")
+		}
+		d.containers[id] = &state.ContainerState{ID: id, ContainerConfig: state.ContainerConfig{Labels: labels}}
+	}
+	return d
+}
+
+func TestStartAndStopOrder(t *testing.T) {
+	// web depends on api and cache, api on db; worker also depends on db
+	d := depsDaemon(map[string][]string{
+		"web":    {"api", "cache"},
+		"api":    {"db"},
+		"cache":  nil,
+		"db":     nil,
+		"worker": {"db"},
+	})
+
+	tests := []struct {
+		id        string
+		wantStart []string // Dependencies first
+		wantStop  []string // Dependents first
+	}{
+		{id: "web", wantStart: []string{"db", "api", "cache", "web"}, wantStop: []string{"web"}},
+		{id: "api", wantStart: []string{"db", "api"}, wantStop: []string{"web", "api"}},
+		{id: "db", wantStart: []string{"db"}, wantStop: []string{"web", "api", "worker", "db"}},
+		{id: "cache", wantStart: []string{"cache"}, wantStop: []string{"web", "cache"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			start, err := d.startOrder(tt.id)
+			if err != nil {
+				t.Fatalf("startOrder failed: %v", err)
+			}
+			if fmt.Sprint(start) != fmt.Sprint(tt.wantStart) {
+				t.Errorf("startOrder = %q, want %q", start, tt.wantStart)
+			}
+
+			stop, err := d.stopOrder(tt.id)
+			if err != nil {
+				t.Fatalf("stopOrder failed: %v", err)
+			}
+			if fmt.Sprint(stop) != fmt.Sprint(tt.wantStop) {
+				t.Errorf("stopOrder = %q, want %q", stop, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestStartAndStopOrderErrors(t *testing.T) {
+	d := depsDaemon(map[string][]string{
+		"a":      {"b"},
+		"b":      {"a"},
+		"orphan": {"removed"},
+	})
+
+	if _, err := d.startOrder("a"); err == nil {
+		t.Error("startOrder succeeded for a container in a cycle")
+	}
+	if _, err := d.stopOrder("a"); err == nil {
+		t.Error("stopOrder succeeded for a container in a cycle")
+	}
+	if _, err := d.startOrder("missing"); err == nil {
+		t.Error("startOrder succeeded for an unknown container")
+	}
+	if _, err := d.stopOrder("missing"); err == nil {
+		t.Error("stopOrder succeeded for an unknown container")
+	}
+
+	// A dependency that no longer exists is still ordered first; starting it is what reports it missing
+	order, err := d.startOrder("orphan")
+	if err != nil {
+		t.Fatalf("startOrder failed: %v", err)
+	}
+	if fmt.Sprint(order) != fmt.Sprint([]string{"removed", "orphan"}) {
+		t.Errorf("startOrder = %q, want [removed orphan]", order)
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	d := depsDaemon(map[string][]string{"web": {"api"}, "api": {"db"}, "db": nil, "other": nil})
+
+	got := d.shutdownOrder([]string{"db", "other", "web"})
+	if fmt.Sprint(got) != fmt.Sprint([]string{"web", "db", "other"}) {
+		t.Errorf("shutdownOrder = %q, want [web db other]", got)
+	}
+}
+
+func TestHasDependentsLocked(t *testing.T) {
+	d := depsDaemon(map[string][]string{"web": {"api", "cache"}, "api": nil, "cache": nil, "lone": nil})
+
+	for id, want := range map[string]bool{"api": true, "cache": true, "web": false, "lone": false, "missing": false} {
+		if got := d.hasDependentsLocked(id); got != want {
+			t.Errorf("hasDependentsLocked(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// Drain puts the daemon in maintenance mode on behalf of actor and stops every running or queued container
+// Dependents are stopped before the containers they depend on, up to req.Parallelism at a time. Once the
+// deadline passes, containers still running are killed without waiting for them to exit
+func (d *Daemon) Drain(req api.SystemDrainRequest, actor string) (*api.SystemDrainResponse, error) {
+	if req.Parallelism < 0 || req.Timeout < 0 || req.Deadline < 0 {
+		return nil, errInvalidRequest(fmt.Errorf("parallelism, timeout and deadline must not be negative"))
+	}
+	parallelism := max(req.Parallelism, 1)
+	timeout := req.Timeout
+	if timeout == 0 {
+		timeout = defaultStopTimeout
+	}
+	reason := req.Reason
+	if reason == "" {
+		reason = "drain"
+	}
+
+	if !d.drainMu.TryLock() {
+		return nil, errConflict(api.ErrCodeDrainInProgress, "a drain is already in progress")
+	}
+	defer d.drainMu.Unlock()
+
+	// New containers are refused before the running ones are listed, so none are missed
+	if err := d.SetMode(api.ModeMaintenance, reason, actor); err != nil {
+		return nil, err
+	}
+
+	resp := &api.SystemDrainResponse{Mode: api.ModeMaintenance, Started: time.Now(), Containers: []api.DrainedContainer{}}
+	var deadline time.Time
+	if req.Deadline > 0 {
+		deadline = resp.Started.Add(req.Deadline)
+	}
+
+	// waiting counts the dependents each container waits on to be stopped before its own turn
+	d.mu.RLock()
+	draining := make(map[string]bool)
+	for id, c := range d.containers {
+		if c.Status == "running" || c.Status == "queued" {
+			draining[id] = true
+		}
+	}
+	deps := make(map[string][]string, len(draining))
+	waiting := make(map[string]int, len(draining))
+	for id := range draining {
+		for _, dep := range containerDeps(d.containers[id]) {
+			if draining[dep] {
+				deps[id] = append(deps[id], dep)
+				waiting[dep]++
+			}
+		}
+	}
+	d.mu.RUnlock()
+
+	fmt.Printf("Draining %d containers (parallelism %d, timeout %s)\n", len(draining), parallelism, timeout)
+
+	var ready []string
+	for id := range draining {
+		if waiting[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	sort.Strings(ready)
+
+	cause := transitionCause{actor, "drain"}
+	dispatched := make(map[string]bool, len(draining))
+	results := make(chan api.DrainedContainer)
+	inFlight := 0
+	for len(resp.Containers) < len(draining) {
+		// Only a dependency cycle leaves containers waiting with none ready or in flight; stop them in any order
+		if len(ready) == 0 && inFlight == 0 {
+			fmt.Printf("Warning: dependency cycle among drained containers, stopping the rest in arbitrary order\n")
+			for id := range draining {
+				if !dispatched[id] {
+					ready = append(ready, id)
+				}
+			}
+			sort.Strings(ready)
+		}
+
+		for len(ready) > 0 && inFlight < parallelism {
+			id := ready[0]
+			ready = ready[1:]
+
+			grace := timeout
+			if !deadline.IsZero() {
+				if left := time.Until(deadline); left < grace {
+					grace = max(left, 0)
+					resp.DeadlineExceeded = resp.DeadlineExceeded || left <= 0
+				}
+			}
+
+			dispatched[id] = true
+			inFlight++
+			go func() { results <- d.drainContainer(id, cause, grace) }()
+		}
+
+		result := <-results
+		inFlight--
+		resp.Containers = append(resp.Containers, result)
+		for _, dep := range deps[result.ID] {
+			if waiting[dep]--; waiting[dep] == 0 && !dispatched[dep] {
+				ready = append(ready, dep)
+			}
+		}
+	}
+
+	for _, result := range resp.Containers {
+		switch result.Result {
+		case "stopped":
+			resp.Stopped++
+		case "killed":
+			resp.Killed++
+		case "cancelled":
+			resp.Cancelled++
+		default:
+			resp.Failed++
+		}
+	}
+	resp.Duration = time.Since(resp.Started)
+
+	fmt.Printf("Drain finished in %s: %d stopped, %d killed, %d cancelled, %d failed\n",
+		resp.Duration.Round(time.Millisecond), resp.Stopped, resp.Killed, resp.Cancelled, resp.Failed)
+	d.publishEvent(api.DaemonEventType, "drain", "", map[string]string{
+		"actor":     actor,
+		"stopped":   strconv.Itoa(resp.Stopped),
+		"killed":    strconv.Itoa(resp.Killed),
+		"cancelled": strconv.Itoa(resp.Cancelled),
+		"failed":    strconv.Itoa(resp.Failed),
+	})
+	return resp, nil
+}
+
+// drainContainer stops one container for a drain, killing it if it hasn't exited grace after SIGTERM
+func (d *Daemon) drainContainer(id string, cause transitionCause, grace time.Duration) api.DrainedContainer {
+	start := time.Now()
+	result := api.DrainedContainer{ID: id, Result: "stopped"}
+
+	queued := false
+	if c, err := d.getContainer(id); err == nil {
+		queued = c.Status == "queued"
+	}
+
+	killed, err := d.stopContainerWithin(id, cause, grace)
+	switch {
+	case err != nil:
+		// One that exited on its own, or was removed, since the drain began needs no stopping
+		if c, getErr := d.getContainer(id); getErr != nil || (c.Status != "running" && c.Status != "queued") {
+			break
+		}
+		result.Result, result.Error = "failed", err.Error()
+		fmt.Printf("Warning: failed to stop container %s during drain: %v\n", id, err)
+	case killed:
+		result.Result = "killed"
+	case queued:
+		result.Result = "cancelled"
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// handleSystemDrain handles drain requests, responding once every container has been stopped
+func (d *Daemon) handleSystemDrain(w http.ResponseWriter, r *http.Request) {
+	var req api.SystemDrainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	resp, err := d.Drain(req, requestActor(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
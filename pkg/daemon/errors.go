@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// apiError is an error that carries the HTTP status and error code it should be reported with
+// Errors without one are reported as internal errors
+type apiError struct {
+	status int
+	code   string
+	err    error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+// errInvalidRequest marks err as a problem with the request itself
+func errInvalidRequest(err error) error {
+	return &apiError{status: http.StatusBadRequest, code: api.ErrCodeInvalidRequest, err: err}
+}
+
+// errContainerNotFound reports that no container matches ref
+func errContainerNotFound(ref string) error {
+	return &apiError{status: http.StatusNotFound, code: api.ErrCodeContainerNotFound, err: fmt.Errorf("container not found: %s", ref)}
+}
+
+// errConflict reports a request that can't be carried out in the container's current state
+func errConflict(code string, format string, args ...interface{}) error {
+	return &apiError{status: http.StatusConflict, code: code, err: fmt.Errorf(format, args...)}
+}
+
+// writeError sends err as a JSON api.ErrorResponse with the status it carries
+func writeError(w http.ResponseWriter, err error) {
+	status, code := http.StatusInternalServerError, api.ErrCodeInternal
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		status, code = apiErr.status, apiErr.code
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(api.ErrorResponse{Error: err.Error(), Code: code})
+}
+
+// writeMethodNotAllowed rejects a request made with the wrong HTTP method
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, &apiError{status: http.StatusMethodNotAllowed, code: api.ErrCodeMethodNotAllowed, err: fmt.Errorf("method not allowed")})
+}
@@ -0,0 +1,304 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// eventBufferSize is how many events a subscriber can fall behind before events are dropped for it
+const eventBufferSize = 256
+
+// eventLogName is the file in the data directory that events are appended to, one JSON event per line
+const eventLogName = "events.log"
+
+// events fans daemon events out to subscribers and appends them to the event log
+type events struct {
+	mu          sync.Mutex
+	subscribers map[chan api.Event]struct{}
+
+	logPath string
+	log     *os.File // nil if the log couldn't be opened; events are then only streamed live
+}
+
+// openLog opens the event log for appending
+func (e *events) openLog(path string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %v", err)
+	}
+	e.logPath = path
+	e.log = file
+	return nil
+}
+
+// readLog returns the logged events from since up to until (zero for no end) that pass filter, oldest first
+func (e *events) readLog(since, until time.Time, filter api.EventFilter) ([]api.Event, error) {
+	if e.logPath == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(e.logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event log: %v", err)
+	}
+	defer file.Close()
+
+	var result []api.Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event api.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// A line cut short by a crash, or still being written
+			continue
+		}
+		t := time.Unix(0, event.TimeNano)
+		if t.Before(since) || (!until.IsZero() && t.After(until)) || !filter.Match(event) {
+			continue
+		}
+		result = append(result, event)
+	}
+	return result, scanner.Err()
+}
+
+// trimLog drops logged events older than cutoff and returns how many were dropped
+func (e *events) trimLog(cutoff time.Time) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.log == nil {
+		return 0, nil
+	}
+
+	data, err := os.ReadFile(e.logPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read event log: %v", err)
+	}
+
+	// Events are appended in order, so everything after the first recent event is kept
+	dropped := 0
+	kept := data
+	for len(kept) > 0 {
+		line, rest, _ := bytes.Cut(kept, []byte("\n"))
+		var event api.Event
+		if err := json.Unmarshal(line, &event); err == nil && !time.Unix(0, event.TimeNano).Before(cutoff) {
+			break
+		}
+		kept = rest
+		dropped++
+	}
+	if dropped == 0 {
+		return 0, nil
+	}
+
+	// Replace the log atomically so a crash never loses the events being kept
+	tmp := e.logPath + ".tmp"
+	if err := os.WriteFile(tmp, kept, 0600); err != nil {
+		return 0, fmt.Errorf("failed to write event log: %v", err)
+	}
+	if err := os.Rename(tmp, e.logPath); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to replace event log: %v", err)
+	}
+
+	file, err := os.OpenFile(e.logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return dropped, fmt.Errorf("failed to reopen event log: %v", err)
+	}
+	e.log.Close()
+	e.log = file
+	return dropped, nil
+}
+
+// subscribe registers a new subscriber and returns its channel and a function to unsubscribe
+func (e *events) subscribe() (<-chan api.Event, func()) {
+	ch := make(chan api.Event, eventBufferSize)
+
+	e.mu.Lock()
+	if e.subscribers == nil {
+		e.subscribers = make(map[chan api.Event]struct{})
+	}
+	e.subscribers[ch] = struct{}{}
+	e.mu.Unlock()
+
+	cancel := func() {
+		e.mu.Lock()
+		delete(e.subscribers, ch)
+		e.mu.Unlock()
+	}
+	return ch, cancel
+}
+
+// publish logs an event and sends it to all subscribers without blocking on slow ones
+func (e *events) publish(event api.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.log != nil {
+		data, _ := json.Marshal(event)
+		if _, err := e.log.Write(append(data, '\n')); err != nil {
+			fmt.Printf("Warning: failed to write event log: %v\n", err)
+		}
+	}
+
+	for ch := range e.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber isn't keeping up, drop the event for it
+		}
+	}
+}
+
+// logEvent publishes a container event with the given attributes
+// The container's image and labels are added so events can be filtered by them
+func (d *Daemon) logEvent(action, id string, attributes map[string]string) {
+	if container, err := d.getContainer(id); err == nil {
+		attributes = eventAttributes(container, attributes)
+	}
+	d.publishEvent(api.ContainerEventType, action, id, attributes)
+}
+
+// publishEvent publishes an event of the given type, stamped with the current time
+func (d *Daemon) publishEvent(eventType, action, id string, attributes map[string]string) {
+	now := time.Now()
+	d.events.publish(api.Event{
+		Type:       eventType,
+		Action:     action,
+		ID:         id,
+		Attributes: attributes,
+		Time:       now.Unix(),
+		TimeNano:   now.UnixNano(),
+	})
+}
+
+// eventAttributes returns attributes with the image and labels of a container added
+// Labels are added as "label.<key>" so they can't clash with the attributes of an event
+func eventAttributes(container *state.ContainerState, attributes map[string]string) map[string]string {
+	result := map[string]string{"image": container.Rootfs}
+	for key, value := range container.Labels {
+		result["label."+key] = value
+	}
+	for key, value := range attributes {
+		result[key] = value
+	}
+	return result
+}
+
+// trimEvents drops events older than the event retention from the event log
+func (d *Daemon) trimEvents() {
+	retention := time.Duration(d.currentConfig().GC.EventRetention)
+	if retention == 0 {
+		return
+	}
+
+	dropped, err := d.events.trimLog(time.Now().Add(-retention))
+	if err != nil {
+		fmt.Printf("GC: failed to trim event log: %v\n", err)
+	}
+	if dropped > 0 {
+		fmt.Printf("GC: dropped %d event(s) older than %s\n", dropped, retention)
+	}
+}
+
+// handleEvents streams daemon events as JSON lines
+// With since set, logged events from then on are sent first; with until set, the stream ends at that time
+// Events are limited to those matching every "filter" parameter (key=value)
+func (d *Daemon) handleEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := api.ParseEventFilter(query["filter"])
+	if err != nil {
+		writeError(w, errInvalidRequest(err))
+		return
+	}
+
+	var since, until time.Time
+	for _, p := range []struct {
+		name string
+		dest *time.Time
+	}{{"since", &since}, {"until", &until}} {
+		if value := query.Get(p.name); value != "" {
+			t, err := parseSince(value)
+			if err != nil {
+				writeError(w, errInvalidRequest(fmt.Errorf("invalid %s: %v", p.name, err)))
+				return
+			}
+			*p.dest = t
+		}
+	}
+
+	// Subscribe before reading the log so no event falls between the two
+	ch, cancel := d.events.subscribe()
+	defer cancel()
+
+	var past []api.Event
+	if !since.IsZero() {
+		past, err = d.events.readLog(since, until, filter)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	encoder := json.NewEncoder(w)
+	var last int64
+	for _, event := range past {
+		if err := encoder.Encode(event); err != nil {
+			return
+		}
+		last = event.TimeNano
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	var end <-chan time.Time
+	if !until.IsZero() {
+		if !until.After(time.Now()) {
+			return
+		}
+		timer := time.NewTimer(time.Until(until))
+		defer timer.Stop()
+		end = timer.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-d.stopCh:
+			return
+		case <-end:
+			return
+		case event := <-ch:
+			// Events already sent from the log
+			if event.TimeNano <= last || !filter.Match(event) {
+				continue
+			}
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		ago     time.Duration // Set for durations, which are relative to now
+		wantErr bool
+	}{
+		{value: "2h", ago: 2 * time.Hour},
+		{value: "90s", ago: 90 * time.Second},
+		{value: "0s", ago: 0},
+		{value: "0", ago: 0}, // A zero duration, not the Unix epoch
+		{value: "2026-01-02T03:04:05Z", want: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{value: "2026-01-02T03:04:05+02:00", want: time.Date(2026, 1, 2, 1, 4, 5, 0, time.UTC)},
+		{value: "1767322800", want: time.Unix(1767322800, 0)},
+		{value: "-1h", wantErr: true},
+		{value: "2 hours", wantErr: true},
+		{value: "2026-01-02", wantErr: true},
+		{value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			before := time.Now()
+			got, err := parseSince(tt.value)
+			after := time.Now()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSince(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSince(%q) failed: %v", tt.value, err)
+			}
+			if tt.want.IsZero() {
+				if got.Before(before.Add(-tt.ago)) || got.After(after.Add(-tt.ago)) {
+					t.Errorf("parseSince(%q) = %v, want %v before now", tt.value, got, tt.ago)
+				}
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventsReadLog(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	logged := []api.Event{
+		{Type: "container", Action: "create", ID: "aaa", TimeNano: base.UnixNano()},
+		{Type: "container", Action: "start", ID: "aaa", TimeNano: base.Add(time.Minute).UnixNano()},
+		{Type: "container", Action: "start", ID: "bbb", TimeNano: base.Add(2 * time.Minute).UnixNano()},
+		{Type: "daemon", Action: "reload", TimeNano: base.Add(3 * time.Minute).UnixNano()},
+		{Type: "container", Action: "die", ID: "aaa", TimeNano: base.Add(4 * time.Minute).UnixNano()},
+	}
+
+	path := filepath.Join(t.TempDir(), eventLogName)
+	var data []byte
+	for i, event := range logged {
+		line, err := json.Marshal(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(append(data, line...), '\n')
+		if i == 2 {
+			// A line cut short by a crash is skipped
+			data = append(data, `{"type":"contai`+"\n"...)
+		}
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	e := &events{logPath: path}
+
+	tests := []struct {
+		name         string
+		since, until time.Time
+		filter       []string
+		want         []string // Actions of the events returned, in order
+	}{
+		{name: "all", want: []string{"create", "start", "start", "reload", "die"}},
+		{name: "since is inclusive", since: base.Add(time.Minute), want: []string{"start", "start", "reload", "die"}},
+		{name: "until is inclusive", until: base.Add(2 * time.Minute), want: []string{"create", "start", "start"}},
+		{name: "window", since: base.Add(time.Minute), until: base.Add(3 * time.Minute), want: []string{"start", "start", "reload"}},
+		{name: "empty window", since: base.Add(90 * time.Second), until: base.Add(100 * time.Second), want: nil},
+		{name: "since after the log", since: base.Add(time.Hour), want: nil},
+		{name: "filter", filter: []string{"container=a"}, want: []string{"create", "start", "die"}},
+		{name: "filter and window", since: base.Add(time.Minute), filter: []string{"event=start", "event=die", "container=a"}, want: []string{"start", "die"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := api.ParseEventFilter(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := e.readLog(tt.since, tt.until, filter)
+			if err != nil {
+				t.Fatalf("readLog failed: %v", err)
+			}
+			var got []string
+			for _, event := range events {
+				got = append(got, event.Action)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("readLog returned %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("readLog returned %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/config"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// runGC runs garbage collection passes on the configured interval until stop is closed
+// The policy is re-read before every pass so config reloads take effect without a restart
+func (d *Daemon) runGC(stop <-chan struct{}) {
+	for {
+		interval := time.Duration(d.currentConfig().GC.Interval)
+		if interval == 0 {
+			interval = config.DefaultGCInterval
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		d.CollectGarbage()
+	}
+}
+
+// CollectGarbage removes resources that fall outside the GC policy
+// Returns the number of containers removed and the bytes reclaimed
+func (d *Daemon) CollectGarbage() (int, int64) {
+	d.trimHistories()
+	d.trimEvents()
+
+	retention := time.Duration(d.currentConfig().GC.ExitedContainerRetention)
+	if retention == 0 {
+		return 0, 0
+	}
+
+	cutoff := time.Now().Add(-retention)
+
+	// Containers of a pod are removed with the pod, which can restart them until then
+	// Containers others depend on are kept too, or their dependents could no longer start
+	pods := d.podIDs()
+
+	// Collect candidates first so we don't hold the lock while deleting
+	d.mu.RLock()
+	var expired []string
+	for id, container := range d.containers {
+		if !pods[container.Labels[api.PodLabel]] && exitedBefore(container, cutoff) && !d.hasDependentsLocked(id) {
+			expired = append(expired, id)
+		}
+	}
+	d.mu.RUnlock()
+
+	removed := 0
+	var reclaimed int64
+	for _, id := range expired {
+		container, size, err := d.removeIfExpired(id, cutoff)
+		if err != nil {
+			fmt.Printf("GC: failed to remove container %s: %v\n", id, err)
+			continue
+		}
+		if container == nil {
+			continue
+		}
+		d.logEvent("destroy", id, eventAttributes(container, map[string]string{"reclaimed_bytes": strconv.FormatInt(size, 10)}))
+		removed++
+		reclaimed += size
+	}
+
+	if removed > 0 {
+		fmt.Printf("GC: removed %d exited container(s) older than %s, reclaimed %d bytes\n", removed, retention, reclaimed)
+		d.publishEvent(api.DaemonEventType, "gc", "", map[string]string{
+			"removed":         strconv.Itoa(removed),
+			"reclaimed_bytes": strconv.FormatInt(reclaimed, 10),
+		})
+	}
+
+	return removed, reclaimed
+}
+
+// exitedBefore reports whether a container has been exited since before cutoff
+// Containers saved before exit times were recorded fall back to their creation time
+func exitedBefore(container *state.ContainerState, cutoff time.Time) bool {
+	if container.Status != "exited" {
+		return false
+	}
+	exited := container.Exited
+	if exited.IsZero() {
+		exited = container.Created
+	}
+	return exited.Before(cutoff)
+}
+
+// removeIfExpired removes a GC candidate if it is still exited since before cutoff and nothing depends on it,
+// returning it and the bytes its state and log took up. The check and the removal happen under d.mu, so a
+// container restarted since it was picked isn't removed while running; nil is returned for one that no longer qualifies
+func (d *Daemon) removeIfExpired(id string, cutoff time.Time) (*state.ContainerState, int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	container, ok := d.containers[id]
+	if !ok || !exitedBefore(container, cutoff) || d.hasDependentsLocked(id) {
+		return nil, 0, nil
+	}
+
+	size := d.store.ContainerSize(id)
+	if info, err := os.Stat(d.logPath(id)); err == nil {
+		size += info.Size()
+	}
+
+	delete(d.containers, id)
+	if err := d.store.DeleteContainer(id); err != nil {
+		return nil, 0, fmt.Errorf("failed to delete container state: %v", err)
+	}
+	d.removeLog(id)
+	return container, size, nil
+}
+
+// trimHistories drops state transitions older than the history retention from every container
+func (d *Daemon) trimHistories() {
+	retention := time.Duration(d.currentConfig().GC.HistoryRetention)
+	if retention == 0 {
+		return
+	}
+
+	d.mu.RLock()
+	ids := make([]string, 0, len(d.containers))
+	for id := range d.containers {
+		ids = append(ids, id)
+	}
+	d.mu.RUnlock()
+
+	cutoff := time.Now().Add(-retention)
+	trimmed := 0
+	for _, id := range ids {
+		n, err := d.store.TrimHistory(id, cutoff)
+		if err != nil {
+			fmt.Printf("GC: failed to trim history of container %s: %v\n", id, err)
+			continue
+		}
+		trimmed += n
+	}
+
+	if trimmed > 0 {
+		fmt.Printf("GC: dropped %d state transition(s) older than %s\n", trimmed, retention)
+	}
+}
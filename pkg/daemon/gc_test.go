@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+func TestRemoveIfExpired(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := state.NewStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Daemon{dataDir: dataDir, store: store, containers: make(map[string]*state.ContainerState)}
+
+	now := time.Now()
+	cutoff := now.Add(-time.Hour)
+	containers := []*state.ContainerState{
+		{ID: "expired", Status: "exited", Exited: now.Add(-2 * time.Hour)},
+		{ID: "recent", Status: "exited", Exited: now.Add(-time.Minute)},
+		{ID: "restarted", Status: "running", Exited: now.Add(-2 * time.Hour)},
+		{ID: "legacy", Status: "exited", Created: now.Add(-2 * time.Hour)},
+		{ID: "dependency", Status: "exited", Exited: now.Add(-2 * time.Hour)},
+		{ID: "dependent", Status: "exited", Exited: now.Add(-time.Minute), ContainerConfig: state.ContainerConfig{
+			Labels: map[string]string{api.DependsOnLabel: "dependency"},
+		}},
+	}
+	for _, c := range containers {
+		d.containers[c.ID] = c
+		if err := store.SaveContainer(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dataDir, "logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(d.logPath("expired"), make([]byte, 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stateSize := store.ContainerSize("expired")
+
+	tests := []struct {
+		id          string
+		wantRemoved bool
+	}{
+		{id: "expired", wantRemoved: true},
+		{id: "recent"},
+		{id: "restarted"},
+		{id: "legacy", wantRemoved: true},
+		{id: "dependency"},
+		{id: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			removed, size, err := d.removeIfExpired(tt.id, cutoff)
+			if err != nil {
+				t.Fatalf("removeIfExpired failed: %v", err)
+			}
+			if got := removed != nil; got != tt.wantRemoved {
+				t.Fatalf("removeIfExpired removed = %v, want %v", got, tt.wantRemoved)
+			}
+			if _, known := d.containers[tt.id]; known == tt.wantRemoved && tt.id != "missing" {
+				t.Errorf("container still known = %v after removeIfExpired", known)
+			}
+			if tt.id == "expired" && size != stateSize+1000 {
+				t.Errorf("reclaimed %d bytes, want %d for the state and log", size, stateSize+1000)
+			}
+		})
+	}
+}
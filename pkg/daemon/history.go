@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// daemonActor is recorded for transitions the daemon makes on its own, such as a container exiting
+const daemonActor = "daemon"
+
+// transitionCause says who asked for a status change and why
+type transitionCause struct {
+	actor  string
+	reason string
+}
+
+// setStatus changes a container's status and appends the transition to its history
+// The caller still has to persist the state itself
+func (d *Daemon) setStatus(containerState *state.ContainerState, status string, cause transitionCause) {
+	from := containerState.Status
+	containerState.Status = status
+
+	t := state.Transition{From: from, To: status, Reason: cause.reason, Actor: cause.actor, Time: time.Now()}
+	if err := d.store.AppendHistory(containerState.ID, t); err != nil {
+		fmt.Printf("Warning: failed to record history for container %s: %v\n", containerState.ID, err)
+	}
+}
+
+// setStopCause remembers why a container is being stopped until its exit is recorded
+func (d *Daemon) setStopCause(id string, cause transitionCause) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stopCauses[id] = cause
+}
+
+// takeStopCause returns and forgets the cause of a requested stop, if there was one
+func (d *Daemon) takeStopCause(id string) (transitionCause, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cause, ok := d.stopCauses[id]
+	delete(d.stopCauses, id)
+	return cause, ok
+}
+
+// connContextKey is the context key under which the server stores each request's connection
+type connContextKey struct{}
+
+// saveConn makes the underlying connection available to handlers via the request context
+func saveConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// peer identifies the API client behind a request by the credentials of its Unix socket peer
+type peer struct {
+	known bool // False when the credentials couldn't be read
+	uid   uint32
+	pid   int32
+
+	via string // Set when the daemon acts on the client's behalf, e.g. "schedule <id>"
+}
+
+// String formats the peer the way it is recorded in container histories
+func (p peer) String() string {
+	if p.via != "" {
+		if !p.known {
+			return p.via
+		}
+		return fmt.Sprintf("%s (uid=%d)", p.via, p.uid)
+	}
+	if !p.known {
+		return "unknown"
+	}
+	return fmt.Sprintf("uid=%d pid=%d", p.uid, p.pid)
+}
+
+// requestPeer returns the client behind a request
+func requestPeer(r *http.Request) peer {
+	conn, ok := r.Context().Value(connContextKey{}).(*net.UnixConn)
+	if !ok {
+		return peer{}
+	}
+
+	return socketPeer(conn)
+}
+
+// requestActor identifies the client behind a request for container histories
+func requestActor(r *http.Request) string {
+	return requestPeer(r).String()
+}
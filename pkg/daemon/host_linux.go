@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// checkHost reports whether the daemon can run containers on this platform
+func checkHost() error {
+	return nil
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
+// socketPeer returns the client on the other end of a unix socket from its SO_PEERCRED credentials
+func socketPeer(conn *net.UnixConn) peer {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return peer{}
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if credErr != nil || cred == nil {
+		return peer{}
+	}
+	return peer{known: true, uid: cred.Uid, pid: cred.Pid}
+}
+
+// lockFile takes an exclusive flock on file without blocking, returning errLocked if another process holds it
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return errLocked
+	}
+	return err
+}
+
+// unmountDetached lazily unmounts path, even if it is still busy
+func unmountDetached(path string) error {
+	return syscall.Unmount(path, syscall.MNT_DETACH)
+}
+
+// kernelVersion returns the host kernel release (uname -r)
+func kernelVersion() string {
+	var uts syscall.Utsname
+	if err := syscall.Uname(&uts); err != nil {
+		return "unknown"
+	}
+
+	buf := make([]byte, 0, len(uts.Release))
+	for _, c := range uts.Release {
+		if c == 0 {
+			break
+		}
+		buf = append(buf, byte(c))
+	}
+	return string(buf)
+}
@@ -0,0 +1,44 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+)
+
+// errUnsupported is returned for everything the daemon needs Linux for
+var errUnsupported = fmt.Errorf("%w on %s: mydockerd needs Linux namespaces and cgroups", errors.ErrUnsupported, runtime.GOOS)
+
+// checkHost always fails; only the client runs outside Linux
+func checkHost() error {
+	return errUnsupported
+}
+
+// processAlive always reports false; no containers run outside Linux
+func processAlive(pid int) bool {
+	return false
+}
+
+// socketPeer can't identify clients outside Linux
+func socketPeer(conn *net.UnixConn) peer {
+	return peer{}
+}
+
+// lockFile always fails outside Linux
+func lockFile(file *os.File) error {
+	return errUnsupported
+}
+
+// unmountDetached always fails outside Linux
+func unmountDetached(path string) error {
+	return errUnsupported
+}
+
+// kernelVersion is unknown outside Linux
+func kernelVersion() string {
+	return "unknown"
+}
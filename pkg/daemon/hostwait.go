@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// validUnitName matches systemd unit names, which must carry a type suffix such as .mount or .service
+var validUnitName = regexp.MustCompile(`^[a-zA-Z0-9:_.\\@-]+\.[a-z]+$`)
+
+// validateWaitConditions checks the host resources a container asks to wait for
+func validateWaitConditions(conditions *api.WaitConditions) error {
+	if conditions == nil {
+		return nil
+	}
+	for _, path := range conditions.Paths {
+		if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+			return fmt.Errorf("wait path must be a clean absolute path: %q", path)
+		}
+	}
+	for _, unit := range conditions.Units {
+		if !validUnitName.MatchString(unit) {
+			return fmt.Errorf("invalid systemd unit name %q: expected a name with a type suffix such as nfs.mount", unit)
+		}
+	}
+	if conditions.Timeout < 0 {
+		return fmt.Errorf("wait timeout cannot be negative")
+	}
+	return nil
+}
+
+// unmetWaitConditions returns the first host resource still missing, or "" once all of them exist
+// Units are checked with systemctl, so this shouldn't be called with locks held
+func unmetWaitConditions(conditions *api.WaitConditions) string {
+	if conditions == nil {
+		return ""
+	}
+	for _, path := range conditions.Paths {
+		if _, err := os.Stat(path); err != nil {
+			return "waiting for path " + path
+		}
+	}
+	for _, unit := range conditions.Units {
+		if exec.Command("systemctl", "is-active", "--quiet", unit).Run() != nil {
+			return "waiting for unit " + unit
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+	"github.com/AbhishekGY/mydocker/pkg/udev"
+)
+
+// hotplugDeviceMode is the mode of device nodes created for devices the host has no node for yet
+const hotplugDeviceMode = 0660
+
+// validateDeviceHotplug checks that hotplug patterns are clean absolute globs under /dev
+func validateDeviceHotplug(patterns []string) error {
+	for _, pattern := range patterns {
+		if !strings.HasPrefix(pattern, "/dev/") || filepath.Clean(pattern) != pattern {
+			return fmt.Errorf("device hotplug pattern must be a clean path under /dev: %q", pattern)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid device hotplug pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesHotplug reports whether a host device path matches one of the patterns
+func matchesHotplug(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// monitorDeviceHotplug mirrors host devices matching the container's hotplug patterns into its /dev while it
+// runs: matching devices present at start are added, then nodes follow the kernel's add and remove events
+// Containers run without a device cgroup policy, so the nodes are usable without any rule changes
+func (d *Daemon) monitorDeviceHotplug(id string, runner *container.Runner, patterns []string) {
+	monitor, err := udev.Listen()
+	if err != nil {
+		fmt.Printf("Warning: device hotplug for container %s is disabled: %v\n", id, err)
+		return
+	}
+	defer monitor.Close()
+
+	quit := make(chan struct{})
+	defer close(quit)
+	events := make(chan *udev.Event)
+	go func() {
+		defer close(events)
+		for {
+			event, err := monitor.Receive()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	// Devices already plugged in are added after listening starts, so none is missed in between
+	for _, pattern := range patterns {
+		paths, _ := filepath.Glob(pattern)
+		for _, path := range paths {
+			dev, err := namespace.HostDevice(path)
+			if err != nil {
+				continue
+			}
+			d.hotplugDevice(id, runner, "add", dev)
+		}
+	}
+
+	for {
+		select {
+		case <-runner.Exited():
+			return
+		case <-d.stopCh:
+			return
+		case event, ok := <-events:
+			if !ok {
+				fmt.Printf("Warning: device hotplug for container %s stopped: uevent monitor closed\n", id)
+				return
+			}
+			if event.DevName == "" || !matchesHotplug(patterns, event.DevName) {
+				continue
+			}
+			if event.Action != "add" && event.Action != "remove" {
+				continue
+			}
+
+			dev, err := namespace.HostDevice(event.DevName)
+			if err != nil || event.Action == "remove" {
+				// The host node is already gone on removal, and may not be made yet on addition
+				dev = namespace.Device{
+					Path:  event.DevName,
+					Block: event.Block(),
+					Major: event.Major,
+					Minor: event.Minor,
+					Mode:  hotplugDeviceMode,
+				}
+			}
+			d.hotplugDevice(id, runner, event.Action, dev)
+		}
+	}
+}
+
+// hotplugDevice adds or removes a device node in a running container and records a "device" event
+func (d *Daemon) hotplugDevice(id string, runner *container.Runner, action string, dev namespace.Device) {
+	var err error
+	if action == "add" {
+		err = namespace.AddDevice(runner.PID(), dev)
+	} else {
+		err = namespace.RemoveDevice(runner.PID(), dev)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to %s device %s in container %s: %v\n", action, dev.Path, id, err)
+		return
+	}
+
+	d.debugf("Container %s: %s device %s (%d:%d)\n", id, action, dev.Path, dev.Major, dev.Minor)
+	d.logEvent("device", id, map[string]string{
+		"action": action,
+		"path":   dev.Path,
+		"device": fmt.Sprintf("%d:%d", dev.Major, dev.Minor),
+	})
+}
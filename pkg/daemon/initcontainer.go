@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// isInitContainer reports whether a container is an init container, which its dependents wait on to run to completion
+func isInitContainer(cs *state.ContainerState) bool {
+	return cs.Labels[api.InitLabel] == "true"
+}
+
+// runInitContainer runs an init container to completion on behalf of cause, returning whether it ran
+// One that exited with code 0 after its own dependencies last started isn't run again, so the containers sharing
+// it run it once per bring-up; one that is running is waited for. A non-zero exit code is returned as an error
+func (d *Daemon) runInitContainer(ctx context.Context, id string, cause transitionCause) (bool, error) {
+	d.mu.RLock()
+	c, ok := d.containers[id]
+	if !ok {
+		d.mu.RUnlock()
+		return false, errContainerNotFound(id)
+	}
+	status := c.Status
+	completed := status == "exited" && c.ExitCode != nil && *c.ExitCode == 0
+	for _, dep := range containerDeps(c) {
+		if depState, ok := d.containers[dep]; ok && depState.Started.After(c.Exited) {
+			completed = false
+		}
+	}
+	d.mu.RUnlock()
+	if completed {
+		return false, nil
+	}
+
+	var runner *container.Runner
+	var err error
+	switch status {
+	case "running":
+		runner, err = d.getRunner(id)
+	case "queued":
+		err = errConflict(api.ErrCodeContainerNotRunning, "init container %s is waiting in the admission queue", id)
+	default:
+		fmt.Printf("Running init container %s (%s)\n", id, cause.reason)
+		runner, err = d.StartContainerWithRunner(ctx, id, true, cause)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to run init container %s: %v", id, err)
+	}
+
+	if code := container.ExitCode(runner.Wait()); code != 0 {
+		return true, fmt.Errorf("init container %s exited with code %d", id, code)
+	}
+	return true, nil
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// listFields are the ContainerInfo fields that can be selected with the fields parameter
+var listFields = []string{"id", "image", "command", "status", "created", "pid", "labels", "size_root_fs"}
+
+// listOptions controls which containers ListContainersPage returns and what it fills in
+type listOptions struct {
+	limit  int             // Maximum number of containers to return, 0 for all
+	offset int             // Number of containers to skip after sorting
+	oldest bool            // Sort oldest first instead of newest first
+	size   bool            // Compute rootfs sizes, which can be slow
+	fields map[string]bool // Fields to return, nil for all
+}
+
+// parseListOptions reads the limit, offset, sort, size and fields query parameters of a list request
+func parseListOptions(query url.Values) (listOptions, error) {
+	opts := listOptions{size: query.Get("size") == "true"}
+
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{{"limit", &opts.limit}, {"offset", &opts.offset}} {
+		s := query.Get(p.name)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("%s must be a non-negative integer, got %q", p.name, s)
+		}
+		*p.value = n
+	}
+
+	switch query.Get("sort") {
+	case "", "-created":
+	case "created":
+		opts.oldest = true
+	default:
+		return opts, fmt.Errorf("sort must be \"created\" or \"-created\", got %q", query.Get("sort"))
+	}
+
+	if s := query.Get("fields"); s != "" {
+		// The ID is always returned so results can be acted on
+		opts.fields = map[string]bool{"id": true}
+		for _, field := range strings.Split(s, ",") {
+			if !slices.Contains(listFields, field) {
+				return opts, fmt.Errorf("unknown field %q (valid fields: %s)", field, strings.Join(listFields, ", "))
+			}
+			opts.fields[field] = true
+		}
+		// Selecting the size asks for it to be computed; leaving it out skips the rootfs walk
+		opts.size = opts.fields["size_root_fs"]
+	}
+
+	return opts, nil
+}
+
+// ListContainersPage returns one page of containers sorted by creation time, and the total number of containers
+// Sizes are only computed for the containers on the page
+func (d *Daemon) ListContainersPage(opts listOptions) ([]api.ContainerInfo, int) {
+	containers := d.ListContainers()
+
+	// Ties are broken by ID so pages stay stable between requests
+	sort.Slice(containers, func(i, j int) bool {
+		a, b := containers[i], containers[j]
+		if a.Created != b.Created {
+			return (a.Created < b.Created) == opts.oldest
+		}
+		return a.ID < b.ID
+	})
+
+	total := len(containers)
+	start := min(opts.offset, total)
+	end := total
+	if opts.limit > 0 {
+		end = min(start+opts.limit, total)
+	}
+	page := containers[start:end]
+
+	if opts.size {
+		for i := range page {
+			page[i].SizeRootFs = d.sizes.rootfsSize(page[i].Image)
+		}
+	}
+	return page, total
+}
+
+// selectFields encodes containers with only the given JSON fields
+func selectFields(containers []api.ContainerInfo, fields map[string]bool) ([]map[string]json.RawMessage, error) {
+	selected := make([]map[string]json.RawMessage, 0, len(containers))
+	for _, container := range containers {
+		data, err := json.Marshal(container)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode container: %v", err)
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, fmt.Errorf("failed to encode container: %v", err)
+		}
+		for key := range all {
+			if !fields[key] {
+				delete(all, key)
+			}
+		}
+		selected = append(selected, all)
+	}
+	return selected, nil
+}
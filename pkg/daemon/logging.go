@@ -0,0 +1,240 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/logger"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// openLogDriver starts the log driver selected by a container's LogConfig
+func openLogDriver(c *state.ContainerState) (logger.Driver, error) {
+	info := logger.Info{ID: c.ID, Command: c.Command, Created: c.Created, Labels: c.Labels}
+	driver, err := logger.New(c.LogConfig.Driver, c.LogConfig.Options, info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start log driver %s: %v", c.LogConfig.Driver, err)
+	}
+	return driver, nil
+}
+
+// closeLogDriver flushes and closes a container's log driver
+func closeLogDriver(id string, driver logger.Driver) {
+	if err := driver.Close(); err != nil {
+		fmt.Printf("Warning: failed to close log driver of container %s: %v\n", id, err)
+	}
+}
+
+// addLogDriver registers the log driver of a running container
+func (d *Daemon) addLogDriver(id string, driver logger.Driver) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.logDrivers[id] = driver
+}
+
+// removeLogDriver forgets the log driver of an exited container
+func (d *Daemon) removeLogDriver(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.logDrivers, id)
+}
+
+// logStats reports how a running container's output is being shipped, zero without a log driver
+func (d *Daemon) logStats(id string) api.LogStats {
+	d.mu.RLock()
+	driver := d.logDrivers[id]
+	d.mu.RUnlock()
+
+	if driver == nil {
+		return api.LogStats{}
+	}
+	return api.LogStats{DroppedLines: logger.DroppedLines(driver)}
+}
+
+// outputBroadcaster passes all of a container's output through the log subsystem: every line goes to the
+// container's local store and log driver, and every chunk to the clients following it at the time
+// Detached containers write their output into it. For attached containers it reads the PTY or stdio pipes,
+// starting with the first client so output written before it arrives still reaches it, and then continues
+// until the container's output ends, so detaching leaves no output unread and unlogged
+type outputBroadcaster struct {
+	id     string
+	runner *container.Runner
+	local  *logger.Local // Followed by "logs --follow"
+	sink   logger.Driver // The local store, teed to the log driver if there is one
+
+	stdout, stderr *logger.Writer
+
+	startOnce sync.Once
+	done      chan struct{} // Closed once all output has been read and logged
+
+	mu      sync.Mutex
+	clients map[*outputClient]bool
+}
+
+// outputClient is a client following a container's output
+// A slow client holds up the others and the log subsystem, just as it held up the container before
+type outputClient struct {
+	write func(kind byte, p []byte) error
+	gone  chan struct{} // Closed once a write to the client has failed
+}
+
+// newOutputBroadcaster returns the broadcaster for a runner about to start, logging to sink
+// A detached runner is set up to write its output into the broadcaster
+func newOutputBroadcaster(id string, runner *container.Runner, local *logger.Local, sink logger.Driver) *outputBroadcaster {
+	b := &outputBroadcaster{
+		id:      id,
+		runner:  runner,
+		local:   local,
+		sink:    sink,
+		stdout:  logger.NewWriter(sink, "stdout"),
+		stderr:  logger.NewWriter(sink, "stderr"),
+		done:    make(chan struct{}),
+		clients: make(map[*outputClient]bool),
+	}
+	if runner.Detach {
+		runner.Stdio = &container.Stdio{Stdout: streamWriter{b, api.StreamStdout}, Stderr: streamWriter{b, api.StreamStderr}}
+	}
+	return b
+}
+
+// streamWriter is one of a detached container's output streams
+type streamWriter struct {
+	b    *outputBroadcaster
+	kind byte
+}
+
+// Write logs and broadcasts the output; it never fails, so the container never sees its output go away
+func (w streamWriter) Write(p []byte) (int, error) {
+	w.b.write(w.kind, p)
+	return len(p), nil
+}
+
+// start begins reading the container's output once it has started; later calls do nothing
+func (b *outputBroadcaster) start() {
+	b.startOnce.Do(func() {
+		// The runner has copied all of a detached container's output by the time it reports the exit
+		if b.runner.Stdio != nil {
+			go func() {
+				<-b.runner.Exited()
+				b.finish()
+			}()
+			return
+		}
+
+		var wg sync.WaitGroup
+		pump := func(kind byte, src *os.File) {
+			defer wg.Done()
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := src.Read(buf)
+				if n > 0 {
+					b.write(kind, buf[:n])
+				}
+				if err != nil {
+					return
+				}
+			}
+		}
+
+		// A PTY merges stderr into stdout
+		if pty := b.runner.GetPtyFile(); pty != nil {
+			wg.Add(1)
+			go pump(api.StreamStdout, pty)
+		} else if b.runner.Stdout != nil {
+			wg.Add(2)
+			go pump(api.StreamStdout, b.runner.Stdout)
+			go pump(api.StreamStderr, b.runner.Stderr)
+		}
+
+		go func() {
+			wg.Wait()
+			b.finish()
+		}()
+	})
+}
+
+// write logs a chunk of one of the container's streams and passes it on to the clients
+func (b *outputBroadcaster) write(kind byte, p []byte) {
+	if kind == api.StreamStderr {
+		b.stderr.Write(p)
+	} else {
+		b.stdout.Write(p)
+	}
+	b.broadcast(kind, p)
+}
+
+// finish logs what is left after the last newlines and closes the log subsystem once the output has ended
+func (b *outputBroadcaster) finish() {
+	b.stdout.Close()
+	b.stderr.Close()
+	closeLogDriver(b.id, b.sink)
+	close(b.done)
+}
+
+// broadcast sends a chunk of output to every client, dropping clients that can't take it
+func (b *outputBroadcaster) broadcast(kind byte, p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for client := range b.clients {
+		if err := client.write(kind, p); err != nil {
+			delete(b.clients, client)
+			close(client.gone)
+		}
+	}
+}
+
+// follow sends the container's output to write until the output ends, returning nil, or a write fails
+func (b *outputBroadcaster) follow(write func(kind byte, p []byte) error) error {
+	client := &outputClient{write: write, gone: make(chan struct{})}
+	b.mu.Lock()
+	b.clients[client] = true
+	b.mu.Unlock()
+	b.start()
+
+	select {
+	case <-b.done:
+		b.mu.Lock()
+		delete(b.clients, client)
+		b.mu.Unlock()
+		return nil
+	case <-client.gone:
+		return fmt.Errorf("client stopped reading the output of container %s", b.id)
+	}
+}
+
+// addOutput registers the broadcaster of a running container
+func (d *Daemon) addOutput(b *outputBroadcaster) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.outputs[b.id] = b
+}
+
+// drainOutput reads what is left of an exited container's output into its local store and log driver, even if
+// no client ever followed it, and forgets the broadcaster; it must run before the runner's PTY or pipes are closed
+func (d *Daemon) drainOutput(id string) {
+	b := d.getOutput(id)
+	if b == nil {
+		return
+	}
+	b.start()
+	<-b.done
+
+	d.mu.Lock()
+	delete(d.outputs, id)
+	d.mu.Unlock()
+}
+
+// getOutput returns the broadcaster of a running container, nil once its output has ended
+func (d *Daemon) getOutput(id string) *outputBroadcaster {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.outputs[id]
+}
@@ -0,0 +1,233 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/logger"
+)
+
+// logPath returns the file a container's output is kept in, read back by the logs endpoint
+func (d *Daemon) logPath(id string) string {
+	return filepath.Join(d.dataDir, "logs", id+".log")
+}
+
+// removeLog deletes the stored output of a removed container
+func (d *Daemon) removeLog(id string) {
+	if err := os.Remove(d.logPath(id)); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: failed to remove log of container %s: %v\n", id, err)
+	}
+}
+
+// logsOptions selects the stored output a logs request returns
+type logsOptions struct {
+	tail           int // Last lines to return, -1 for all of them
+	since, until   time.Time
+	stdout, stderr bool
+	follow         bool // Keep sending lines as they are written until the container's output ends
+}
+
+// parseLogsOptions reads the tail, since, until, stdout, stderr and follow query parameters of a logs request
+// Both streams are returned unless one of them is selected
+func parseLogsOptions(query url.Values) (logsOptions, error) {
+	opts := logsOptions{tail: -1, stdout: true, stderr: true}
+
+	if value := query.Get("follow"); value != "" {
+		follow, err := strconv.ParseBool(value)
+		if err != nil {
+			return logsOptions{}, fmt.Errorf("invalid follow value %q", value)
+		}
+		opts.follow = follow
+	}
+
+	if value := query.Get("tail"); value != "" && value != "all" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return logsOptions{}, fmt.Errorf("invalid tail %q, expected a number of lines or \"all\"", value)
+		}
+		opts.tail = n
+	}
+
+	for _, p := range []struct {
+		name string
+		dest *time.Time
+	}{{"since", &opts.since}, {"until", &opts.until}} {
+		if value := query.Get(p.name); value != "" {
+			t, err := parseSince(value)
+			if err != nil {
+				return logsOptions{}, fmt.Errorf("invalid %s: %v", p.name, err)
+			}
+			*p.dest = t
+		}
+	}
+
+	if query.Has("stdout") || query.Has("stderr") {
+		opts.stdout, opts.stderr = false, false
+		for name, selected := range map[string]*bool{"stdout": &opts.stdout, "stderr": &opts.stderr} {
+			value := query.Get(name)
+			if value == "" {
+				continue
+			}
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return logsOptions{}, fmt.Errorf("invalid %s value %q", name, value)
+			}
+			*selected = b
+		}
+	}
+	return opts, nil
+}
+
+// match reports whether a stored line is selected
+func (o logsOptions) match(entry logger.Entry) bool {
+	if entry.Stream == "stderr" && !o.stderr || entry.Stream != "stderr" && !o.stdout {
+		return false
+	}
+	if !o.since.IsZero() && entry.Timestamp.Before(o.since) {
+		return false
+	}
+	return o.until.IsZero() || !entry.Timestamp.After(o.until)
+}
+
+// ContainerLogs calls fn with the selected lines of a container's stored output, oldest first
+// When following a running container, lines written later are passed on until its output ends or ctx is done
+func (d *Daemon) ContainerLogs(ctx context.Context, ref string, opts logsOptions, fn func(api.LogEntry) error) error {
+	id, err := d.resolveID(ref)
+	if err != nil {
+		return err
+	}
+
+	// Followers subscribe before the file is read, so every line is either in the part read or sent to them
+	limit := int64(-1)
+	var entries <-chan logger.Entry
+	if output := d.getOutput(id); opts.follow && output != nil {
+		var stop func()
+		limit, entries, stop = output.local.Follow()
+		defer stop()
+	}
+
+	var tail []api.LogEntry
+	err = logger.ReadLocal(d.logPath(id), limit, func(entry logger.Entry) error {
+		if !opts.match(entry) {
+			return nil
+		}
+		line := api.LogEntry{Time: entry.Timestamp, Stream: entry.Stream, Line: entry.Line}
+		if opts.tail < 0 {
+			return fn(line)
+		}
+		if tail = append(tail, line); len(tail) > opts.tail {
+			tail = tail[1:]
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, line := range tail {
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if entries == nil {
+		return nil
+	}
+
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return nil
+			}
+			if !opts.match(entry) {
+				continue
+			}
+			if err := fn(api.LogEntry{Time: entry.Timestamp, Stream: entry.Stream, Line: entry.Line}); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// handleContainerLogs streams a container's stored output as JSON-encoded api.LogEntry values, one per line
+// WebSocket clients get each entry as a text message instead
+func (d *Daemon) handleContainerLogs(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseLogsOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, errInvalidRequest(err))
+		return
+	}
+	id, err := d.resolveID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if isWebSocketUpgrade(r) {
+		d.streamLogsWebSocket(w, r, id, opts)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	send := func(line api.LogEntry) error {
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+		// Followers see each line as it is written
+		if opts.follow && flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	if opts.follow && flusher != nil {
+		flusher.Flush()
+	}
+	if err := d.ContainerLogs(r.Context(), id, opts, send); err != nil {
+		// Once lines have been sent the status can't change; the client sees the stream end early
+		d.debugf("Failed to send log of container %s: %v\n", id, err)
+	}
+}
+
+// streamLogsWebSocket sends a container's stored output over a WebSocket, one JSON-encoded api.LogEntry per text message
+// A follow ends when the container's output does or when the client closes the connection
+func (d *Daemon) streamLogsWebSocket(w http.ResponseWriter, r *http.Request, id string, opts logsOptions) {
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		fmt.Printf("Failed to upgrade connection for container %s: %v\n", id, err)
+		return
+	}
+	defer ws.Close()
+
+	// Reading answers the client's pings and sees its close, which the hijacked request's context wouldn't
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		io.Copy(io.Discard, ws)
+		cancel()
+	}()
+
+	send := func(line api.LogEntry) error {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		return ws.writeFrame(wsOpText, data)
+	}
+	if err := d.ContainerLogs(ctx, id, opts, send); err != nil {
+		d.debugf("Failed to send log of container %s: %v\n", id, err)
+	}
+}
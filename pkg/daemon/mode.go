@@ -0,0 +1,206 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/version"
+)
+
+// apiMode is the daemon's current API mode; the zero value is the normal mode
+type apiMode struct {
+	mu     sync.RWMutex
+	name   string
+	reason string
+	since  time.Time
+}
+
+// maintenanceRejected are the requests that create or start containers, rejected in maintenance mode
+// Patterns are matched against the request path with path.Match
+var maintenanceRejected = []struct {
+	method  string
+	pattern string
+}{
+	{http.MethodPost, "/containers/create"},
+	{http.MethodPost, "/containers/start"},
+	{http.MethodPost, "/containers/adopt"},
+	{http.MethodPost, "/containers/*/clone"},
+	{http.MethodPost, "/schedules"},
+	{http.MethodPost, "/schedules/*/enable"},
+	{http.MethodPost, "/services"},
+	{http.MethodPost, "/services/*/scale"},
+	{http.MethodPost, "/services/*/update"},
+	{http.MethodPost, "/pods"},
+	{http.MethodPost, "/pods/*/containers"},
+	{http.MethodPost, "/pods/*/start"},
+}
+
+// validMode reports whether name is one of the API modes
+func validMode(name string) bool {
+	return name == api.ModeNormal || name == api.ModeReadOnly || name == api.ModeMaintenance
+}
+
+// SetMode switches the API mode, publishing an event when it changes
+// reason is reported to the clients whose requests the mode rejects
+func (d *Daemon) SetMode(name, reason, actor string) error {
+	if !validMode(name) {
+		return errInvalidRequest(fmt.Errorf("unknown mode %q (must be %s, %s or %s)", name, api.ModeNormal, api.ModeReadOnly, api.ModeMaintenance))
+	}
+	if name == api.ModeNormal {
+		reason = ""
+	}
+
+	d.mode.mu.Lock()
+	old := d.currentModeLocked()
+	d.mode.name, d.mode.reason = name, reason
+	if name != old {
+		d.mode.since = time.Now()
+	}
+	d.mode.mu.Unlock()
+
+	if name != old {
+		fmt.Printf("API mode changed from %s to %s\n", old, name)
+		attrs := map[string]string{"mode": name, "previous": old}
+		if reason != "" {
+			attrs["reason"] = reason
+		}
+		if actor != "" {
+			attrs["actor"] = actor
+		}
+		d.publishEvent(api.DaemonEventType, "mode", "", attrs)
+	}
+	return nil
+}
+
+// currentModeLocked returns the name of the API mode; d.mode.mu must be held
+func (d *Daemon) currentModeLocked() string {
+	if d.mode.name == "" {
+		return api.ModeNormal
+	}
+	return d.mode.name
+}
+
+// currentMode returns the name of the API mode and why it was set
+func (d *Daemon) currentMode() (string, string) {
+	d.mode.mu.RLock()
+	defer d.mode.mu.RUnlock()
+	return d.currentModeLocked(), d.mode.reason
+}
+
+// acceptingContainers reports whether background jobs may create and start containers
+// Schedules and services are paused outside the normal mode, like the API requests that would do the same
+func (d *Daemon) acceptingContainers() bool {
+	mode, _ := d.currentMode()
+	return mode == api.ModeNormal
+}
+
+// modeAllows reports whether the API mode accepts r
+// Switching the mode and reading the daemon's information are always accepted, so a restricted daemon can be restored
+func modeAllows(mode string, r *http.Request) bool {
+	if r.URL.Path == "/system/mode" || r.URL.Path == "/system/info" {
+		return true
+	}
+
+	switch mode {
+	case api.ModeReadOnly:
+		// Attaching writes to the container's stdin, even over a GET upgrade
+		readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+		return readOnly && r.URL.Path != "/containers/attach"
+	case api.ModeMaintenance:
+		for _, rule := range maintenanceRejected {
+			if matched, _ := path.Match(rule.pattern, r.URL.Path); matched && r.Method == rule.method {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// enforceMode rejects the requests the current API mode doesn't accept
+func (d *Daemon) enforceMode(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mode, reason := d.currentMode()
+		if !modeAllows(mode, r) {
+			msg := fmt.Sprintf("daemon is in %s mode", mode)
+			if reason != "" {
+				msg += fmt.Sprintf(" (%s)", reason)
+			}
+			writeError(w, &apiError{
+				status: http.StatusServiceUnavailable,
+				code:   api.ErrCodeModeRestricted,
+				err:    fmt.Errorf("%s: %s %s is not accepted", msg, r.Method, r.URL.Path),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Info summarizes the daemon's mode and the objects it manages
+func (d *Daemon) Info() api.SystemInfo {
+	info := api.SystemInfo{
+		ServerVersion: version.Version,
+		DataDir:       d.dataDir,
+		CgroupVersion: strconv.Itoa(int(d.cgroupVersion)),
+		Debug:         d.debug,
+	}
+
+	d.mode.mu.RLock()
+	info.Mode, info.ModeReason, info.ModeSince = d.currentModeLocked(), d.mode.reason, d.mode.since
+	d.mode.mu.RUnlock()
+
+	d.mu.RLock()
+	for _, c := range d.containers {
+		info.Containers++
+		switch c.Status {
+		case "running":
+			info.ContainersRunning++
+		case "queued":
+			info.ContainersQueued++
+		default:
+			info.ContainersStopped++
+		}
+	}
+	d.mu.RUnlock()
+
+	d.pods.mu.Lock()
+	info.Pods = len(d.pods.byID)
+	d.pods.mu.Unlock()
+	d.services.mu.Lock()
+	info.Services = len(d.services.byID)
+	d.services.mu.Unlock()
+	d.schedules.mu.Lock()
+	info.Schedules = len(d.schedules.byID)
+	d.schedules.mu.Unlock()
+
+	return info
+}
+
+// handleSystemInfo handles daemon information requests
+func (d *Daemon) handleSystemInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.Info())
+}
+
+// handleSystemMode handles requests to switch the API mode
+func (d *Daemon) handleSystemMode(w http.ResponseWriter, r *http.Request) {
+	var req api.SystemModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	if err := d.SetMode(req.Mode, req.Reason, requestActor(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.Info())
+}
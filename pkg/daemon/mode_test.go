@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+func TestModeAllows(t *testing.T) {
+	tests := []struct {
+		method          string
+		path            string
+		wantReadOnly    bool
+		wantMaintenance bool
+	}{
+		{method: http.MethodGet, path: "/containers/list", wantReadOnly: true, wantMaintenance: true},
+		{method: http.MethodGet, path: "/containers/abc/logs", wantReadOnly: true, wantMaintenance: true},
+		{method: http.MethodHead, path: "/version", wantReadOnly: true, wantMaintenance: true},
+		{method: http.MethodGet, path: "/containers/attach", wantReadOnly: false, wantMaintenance: true},
+		{method: http.MethodPost, path: "/containers/create", wantReadOnly: false, wantMaintenance: false},
+		{method: http.MethodPost, path: "/containers/start", wantReadOnly: false, wantMaintenance: false},
+		{method: http.MethodPost, path: "/containers/adopt", wantReadOnly: false, wantMaintenance: false},
+		{method: http.MethodPost, path: "/containers/abc/clone", wantReadOnly: false, wantMaintenance: false},
+		{method: http.MethodPost, path: "/containers/stop", wantReadOnly: false, wantMaintenance: true},
+		{method: http.MethodPost, path: "/schedules", wantReadOnly: false, wantMaintenance: false},
+		{method: http.MethodPost, path: "/schedules/nightly/enable", wantReadOnly: false, wantMaintenance: false},
+		{method: http.MethodPost, path: "/schedules/nightly/disable", wantReadOnly: false, wantMaintenance: true},
+		{method: http.MethodDelete, path: "/schedules/nightly", wantReadOnly: false, wantMaintenance: true},
+		{method: http.MethodPost, path: "/services/web/scale", wantReadOnly: false, wantMaintenance: false},
+		{method: http.MethodDelete, path: "/services/web", wantReadOnly: false, wantMaintenance: true},
+		{method: http.MethodPost, path: "/pods/p1/start", wantReadOnly: false, wantMaintenance: false},
+		{method: http.MethodPost, path: "/pods/p1/stop", wantReadOnly: false, wantMaintenance: true},
+		{method: http.MethodPost, path: "/system/drain", wantReadOnly: false, wantMaintenance: true},
+		// The mode can always be switched back and inspected
+		{method: http.MethodPost, path: "/system/mode", wantReadOnly: true, wantMaintenance: true},
+		{method: http.MethodGet, path: "/system/info", wantReadOnly: true, wantMaintenance: true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		for mode, want := range map[string]bool{
+			"":                  true, // Unset is the normal mode
+			api.ModeNormal:      true,
+			api.ModeReadOnly:    tt.wantReadOnly,
+			api.ModeMaintenance: tt.wantMaintenance,
+		} {
+			if got := modeAllows(mode, r); got != want {
+				t.Errorf("modeAllows(%q, %s %s) = %v, want %v", mode, tt.method, tt.path, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// pidFileName is the lock/pid file created inside the data directory
+const pidFileName = "mydockerd.pid"
+
+// errLocked is returned by lockFile when another process holds the lock
+var errLocked = errors.New("file is locked by another process")
+
+// pidFile holds an exclusive flock on the data directory's pid file
+// The kernel drops the lock when the process exits, so a crashed daemon never blocks a restart
+type pidFile struct {
+	path string
+	file *os.File
+}
+
+// acquirePidFile locks the pid file in dataDir and records the current PID in it
+// Returns an error if another daemon instance holds the lock
+func acquirePidFile(dataDir string) (*pidFile, error) {
+	path := filepath.Join(dataDir, pidFileName)
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pid file: %v", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if err == errLocked {
+			if pid := readPid(path); pid > 0 {
+				return nil, fmt.Errorf("another mydockerd (PID %d) is already using data directory %s", pid, dataDir)
+			}
+			return nil, fmt.Errorf("another mydockerd is already using data directory %s", dataDir)
+		}
+		return nil, fmt.Errorf("failed to lock pid file: %v", err)
+	}
+
+	// We hold the lock, so any PID left in the file belongs to a crashed instance
+	if pid := readPid(path); pid > 0 && pid != os.Getpid() {
+		fmt.Printf("Recovering stale pid file %s (previous daemon PID %d is gone)\n", path, pid)
+	}
+
+	// Record our PID
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate pid file: %v", err)
+	}
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write pid file: %v", err)
+	}
+
+	return &pidFile{
+		path: path,
+		file: file,
+	}, nil
+}
+
+// release clears the pid file and drops the lock
+// The file itself stays: unlinking it while locked would let a new instance lock a fresh file at the
+// same path while another still waits on the old inode, leaving two daemons owning the data directory
+func (p *pidFile) release() error {
+	if p == nil || p.file == nil {
+		return nil
+	}
+
+	// Clear while still holding the lock so a new instance never sees our PID
+	err := p.file.Truncate(0)
+	p.file.Close()
+	p.file = nil
+
+	if err != nil {
+		return fmt.Errorf("failed to clear pid file: %v", err)
+	}
+	return nil
+}
+
+// readPid returns the PID stored in a pid file, or 0 if it can't be read
+func readPid(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPidFileRelease(t *testing.T) {
+	dataDir := t.TempDir()
+	path := filepath.Join(dataDir, pidFileName)
+
+	first, err := acquirePidFile(dataDir)
+	if err != nil {
+		t.Fatalf("acquirePidFile failed: %v", err)
+	}
+	if pid := readPid(path); pid != os.Getpid() {
+		t.Errorf("pid file holds %d, want %d", pid, os.Getpid())
+	}
+	if _, err := acquirePidFile(dataDir); err == nil || !strings.Contains(err.Error(), strconv.Itoa(os.Getpid())) {
+		t.Errorf("second acquirePidFile = %v, want an error naming the holder", err)
+	}
+
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.release(); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+
+	// The file outlives the lock, so the next instance locks the same inode rather than a new one
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("pid file is gone after release: %v", err)
+	}
+	if !os.SameFile(before, after) || after.Size() != 0 {
+		t.Errorf("release left a different or non-empty pid file")
+	}
+
+	second, err := acquirePidFile(dataDir)
+	if err != nil {
+		t.Fatalf("acquirePidFile after release failed: %v", err)
+	}
+	defer second.release()
+	if _, err := acquirePidFile(dataDir); err == nil {
+		t.Error("acquirePidFile succeeded while the lock was held again")
+	}
+}
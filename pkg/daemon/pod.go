@@ -0,0 +1,548 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// defaultInfraCommand keeps a pod's infra container running, and with it the namespaces it holds
+var defaultInfraCommand = []string{"sleep", "infinity"}
+
+// pods holds the pods whose containers share the namespaces of an infra container
+type pods struct {
+	mu   sync.Mutex
+	byID map[string]*state.Pod
+}
+
+// loadPods loads the pods stored on disk
+func (d *Daemon) loadPods() error {
+	list, err := d.store.ListPods()
+	if err != nil {
+		return err
+	}
+
+	d.pods.mu.Lock()
+	defer d.pods.mu.Unlock()
+
+	d.pods.byID = make(map[string]*state.Pod)
+	for _, pod := range list {
+		d.pods.byID[pod.ID] = pod
+	}
+
+	fmt.Printf("Loaded %d pod(s) from disk\n", len(d.pods.byID))
+	return nil
+}
+
+// CreatePod saves a pod and creates and starts its infra container on behalf of caller
+func (d *Daemon) CreatePod(ctx context.Context, req api.PodCreateRequest, caller peer) (*api.Pod, error) {
+	// Pods are named like services
+	if !validServiceName.MatchString(req.Name) {
+		return nil, errInvalidRequest(fmt.Errorf("invalid pod name %q: use letters, digits, '_', '.' and '-'", req.Name))
+	}
+	if req.Rootfs == "" {
+		return nil, errInvalidRequest(fmt.Errorf("rootfs of the infra container required"))
+	}
+	command := req.Command
+	if len(command) == 0 {
+		command = defaultInfraCommand
+	}
+
+	d.pods.mu.Lock()
+	defer d.pods.mu.Unlock()
+
+	for _, existing := range d.pods.byID {
+		if existing.Name == req.Name {
+			return nil, errConflict(api.ErrCodeNameInUse, "pod name %s is already in use by %s", req.Name, existing.ID)
+		}
+	}
+
+	id := make([]byte, containerIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate pod ID: %v", err)
+	}
+	pod := &state.Pod{
+		ID:      hex.EncodeToString(id),
+		Name:    req.Name,
+		Labels:  req.Labels,
+		Created: time.Now(),
+	}
+	if caller.known {
+		pod.Owner = &caller.uid
+	}
+
+	labels := maps.Clone(req.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[api.PodLabel] = pod.ID
+	infra, _, err := d.CreateContainer(ctx, api.ContainerCreateRequest{
+		Rootfs:  req.Rootfs,
+		Command: command,
+		Labels:  labels,
+		Detach:  true,
+	}, caller)
+	if err != nil {
+		// A container that failed to start is kept for inspection, but this one belongs to a pod that never existed
+		d.removeContainers(d.containersByPod()[pod.ID])
+		return nil, err
+	}
+	pod.Infra = infra
+
+	if err := d.store.SavePod(pod); err != nil {
+		return nil, err
+	}
+	d.pods.byID[pod.ID] = pod
+
+	fmt.Printf("Created pod %s (%s) with infra container %s for %s\n", pod.Name, pod.ID, infra, caller)
+	d.publishEvent(api.PodEventType, "create", pod.ID, map[string]string{"name": pod.Name, "infra": infra})
+	return d.podInfo(pod, d.containersByPod()[pod.ID]), nil
+}
+
+// removeContainers removes stopped containers, such as those of a pod being removed
+func (d *Daemon) removeContainers(containers []*state.ContainerState) {
+	for _, c := range containers {
+		if err := d.removeContainer(c.ID); err != nil {
+			fmt.Printf("Warning: failed to remove container %s: %v\n", c.ID, err)
+			continue
+		}
+		d.logEvent("destroy", c.ID, eventAttributes(c, nil))
+	}
+}
+
+// resolvePodLocked finds a pod by name, ID or unique ID prefix
+// The caller must hold d.pods.mu
+func (d *Daemon) resolvePodLocked(ref string) (*state.Pod, error) {
+	if ref == "" {
+		return nil, errInvalidRequest(fmt.Errorf("pod name or ID required"))
+	}
+	for _, pod := range d.pods.byID {
+		if pod.Name == ref {
+			return pod, nil
+		}
+	}
+	if pod, ok := d.pods.byID[ref]; ok {
+		return pod, nil
+	}
+
+	var matches []*state.Pod
+	for id, pod := range d.pods.byID {
+		if strings.HasPrefix(id, ref) {
+			matches = append(matches, pod)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, &apiError{status: http.StatusNotFound, code: api.ErrCodePodNotFound, err: fmt.Errorf("pod not found: %s", ref)}
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, &apiError{
+			status: http.StatusBadRequest,
+			code:   api.ErrCodeAmbiguousID,
+			err:    fmt.Errorf("pod ID prefix %s is ambiguous, it matches %d pods", ref, len(matches)),
+		}
+	}
+}
+
+// resolvePod is resolvePodLocked for callers not holding d.pods.mu; it returns a copy of the pod
+func (d *Daemon) resolvePod(ref string) (state.Pod, error) {
+	d.pods.mu.Lock()
+	defer d.pods.mu.Unlock()
+
+	pod, err := d.resolvePodLocked(ref)
+	if err != nil {
+		return state.Pod{}, err
+	}
+	return *pod, nil
+}
+
+// containersByPod returns the containers of every pod, infra container included, keyed by pod ID and oldest first
+func (d *Daemon) containersByPod() map[string][]*state.ContainerState {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.containersByPodLocked()
+}
+
+// containersByPodLocked is containersByPod for callers already holding d.mu
+func (d *Daemon) containersByPodLocked() map[string][]*state.ContainerState {
+	members := make(map[string][]*state.ContainerState)
+	for _, c := range d.containers {
+		if id := c.Labels[api.PodLabel]; id != "" {
+			members[id] = append(members[id], c)
+		}
+	}
+	for _, list := range members {
+		sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	}
+	return members
+}
+
+// podInfo converts a pod and its containers to the API form
+func (d *Daemon) podInfo(pod *state.Pod, containers []*state.ContainerState) *api.Pod {
+	info := &api.Pod{
+		ID:         pod.ID,
+		Name:       pod.Name,
+		Infra:      pod.Infra,
+		Status:     "removed",
+		Labels:     pod.Labels,
+		Created:    pod.Created,
+		Containers: []api.PodContainer{},
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, c := range containers {
+		if c.ID == pod.Infra {
+			info.Status = c.Status
+			continue
+		}
+		if c.Status == "running" {
+			info.Running++
+		}
+		info.Containers = append(info.Containers, api.PodContainer{
+			ID:       c.ID,
+			Status:   c.Status,
+			ExitCode: c.ExitCode,
+			Init:     isInitContainer(c),
+			Command:  c.Command,
+			Created:  c.Created,
+		})
+	}
+	return info
+}
+
+// ListPods returns every pod with its containers, oldest first
+func (d *Daemon) ListPods() []api.Pod {
+	members := d.containersByPod()
+
+	d.pods.mu.Lock()
+	defer d.pods.mu.Unlock()
+
+	list := []api.Pod{}
+	for id, pod := range d.pods.byID {
+		list = append(list, *d.podInfo(pod, members[id]))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	return list
+}
+
+// InspectPod returns a pod with its containers
+func (d *Daemon) InspectPod(ref string) (*api.Pod, error) {
+	pod, err := d.resolvePod(ref)
+	if err != nil {
+		return nil, err
+	}
+	return d.podInfo(&pod, d.containersByPod()[pod.ID]), nil
+}
+
+// AddToPod creates a container in a pod on behalf of caller and starts it, along with the infra container if
+// that isn't running. The container joins the infra container's network, PID, IPC and UTS namespaces and
+// depends on it, so stopping the infra container stops it first
+// Init containers, labelled with api.InitLabel, must be added before the others; each depends on the ones added
+// before it and the others depend on all of them, so they run to completion in order before anything else starts
+func (d *Daemon) AddToPod(ctx context.Context, ref string, req api.ContainerCreateRequest, caller peer) (*api.ContainerCreateResponse, error) {
+	pod, err := d.resolvePod(ref)
+	if err != nil {
+		return nil, err
+	}
+	if req.NamespacesFrom != "" {
+		return nil, errInvalidRequest(fmt.Errorf("containers in a pod join the namespaces of its infra container; namespaces_from can't be set"))
+	}
+	if _, err := d.getContainer(pod.Infra); err != nil {
+		return nil, fmt.Errorf("infra container of pod %s: %v", pod.Name, err)
+	}
+
+	req.Labels = maps.Clone(req.Labels)
+	if req.Labels == nil {
+		req.Labels = make(map[string]string)
+	}
+	req.Labels[api.PodLabel] = pod.ID
+
+	deps := []string{pod.Infra}
+	init := req.Labels[api.InitLabel] == "true"
+	for _, c := range d.containersByPod()[pod.ID] {
+		switch {
+		case c.ID == pod.Infra:
+		case isInitContainer(c):
+			deps = append(deps, c.ID)
+		case init:
+			return nil, errInvalidRequest(fmt.Errorf("init containers must be added to pod %s before its other containers", pod.Name))
+		}
+	}
+	if existing := req.Labels[api.DependsOnLabel]; existing != "" {
+		deps = append([]string{existing}, deps...)
+	}
+	req.Labels[api.DependsOnLabel] = strings.Join(deps, ",")
+	req.NamespacesFrom = pod.Infra
+	req.Detach = true
+	req.NoDeps = false
+
+	id, runner, err := d.CreateContainer(ctx, req, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Added container %s to pod %s\n", id, pod.Name)
+	d.publishEvent(api.PodEventType, "add", pod.ID, map[string]string{"name": pod.Name, "container": id})
+	return &api.ContainerCreateResponse{ID: id, Queued: runner == nil}, nil
+}
+
+// StartPod starts a pod's infra container, runs its init containers to completion in order and then starts its
+// other containers that aren't running, on behalf of actor
+// A failing init container aborts the start; any other container that fails to start doesn't keep the rest down,
+// and the first failure is returned once all were tried
+func (d *Daemon) StartPod(ctx context.Context, ref, actor string) (*api.Pod, error) {
+	pod, err := d.resolvePod(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// The infra container is started first so the others have namespaces to join, then the init containers
+	members := d.containersByPod()[pod.ID]
+	rank := func(c *state.ContainerState) int {
+		switch {
+		case c.ID == pod.Infra:
+			return 0
+		case isInitContainer(c):
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool { return rank(members[i]) < rank(members[j]) })
+
+	var firstErr error
+	for _, c := range members {
+		if isInitContainer(c) {
+			if _, err := d.runInitContainer(ctx, c.ID, transitionCause{actor, "init container of pod " + pod.Name}); err != nil {
+				return nil, fmt.Errorf("failed to start pod %s: %v", pod.Name, err)
+			}
+			continue
+		}
+
+		d.mu.RLock()
+		status := c.Status
+		d.mu.RUnlock()
+		if status == "running" || status == "queued" {
+			continue
+		}
+		if _, _, err := d.StartContainer(ctx, c.ID, false, false, actor); err != nil {
+			err = fmt.Errorf("failed to start container %s of pod %s: %v", c.ID, pod.Name, err)
+			if c.ID == pod.Infra {
+				return nil, err
+			}
+			fmt.Printf("Warning: %v\n", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	fmt.Printf("Started pod %s\n", pod.Name)
+	d.publishEvent(api.PodEventType, "start", pod.ID, map[string]string{"name": pod.Name})
+	return d.podInfo(&pod, d.containersByPod()[pod.ID]), nil
+}
+
+// StopPod stops a pod's containers and then its infra container, on behalf of actor
+func (d *Daemon) StopPod(ref, actor string) (*api.Pod, error) {
+	pod, err := d.resolvePod(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	infra, err := d.getContainer(pod.Infra)
+	if err != nil {
+		return nil, fmt.Errorf("infra container of pod %s: %v", pod.Name, err)
+	}
+	d.mu.RLock()
+	running := infra.Status == "running"
+	d.mu.RUnlock()
+
+	// Every container of the pod depends on the infra container, so they are stopped before it
+	if running {
+		if _, err := d.StopContainer(pod.Infra, false, actor); err != nil {
+			return nil, fmt.Errorf("failed to stop pod %s: %v", pod.Name, err)
+		}
+	}
+
+	fmt.Printf("Stopped pod %s\n", pod.Name)
+	d.publishEvent(api.PodEventType, "stop", pod.ID, map[string]string{"name": pod.Name})
+	return d.podInfo(&pod, d.containersByPod()[pod.ID]), nil
+}
+
+// RemovePod deletes a pod with its containers on behalf of actor
+// A pod with running containers is only removed with force; they are stopped and removed once their exit is recorded
+func (d *Daemon) RemovePod(ref string, force bool, actor string) (string, error) {
+	d.pods.mu.Lock()
+	defer d.pods.mu.Unlock()
+
+	pod, err := d.resolvePodLocked(ref)
+	if err != nil {
+		return "", err
+	}
+
+	var running, queued []string
+	var stopped []*state.ContainerState
+	d.mu.Lock()
+	members := d.containersByPodLocked()[pod.ID]
+	for _, c := range members {
+		switch c.Status {
+		case "running":
+			running = append(running, c.ID)
+		case "queued":
+			queued = append(queued, c.ID)
+		}
+		if c.Status != "running" {
+			stopped = append(stopped, c)
+		}
+	}
+	if force {
+		// Running containers remove themselves once their exit is recorded, so it isn't lost to a removal racing it
+		for _, c := range members {
+			if c.Status == "running" {
+				c.AutoRemove = true
+			}
+		}
+	}
+	d.mu.Unlock()
+	if len(running)+len(queued) > 0 && !force {
+		return "", errConflict(api.ErrCodeContainerRunning, "pod %s has %d running container(s); stop it first or force the removal", pod.Name, len(running)+len(queued))
+	}
+
+	cause := transitionCause{actor, "pod " + pod.Name + " removed"}
+	for _, id := range append(queued, d.shutdownOrder(running)...) {
+		if err := d.stopContainer(id, cause); err != nil {
+			fmt.Printf("Warning: failed to stop container %s of pod %s: %v\n", id, pod.Name, err)
+		}
+	}
+	d.removeContainers(stopped)
+
+	if err := d.store.DeletePod(pod.ID); err != nil {
+		return "", err
+	}
+	delete(d.pods.byID, pod.ID)
+
+	fmt.Printf("Removed pod %s (%s)\n", pod.Name, pod.ID)
+	d.publishEvent(api.PodEventType, "destroy", pod.ID, map[string]string{"name": pod.Name})
+	return pod.ID, nil
+}
+
+// podIDs returns the IDs of every pod
+func (d *Daemon) podIDs() map[string]bool {
+	d.pods.mu.Lock()
+	defer d.pods.mu.Unlock()
+
+	ids := make(map[string]bool, len(d.pods.byID))
+	for id := range d.pods.byID {
+		ids[id] = true
+	}
+	return ids
+}
+
+// handlePodCreate creates a pod
+func (d *Daemon) handlePodCreate(w http.ResponseWriter, r *http.Request) {
+	var req api.PodCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	pod, err := d.CreatePod(r.Context(), req, requestPeer(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pod)
+}
+
+// handlePodList lists pods
+func (d *Daemon) handlePodList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.PodListResponse{Pods: d.ListPods()})
+}
+
+// handlePodInspect returns a pod with its containers
+func (d *Daemon) handlePodInspect(w http.ResponseWriter, r *http.Request) {
+	pod, err := d.InspectPod(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pod)
+}
+
+// handlePodAdd creates and starts a container in a pod
+func (d *Daemon) handlePodAdd(w http.ResponseWriter, r *http.Request) {
+	var req api.ContainerCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	resp, err := d.AddToPod(r.Context(), r.PathValue("id"), req, requestPeer(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handlePodStart starts a pod
+func (d *Daemon) handlePodStart(w http.ResponseWriter, r *http.Request) {
+	pod, err := d.StartPod(r.Context(), r.PathValue("id"), requestPeer(r).String())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pod)
+}
+
+// handlePodStop stops a pod
+func (d *Daemon) handlePodStop(w http.ResponseWriter, r *http.Request) {
+	pod, err := d.StopPod(r.PathValue("id"), requestPeer(r).String())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pod)
+}
+
+// handlePodRemove deletes a pod with its containers
+func (d *Daemon) handlePodRemove(w http.ResponseWriter, r *http.Request) {
+	id, err := d.RemovePod(r.PathValue("id"), r.URL.Query().Get("force") == "true", requestPeer(r).String())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.Pod{ID: id})
+}
@@ -0,0 +1,304 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/config"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// presets holds the container templates created through the API
+// Presets from the daemon config are read from the current config instead
+type presets struct {
+	mu     sync.Mutex
+	byName map[string]*state.Preset
+}
+
+// loadPresets loads the presets stored on disk
+func (d *Daemon) loadPresets() error {
+	list, err := d.store.ListPresets()
+	if err != nil {
+		return err
+	}
+
+	d.presets.mu.Lock()
+	defer d.presets.mu.Unlock()
+
+	d.presets.byName = make(map[string]*state.Preset)
+	for _, preset := range list {
+		d.presets.byName[preset.Name] = preset
+	}
+
+	fmt.Printf("Loaded %d preset(s) from disk\n", len(d.presets.byName))
+	return nil
+}
+
+// CreatePreset validates and saves a preset
+// The template may leave out anything, including the command and rootfs, for create requests to fill in
+func (d *Daemon) CreatePreset(req api.PresetCreateRequest, caller peer) (*api.Preset, error) {
+	if !config.PresetNamePattern.MatchString(req.Name) {
+		return nil, errInvalidRequest(fmt.Errorf("invalid preset name %q: use letters, digits, '_', '.' and '-'", req.Name))
+	}
+	if _, ok := d.currentConfig().Presets[req.Name]; ok {
+		return nil, errConflict(api.ErrCodeNameInUse, "preset %s is defined in the daemon config", req.Name)
+	}
+
+	// Catch mistakes in the template now rather than when it's used
+	template := req.Template
+	if template.Preset != "" {
+		return nil, errInvalidRequest(fmt.Errorf("a preset cannot start from another preset"))
+	}
+	if template.Rootfs != "" {
+		rootfs, err := d.validateRootfs(template.Rootfs)
+		if err != nil {
+			return nil, errInvalidRequest(err)
+		}
+		template.Rootfs = rootfs
+	}
+	for _, kv := range template.Env {
+		if !strings.Contains(kv, "=") || strings.HasPrefix(kv, "=") {
+			return nil, errInvalidRequest(fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", kv))
+		}
+		if key, _, _ := strings.Cut(kv, "="); namespace.ReservedEnv(key) {
+			return nil, errInvalidRequest(fmt.Errorf("environment variable %s is reserved for container-init", key))
+		}
+	}
+	if err := validateWaitConditions(template.WaitFor); err != nil {
+		return nil, errInvalidRequest(err)
+	}
+	if err := d.applyDefaultLimits(&cgroups.ResourceLimits{}, template.Profile); err != nil {
+		return nil, err
+	}
+	// Whether to detach or start dependencies is up to each create request
+	template.Detach = false
+	template.NoDeps = false
+
+	preset := &state.Preset{
+		Name:     req.Name,
+		Template: template,
+		Created:  time.Now(),
+	}
+	if caller.known {
+		preset.Owner = &caller.uid
+	}
+
+	d.presets.mu.Lock()
+	defer d.presets.mu.Unlock()
+
+	if _, exists := d.presets.byName[req.Name]; exists {
+		return nil, errConflict(api.ErrCodeNameInUse, "preset name %s is already in use", req.Name)
+	}
+	if err := d.store.SavePreset(preset); err != nil {
+		return nil, err
+	}
+	d.presets.byName[preset.Name] = preset
+
+	fmt.Printf("Created preset %s for %s\n", preset.Name, caller)
+	d.publishEvent(api.PresetEventType, "create", preset.Name, nil)
+	return presetInfo(preset), nil
+}
+
+// presetInfo converts a preset created through the API to its API form
+func presetInfo(preset *state.Preset) *api.Preset {
+	return &api.Preset{Name: preset.Name, Source: api.PresetSourceAPI, Template: preset.Template, Created: preset.Created}
+}
+
+// ListPresets returns the presets from the daemon config and the API, by name
+// An API preset shadowed by a config preset of the same name is left out
+func (d *Daemon) ListPresets() []api.Preset {
+	configPresets := d.currentConfig().Presets
+
+	list := []api.Preset{}
+	for name, template := range configPresets {
+		list = append(list, api.Preset{Name: name, Source: api.PresetSourceConfig, Template: template})
+	}
+
+	d.presets.mu.Lock()
+	for name, preset := range d.presets.byName {
+		if _, shadowed := configPresets[name]; !shadowed {
+			list = append(list, *presetInfo(preset))
+		}
+	}
+	d.presets.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// InspectPreset returns a preset by name, looking in the daemon config first
+func (d *Daemon) InspectPreset(name string) (*api.Preset, error) {
+	if template, ok := d.currentConfig().Presets[name]; ok {
+		return &api.Preset{Name: name, Source: api.PresetSourceConfig, Template: template}, nil
+	}
+
+	d.presets.mu.Lock()
+	defer d.presets.mu.Unlock()
+
+	preset, ok := d.presets.byName[name]
+	if !ok {
+		return nil, &apiError{status: http.StatusNotFound, code: api.ErrCodePresetNotFound, err: fmt.Errorf("preset not found: %s", name)}
+	}
+	return presetInfo(preset), nil
+}
+
+// RemovePreset deletes a preset created through the API; containers created from it are kept
+func (d *Daemon) RemovePreset(name string) error {
+	if _, ok := d.currentConfig().Presets[name]; ok {
+		return errConflict(api.ErrCodeNameInUse, "preset %s is defined in the daemon config, remove it there", name)
+	}
+
+	d.presets.mu.Lock()
+	defer d.presets.mu.Unlock()
+
+	if _, ok := d.presets.byName[name]; !ok {
+		return &apiError{status: http.StatusNotFound, code: api.ErrCodePresetNotFound, err: fmt.Errorf("preset not found: %s", name)}
+	}
+	if err := d.store.DeletePreset(name); err != nil {
+		return err
+	}
+	delete(d.presets.byName, name)
+
+	fmt.Printf("Removed preset %s\n", name)
+	d.publishEvent(api.PresetEventType, "destroy", name, nil)
+	return nil
+}
+
+// expandPreset fills in the fields a create request leaves unset from the preset it names
+// The request is labelled with the preset and no longer names it, so expanding it again changes nothing
+func (d *Daemon) expandPreset(req *api.ContainerCreateRequest) error {
+	if req.Preset == "" {
+		return nil
+	}
+	preset, err := d.InspectPreset(req.Preset)
+	if err != nil {
+		return errInvalidRequest(fmt.Errorf("preset not found: %s", req.Preset))
+	}
+
+	applyPreset(req, preset.Template)
+	if req.Labels == nil {
+		req.Labels = make(map[string]string)
+	}
+	req.Labels[api.PresetLabel] = preset.Name
+	req.Preset = ""
+	return nil
+}
+
+// applyPreset fills every field req leaves at its zero value from template
+// Env, labels and mounts are combined instead; req's entries win for the same variable, key or destination
+func applyPreset(req *api.ContainerCreateRequest, template api.ContainerCreateRequest) {
+	env := append([]string{}, template.Env...)
+	for _, kv := range req.Env {
+		key, _, _ := strings.Cut(kv, "=")
+		env = withoutEnvKey(env, key)
+		env = append(env, kv)
+	}
+
+	var labels map[string]string
+	if len(template.Labels) > 0 || len(req.Labels) > 0 {
+		labels = maps.Clone(template.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		maps.Copy(labels, req.Labels)
+	}
+
+	overridden := make(map[string]bool)
+	for _, m := range req.Mounts {
+		overridden[m.Destination] = true
+	}
+	var mounts []api.Mount
+	for _, m := range template.Mounts {
+		if !overridden[m.Destination] {
+			mounts = append(mounts, m)
+		}
+	}
+	mounts = append(mounts, req.Mounts...)
+
+	// Detaching and starting dependencies are always the request's choice
+	template.Detach = false
+	template.NoDeps = false
+
+	reqValue := reflect.ValueOf(req).Elem()
+	templateValue := reflect.ValueOf(template)
+	for i := 0; i < reqValue.NumField(); i++ {
+		if field := reqValue.Field(i); field.IsZero() {
+			field.Set(templateValue.Field(i))
+		}
+	}
+
+	if len(env) > 0 {
+		req.Env = env
+	}
+	req.Labels = labels
+	req.Mounts = mounts
+}
+
+// withoutEnvKey removes the KEY=VALUE entries for key from env
+func withoutEnvKey(env []string, key string) []string {
+	kept := env[:0]
+	for _, kv := range env {
+		if k, _, _ := strings.Cut(kv, "="); k != key {
+			kept = append(kept, kv)
+		}
+	}
+	return kept
+}
+
+// handlePresetCreate creates a preset
+func (d *Daemon) handlePresetCreate(w http.ResponseWriter, r *http.Request) {
+	var req api.PresetCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	preset, err := d.CreatePreset(req, requestPeer(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preset)
+}
+
+// handlePresetList lists presets
+func (d *Daemon) handlePresetList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.PresetListResponse{Presets: d.ListPresets()})
+}
+
+// handlePresetInspect returns a preset
+func (d *Daemon) handlePresetInspect(w http.ResponseWriter, r *http.Request) {
+	preset, err := d.InspectPreset(r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preset)
+}
+
+// handlePresetRemove deletes a preset
+func (d *Daemon) handlePresetRemove(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := d.RemovePreset(name); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.Preset{Name: name, Source: api.PresetSourceAPI})
+}
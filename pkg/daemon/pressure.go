@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+)
+
+// pressureInterval is how often a container's pressure stall information is checked
+const pressureInterval = 2 * time.Second
+
+// validatePressureThresholds checks keys of the form "<cpu|memory|io>.<some|full>" with percentages in (0, 100]
+func validatePressureThresholds(thresholds map[string]float64) error {
+	for key, value := range thresholds {
+		resource, kind, ok := strings.Cut(key, ".")
+		if !ok || (kind != "some" && kind != "full") || !isPressureResource(resource) {
+			return fmt.Errorf("invalid pressure threshold %q, expected <cpu|memory|io>.<some|full>", key)
+		}
+		if value <= 0 || value > 100 {
+			return fmt.Errorf("pressure threshold %s must be a percentage between 0 and 100, got %g", key, value)
+		}
+	}
+	return nil
+}
+
+// isPressureResource reports whether resource exposes a pressure file
+func isPressureResource(resource string) bool {
+	for _, r := range cgroups.PressureResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// monitorPressure emits a "pressure" event each time a stall percentage (avg10) rises above its threshold
+// Events are edge-triggered: a threshold fires again only after the stall has dropped back below it
+func (d *Daemon) monitorPressure(id string, runner *container.Runner, thresholds map[string]float64) {
+	if runner.Cgroup == nil {
+		return
+	}
+	if _, err := runner.Cgroup.Pressure(); err != nil {
+		fmt.Printf("Warning: pressure thresholds for container %s are ignored: %v\n", id, err)
+		return
+	}
+
+	ticker := time.NewTicker(pressureInterval)
+	defer ticker.Stop()
+
+	exceeded := make(map[string]bool, len(thresholds))
+	for {
+		select {
+		case <-runner.Exited():
+			return
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		pressure, err := runner.Cgroup.Pressure()
+		if err != nil {
+			// The cgroup goes away when the container exits
+			d.debugf("Pressure monitor for container %s stopped: %v\n", id, err)
+			return
+		}
+
+		for key, threshold := range thresholds {
+			resource, kind, _ := strings.Cut(key, ".")
+			psi := pressure[resource].Some
+			if kind == "full" {
+				psi = pressure[resource].Full
+			}
+
+			if psi.Avg10 < threshold {
+				exceeded[key] = false
+				continue
+			}
+			if exceeded[key] {
+				continue
+			}
+			exceeded[key] = true
+
+			fmt.Printf("Container %s: %s pressure %.2f%% exceeds threshold %.2f%%\n", id, key, psi.Avg10, threshold)
+			d.logEvent("pressure", id, map[string]string{
+				"resource":  resource,
+				"kind":      kind,
+				"avg10":     strconv.FormatFloat(psi.Avg10, 'f', 2, 64),
+				"threshold": strconv.FormatFloat(threshold, 'f', 2, 64),
+			})
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/config"
+)
+
+// hostMemoryInterval is how often the host's available memory is checked against low-memory-warning
+const hostMemoryInterval = 5 * time.Second
+
+// applyProtection applies the daemon-protection settings that differ from old
+// Failures are reported as warnings; the daemon keeps running unprotected rather than refusing to start
+func (d *Daemon) applyProtection(old, cfg config.DaemonProtection) {
+	if cfg.OOMScoreAdj != old.OOMScoreAdj {
+		if err := os.WriteFile("/proc/self/oom_score_adj", []byte(strconv.Itoa(cfg.OOMScoreAdj)), 0644); err != nil {
+			fmt.Printf("Warning: failed to set the daemon's oom_score_adj: %v\n", err)
+		}
+	}
+
+	if cfg.MemoryReservation != old.MemoryReservation || cfg.CpuShares != old.CpuShares {
+		if err := d.cgroupVersion.ProtectDaemon(cfg.MemoryReservation, cfg.CpuShares); err != nil {
+			fmt.Printf("Warning: failed to reserve resources for the daemon: %v\n", err)
+		}
+	}
+}
+
+// monitorHostMemory warns when the host's available memory drops below the configured percentage
+// Warnings are edge-triggered: another one is printed only after memory has recovered in between
+func (d *Daemon) monitorHostMemory(stop <-chan struct{}) {
+	ticker := time.NewTicker(hostMemoryInterval)
+	defer ticker.Stop()
+
+	low := false
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		threshold := d.currentConfig().Protection.LowMemoryWarning
+		if threshold == 0 {
+			low = false
+			continue
+		}
+
+		total, err := cgroups.HostMemoryTotal()
+		if err != nil {
+			continue
+		}
+		available, err := cgroups.HostMemoryAvailable()
+		if err != nil {
+			continue
+		}
+
+		percent := float64(available) / float64(total) * 100
+		if percent >= threshold {
+			if low {
+				fmt.Printf("Host memory recovered: %.1f%% available\n", percent)
+			}
+			low = false
+			continue
+		}
+		if !low {
+			fmt.Printf("Warning: host memory is low, %.1f%% (%d bytes) available, below the %.1f%% warning threshold; the daemon may be OOM killed\n", percent, available, threshold)
+		}
+		low = true
+	}
+}
@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+)
+
+// errQuotaExceeded reports a request that would take a user over their quota
+func errQuotaExceeded(uid uint32, format string, args ...interface{}) error {
+	return &apiError{status: http.StatusForbidden, code: api.ErrCodeQuotaExceeded, err: fmt.Errorf("quota exceeded for uid %d: %s", uid, fmt.Sprintf(format, args...))}
+}
+
+// checkQuota rejects running a container with the given limits, rootfs, mounts and secrets when that would exceed its
+// owner's quota
+// id is the container being started, empty for one that doesn't exist yet; containers without a known owner are exempt
+func (d *Daemon) checkQuota(owner *uint32, id string, limits cgroups.ResourceLimits, rootfs string, mounts []namespace.Mount, secrets []namespace.Secret) error {
+	if owner == nil {
+		return nil
+	}
+	quota := d.currentConfig().QuotaFor(*owner)
+	if quota == nil {
+		return nil
+	}
+	uid := *owner
+
+	if len(quota.RootfsPrefixes) > 0 {
+		ok, err := underRoots(rootfs, quota.RootfsPrefixes)
+		if err != nil {
+			return errInvalidRequest(fmt.Errorf("invalid rootfs: %v", err))
+		}
+		if !ok {
+			return errQuotaExceeded(uid, "rootfs %s is not under an allowed prefix %v", rootfs, quota.RootfsPrefixes)
+		}
+
+		// Bind mounts reach the host just like the rootfs does
+		for _, m := range mounts {
+			if m.Type != "bind" {
+				continue
+			}
+			ok, err := underRoots(m.Source, quota.RootfsPrefixes)
+			if err != nil {
+				return errInvalidRequest(fmt.Errorf("invalid bind mount source: %v", err))
+			}
+			if !ok {
+				return errQuotaExceeded(uid, "bind mount source %s is not under an allowed prefix %v", m.Source, quota.RootfsPrefixes)
+			}
+		}
+
+		// So do secrets, which are read from the host on the container's behalf
+		for _, secret := range secrets {
+			ok, err := underRoots(secret.Source, quota.RootfsPrefixes)
+			if err != nil {
+				return errInvalidRequest(fmt.Errorf("invalid secret source: %v", err))
+			}
+			if !ok {
+				return errQuotaExceeded(uid, "secret source %s is not under an allowed prefix %v", secret.Source, quota.RootfsPrefixes)
+			}
+		}
+	}
+
+	if quota.MaxMemory > 0 && limits.MemoryLimit == 0 {
+		return errQuotaExceeded(uid, "containers must set a memory limit under a memory quota")
+	}
+
+	// Add up what the owner's other containers are using
+	running := 0
+	var memory uint64
+	d.mu.RLock()
+	for _, c := range d.containers {
+		if c.ID == id || c.Status != "running" || c.Owner == nil || *c.Owner != uid {
+			continue
+		}
+		running++
+		memory += c.Limits.MemoryLimit
+	}
+	d.mu.RUnlock()
+
+	if quota.MaxRunning > 0 && running+1 > quota.MaxRunning {
+		return errQuotaExceeded(uid, "%d of %d running containers already in use", running, quota.MaxRunning)
+	}
+	if quota.MaxMemory > 0 && memory+limits.MemoryLimit > quota.MaxMemory {
+		return errQuotaExceeded(uid, "memory limit %d on top of %d already in use exceeds %d", limits.MemoryLimit, memory, quota.MaxMemory)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+)
+
+// Reconcile compares the host's kernel state with the store and removes resources no container owns
+// It runs at startup to clean up after a crash; with dryRun it only reports what it would remove
+func (d *Daemon) Reconcile(dryRun bool) *api.SystemReconcileResponse {
+	resp := &api.SystemReconcileResponse{DryRun: dryRun, Cgroups: []string{}, Mounts: []string{}}
+
+	// Hold the lock so no container starts or stops while its resources are being judged
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// A cgroup is orphaned unless its container is known and still running
+	ids, err := d.cgroupVersion.ContainerIDs()
+	if err != nil {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+	for _, id := range ids {
+		if container, ok := d.containers[id]; ok && container.Status == "running" {
+			continue
+		}
+		if !dryRun {
+			cg := cgroups.NewManager(d.cgroupVersion, id, d.cgroupVersion.AllControllers())
+			if err := cg.Delete(); err != nil {
+				resp.Errors = append(resp.Errors, err.Error())
+				continue
+			}
+		}
+		resp.Cgroups = append(resp.Cgroups, id)
+	}
+
+	// Containers mount only inside their own mount namespace, so any of their mounts visible here leaked
+	// Several containers may share a rootfs, so each mount point is handled once
+	seen := make(map[string]bool)
+	for _, container := range d.containers {
+		// An adopted process's root may be the host's, whose mounts are all legitimate
+		if container.Adopted {
+			continue
+		}
+		leaked, err := namespace.LeakedMounts(container.Rootfs, container.Mounts)
+		if err != nil {
+			resp.Errors = append(resp.Errors, err.Error())
+			break
+		}
+		for _, path := range leaked {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			if !dryRun {
+				if err := unmountDetached(path); err != nil {
+					resp.Errors = append(resp.Errors, fmt.Sprintf("failed to unmount %s: %v", path, err))
+					continue
+				}
+			}
+			resp.Mounts = append(resp.Mounts, path)
+		}
+	}
+
+	// Network namespace handles only belong to running containers
+	handles, err := namespace.BoundNetns()
+	if err != nil {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+	for _, id := range handles {
+		if container, ok := d.containers[id]; ok && container.Status == "running" {
+			continue
+		}
+		if !dryRun {
+			if err := namespace.ReleaseNetns(id); err != nil {
+				resp.Errors = append(resp.Errors, err.Error())
+				continue
+			}
+		}
+		resp.Mounts = append(resp.Mounts, namespace.NetnsPath(id))
+	}
+
+	if !dryRun && len(resp.Cgroups)+len(resp.Mounts) > 0 {
+		fmt.Printf("Reconcile: removed %d orphaned cgroup(s) and %d leaked mount(s)\n", len(resp.Cgroups), len(resp.Mounts))
+	}
+	for _, msg := range resp.Errors {
+		fmt.Printf("Reconcile: %s\n", msg)
+	}
+
+	return resp
+}
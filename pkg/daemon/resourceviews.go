@@ -0,0 +1,226 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+)
+
+// resourceViewInterval is how often the generated meminfo of a running container is brought up to date
+const resourceViewInterval = time.Second
+
+// resourceViewFiles maps each generated file to the path it is bind-mounted over inside the container
+var resourceViewFiles = map[string]string{
+	"meminfo": "/proc/meminfo",
+	"cpuinfo": "/proc/cpuinfo",
+	"online":  "/sys/devices/system/cpu/online",
+}
+
+// resourceViewDir returns the directory holding the generated files of a container
+func (d *Daemon) resourceViewDir(id string) string {
+	return filepath.Join(d.dataDir, "resourceviews", id)
+}
+
+// setupResourceViews generates the files showing the container its own limits and returns the mounts
+// that put them in place of the host's; on cgroups v2 the container's cgroup is also mounted at /sys/fs/cgroup
+func (d *Daemon) setupResourceViews(id string, runner *container.Runner, limits cgroups.ResourceLimits) ([]namespace.Mount, error) {
+	if runner.Cgroup == nil {
+		return nil, fmt.Errorf("resource views need the container's cgroup")
+	}
+	dir := d.resourceViewDir(id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create resource view directory: %v", err)
+	}
+	if err := writeResourceViews(dir, runner.Cgroup, limits); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	mounts := make([]namespace.Mount, 0, len(resourceViewFiles)+1)
+	for name, dest := range resourceViewFiles {
+		mounts = append(mounts, namespace.Mount{Source: filepath.Join(dir, name), Destination: dest, Type: "bind", Options: []string{"ro"}})
+	}
+	if path := cgroups.UnifiedPath(runner.Cgroup); path != "" {
+		mounts = append(mounts, namespace.Mount{Source: path, Destination: "/sys/fs/cgroup", Type: "bind", Options: []string{"ro", "nosuid", "nodev", "noexec"}})
+	}
+	return mounts, nil
+}
+
+// refreshResourceViews rewrites the generated files of a running container so free memory tracks its usage
+// The files are rewritten in place; replacing them would leave the container's bind mounts on the old inodes
+func (d *Daemon) refreshResourceViews(id string, runner *container.Runner, limits cgroups.ResourceLimits) {
+	ticker := time.NewTicker(resourceViewInterval)
+	defer ticker.Stop()
+
+	dir := d.resourceViewDir(id)
+	for {
+		select {
+		case <-runner.Exited():
+			return
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		// An autoscale policy may have raised the limits since the container started
+		if c, err := d.getContainer(id); err == nil {
+			limits = c.Limits
+		}
+		if err := writeResourceViews(dir, runner.Cgroup, limits); err != nil {
+			// The cgroup goes away when the container exits
+			d.debugf("Resource views of container %s stopped: %v\n", id, err)
+			return
+		}
+	}
+}
+
+// removeResourceViews deletes the generated files of a container
+func (d *Daemon) removeResourceViews(id string) {
+	if err := os.RemoveAll(d.resourceViewDir(id)); err != nil {
+		fmt.Printf("Warning: failed to remove resource views of container %s: %v\n", id, err)
+	}
+}
+
+// writeResourceViews generates meminfo, cpuinfo and the online CPU list from the host's files and the container's limits
+func writeResourceViews(dir string, cg cgroups.Manager, limits cgroups.ResourceLimits) error {
+	stats, err := cg.Stats()
+	if err != nil {
+		return err
+	}
+	hostMeminfo, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return fmt.Errorf("failed to read /proc/meminfo: %v", err)
+	}
+	hostCpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return fmt.Errorf("failed to read /proc/cpuinfo: %v", err)
+	}
+
+	cpus := limitedCPUs(limits, runtime.NumCPU())
+	online := "0\n"
+	if cpus > 1 {
+		online = fmt.Sprintf("0-%d\n", cpus-1)
+	}
+
+	files := map[string][]byte{
+		"meminfo": meminfoView(hostMeminfo, stats, limits.SwapLimit()),
+		"cpuinfo": cpuinfoView(hostCpuinfo, cpus),
+		"online":  []byte(online),
+	}
+	for name, data := range files {
+		if err := rewriteFile(filepath.Join(dir, name), data); err != nil {
+			return fmt.Errorf("failed to write resource view %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// rewriteFile replaces the contents of the file at path in place, creating it if needed
+// The new contents are written over the old before the file is cut to length, so a reader never sees it empty
+func rewriteFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0444)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(data, 0); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Truncate(int64(len(data))); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// limitedCPUs returns how many CPUs the container's quota amounts to, rounded up and at most hostCPUs
+func limitedCPUs(limits cgroups.ResourceLimits, hostCPUs int) int {
+	if limits.CpuQuota <= 0 {
+		return hostCPUs
+	}
+	period := int64(limits.CpuPeriod)
+	if period == 0 {
+		period = int64(cgroups.DefaultResourceLimits().CpuPeriod)
+	}
+	cpus := int((limits.CpuQuota + period - 1) / period)
+	return max(1, min(cpus, hostCPUs))
+}
+
+// meminfoView rewrites the memory and swap totals of the host's meminfo to the container's limit and usage
+// Without a memory limit below the host's memory the host's meminfo is returned unchanged
+func meminfoView(host []byte, stats *cgroups.Stats, swapLimit int64) []byte {
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(string(host), "\n") {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(rest), " kB"), 10, 64); err == nil {
+			values[key] = n
+		}
+	}
+
+	total := stats.MemoryLimit / 1024
+	if total == 0 || total >= values["MemTotal"] {
+		return host
+	}
+	used := min(stats.MemoryUsage/1024, total)
+	cache := min(stats.MemoryCache/1024, used)
+
+	// Swap usage isn't tracked, so free swap is only capped at the container's allowance
+	swap := values["SwapTotal"]
+	if swapLimit >= 0 {
+		swap = min(uint64(swapLimit)/1024, swap)
+	}
+
+	replace := map[string]uint64{
+		"MemTotal":     total,
+		"MemFree":      total - used,
+		"MemAvailable": total - used + cache,
+		"Buffers":      0,
+		"Cached":       cache,
+		"SwapTotal":    swap,
+		"SwapFree":     min(values["SwapFree"], swap),
+	}
+
+	var buf bytes.Buffer
+	for _, line := range strings.SplitAfter(string(host), "\n") {
+		key, _, _ := strings.Cut(line, ":")
+		if value, ok := replace[key]; ok {
+			// Same layout as the kernel: the label padded to 16 columns, then the value in 8
+			fmt.Fprintf(&buf, "%-16s%8d kB\n", key+":", value)
+			continue
+		}
+		buf.WriteString(line)
+	}
+	return buf.Bytes()
+}
+
+// cpuinfoView keeps the first cpus processor entries of the host's cpuinfo and any entries that aren't per-processor
+func cpuinfoView(host []byte, cpus int) []byte {
+	var buf bytes.Buffer
+	processors := 0
+	for _, entry := range strings.SplitAfter(string(host), "\n\n") {
+		if !strings.HasPrefix(entry, "processor") {
+			buf.WriteString(entry)
+			continue
+		}
+		if processors == cpus {
+			continue
+		}
+		// Keep the numbering contiguous in case the host's online CPUs aren't
+		_, rest, _ := strings.Cut(entry, "\n")
+		fmt.Fprintf(&buf, "processor\t: %d\n%s", processors, rest)
+		processors++
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,233 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+)
+
+func TestLimitedCPUs(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    int64
+		period   uint64
+		hostCPUs int
+		want     int
+	}{
+		{name: "no limit", quota: -1, period: 100000, hostCPUs: 8, want: 8},
+		{name: "zero quota", quota: 0, period: 100000, hostCPUs: 8, want: 8},
+		{name: "whole CPUs", quota: 200000, period: 100000, hostCPUs: 8, want: 2},
+		{name: "rounded up", quota: 150000, period: 100000, hostCPUs: 8, want: 2},
+		{name: "fraction of a CPU", quota: 10000, period: 100000, hostCPUs: 8, want: 1},
+		{name: "more than the host", quota: 1600000, period: 100000, hostCPUs: 8, want: 8},
+		{name: "default period", quota: 300000, period: 0, hostCPUs: 8, want: 3},
+		{name: "short period", quota: 5000, period: 1000, hostCPUs: 8, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := cgroups.ResourceLimits{CpuQuota: tt.quota, CpuPeriod: tt.period}
+			if got := limitedCPUs(limits, tt.hostCPUs); got != tt.want {
+				t.Errorf("limitedCPUs(quota %d, period %d, %d host CPUs) = %d, want %d", tt.quota, tt.period, tt.hostCPUs, got, tt.want)
+			}
+		})
+	}
+}
+
+const hostMeminfo = `MemTotal:       16000000 kB
+MemFree:         8000000 kB
+MemAvailable:   12000000 kB
+Buffers:          500000 kB
+Cached:          3000000 kB
+SwapCached:            0 kB
+SwapTotal:       4000000 kB
+SwapFree:        3000000 kB
+Shmem:             10000 kB
+`
+
+func TestMeminfoView(t *testing.T) {
+	const mib = 1024 * 1024
+
+	tests := []struct {
+		name      string
+		stats     cgroups.Stats
+		swapLimit int64
+		want      string
+	}{
+		{
+			name:      "no limit",
+			stats:     cgroups.Stats{MemoryUsage: 100 * mib},
+			swapLimit: -1,
+			want:      hostMeminfo,
+		},
+		{
+			name:      "limit above the host's memory",
+			stats:     cgroups.Stats{MemoryLimit: 32000000 * 1024, MemoryUsage: 100 * mib},
+			swapLimit: -1,
+			want:      hostMeminfo,
+		},
+		{
+			name:      "limit with unlimited swap",
+			stats:     cgroups.Stats{MemoryLimit: 512 * mib, MemoryUsage: 200 * mib, MemoryCache: 50 * mib},
+			swapLimit: -1,
+			want: `MemTotal:         524288 kB
+MemFree:          319488 kB
+MemAvailable:     370688 kB
+Buffers:               0 kB
+Cached:            51200 kB
+SwapCached:            0 kB
+SwapTotal:       4000000 kB
+SwapFree:        3000000 kB
+Shmem:             10000 kB
+`,
+		},
+		{
+			name:      "swap capped",
+			stats:     cgroups.Stats{MemoryLimit: 512 * mib, MemoryUsage: 200 * mib},
+			swapLimit: 1024 * mib,
+			want: `MemTotal:         524288 kB
+MemFree:          319488 kB
+MemAvailable:     319488 kB
+Buffers:               0 kB
+Cached:                0 kB
+SwapCached:            0 kB
+SwapTotal:       1048576 kB
+SwapFree:        1048576 kB
+Shmem:             10000 kB
+`,
+		},
+		{
+			name:      "no swap",
+			stats:     cgroups.Stats{MemoryLimit: 512 * mib, MemoryUsage: 200 * mib},
+			swapLimit: 0,
+			want: `MemTotal:         524288 kB
+MemFree:          319488 kB
+MemAvailable:     319488 kB
+Buffers:               0 kB
+Cached:                0 kB
+SwapCached:            0 kB
+SwapTotal:             0 kB
+SwapFree:              0 kB
+Shmem:             10000 kB
+`,
+		},
+		{
+			// Usage can briefly read above the limit; free memory stays at zero rather than wrapping around
+			name:      "usage over the limit",
+			stats:     cgroups.Stats{MemoryLimit: 512 * mib, MemoryUsage: 600 * mib, MemoryCache: 700 * mib},
+			swapLimit: 0,
+			want: `MemTotal:         524288 kB
+MemFree:               0 kB
+MemAvailable:     524288 kB
+Buffers:               0 kB
+Cached:           524288 kB
+SwapCached:            0 kB
+SwapTotal:             0 kB
+SwapFree:              0 kB
+Shmem:             10000 kB
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(meminfoView([]byte(hostMeminfo), &tt.stats, tt.swapLimit))
+			if got != tt.want {
+				t.Errorf("meminfoView returned\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+const hostCpuinfo = `processor	: 0
+model name	: Test CPU
+core id		: 0
+
+processor	: 2
+model name	: Test CPU
+core id		: 2
+
+processor	: 3
+model name	: Test CPU
+core id		: 3
+
+`
+
+func TestCpuinfoView(t *testing.T) {
+	tests := []struct {
+		name string
+		cpus int
+		want string
+	}{
+		{
+			name: "one",
+			cpus: 1,
+			want: "processor\t: 0\nmodel name\t: Test CPU\ncore id\t\t: 0\n\n",
+		},
+		{
+			// Numbering stays contiguous though the host skips CPU 1
+			name: "renumbered",
+			cpus: 2,
+			want: "processor\t: 0\nmodel name\t: Test CPU\ncore id\t\t: 0\n\n" +
+				"processor\t: 1\nmodel name\t: Test CPU\ncore id\t\t: 2\n\n",
+		},
+		{
+			name: "all of the host's",
+			cpus: 3,
+			want: "processor\t: 0\nmodel name\t: Test CPU\ncore id\t\t: 0\n\n" +
+				"processor\t: 1\nmodel name\t: Test CPU\ncore id\t\t: 2\n\n" +
+				"processor\t: 2\nmodel name\t: Test CPU\ncore id\t\t: 3\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(cpuinfoView([]byte(hostCpuinfo), tt.cpus)); got != tt.want {
+				t.Errorf("cpuinfoView(%d) returned\n%q\nwant\n%q", tt.cpus, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCpuinfoViewKeepsOtherEntries(t *testing.T) {
+	// s390x starts cpuinfo with an entry describing the machine rather than a processor
+	host := "vendor_id\t: IBM/S390\n# processors\t: 2\n\nprocessor\t: 0\nversion\t: 00\n\nprocessor\t: 1\nversion\t: 00\n\n"
+	want := "vendor_id\t: IBM/S390\n# processors\t: 2\n\nprocessor\t: 0\nversion\t: 00\n\n"
+	if got := string(cpuinfoView([]byte(host), 1)); got != want {
+		t.Errorf("cpuinfoView returned\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRewriteFile(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("the generated files are read-only, only root can rewrite them")
+	}
+	path := filepath.Join(t.TempDir(), "meminfo")
+
+	var first os.FileInfo
+	for _, data := range []string{"a longer first version\n", "short\n", "", "grown again to a longer one\n"} {
+		if err := rewriteFile(path, []byte(data)); err != nil {
+			t.Fatalf("rewriteFile(%q) failed: %v", data, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("file holds %q after rewriting it with %q", got, data)
+		}
+
+		// Bind mounts of the file only see writes to the same inode
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = info
+		} else if !os.SameFile(first, info) {
+			t.Errorf("rewriting the file with %q replaced it", data)
+		}
+	}
+}
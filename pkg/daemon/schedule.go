@@ -0,0 +1,436 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// maxScheduleRuns is how many runs are kept in the history of a schedule
+const maxScheduleRuns = 20
+
+// schedules holds the cron schedules the daemon runs containers on
+type schedules struct {
+	mu     sync.Mutex
+	byID   map[string]*state.Schedule
+	parsed map[string]*cronSchedule
+}
+
+// loadSchedules loads the schedules stored on disk
+func (d *Daemon) loadSchedules() error {
+	list, err := d.store.ListSchedules()
+	if err != nil {
+		return err
+	}
+
+	d.schedules.mu.Lock()
+	defer d.schedules.mu.Unlock()
+
+	d.schedules.byID = make(map[string]*state.Schedule)
+	d.schedules.parsed = make(map[string]*cronSchedule)
+	for _, schedule := range list {
+		parsed, err := parseCron(schedule.Cron)
+		if err != nil {
+			fmt.Printf("Warning: skipping schedule %s: %v\n", schedule.ID, err)
+			continue
+		}
+		d.schedules.byID[schedule.ID] = schedule
+		d.schedules.parsed[schedule.ID] = parsed
+	}
+
+	fmt.Printf("Loaded %d schedule(s) from disk\n", len(d.schedules.byID))
+	return nil
+}
+
+// CreateSchedule validates and saves a schedule; its runs are owned by the caller
+func (d *Daemon) CreateSchedule(req api.ScheduleCreateRequest, caller peer) (*api.Schedule, error) {
+	parsed, err := parseCron(req.Cron)
+	if err != nil {
+		return nil, errInvalidRequest(err)
+	}
+
+	// Catch mistakes in the template now rather than at the first run
+	// The preset is expanded now, so later changes to it don't alter the schedule
+	template := req.Template
+	if err := d.expandPreset(&template); err != nil {
+		return nil, err
+	}
+	if err := d.validateCreateRequest(&template); err != nil {
+		return nil, errInvalidRequest(err)
+	}
+	if err := d.applyDefaultLimits(&cgroups.ResourceLimits{}, template.Profile); err != nil {
+		return nil, err
+	}
+	template.Detach = true
+
+	schedule := &state.Schedule{
+		Cron:     req.Cron,
+		Template: template,
+		Enabled:  !req.Disabled,
+		Created:  time.Now(),
+	}
+	if caller.known {
+		schedule.Owner = &caller.uid
+	}
+
+	d.schedules.mu.Lock()
+	defer d.schedules.mu.Unlock()
+
+	id := make([]byte, containerIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate schedule ID: %v", err)
+	}
+	schedule.ID = hex.EncodeToString(id)
+
+	if err := d.store.SaveSchedule(schedule); err != nil {
+		return nil, err
+	}
+	d.schedules.byID[schedule.ID] = schedule
+	d.schedules.parsed[schedule.ID] = parsed
+
+	fmt.Printf("Created schedule %s (%s) for %s\n", schedule.ID, schedule.Cron, caller)
+	d.publishEvent(api.ScheduleEventType, "create", schedule.ID, map[string]string{"cron": schedule.Cron})
+	return d.scheduleInfo(schedule), nil
+}
+
+// resolveScheduleLocked finds a schedule by ID or unique ID prefix
+// The caller must hold d.schedules.mu
+func (d *Daemon) resolveScheduleLocked(ref string) (*state.Schedule, error) {
+	if ref == "" {
+		return nil, errInvalidRequest(fmt.Errorf("schedule ID required"))
+	}
+	if schedule, ok := d.schedules.byID[ref]; ok {
+		return schedule, nil
+	}
+
+	var matches []*state.Schedule
+	for id, schedule := range d.schedules.byID {
+		if strings.HasPrefix(id, ref) {
+			matches = append(matches, schedule)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, &apiError{status: http.StatusNotFound, code: api.ErrCodeScheduleNotFound, err: fmt.Errorf("schedule not found: %s", ref)}
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, &apiError{
+			status: http.StatusBadRequest,
+			code:   api.ErrCodeAmbiguousID,
+			err:    fmt.Errorf("schedule ID prefix %s is ambiguous, it matches %d schedules", ref, len(matches)),
+		}
+	}
+}
+
+// scheduleInfo converts a schedule to its API form
+// The caller must hold d.schedules.mu
+func (d *Daemon) scheduleInfo(schedule *state.Schedule) *api.Schedule {
+	info := &api.Schedule{
+		ID:       schedule.ID,
+		Cron:     schedule.Cron,
+		Template: schedule.Template,
+		Enabled:  schedule.Enabled,
+		Created:  schedule.Created,
+		Runs:     make([]api.ScheduleRun, 0, len(schedule.Runs)),
+	}
+	if schedule.Enabled {
+		info.NextRun = d.schedules.parsed[schedule.ID].next(time.Now())
+	}
+	for _, run := range schedule.Runs {
+		info.Runs = append(info.Runs, api.ScheduleRun(run))
+	}
+	return info
+}
+
+// ListSchedules returns every schedule, oldest first
+func (d *Daemon) ListSchedules() []api.Schedule {
+	d.schedules.mu.Lock()
+	defer d.schedules.mu.Unlock()
+
+	list := []api.Schedule{}
+	for _, schedule := range d.schedules.byID {
+		list = append(list, *d.scheduleInfo(schedule))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	return list
+}
+
+// InspectSchedule returns a schedule with its run history
+func (d *Daemon) InspectSchedule(ref string) (*api.Schedule, error) {
+	d.schedules.mu.Lock()
+	defer d.schedules.mu.Unlock()
+
+	schedule, err := d.resolveScheduleLocked(ref)
+	if err != nil {
+		return nil, err
+	}
+	return d.scheduleInfo(schedule), nil
+}
+
+// SetScheduleEnabled enables or disables a schedule; runs already started are left alone
+func (d *Daemon) SetScheduleEnabled(ref string, enabled bool) (*api.Schedule, error) {
+	d.schedules.mu.Lock()
+	defer d.schedules.mu.Unlock()
+
+	schedule, err := d.resolveScheduleLocked(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.Enabled != enabled {
+		schedule.Enabled = enabled
+		if err := d.store.SaveSchedule(schedule); err != nil {
+			return nil, err
+		}
+
+		action := "disable"
+		if enabled {
+			action = "enable"
+		}
+		fmt.Printf("Schedule %s: %sd\n", schedule.ID, action)
+		d.publishEvent(api.ScheduleEventType, action, schedule.ID, nil)
+	}
+	return d.scheduleInfo(schedule), nil
+}
+
+// RemoveSchedule deletes a schedule; containers it started are kept
+func (d *Daemon) RemoveSchedule(ref string) (string, error) {
+	d.schedules.mu.Lock()
+	defer d.schedules.mu.Unlock()
+
+	schedule, err := d.resolveScheduleLocked(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if err := d.store.DeleteSchedule(schedule.ID); err != nil {
+		return "", err
+	}
+	delete(d.schedules.byID, schedule.ID)
+	delete(d.schedules.parsed, schedule.ID)
+
+	fmt.Printf("Removed schedule %s\n", schedule.ID)
+	d.publishEvent(api.ScheduleEventType, "destroy", schedule.ID, nil)
+	return schedule.ID, nil
+}
+
+// runScheduler starts the containers of due schedules at the start of every minute until stop is closed
+// Like cron, runs that fall due while the daemon is down are not made up
+func (d *Daemon) runScheduler(stop <-chan struct{}) {
+	// Exit codes of scheduled runs come from their containers' die events
+	events, cancel := d.events.subscribe()
+	defer cancel()
+
+	next := time.Now().Truncate(time.Minute).Add(time.Minute)
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case event := <-events:
+			timer.Stop()
+			if event.Type == api.ContainerEventType && event.Action == "die" {
+				if scheduleID := event.Attributes["label."+api.ScheduleLabel]; scheduleID != "" {
+					code, _ := strconv.Atoi(event.Attributes["exitCode"])
+					d.recordScheduledExit(scheduleID, event.ID, code, time.Unix(0, event.TimeNano))
+				}
+			}
+			continue
+		case <-timer.C:
+		}
+
+		if d.acceptingContainers() {
+			d.runDueSchedules(next)
+		} else {
+			d.debugf("Skipping schedules due at %s: the API mode doesn't accept new containers\n", next.Format(time.RFC3339))
+		}
+
+		// After a suspend or a clock change, pick up from the current minute
+		next = next.Add(time.Minute)
+		if now := time.Now(); next.Before(now) {
+			next = now.Truncate(time.Minute).Add(time.Minute)
+		}
+	}
+}
+
+// runDueSchedules starts a run of every enabled schedule that matches minute
+func (d *Daemon) runDueSchedules(minute time.Time) {
+	d.schedules.mu.Lock()
+	var due []string
+	for id, schedule := range d.schedules.byID {
+		if schedule.Enabled && d.schedules.parsed[id].matches(minute) {
+			due = append(due, id)
+		}
+	}
+	d.schedules.mu.Unlock()
+
+	for _, id := range due {
+		go d.runSchedule(id)
+	}
+}
+
+// runSchedule starts a container from a schedule's template and records the run
+// A run is skipped while the previous run's container is still running
+func (d *Daemon) runSchedule(id string) {
+	// Holding the lock until the run is recorded keeps the container's die event from overtaking it
+	d.schedules.mu.Lock()
+	defer d.schedules.mu.Unlock()
+
+	schedule, ok := d.schedules.byID[id]
+	if !ok {
+		return
+	}
+
+	if n := len(schedule.Runs); n > 0 && schedule.Runs[n-1].ContainerID != "" && schedule.Runs[n-1].Finished.IsZero() {
+		previous := schedule.Runs[n-1].ContainerID
+		if c, err := d.getContainer(previous); err == nil && (c.Status == "running" || c.Status == "queued") {
+			reason := fmt.Sprintf("skipped, previous run %s is still %s", previous[:12], c.Status)
+			fmt.Printf("Schedule %s: %s\n", id, reason)
+			d.appendScheduleRun(schedule, state.ScheduleRun{Started: time.Now(), Finished: time.Now(), Error: reason})
+			d.publishEvent(api.ScheduleEventType, "skip", id, map[string]string{"container": previous})
+			return
+		}
+	}
+
+	template := schedule.Template
+	template.Labels = maps.Clone(template.Labels)
+	if template.Labels == nil {
+		template.Labels = make(map[string]string)
+	}
+	template.Labels[api.ScheduleLabel] = id
+
+	caller := peer{via: "schedule " + id}
+	if schedule.Owner != nil {
+		caller.known, caller.uid = true, *schedule.Owner
+	}
+
+	run := state.ScheduleRun{Started: time.Now()}
+	containerID, _, err := d.CreateContainer(context.Background(), template, caller)
+	run.ContainerID = containerID
+	if err != nil {
+		fmt.Printf("Schedule %s: failed to start container: %v\n", id, err)
+		run.Finished = time.Now()
+		run.Error = err.Error()
+		d.appendScheduleRun(schedule, run)
+		d.publishEvent(api.ScheduleEventType, "run", id, map[string]string{"error": run.Error})
+		return
+	}
+
+	fmt.Printf("Schedule %s: started container %s\n", id, containerID)
+	d.appendScheduleRun(schedule, run)
+	d.publishEvent(api.ScheduleEventType, "run", id, map[string]string{"container": containerID})
+}
+
+// appendScheduleRun adds a run to a schedule's history and saves it
+// The caller must hold d.schedules.mu
+func (d *Daemon) appendScheduleRun(schedule *state.Schedule, run state.ScheduleRun) {
+	schedule.Runs = append(schedule.Runs, run)
+	if len(schedule.Runs) > maxScheduleRuns {
+		schedule.Runs = schedule.Runs[len(schedule.Runs)-maxScheduleRuns:]
+	}
+	if err := d.store.SaveSchedule(schedule); err != nil {
+		fmt.Printf("Warning: failed to save schedule %s: %v\n", schedule.ID, err)
+	}
+}
+
+// recordScheduledExit records the exit code of a scheduled run's container
+// Containers of runs that have dropped out of the history are ignored
+func (d *Daemon) recordScheduledExit(id, containerID string, code int, at time.Time) {
+	d.schedules.mu.Lock()
+	defer d.schedules.mu.Unlock()
+
+	schedule, ok := d.schedules.byID[id]
+	if !ok {
+		return
+	}
+
+	for i := len(schedule.Runs) - 1; i >= 0; i-- {
+		run := &schedule.Runs[i]
+		if run.ContainerID != containerID {
+			continue
+		}
+		run.ExitCode = &code
+		run.Finished = at
+		if err := d.store.SaveSchedule(schedule); err != nil {
+			fmt.Printf("Warning: failed to save schedule %s: %v\n", id, err)
+		}
+		return
+	}
+}
+
+// handleScheduleCreate creates a schedule
+func (d *Daemon) handleScheduleCreate(w http.ResponseWriter, r *http.Request) {
+	var req api.ScheduleCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	schedule, err := d.CreateSchedule(req, requestPeer(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// handleScheduleList lists schedules
+func (d *Daemon) handleScheduleList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.ScheduleListResponse{Schedules: d.ListSchedules()})
+}
+
+// handleScheduleInspect returns a schedule with its run history
+func (d *Daemon) handleScheduleInspect(w http.ResponseWriter, r *http.Request) {
+	schedule, err := d.InspectSchedule(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// handleScheduleEnable enables or disables a schedule, depending on the last path element
+func (d *Daemon) handleScheduleEnable(w http.ResponseWriter, r *http.Request) {
+	enabled := strings.HasSuffix(r.URL.Path, "/enable")
+	schedule, err := d.SetScheduleEnabled(r.PathValue("id"), enabled)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// handleScheduleRemove deletes a schedule
+func (d *Daemon) handleScheduleRemove(w http.ResponseWriter, r *http.Request) {
+	id, err := d.RemoveSchedule(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.Schedule{ID: id})
+}
@@ -0,0 +1,205 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/AbhishekGY/mydocker/pkg/apparmor"
+	"github.com/AbhishekGY/mydocker/pkg/selinux"
+)
+
+// securityOptions are the parsed security options of a container
+type securityOptions struct {
+	apparmorProfile string // Empty to use the daemon default
+	labelLevel      string // SELinux MCS level requested with label=level:<level>
+	labelDisable    bool   // label=disable turns off SELinux separation for the container
+	labelRelabel    bool   // label=relabel labels the rootfs so every container can read it
+}
+
+// parseSecurityOpts parses "apparmor=<profile>", "label=level:<level>", "label=disable" and "label=relabel"
+func parseSecurityOpts(securityOpt []string) (securityOptions, error) {
+	var opts securityOptions
+	for _, opt := range securityOpt {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok || value == "" {
+			return opts, fmt.Errorf("invalid security option %q, expected KEY=VALUE", opt)
+		}
+
+		switch key {
+		case "apparmor":
+			opts.apparmorProfile = value
+		case "label":
+			switch value {
+			case "disable":
+				opts.labelDisable = true
+				continue
+			case "relabel":
+				opts.labelRelabel = true
+				continue
+			}
+			level, ok := strings.CutPrefix(value, "level:")
+			if !ok {
+				return opts, fmt.Errorf("unsupported label option %q, expected level:<level>, disable or relabel", value)
+			}
+			if err := selinux.ValidateLevel(level); err != nil {
+				return opts, err
+			}
+			opts.labelLevel = level
+		default:
+			return opts, fmt.Errorf("unknown security option %q", key)
+		}
+	}
+
+	if opts.labelDisable && opts.labelLevel != "" {
+		return opts, fmt.Errorf("label=disable conflicts with label=level")
+	}
+	if opts.labelDisable && opts.labelRelabel {
+		return opts, fmt.Errorf("label=disable conflicts with label=relabel")
+	}
+	return opts, nil
+}
+
+// setupAppArmor loads the default profile if the host has AppArmor enabled
+// Containers run unconfined by default when it can't be loaded
+func (d *Daemon) setupAppArmor() {
+	if !apparmor.IsEnabled() {
+		return
+	}
+
+	if err := apparmor.LoadDefaultProfile(); err != nil {
+		fmt.Printf("Warning: %v, containers will run without AppArmor confinement\n", err)
+		return
+	}
+
+	d.apparmorProfile = apparmor.DefaultProfile
+	fmt.Printf("Loaded AppArmor profile %s\n", apparmor.DefaultProfile)
+}
+
+// resolveAppArmorProfile picks the AppArmor profile for a container, falling back to the daemon default
+func (d *Daemon) resolveAppArmorProfile(profile string) (string, error) {
+	if profile == "" {
+		profile = d.apparmorProfile
+	}
+	if profile == "" || profile == apparmor.Unconfined {
+		return profile, nil
+	}
+
+	// A custom profile must already be loaded, otherwise container-init fails right before exec
+	if !apparmor.IsEnabled() {
+		return "", fmt.Errorf("AppArmor is not enabled on the host, cannot apply profile %s", profile)
+	}
+	loaded, err := apparmor.IsLoaded(profile)
+	if err != nil {
+		return "", err
+	}
+	if !loaded {
+		return "", fmt.Errorf("AppArmor profile %s is not loaded", profile)
+	}
+	return profile, nil
+}
+
+// selinuxLabels picks the process and mount labels for a container
+// Every container gets its own MCS level unless one is requested; both labels are empty without SELinux
+// The rootfs is only relabelled when label=relabel asks for it
+func (d *Daemon) selinuxLabels(opts securityOptions, rootfs string) (string, string, error) {
+	if !d.selinuxEnabled {
+		if opts.labelLevel != "" {
+			return "", "", fmt.Errorf("SELinux is not enabled on the host, cannot apply label level %s", opts.labelLevel)
+		}
+		if opts.labelRelabel {
+			return "", "", fmt.Errorf("SELinux is not enabled on the host, cannot relabel %s", rootfs)
+		}
+		return "", "", nil
+	}
+	if opts.labelDisable {
+		return "", "", nil
+	}
+
+	// Containers share their rootfs directory, so it gets the shared level every container can read
+	if opts.labelRelabel {
+		if err := d.checkRelabelRoot(rootfs); err != nil {
+			return "", "", errInvalidRequest(err)
+		}
+		if err := relabelShared(rootfs); err != nil {
+			return "", "", err
+		}
+	}
+
+	level := opts.labelLevel
+	if level == "" {
+		var err error
+		if level, err = selinux.NewLevel(d.selinuxLevelsInUse()); err != nil {
+			return "", "", err
+		}
+	}
+	return selinux.ProcessLabel(level), selinux.FileLabel(level), nil
+}
+
+// selinuxLevelsInUse returns the MCS levels of all known containers
+func (d *Daemon) selinuxLevelsInUse() map[string]bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	inUse := make(map[string]bool)
+	for _, c := range d.containers {
+		if level := selinux.Level(c.ProcessLabel); level != "" {
+			inUse[level] = true
+		}
+	}
+	return inUse
+}
+
+// checkRelabelRoot refuses to relabel a rootfs outside the daemon's rootfs directory and the configured rootfs-roots
+// A relabel rewrites the label of every file in the tree, so it must never reach the host's own directories
+func (d *Daemon) checkRelabelRoot(rootfs string) error {
+	if rootfsDir, err := filepath.EvalSymlinks(d.rootfsDir); err == nil && rootfs != rootfsDir && isUnder(rootfs, rootfsDir) {
+		return nil
+	}
+
+	roots := d.currentConfig().RootfsRoots
+	if len(roots) == 0 {
+		return fmt.Errorf("label=relabel needs rootfs-roots to be configured for a rootfs outside %s", d.rootfsDir)
+	}
+	ok, err := underRoots(rootfs, roots)
+	if err != nil {
+		return fmt.Errorf("rootfs %s: %v", rootfs, err)
+	}
+	if !ok {
+		return fmt.Errorf("label=relabel only relabels a rootfs under %s or a rootfs root (%s)", d.rootfsDir, strings.Join(roots, ", "))
+	}
+	return nil
+}
+
+// relabelBindSources relabels the bind mount sources a container asked for with the z and Z options
+// Nothing is relabelled for a container without a mount label, when SELinux is off or label=disable is set
+func relabelBindSources(relabels []bindRelabel, mountLabel string) error {
+	if mountLabel == "" {
+		return nil
+	}
+	for _, r := range relabels {
+		if !r.private {
+			if err := relabelShared(r.source); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("Relabeling %s as %s\n", r.source, mountLabel)
+		if err := selinux.Relabel(r.source, mountLabel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relabelShared labels a directory tree for access by all containers, like a :z mount
+// The tree is assumed to be labeled already if its top directory is
+func relabelShared(path string) error {
+	label := selinux.FileLabel(selinux.SharedLevel)
+	if current, err := selinux.FileLabelOf(path); err == nil && current == label {
+		return nil
+	}
+
+	fmt.Printf("Relabeling %s as %s\n", path, label)
+	return selinux.Relabel(path, label)
+}
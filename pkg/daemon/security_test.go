@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AbhishekGY/mydocker/pkg/config"
+)
+
+func TestParseSecurityOpts(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []string
+		want    securityOptions
+		wantErr bool
+	}{
+		{name: "none", want: securityOptions{}},
+		{name: "apparmor", opts: []string{"apparmor=unconfined"}, want: securityOptions{apparmorProfile: "unconfined"}},
+		{name: "level", opts: []string{"label=level:s0:c1,c2"}, want: securityOptions{labelLevel: "s0:c1,c2"}},
+		{name: "disable", opts: []string{"label=disable"}, want: securityOptions{labelDisable: true}},
+		{name: "relabel", opts: []string{"label=relabel"}, want: securityOptions{labelRelabel: true}},
+		{name: "relabel with level", opts: []string{"label=relabel", "label=level:s0:c1,c2"}, want: securityOptions{labelRelabel: true, labelLevel: "s0:c1,c2"}},
+		{name: "disable and level", opts: []string{"label=disable", "label=level:s0:c1,c2"}, wantErr: true},
+		{name: "disable and relabel", opts: []string{"label=disable", "label=relabel"}, wantErr: true},
+		{name: "unknown label option", opts: []string{"label=user:root"}, wantErr: true},
+		{name: "unknown key", opts: []string{"seccomp=unconfined"}, wantErr: true},
+		{name: "no value", opts: []string{"label"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSecurityOpts(tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSecurityOpts(%q) = %+v, want an error", tt.opts, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSecurityOpts(%q) failed: %v", tt.opts, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseSecurityOpts(%q) = %+v, want %+v", tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRelabelRoot(t *testing.T) {
+	tmp, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootfsDir := filepath.Join(tmp, "rootfs")
+	allowed := filepath.Join(tmp, "allowed")
+	for _, dir := range []string{filepath.Join(rootfsDir, "alpine"), filepath.Join(allowed, "app"), filepath.Join(tmp, "other")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		roots   []string
+		rootfs  string
+		wantErr bool
+	}{
+		{name: "managed rootfs", rootfs: filepath.Join(rootfsDir, "alpine")},
+		{name: "managed rootfs with roots", roots: []string{allowed}, rootfs: filepath.Join(rootfsDir, "alpine")},
+		{name: "rootfs directory itself", rootfs: rootfsDir, wantErr: true},
+		{name: "no roots", rootfs: filepath.Join(tmp, "other"), wantErr: true},
+		{name: "under a root", roots: []string{allowed}, rootfs: filepath.Join(allowed, "app")},
+		{name: "outside the roots", roots: []string{allowed}, rootfs: filepath.Join(tmp, "other"), wantErr: true},
+		{name: "host directory", roots: []string{allowed}, rootfs: "/opt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Daemon{rootfsDir: rootfsDir, config: &config.DaemonConfig{RootfsRoots: tt.roots}}
+			err := d.checkRelabelRoot(tt.rootfs)
+			if tt.wantErr && err == nil {
+				t.Errorf("checkRelabelRoot(%q) succeeded, want an error", tt.rootfs)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkRelabelRoot(%q) failed: %v", tt.rootfs, err)
+			}
+		})
+	}
+}
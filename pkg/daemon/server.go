@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/container"
 )
 
 // httpServer holds the HTTP server instance
@@ -43,17 +44,70 @@ func (d *Daemon) Start() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/containers/create", d.handleContainerCreate)
 	mux.HandleFunc("/containers/list", d.handleContainerList)
+	mux.HandleFunc("/containers/start", d.handleContainerStart)
 	mux.HandleFunc("/containers/stop", d.handleContainerStop)
+	mux.HandleFunc("/containers/attach", d.handleContainerAttach)
+	mux.HandleFunc("POST /containers/adopt", d.handleContainerAdopt)
+	mux.HandleFunc("POST /containers/{id}/clone", d.handleContainerClone)
+	mux.HandleFunc("GET /containers/{id}/stats", d.handleContainerStats)
+	mux.HandleFunc("GET /containers/{id}/json", d.handleContainerInspect)
+	mux.HandleFunc("GET /containers/{id}/logs", d.handleContainerLogs)
+	mux.HandleFunc("GET /containers/{id}/netns", d.handleContainerNetns)
+	mux.HandleFunc("GET /containers/{id}/sessions", d.handleContainerSessions)
+	mux.HandleFunc("GET /containers/{id}/sessions/{name}", d.handleContainerSession)
+	mux.HandleFunc("GET /events", d.handleEvents)
+	mux.HandleFunc("/system/reload", d.handleSystemReload)
+	mux.HandleFunc("/system/reconcile", d.handleSystemReconcile)
+	mux.HandleFunc("GET /system/queue", d.handleAdmissionQueue)
+	mux.HandleFunc("GET /system/info", d.handleSystemInfo)
+	mux.HandleFunc("POST /system/mode", d.handleSystemMode)
+	mux.HandleFunc("POST /system/drain", d.handleSystemDrain)
+	mux.HandleFunc("POST /schedules", d.handleScheduleCreate)
+	mux.HandleFunc("GET /schedules", d.handleScheduleList)
+	mux.HandleFunc("GET /schedules/{id}", d.handleScheduleInspect)
+	mux.HandleFunc("POST /schedules/{id}/enable", d.handleScheduleEnable)
+	mux.HandleFunc("POST /schedules/{id}/disable", d.handleScheduleEnable)
+	mux.HandleFunc("DELETE /schedules/{id}", d.handleScheduleRemove)
+	mux.HandleFunc("POST /services", d.handleServiceCreate)
+	mux.HandleFunc("GET /services", d.handleServiceList)
+	mux.HandleFunc("GET /services/{id}", d.handleServiceInspect)
+	mux.HandleFunc("POST /services/{id}/scale", d.handleServiceScale)
+	mux.HandleFunc("POST /services/{id}/update", d.handleServiceUpdate)
+	mux.HandleFunc("DELETE /services/{id}", d.handleServiceRemove)
+	mux.HandleFunc("POST /presets", d.handlePresetCreate)
+	mux.HandleFunc("GET /presets", d.handlePresetList)
+	mux.HandleFunc("GET /presets/{name}", d.handlePresetInspect)
+	mux.HandleFunc("DELETE /presets/{name}", d.handlePresetRemove)
+	mux.HandleFunc("POST /pods", d.handlePodCreate)
+	mux.HandleFunc("GET /pods", d.handlePodList)
+	mux.HandleFunc("GET /pods/{id}", d.handlePodInspect)
+	mux.HandleFunc("POST /pods/{id}/containers", d.handlePodAdd)
+	mux.HandleFunc("POST /pods/{id}/start", d.handlePodStart)
+	mux.HandleFunc("POST /pods/{id}/stop", d.handlePodStop)
+	mux.HandleFunc("DELETE /pods/{id}", d.handlePodRemove)
+	mux.HandleFunc("/version", d.handleVersion)
+	if d.debug {
+		d.registerDebugHandlers(mux)
+	}
 
 	// Create HTTP server
 	srv = &httpServer{
 		server: &http.Server{
-			Handler: mux,
+			Handler:     d.logRequests(d.traceRequests(d.enforceMode(mux))),
+			ConnContext: saveConn,
 		},
 	}
 
 	fmt.Printf("Daemon listening on %s\n", d.socketPath)
 
+	// Start background jobs: garbage collection, stats sampling, host memory monitoring, admission, schedules and services
+	go d.runGC(d.stopCh)
+	go d.runStatsSampler(d.stopCh)
+	go d.monitorHostMemory(d.stopCh)
+	go d.runAdmission(d.stopCh)
+	go d.runScheduler(d.stopCh)
+	go d.runServices(d.stopCh)
+
 	// Start serving (this blocks)
 	if err := srv.server.Serve(listener); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server error: %v", err)
@@ -66,9 +120,14 @@ func (d *Daemon) Start() error {
 func (d *Daemon) Stop() error {
 	fmt.Println("Shutting down daemon...")
 
-	// Stop all running containers first
+	// Stop background jobs and all running containers first
+	close(d.stopCh)
 	d.stopAllContainers()
 
+	// Release the data directory lock and send the last spans once we're done
+	defer d.pidFile.release()
+	defer stopTracing()
+
 	// Then stop the HTTP server
 	if srv != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -82,89 +141,341 @@ func (d *Daemon) Stop() error {
 // handleContainerCreate handles container creation requests
 func (d *Daemon) handleContainerCreate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 
 	var req api.ContainerCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
 		return
 	}
 
-	id, runner, err := d.CreateContainer(req)
+	id, runner, err := d.CreateContainer(r.Context(), req, requestPeer(r))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create container: %v", err), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	// If detached, just return the container ID
+	// If detached, just return the container ID; a nil runner means the start was queued
 	if req.Detach {
-		resp := api.ContainerCreateResponse{ID: id}
+		resp := api.ContainerCreateResponse{ID: id, Queued: runner == nil}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
 
+	resp := api.ContainerCreateResponse{ID: id}
+	respBytes, _ := json.Marshal(resp)
+	d.streamAttached(w, r, id, runner, respBytes)
+}
+
+// streamAttached sends respBytes to the client, then streams the container's PTY or stdio pipes until it exits
+// WebSocket clients get respBytes as a text message; others get it as the body of a hijacked HTTP response
+func (d *Daemon) streamAttached(w http.ResponseWriter, r *http.Request, id string, runner *container.Runner, respBytes []byte) {
+	// Mark the PTY as attached so /containers/attach can't steal it
+	runner.AcquireAttach()
+	defer runner.ReleaseAttach()
+
+	containerState, err := d.getContainer(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	streams := defaultAttachStreams(containerState)
+
+	if isWebSocketUpgrade(r) {
+		ws, err := upgradeWebSocket(w, r)
+		if err != nil {
+			fmt.Printf("Failed to upgrade connection for container %s: %v\n", id, err)
+			return
+		}
+		defer ws.Close()
+
+		if err := ws.writeFrame(wsOpText, respBytes); err != nil {
+			return
+		}
+
+		if !runner.Attachable() {
+			ws.writeFrame(wsOpText, []byte("Error: No PTY available for attached mode"))
+			return
+		}
+
+		d.streamContainer(r, id, ws, runner, streams, containerState.StdinOnce)
+		runner.Wait()
+		return
+	}
+
 	// For attached mode, hijack the connection and stream I/O
 	hijacker, ok := w.(http.Hijacker)
 	if !ok {
-		http.Error(w, "Hijacking not supported", http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("hijacking not supported"))
 		return
 	}
 
 	conn, bufrw, err := hijacker.Hijack()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to hijack connection: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("failed to hijack connection: %v", err))
 		return
 	}
 	defer conn.Close()
 
-	// Send container ID first as a JSON response
-	resp := api.ContainerCreateResponse{ID: id}
-	respBytes, _ := json.Marshal(resp)
-	fmt.Fprintf(bufrw, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(respBytes), string(respBytes))
+	// Send the response first as JSON
+	fmt.Fprintf(bufrw, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n%s: %s\r\nContent-Length: %d\r\n\r\n%s", streamHeader, streamKind(runner), len(respBytes), string(respBytes))
 	bufrw.Flush()
 
-	// Now stream I/O with the container's PTY
-	if runner.GetPtyFile() == nil {
+	// Now stream I/O with the container's PTY or pipes
+	// Output written before the client got here is held in them until it's read
+	if !runner.Attachable() {
 		fmt.Fprintln(bufrw, "Error: No PTY available for attached mode")
 		bufrw.Flush()
 		return
 	}
 
-	// Copy data bidirectionally between connection and PTY
+	// Copy data bidirectionally between connection and container
+	d.streamContainer(r, id, conn, runner, streams, containerState.StdinOnce)
+
+	// Wait for container to exit
+	runner.Wait()
+}
+
+// handleContainerAttach attaches a client to a running container's PTY
+// Both raw hijacked connections and WebSocket upgrades are supported
+func (d *Daemon) handleContainerAttach(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if r.URL.Query().Get("id") == "" {
+		writeError(w, errInvalidRequest(fmt.Errorf("missing container id")))
+		return
+	}
+
+	id, err := d.resolveID(r.URL.Query().Get("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	runner, err := d.getRunner(id)
+	if err != nil {
+		writeError(w, errConflict(api.ErrCodeContainerNotRunning, "container is not running: %s", id))
+		return
+	}
+
+	if !runner.Attachable() {
+		writeError(w, errConflict(api.ErrCodeAttachConflict, "container was started detached and has no PTY or stdio to attach to"))
+		return
+	}
+
+	containerState, err := d.getContainer(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	streams, err := parseAttachStreams(r.URL.Query(), defaultAttachStreams(containerState))
+	if err != nil {
+		writeError(w, errInvalidRequest(err))
+		return
+	}
+
+	if !runner.AcquireAttach() {
+		writeError(w, errConflict(api.ErrCodeAttachConflict, "another client is already attached"))
+		return
+	}
+	defer runner.ReleaseAttach()
+
+	if isWebSocketUpgrade(r) {
+		ws, err := upgradeWebSocket(w, r)
+		if err != nil {
+			fmt.Printf("Failed to upgrade connection for container %s: %v\n", id, err)
+			return
+		}
+		defer ws.Close()
+
+		d.streamContainer(r, id, ws, runner, streams, containerState.StdinOnce)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, fmt.Errorf("hijacking not supported"))
+		return
+	}
+
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to hijack connection: %v", err))
+		return
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(bufrw, "HTTP/1.1 200 OK\r\nContent-Type: application/vnd.mydocker.%s-stream\r\n%s: %s\r\n\r\n", streamKind(runner), streamHeader, streamKind(runner))
+	bufrw.Flush()
+
+	d.streamContainer(r, id, conn, runner, streams, containerState.StdinOnce)
+}
+
+// streamPty copies data bidirectionally between a client stream and the container's PTY
+// It returns as soon as either direction finishes; unselected directions are drained and dropped
+func (d *Daemon) streamPty(r *http.Request, id string, stream io.ReadWriter, runner *container.Runner, streams attachStreams) {
+	stream, stopRecording := d.recordSession(r, id, stream, runner)
+	defer stopRecording()
+
 	done := make(chan error, 2)
 
-	// Copy from connection to PTY (stdin)
+	// Copy from client to PTY (stdin)
 	go func() {
-		_, err := io.Copy(runner.GetPtyFile(), conn)
+		var err error
+		if streams.stdin {
+			_, err = io.Copy(runner.GetPtyFile(), stream)
+		} else {
+			_, err = io.Copy(io.Discard, stream)
+		}
 		done <- err
 	}()
 
-	// Copy from PTY to connection (stdout/stderr)
+	// Copy from PTY to client (stdout/stderr); the broadcaster reads the PTY for the log subsystem and passes the output on
 	go func() {
-		_, err := io.Copy(conn, runner.GetPtyFile())
-		done <- err
+		output := d.getOutput(id)
+		if output == nil {
+			done <- nil
+			return
+		}
+		done <- output.follow(func(_ byte, p []byte) error {
+			if !streams.stdout && !streams.stderr {
+				return nil
+			}
+			_, err := stream.Write(p)
+			return err
+		})
 	}()
 
 	// Wait for either direction to finish
 	<-done
-
-	// Wait for container to exit
-	runner.Wait()
 }
 
 // handleContainerList handles container listing requests
 func (d *Daemon) handleContainerList(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	opts, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, errInvalidRequest(err))
+		return
+	}
+
+	containers, total := d.ListContainersPage(opts)
+
+	w.Header().Set("Content-Type", "application/json")
+	if opts.fields == nil {
+		json.NewEncoder(w).Encode(api.ContainerListResponse{Containers: containers, Total: total})
 		return
 	}
 
-	containers := d.ListContainers()
+	// Only the selected fields are sent; clients decoding into ContainerInfo see zero values for the rest
+	selected, err := selectFields(containers, opts.fields)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"containers": selected, "total": total})
+}
+
+// handleSystemReload handles configuration reload requests
+func (d *Daemon) handleSystemReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	changed, err := d.Reload()
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to reload configuration: %w", err))
+		return
+	}
+
+	resp := api.SystemReloadResponse{Changed: changed}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleSystemReconcile handles requests to clean up orphaned container resources
+func (d *Daemon) handleSystemReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var req api.SystemReconcileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	resp := d.Reconcile(req.DryRun)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleContainerInspect returns the details of a container; history=true adds its state transitions
+func (d *Daemon) handleContainerInspect(w http.ResponseWriter, r *http.Request) {
+	inspect, err := d.InspectContainer(r.PathValue("id"), r.URL.Query().Get("history") == "true")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inspect)
+}
+
+// handleContainerNetns returns where a running container's network namespace is bind-mounted
+func (d *Daemon) handleContainerNetns(w http.ResponseWriter, r *http.Request) {
+	netns, err := d.ContainerNetns(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(netns)
+}
+
+// handleContainerStart handles requests to start a created or exited container
+func (d *Daemon) handleContainerStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var req api.ContainerStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	started, runner, err := d.StartContainer(r.Context(), req.ID, req.NoDeps, req.Attach, requestActor(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := api.ContainerStartResponse{Started: started}
+	if runner == nil {
+		// The container itself is waiting in the admission queue; only its dependencies started
+		resp.Started, resp.Queued = started[:len(started)-1], started[len(started)-1:]
+	}
+	if req.Attach {
+		respBytes, _ := json.Marshal(resp)
+		d.streamAttached(w, r, started[len(started)-1], runner, respBytes)
+		return
+	}
 
-	resp := api.ContainerListResponse{Containers: containers}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
@@ -172,23 +483,23 @@ func (d *Daemon) handleContainerList(w http.ResponseWriter, r *http.Request) {
 // handleContainerStop handles container stop requests
 func (d *Daemon) handleContainerStop(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 
 	var req api.ContainerStopRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
 		return
 	}
 
-	err := d.StopContainer(req.ID)
+	stopped, err := d.StopContainer(req.ID, req.NoDeps, requestActor(r))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to stop container: %v", err), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	resp := api.ContainerStopResponse{Success: true}
+	resp := api.ContainerStopResponse{Success: true, Stopped: stopped}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
@@ -0,0 +1,508 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// serviceInterval is how often services are reconciled with their replicas
+// It also paces restarts of replicas that keep failing
+const serviceInterval = 5 * time.Second
+
+// validServiceName matches the names services may have
+var validServiceName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// services holds the services whose replicas the daemon keeps running
+type services struct {
+	mu       sync.Mutex
+	byID     map[string]*state.Service
+	kick     chan struct{}   // Wakes the reconcile loop after a change
+	updating map[string]bool // Services being updated, which the reconcile loop leaves alone
+}
+
+// loadServices loads the services stored on disk
+func (d *Daemon) loadServices() error {
+	list, err := d.store.ListServices()
+	if err != nil {
+		return err
+	}
+
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	d.services.byID = make(map[string]*state.Service)
+	d.services.kick = make(chan struct{}, 1)
+	d.services.updating = make(map[string]bool)
+	for _, service := range list {
+		// Updates don't survive a restart; the reconcile loop keeps replicas of both versions running
+		if status := service.UpdateStatus; status != nil && (status.State == api.ServiceUpdating || status.State == api.ServiceRollingBack) {
+			status.State = api.ServiceUpdatePaused
+			status.Message = "the daemon restarted during the update"
+			if err := d.store.SaveService(service); err != nil {
+				fmt.Printf("Warning: failed to save service %s: %v\n", service.ID, err)
+			}
+		}
+		d.services.byID[service.ID] = service
+	}
+
+	fmt.Printf("Loaded %d service(s) from disk\n", len(d.services.byID))
+	return nil
+}
+
+// kickServices asks the reconcile loop to run now rather than at its next tick
+func (d *Daemon) kickServices() {
+	select {
+	case d.services.kick <- struct{}{}:
+	default:
+	}
+}
+
+// CreateService validates and saves a service; the reconcile loop starts its replicas
+func (d *Daemon) CreateService(req api.ServiceCreateRequest, caller peer) (*api.Service, error) {
+	if !validServiceName.MatchString(req.Name) {
+		return nil, errInvalidRequest(fmt.Errorf("invalid service name %q: use letters, digits, '_', '.' and '-'", req.Name))
+	}
+	if req.Replicas < 0 {
+		return nil, errInvalidRequest(fmt.Errorf("replicas cannot be negative"))
+	}
+
+	// Catch mistakes in the template now rather than in the reconcile loop
+	// The preset is expanded now, so later changes to it don't alter the service
+	template := req.Template
+	if err := d.expandPreset(&template); err != nil {
+		return nil, err
+	}
+	if err := d.validateCreateRequest(&template); err != nil {
+		return nil, errInvalidRequest(err)
+	}
+	if err := d.applyDefaultLimits(&cgroups.ResourceLimits{}, template.Profile); err != nil {
+		return nil, err
+	}
+	template.Detach = true
+
+	service := &state.Service{
+		Name:     req.Name,
+		Replicas: req.Replicas,
+		Template: template,
+		Created:  time.Now(),
+		Version:  1,
+	}
+	if caller.known {
+		service.Owner = &caller.uid
+	}
+
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	for _, existing := range d.services.byID {
+		if existing.Name == req.Name {
+			return nil, errConflict(api.ErrCodeNameInUse, "service name %s is already in use by %s", req.Name, existing.ID)
+		}
+	}
+
+	id := make([]byte, containerIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate service ID: %v", err)
+	}
+	service.ID = hex.EncodeToString(id)
+
+	if err := d.store.SaveService(service); err != nil {
+		return nil, err
+	}
+	d.services.byID[service.ID] = service
+
+	fmt.Printf("Created service %s (%s) with %d replica(s) for %s\n", service.Name, service.ID, service.Replicas, caller)
+	d.publishEvent(api.ServiceEventType, "create", service.ID, map[string]string{"name": service.Name, "replicas": strconv.Itoa(service.Replicas)})
+	d.kickServices()
+	return d.serviceInfo(service, nil), nil
+}
+
+// resolveServiceLocked finds a service by name, ID or unique ID prefix
+// The caller must hold d.services.mu
+func (d *Daemon) resolveServiceLocked(ref string) (*state.Service, error) {
+	if ref == "" {
+		return nil, errInvalidRequest(fmt.Errorf("service name or ID required"))
+	}
+	for _, service := range d.services.byID {
+		if service.Name == ref {
+			return service, nil
+		}
+	}
+	if service, ok := d.services.byID[ref]; ok {
+		return service, nil
+	}
+
+	var matches []*state.Service
+	for id, service := range d.services.byID {
+		if strings.HasPrefix(id, ref) {
+			matches = append(matches, service)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, &apiError{status: http.StatusNotFound, code: api.ErrCodeServiceNotFound, err: fmt.Errorf("service not found: %s", ref)}
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, &apiError{
+			status: http.StatusBadRequest,
+			code:   api.ErrCodeAmbiguousID,
+			err:    fmt.Errorf("service ID prefix %s is ambiguous, it matches %d services", ref, len(matches)),
+		}
+	}
+}
+
+// replicaVersion returns the version of the service template a replica was created from
+// Replicas created before services had versions are version 0
+func replicaVersion(c *state.ContainerState) int {
+	version, _ := strconv.Atoi(c.Labels[api.ServiceVersionLabel])
+	return version
+}
+
+// replicasByService returns the containers of every service, keyed by service ID and oldest first
+func (d *Daemon) replicasByService() map[string][]*state.ContainerState {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	replicas := make(map[string][]*state.ContainerState)
+	for _, c := range d.containers {
+		if id := c.Labels[api.ServiceLabel]; id != "" {
+			replicas[id] = append(replicas[id], c)
+		}
+	}
+	for _, list := range replicas {
+		sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	}
+	return replicas
+}
+
+// serviceInfo converts a service and its replicas to the API form
+func (d *Daemon) serviceInfo(service *state.Service, replicas []*state.ContainerState) *api.Service {
+	info := &api.Service{
+		ID:         service.ID,
+		Name:       service.Name,
+		Replicas:   service.Replicas,
+		Template:   service.Template,
+		Created:    service.Created,
+		Containers: []api.ServiceReplica{},
+
+		Version: service.Version,
+	}
+	if service.UpdateStatus != nil {
+		// Copied so the response isn't changed by an update in progress while it is encoded
+		status := *service.UpdateStatus
+		info.UpdateStatus = &status
+	}
+	for _, c := range replicas {
+		if c.Status == "running" {
+			info.Running++
+		}
+		info.Containers = append(info.Containers, api.ServiceReplica{ID: c.ID, Status: c.Status, Created: c.Created, Version: replicaVersion(c)})
+	}
+	return info
+}
+
+// ListServices returns every service with its replicas, oldest first
+func (d *Daemon) ListServices() []api.Service {
+	replicas := d.replicasByService()
+
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	list := []api.Service{}
+	for id, service := range d.services.byID {
+		list = append(list, *d.serviceInfo(service, replicas[id]))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	return list
+}
+
+// InspectService returns a service with its replicas
+func (d *Daemon) InspectService(ref string) (*api.Service, error) {
+	replicas := d.replicasByService()
+
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	service, err := d.resolveServiceLocked(ref)
+	if err != nil {
+		return nil, err
+	}
+	return d.serviceInfo(service, replicas[service.ID]), nil
+}
+
+// ScaleService changes the desired replica count of a service
+func (d *Daemon) ScaleService(ref string, replicas int) (*api.Service, error) {
+	if replicas < 0 {
+		return nil, errInvalidRequest(fmt.Errorf("replicas cannot be negative"))
+	}
+
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	service, err := d.resolveServiceLocked(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if service.Replicas != replicas {
+		previous := service.Replicas
+		service.Replicas = replicas
+		if err := d.store.SaveService(service); err != nil {
+			service.Replicas = previous
+			return nil, err
+		}
+
+		fmt.Printf("Scaled service %s from %d to %d replica(s)\n", service.Name, previous, replicas)
+		d.publishEvent(api.ServiceEventType, "scale", service.ID, map[string]string{
+			"name":     service.Name,
+			"replicas": strconv.Itoa(replicas),
+			"previous": strconv.Itoa(previous),
+		})
+		d.kickServices()
+	}
+	return d.serviceInfo(service, d.replicasByService()[service.ID]), nil
+}
+
+// RemoveService deletes a service; the reconcile loop stops and removes its replicas
+func (d *Daemon) RemoveService(ref string) (string, error) {
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	service, err := d.resolveServiceLocked(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if err := d.store.DeleteService(service.ID); err != nil {
+		return "", err
+	}
+	delete(d.services.byID, service.ID)
+
+	fmt.Printf("Removed service %s (%s)\n", service.Name, service.ID)
+	d.publishEvent(api.ServiceEventType, "destroy", service.ID, map[string]string{"name": service.Name})
+	d.kickServices()
+	return service.ID, nil
+}
+
+// runServices reconciles services with their replicas on every tick and after every change until stop is closed
+func (d *Daemon) runServices(stop <-chan struct{}) {
+	ticker := time.NewTicker(serviceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-d.services.kick:
+		}
+
+		// Replicas stopped while new containers aren't accepted stay stopped until the mode is back to normal
+		if d.acceptingContainers() {
+			d.reconcileServices()
+		}
+	}
+}
+
+// reconcileServices brings every service to its desired replica count and cleans up after removed services
+func (d *Daemon) reconcileServices() {
+	d.services.mu.Lock()
+	list := make([]state.Service, 0, len(d.services.byID))
+	for _, service := range d.services.byID {
+		list = append(list, *service)
+	}
+	updating := maps.Clone(d.services.updating)
+	d.services.mu.Unlock()
+
+	replicas := d.replicasByService()
+	for _, service := range list {
+		if !updating[service.ID] {
+			d.reconcileService(service, replicas[service.ID])
+		}
+		delete(replicas, service.ID)
+	}
+
+	// Whatever is left belongs to removed services
+	for id, orphans := range replicas {
+		d.reconcileService(state.Service{ID: id, Name: id[:min(len(id), 12)]}, orphans)
+	}
+}
+
+// serviceCaller returns the peer replicas of a service are created for, so they count against its owner's quota
+func serviceCaller(service state.Service) peer {
+	caller := peer{via: "service " + service.Name}
+	if service.Owner != nil {
+		caller.known, caller.uid = true, *service.Owner
+	}
+	return caller
+}
+
+// createReplica creates and starts a replica from the current template of a service
+func (d *Daemon) createReplica(service state.Service) (*state.ContainerState, error) {
+	template := service.Template
+	template.Labels = maps.Clone(template.Labels)
+	if template.Labels == nil {
+		template.Labels = make(map[string]string)
+	}
+	template.Labels[api.ServiceLabel] = service.ID
+	template.Labels[api.ServiceVersionLabel] = strconv.Itoa(service.Version)
+
+	id, _, err := d.CreateContainer(context.Background(), template, serviceCaller(service))
+	if err != nil {
+		return nil, err
+	}
+	return d.getContainer(id)
+}
+
+// reconcileService stops surplus replicas, restarts or replaces missing ones and removes replicas it no longer needs
+// Surplus replicas are the outdated ones, then the newest; replicas that can't be restarted are replaced with new containers
+func (d *Daemon) reconcileService(service state.Service, replicas []*state.ContainerState) {
+	actor := serviceCaller(service).String()
+
+	var live, outdated, idle, stale []*state.ContainerState
+	for _, c := range replicas {
+		running := c.Status == "running" || c.Status == "queued"
+		switch {
+		case running && replicaVersion(c) == service.Version:
+			live = append(live, c)
+		case running:
+			outdated = append(outdated, c)
+		case replicaVersion(c) == service.Version:
+			idle = append(idle, c)
+		default:
+			// Stopped replicas of an old template are removed rather than restarted
+			stale = append(stale, c)
+		}
+	}
+	// Outdated replicas go last so they are the first to be stopped when scaling down
+	live = append(live, outdated...)
+
+	// Scale down; stopped replicas are removed on a later pass, once their exit is recorded
+	for len(live) > service.Replicas {
+		c := live[len(live)-1]
+		live = live[:len(live)-1]
+		fmt.Printf("Service %s: stopping surplus replica %s\n", service.Name, c.ID)
+		if err := d.stopContainer(c.ID, transitionCause{actor, "service scaled down"}); err != nil {
+			fmt.Printf("Service %s: failed to stop replica %s: %v\n", service.Name, c.ID, err)
+		}
+	}
+
+	// Restart failed or stopped replicas first, then create new ones
+	for len(live) < service.Replicas && len(idle) > 0 {
+		c := idle[0]
+		idle = idle[1:]
+		if _, err := d.admitAndStart(context.Background(), c.ID, true, transitionCause{actor, "service replica restart"}); err != nil {
+			fmt.Printf("Service %s: failed to restart replica %s, replacing it: %v\n", service.Name, c.ID, err)
+			idle = append(idle, c)
+			break
+		}
+		fmt.Printf("Service %s: restarted replica %s\n", service.Name, c.ID)
+		live = append(live, c)
+	}
+	for len(live) < service.Replicas {
+		c, err := d.createReplica(service)
+		if err != nil {
+			// Tried again on the next pass
+			fmt.Printf("Service %s: failed to create replica: %v\n", service.Name, err)
+			break
+		}
+		fmt.Printf("Service %s: created replica %s\n", service.Name, c.ID)
+		live = append(live, c)
+	}
+
+	// Replicas that weren't needed are removed once they have exited
+	for _, c := range append(idle, stale...) {
+		if c.Status != "exited" && c.Status != "created" {
+			continue
+		}
+		if err := d.removeContainer(c.ID); err != nil {
+			fmt.Printf("Service %s: failed to remove replica %s: %v\n", service.Name, c.ID, err)
+			continue
+		}
+		fmt.Printf("Service %s: removed replica %s\n", service.Name, c.ID)
+		d.logEvent("destroy", c.ID, eventAttributes(c, nil))
+	}
+}
+
+// handleServiceCreate creates a service
+func (d *Daemon) handleServiceCreate(w http.ResponseWriter, r *http.Request) {
+	var req api.ServiceCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	service, err := d.CreateService(req, requestPeer(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service)
+}
+
+// handleServiceList lists services
+func (d *Daemon) handleServiceList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.ServiceListResponse{Services: d.ListServices()})
+}
+
+// handleServiceInspect returns a service with its replicas
+func (d *Daemon) handleServiceInspect(w http.ResponseWriter, r *http.Request) {
+	service, err := d.InspectService(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service)
+}
+
+// handleServiceScale changes the desired replica count of a service
+func (d *Daemon) handleServiceScale(w http.ResponseWriter, r *http.Request) {
+	var req api.ServiceScaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	service, err := d.ScaleService(r.PathValue("id"), req.Replicas)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service)
+}
+
+// handleServiceRemove deletes a service
+func (d *Daemon) handleServiceRemove(w http.ResponseWriter, r *http.Request) {
+	id, err := d.RemoveService(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.Service{ID: id})
+}
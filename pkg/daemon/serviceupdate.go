@@ -0,0 +1,265 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/state"
+)
+
+// defaultUpdateMonitor is how long new replicas must keep running to count as healthy when the request doesn't say
+const defaultUpdateMonitor = 5 * time.Second
+
+// UpdateService switches a service to a new rootfs and starts rolling its replicas over in the background
+func (d *Daemon) UpdateService(ref string, req api.ServiceUpdateRequest) (*api.Service, error) {
+	if req.Parallelism < 0 {
+		return nil, errInvalidRequest(fmt.Errorf("parallelism cannot be negative"))
+	}
+	if req.Monitor < 0 {
+		return nil, errInvalidRequest(fmt.Errorf("monitor period cannot be negative"))
+	}
+	if req.Monitor == 0 {
+		req.Monitor = defaultUpdateMonitor
+	}
+
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	service, err := d.resolveServiceLocked(ref)
+	if err != nil {
+		return nil, err
+	}
+	if d.services.updating[service.ID] {
+		return nil, errConflict(api.ErrCodeServiceUpdating, "service %s is already being updated", service.Name)
+	}
+
+	template := service.Template
+	template.Rootfs = req.Rootfs
+	if err := d.validateCreateRequest(&template); err != nil {
+		return nil, errInvalidRequest(err)
+	}
+
+	previous := *service
+	service.PreviousTemplate = &previous.Template
+	service.PreviousVersion = previous.Version
+	service.Template = template
+	service.Version = max(previous.Version, previous.PreviousVersion) + 1
+	service.UpdateStatus = &api.ServiceUpdateStatus{State: api.ServiceUpdating, Started: time.Now()}
+	if err := d.store.SaveService(service); err != nil {
+		*service = previous
+		return nil, err
+	}
+	d.services.updating[service.ID] = true
+
+	fmt.Printf("Updating service %s to %s (version %d), %s at a time\n", service.Name, template.Rootfs, service.Version, describeParallelism(req.Parallelism))
+	d.publishEvent(api.ServiceEventType, "update", service.ID, map[string]string{
+		"name":    service.Name,
+		"rootfs":  template.Rootfs,
+		"version": strconv.Itoa(service.Version),
+	})
+
+	go d.rollService(service.ID, req.Parallelism, req.Monitor, d.stopCh)
+	return d.serviceInfo(service, d.replicasByService()[service.ID]), nil
+}
+
+// describeParallelism describes how many replicas an update replaces at a time
+func describeParallelism(parallelism int) string {
+	if parallelism == 0 {
+		return "all replicas"
+	}
+	return fmt.Sprintf("%d replica(s)", parallelism)
+}
+
+// rollService replaces the outdated replicas of a service in batches, rolling back if a new replica fails
+// Each batch starts its new replicas first and stops the old ones only once the new ones have run for monitor,
+// so the service never drops below its replica count while the update goes well
+func (d *Daemon) rollService(id string, parallelism int, monitor time.Duration, stop <-chan struct{}) {
+	defer func() {
+		d.services.mu.Lock()
+		delete(d.services.updating, id)
+		d.services.mu.Unlock()
+		d.kickServices()
+	}()
+
+	for {
+		d.services.mu.Lock()
+		current, ok := d.services.byID[id]
+		var service state.Service
+		if ok {
+			service = *current
+		}
+		d.services.mu.Unlock()
+		if !ok {
+			// Removed mid-update; the reconcile loop stops its replicas
+			return
+		}
+		rollingBack := service.UpdateStatus.State == api.ServiceRollingBack
+
+		var outdated []*state.ContainerState
+		for _, c := range d.replicasByService()[id] {
+			if (c.Status == "running" || c.Status == "queued") && replicaVersion(c) != service.Version {
+				outdated = append(outdated, c)
+			}
+		}
+		if len(outdated) == 0 {
+			if rollingBack {
+				d.finishServiceUpdate(id, api.ServiceRollbackCompleted, "rollback_completed", service.UpdateStatus.Message)
+			} else {
+				d.finishServiceUpdate(id, api.ServiceUpdateCompleted, "update_completed", "")
+			}
+			return
+		}
+
+		batch := outdated
+		if parallelism > 0 && parallelism < len(batch) {
+			batch = batch[:parallelism]
+		}
+
+		failure := d.replaceReplicas(service, batch, monitor, stop)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if failure == "" {
+			continue
+		}
+
+		if rollingBack {
+			fmt.Printf("Service %s: rollback failed: %s\n", service.Name, failure)
+			d.finishServiceUpdate(id, api.ServiceUpdatePaused, "update_paused", "rollback failed: "+failure)
+			return
+		}
+		if !d.rollBackService(id, failure) {
+			return
+		}
+	}
+}
+
+// replaceReplicas starts one new replica per old replica in batch and, once they have all kept running
+// for monitor, stops the old ones
+// It returns why the batch failed, or an empty string if it succeeded
+func (d *Daemon) replaceReplicas(service state.Service, batch []*state.ContainerState, monitor time.Duration, stop <-chan struct{}) string {
+	actor := serviceCaller(service).String()
+
+	var started []*state.ContainerState
+	failure := ""
+	for range batch {
+		c, err := d.createReplica(service)
+		if err != nil {
+			failure = fmt.Sprintf("failed to create replica: %v", err)
+			break
+		}
+		fmt.Printf("Service %s: started replica %s (version %d)\n", service.Name, c.ID, service.Version)
+		started = append(started, c)
+	}
+
+	// New replicas have to stay up for the monitor period to count as healthy
+	if failure == "" {
+		select {
+		case <-stop:
+			return ""
+		case <-time.After(monitor):
+		}
+		for _, c := range started {
+			if c.Status != "running" && c.Status != "queued" {
+				failure = fmt.Sprintf("replica %s exited within %s", c.ID[:12], monitor)
+				break
+			}
+		}
+	}
+
+	// Stop whichever side of the batch lost
+	losers := batch
+	if failure != "" {
+		losers = started
+	}
+	var wg sync.WaitGroup
+	for _, c := range losers {
+		wg.Add(1)
+		go func(c *state.ContainerState) {
+			defer wg.Done()
+			if err := d.stopContainer(c.ID, transitionCause{actor, "service update"}); err != nil && c.Status != "exited" {
+				fmt.Printf("Service %s: failed to stop replica %s: %v\n", service.Name, c.ID, err)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return failure
+}
+
+// rollBackService restores the template a failed update replaced; rollService then rolls the replicas back
+// It returns false if there is nothing to roll back to
+func (d *Daemon) rollBackService(id, failure string) bool {
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	service, ok := d.services.byID[id]
+	if !ok {
+		return false
+	}
+	fmt.Printf("Service %s: update failed, rolling back: %s\n", service.Name, failure)
+
+	failed, failedVersion := service.Template, service.Version
+	service.Template, service.Version = *service.PreviousTemplate, service.PreviousVersion
+	service.PreviousTemplate, service.PreviousVersion = &failed, failedVersion
+	service.UpdateStatus.State = api.ServiceRollingBack
+	service.UpdateStatus.Message = "update failed: " + failure
+	if err := d.store.SaveService(service); err != nil {
+		fmt.Printf("Warning: failed to save service %s: %v\n", service.Name, err)
+	}
+
+	d.publishEvent(api.ServiceEventType, "rollback", service.ID, map[string]string{
+		"name":    service.Name,
+		"version": strconv.Itoa(service.Version),
+		"reason":  failure,
+	})
+	return true
+}
+
+// finishServiceUpdate records the outcome of an update
+func (d *Daemon) finishServiceUpdate(id, result, action, message string) {
+	d.services.mu.Lock()
+	defer d.services.mu.Unlock()
+
+	service, ok := d.services.byID[id]
+	if !ok {
+		return
+	}
+	service.UpdateStatus.State = result
+	service.UpdateStatus.Completed = time.Now()
+	service.UpdateStatus.Message = message
+	if err := d.store.SaveService(service); err != nil {
+		fmt.Printf("Warning: failed to save service %s: %v\n", service.Name, err)
+	}
+
+	fmt.Printf("Service %s: update %s\n", service.Name, result)
+	d.publishEvent(api.ServiceEventType, action, service.ID, map[string]string{
+		"name":    service.Name,
+		"version": strconv.Itoa(service.Version),
+	})
+}
+
+// handleServiceUpdate starts a rolling update of a service
+func (d *Daemon) handleServiceUpdate(w http.ResponseWriter, r *http.Request) {
+	var req api.ServiceUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidRequest(fmt.Errorf("invalid request: %v", err)))
+		return
+	}
+
+	service, err := d.UpdateService(r.PathValue("id"), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service)
+}
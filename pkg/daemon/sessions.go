@@ -0,0 +1,208 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+	"github.com/creack/pty"
+)
+
+// sessionExt is the file extension of session recordings (asciinema v2 "asciicast" files)
+const sessionExt = ".cast"
+
+// sessionHeader is the first line of an asciicast v2 file
+type sessionHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// sessionRecorder wraps a client stream and records the bytes flowing through it as asciicast events
+// Reads are the client's input ("i" events) and writes are the container's output ("o" events)
+type sessionRecorder struct {
+	stream io.ReadWriter
+
+	mu    sync.Mutex
+	file  *os.File
+	start time.Time
+}
+
+// Read reads client input and records it
+func (s *sessionRecorder) Read(p []byte) (int, error) {
+	n, err := s.stream.Read(p)
+	if n > 0 {
+		s.record("i", p[:n])
+	}
+	return n, err
+}
+
+// Write sends container output to the client and records it
+func (s *sessionRecorder) Write(p []byte) (int, error) {
+	n, err := s.stream.Write(p)
+	if n > 0 {
+		s.record("o", p[:n])
+	}
+	return n, err
+}
+
+// record appends one event line; recording failures never interrupt the session
+func (s *sessionRecorder) record(kind string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, _ := json.Marshal([]interface{}{time.Since(s.start).Seconds(), kind, string(data)})
+	s.file.Write(append(event, '\n'))
+}
+
+// Close finishes the recording
+func (s *sessionRecorder) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// sessionDir returns the directory holding the recordings of a container
+func (d *Daemon) sessionDir(id string) string {
+	return filepath.Join(d.dataDir, "sessions", id)
+}
+
+// recordSession starts recording a PTY session if the record-sessions policy is on
+// It returns the stream to use in place of stream; the recording ends when the returned function is called
+func (d *Daemon) recordSession(r *http.Request, id string, stream io.ReadWriter, runner *container.Runner) (io.ReadWriter, func()) {
+	if !d.currentConfig().RecordSessions {
+		return stream, func() {}
+	}
+
+	start := time.Now()
+	dir := d.sessionDir(id)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("Warning: failed to record session for container %s: %v\n", id, err)
+		return stream, func() {}
+	}
+
+	name := start.UTC().Format("20060102T150405.000000000Z") + sessionExt
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Printf("Warning: failed to record session for container %s: %v\n", id, err)
+		return stream, func() {}
+	}
+
+	// The PTY starts out without a size unless the container has set one
+	header := sessionHeader{Version: 2, Width: 80, Height: 24, Timestamp: start.Unix()}
+	if rows, cols, err := pty.Getsize(runner.GetPtyFile()); err == nil && rows > 0 && cols > 0 {
+		header.Width, header.Height = cols, rows
+	}
+	header.Title = fmt.Sprintf("%s %s (%s)", r.URL.Path, id, requestActor(r))
+	data, _ := json.Marshal(header)
+	file.Write(append(data, '\n'))
+
+	d.debugf("Recording session of container %s to %s\n", id, file.Name())
+	rec := &sessionRecorder{stream: stream, file: file, start: start}
+	return rec, func() { rec.Close() }
+}
+
+// resolveSessionsID resolves a container reference for session lookups
+// Recordings outlive their container, so a full ID with recordings on disk is accepted after the container is removed
+func (d *Daemon) resolveSessionsID(ref string) (string, error) {
+	id, err := d.resolveID(ref)
+	if err == nil {
+		return id, nil
+	}
+	if ref != filepath.Base(ref) || strings.HasPrefix(ref, ".") {
+		return "", err
+	}
+	if _, statErr := os.Stat(d.sessionDir(ref)); statErr == nil {
+		return ref, nil
+	}
+	return "", err
+}
+
+// ListSessions returns the recorded sessions of a container, oldest first
+func (d *Daemon) ListSessions(ref string) ([]api.SessionInfo, error) {
+	id, err := d.resolveSessionsID(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(d.sessionDir(id))
+	if os.IsNotExist(err) {
+		return []api.SessionInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %v", err)
+	}
+
+	sessions := []api.SessionInfo{}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), sessionExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		started, _ := time.Parse("20060102T150405.000000000Z", strings.TrimSuffix(entry.Name(), sessionExt))
+		sessions = append(sessions, api.SessionInfo{Name: entry.Name(), Started: started, Size: info.Size()})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Name < sessions[j].Name })
+	return sessions, nil
+}
+
+// openSession opens a container's recording by name
+func (d *Daemon) openSession(ref, name string) (*os.File, error) {
+	id, err := d.resolveSessionsID(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != filepath.Base(name) || !strings.HasSuffix(name, sessionExt) {
+		return nil, errInvalidRequest(fmt.Errorf("invalid session name: %s", name))
+	}
+
+	file, err := os.Open(filepath.Join(d.sessionDir(id), name))
+	if os.IsNotExist(err) {
+		return nil, &apiError{status: http.StatusNotFound, code: api.ErrCodeSessionNotFound, err: fmt.Errorf("session not found: %s", name)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session: %v", err)
+	}
+	return file, nil
+}
+
+// handleContainerSessions lists the recorded PTY sessions of a container
+func (d *Daemon) handleContainerSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := d.ListSessions(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.SessionListResponse{Sessions: sessions})
+}
+
+// handleContainerSession sends a recorded PTY session as an asciicast v2 file
+func (d *Daemon) handleContainerSession(w http.ResponseWriter, r *http.Request) {
+	file, err := d.openSession(r.PathValue("id"), r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	io.Copy(w, file)
+}
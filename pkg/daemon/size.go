@@ -0,0 +1,48 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/filesystem"
+)
+
+// sizeCacheTTL is how long a computed rootfs size is reused before walking the tree again
+const sizeCacheTTL = 30 * time.Second
+
+// sizeCache caches rootfs sizes, which are shared by every container using the same rootfs
+type sizeCache struct {
+	mu      sync.Mutex
+	entries map[string]sizeEntry
+}
+
+type sizeEntry struct {
+	size     int64
+	computed time.Time
+}
+
+// rootfsSize returns the (possibly cached) size of a rootfs directory
+func (c *sizeCache) rootfsSize(rootfs string) int64 {
+	c.mu.Lock()
+	entry, ok := c.entries[rootfs]
+	c.mu.Unlock()
+
+	if ok && time.Since(entry.computed) < sizeCacheTTL {
+		return entry.size
+	}
+
+	size, err := filesystem.DirSize(rootfs)
+	if err != nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]sizeEntry)
+	}
+	c.entries[rootfs] = sizeEntry{size: size, computed: time.Now()}
+
+	return size
+}
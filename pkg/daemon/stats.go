@@ -0,0 +1,263 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+)
+
+// statsInterval is the time between samples on a stats stream
+const statsInterval = time.Second
+
+// clockTicksPerSecond is USER_HZ, the unit of the CPU times in /proc/stat
+const clockTicksPerSecond = 100
+
+// handleContainerStats serves resource usage samples for a running container
+// With stream=false a single sample is returned; otherwise one JSON sample per line is pushed every second
+// With since set, the recorded history since then is returned instead, for running and exited containers
+func (d *Daemon) handleContainerStats(w http.ResponseWriter, r *http.Request) {
+	if value := r.URL.Query().Get("since"); value != "" {
+		since, err := parseSince(value)
+		if err != nil {
+			writeError(w, errInvalidRequest(err))
+			return
+		}
+
+		history, err := d.StatsHistory(r.PathValue("id"), since)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history)
+		return
+	}
+
+	id, err := d.resolveID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	runner, err := d.getRunner(id)
+	if err != nil {
+		writeError(w, errConflict(api.ErrCodeContainerNotRunning, "container is not running: %s", id))
+		return
+	}
+
+	stream := r.URL.Query().Get("stream") != "false"
+
+	// Take a baseline so the first sample sent already has rates filled in
+	prev, err := sampleStats(id, runner, nil)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to read stats: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-runner.Exited():
+			return
+		case <-ticker.C:
+		}
+
+		sample, err := sampleStats(id, runner, prev)
+		if err != nil {
+			// The container most likely exited between ticks
+			d.debugf("Stats stream for container %s ended: %v\n", id, err)
+			return
+		}
+		sample.Log = d.logStats(id)
+		if err := encoder.Encode(sample); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if !stream {
+			return
+		}
+		prev = sample
+	}
+}
+
+// sampleStats reads the container's current usage and computes rates against prev (if non-nil)
+func sampleStats(id string, runner *container.Runner, prev *api.ContainerStats) (*api.ContainerStats, error) {
+	cgStats, err := runner.Stats()
+	if err != nil {
+		return nil, err
+	}
+
+	systemUsage, err := systemCPUUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := netDevStats(runner.PID())
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &api.ContainerStats{
+		ID:   id,
+		Read: time.Now(),
+		CPU: api.CPUStats{
+			TotalUsage:  cgStats.CpuUsage,
+			SystemUsage: systemUsage,
+			OnlineCPUs:  runtime.NumCPU(),
+		},
+		Memory: api.MemoryStats{
+			Cache: cgStats.MemoryCache,
+			Limit: cgStats.MemoryLimit,
+		},
+		Pids: api.PidsStats{
+			Current: cgStats.PidsCurrent,
+			Limit:   cgStats.PidsLimit,
+		},
+		BlockIO: api.BlockIOStats{
+			ReadBytes:  cgStats.IoRead,
+			WriteBytes: cgStats.IoWrite,
+		},
+		Network: network,
+	}
+
+	// Report usage without reclaimable page cache, like the kernel's OOM accounting does
+	if cgStats.MemoryUsage > cgStats.MemoryCache {
+		stats.Memory.Usage = cgStats.MemoryUsage - cgStats.MemoryCache
+	}
+
+	// Unlimited containers are bounded by host memory
+	if hostMemory, err := cgroups.HostMemoryTotal(); err == nil && (stats.Memory.Limit == 0 || stats.Memory.Limit > hostMemory) {
+		stats.Memory.Limit = hostMemory
+	}
+	if stats.Memory.Limit > 0 {
+		stats.Memory.Percent = float64(stats.Memory.Usage) / float64(stats.Memory.Limit) * 100
+	}
+
+	if prev == nil {
+		return stats, nil
+	}
+
+	// Rates against the previous sample
+	cpuDelta := float64(stats.CPU.TotalUsage) - float64(prev.CPU.TotalUsage)
+	systemDelta := float64(stats.CPU.SystemUsage) - float64(prev.CPU.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPU.Percent = cpuDelta / systemDelta * float64(stats.CPU.OnlineCPUs) * 100
+	}
+
+	seconds := stats.Read.Sub(prev.Read).Seconds()
+	if seconds > 0 {
+		stats.BlockIO.ReadBytesRate = rate(prev.BlockIO.ReadBytes, stats.BlockIO.ReadBytes, seconds)
+		stats.BlockIO.WriteBytesRate = rate(prev.BlockIO.WriteBytes, stats.BlockIO.WriteBytes, seconds)
+		stats.Network.RxBytesRate = rate(prev.Network.RxBytes, stats.Network.RxBytes, seconds)
+		stats.Network.TxBytesRate = rate(prev.Network.TxBytes, stats.Network.TxBytes, seconds)
+	}
+
+	return stats, nil
+}
+
+// rate returns the per-second increase from prev to cur, treating counter resets as zero
+func rate(prev, cur uint64, seconds float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / seconds
+}
+
+// systemCPUUsage returns the host's total CPU time in nanoseconds from the "cpu" line of /proc/stat
+func systemCPUUsage() (uint64, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read /proc/stat: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "cpu" {
+			continue
+		}
+
+		var ticks uint64
+		for _, field := range fields[1:] {
+			n, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse /proc/stat: %v", err)
+			}
+			ticks += n
+		}
+		return ticks * uint64(time.Second) / clockTicksPerSecond, nil
+	}
+
+	return 0, fmt.Errorf("cpu line not found in /proc/stat")
+}
+
+// netDevStats reads the traffic counters of the interfaces in the network namespace of pid and their totals
+// The loopback interface is skipped since its traffic never leaves the container
+func netDevStats(pid int) (api.NetworkStats, error) {
+	var stats api.NetworkStats
+	file, err := os.Open(fmt.Sprintf("/proc/%d/net/dev", pid))
+	if err != nil {
+		return stats, fmt.Errorf("failed to read network stats: %v", err)
+	}
+	defer file.Close()
+
+	// Lines look like "  eth0: rx_bytes rx_packets rx_errs rx_drop ... tx_bytes tx_packets tx_errs tx_drop ..."
+	// after two header lines
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, counters, ok := strings.Cut(scanner.Text(), ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "lo" {
+			continue
+		}
+		fields := strings.Fields(counters)
+		if len(fields) < 16 {
+			continue
+		}
+		counter := func(i int) uint64 {
+			n, _ := strconv.ParseUint(fields[i], 10, 64)
+			return n
+		}
+		iface := api.InterfaceStats{
+			Name:      name,
+			RxBytes:   counter(0),
+			RxPackets: counter(1),
+			RxDropped: counter(3),
+			TxBytes:   counter(8),
+			TxPackets: counter(9),
+			TxDropped: counter(11),
+		}
+
+		stats.RxBytes += iface.RxBytes
+		stats.TxBytes += iface.TxBytes
+		stats.RxPackets += iface.RxPackets
+		stats.TxPackets += iface.TxPackets
+		stats.RxDropped += iface.RxDropped
+		stats.TxDropped += iface.TxDropped
+		stats.Interfaces = append(stats.Interfaces, iface)
+	}
+	return stats, scanner.Err()
+}
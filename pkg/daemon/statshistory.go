@@ -0,0 +1,171 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/config"
+	"github.com/AbhishekGY/mydocker/pkg/container"
+)
+
+// statsHistory keeps recent resource usage samples of each container in memory, oldest first
+type statsHistory struct {
+	mu      sync.Mutex
+	samples map[string][]*api.ContainerStats
+}
+
+// add appends a sample and drops the container's samples taken before cutoff
+func (h *statsHistory) add(sample *api.ContainerStats, cutoff time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.samples == nil {
+		h.samples = make(map[string][]*api.ContainerStats)
+	}
+	h.samples[sample.ID] = trimSamples(append(h.samples[sample.ID], sample), cutoff)
+}
+
+// last returns the most recent sample of a container, or nil
+func (h *statsHistory) last(id string) *api.ContainerStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := h.samples[id]
+	if len(samples) == 0 {
+		return nil
+	}
+	return samples[len(samples)-1]
+}
+
+// since returns a container's samples taken at or after t
+func (h *statsHistory) since(id string, t time.Time) []api.ContainerStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := []api.ContainerStats{}
+	for _, sample := range h.samples[id] {
+		if !sample.Read.Before(t) {
+			result = append(result, *sample)
+		}
+	}
+	return result
+}
+
+// prune drops samples taken before cutoff, and every sample of containers keep rejects
+func (h *statsHistory) prune(cutoff time.Time, keep func(id string) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, samples := range h.samples {
+		samples = trimSamples(samples, cutoff)
+		if len(samples) == 0 || !keep(id) {
+			delete(h.samples, id)
+			continue
+		}
+		h.samples[id] = samples
+	}
+}
+
+// trimSamples drops the samples taken before cutoff
+func trimSamples(samples []*api.ContainerStats, cutoff time.Time) []*api.ContainerStats {
+	i := 0
+	for i < len(samples) && samples[i].Read.Before(cutoff) {
+		i++
+	}
+	return samples[i:]
+}
+
+// runStatsSampler samples every running container on the configured interval until stop is closed
+// The policy is re-read before every pass so config reloads take effect without a restart
+func (d *Daemon) runStatsSampler(stop <-chan struct{}) {
+	for {
+		interval := time.Duration(d.currentConfig().StatsHistory.Interval)
+		if interval == 0 {
+			interval = config.DefaultStatsInterval
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		d.sampleAllStats()
+	}
+}
+
+// sampleAllStats records one sample of every running container and forgets expired samples
+func (d *Daemon) sampleAllStats() {
+	retention := time.Duration(d.currentConfig().StatsHistory.Retention)
+	if retention == 0 {
+		d.statsHistory.prune(time.Now(), func(string) bool { return false })
+		return
+	}
+	cutoff := time.Now().Add(-retention)
+
+	d.mu.RLock()
+	runners := make(map[string]*container.Runner, len(d.runners))
+	for id, runner := range d.runners {
+		runners[id] = runner
+	}
+	d.mu.RUnlock()
+
+	for id, runner := range runners {
+		sample, err := sampleStats(id, runner, d.statsHistory.last(id))
+		if err != nil {
+			// The container most likely exited since the runners were collected
+			d.debugf("Failed to sample stats of container %s: %v\n", id, err)
+			continue
+		}
+		sample.Log = d.logStats(id)
+		d.statsHistory.add(sample, cutoff)
+	}
+
+	// Samples of exited containers stay until they expire; removed containers lose theirs
+	d.statsHistory.prune(cutoff, func(id string) bool {
+		_, err := d.getContainer(id)
+		return err == nil
+	})
+}
+
+// StatsHistory returns the samples recorded for a container since the given time, with their peaks
+func (d *Daemon) StatsHistory(ref string, since time.Time) (*api.ContainerStatsHistory, error) {
+	id, err := d.resolveID(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.currentConfig().StatsHistory.Retention == 0 {
+		return nil, errConflict(api.ErrCodeStatsDisabled, "stats history is disabled, set stats-history.retention in the daemon config")
+	}
+
+	history := &api.ContainerStatsHistory{ID: id, Since: since, Samples: d.statsHistory.since(id, since)}
+	for _, sample := range history.Samples {
+		peak := &history.Peak
+		peak.CPUPercent = max(peak.CPUPercent, sample.CPU.Percent)
+		peak.MemoryUsage = max(peak.MemoryUsage, sample.Memory.Usage)
+		peak.MemoryPercent = max(peak.MemoryPercent, sample.Memory.Percent)
+		peak.Pids = max(peak.Pids, sample.Pids.Current)
+	}
+	return history, nil
+}
+
+// parseSince parses a "since" parameter: a duration before now ("1h"), an RFC 3339 time or a Unix timestamp
+func parseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("since duration cannot be negative: %s", value)
+		}
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since value %q: use a duration (1h), an RFC 3339 time or a Unix timestamp", value)
+}
@@ -0,0 +1,130 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/config"
+	"github.com/AbhishekGY/mydocker/pkg/tracing"
+)
+
+// applyTracing starts exporting spans to the configured collector, or stops when the endpoint was removed
+// The previous exporter sends the spans it still holds in the background
+func (d *Daemon) applyTracing(old, cfg config.TracingPolicy) {
+	if cfg == old {
+		return
+	}
+
+	var exporter *tracing.Exporter
+	if cfg.Endpoint != "" {
+		serviceName := cfg.ServiceName
+		if serviceName == "" {
+			serviceName = config.DefaultServiceName
+		}
+		exporter = tracing.NewExporter(cfg.Endpoint, serviceName)
+		fmt.Printf("Exporting traces to %s\n", cfg.Endpoint)
+	}
+
+	if previous := tracing.SetExporter(exporter); previous != nil {
+		go previous.Shutdown()
+	}
+}
+
+// stopTracing sends the spans that haven't been exported yet, on shutdown
+func stopTracing() {
+	if exporter := tracing.SetExporter(nil); exporter != nil {
+		exporter.Shutdown()
+	}
+}
+
+// traceRequests records a span for every API request, continuing the client's trace when it sends a traceparent header
+// Spans are named after the route that handled the request, so requests for different containers are grouped
+func (d *Daemon) traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.StartServer(tracing.Extract(r.Context(), r.Header), r.Method,
+			tracing.String("http.request.method", r.Method),
+			tracing.String("url.path", r.URL.Path),
+		)
+		if span == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer span.End()
+
+		r = r.WithContext(ctx)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// The mux sets the pattern on the request it was given
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(tracing.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(tracing.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("%s", http.StatusText(recorder.status)))
+		}
+	})
+}
+
+// statusRecorder remembers the status of a response while passing on flushes and hijacks
+// for the streaming and attach endpoints
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// traceStage runs one stage of an operation in a span of its own under the span in ctx, recording its error
+func traceStage(ctx context.Context, name string, stage func() error) error {
+	_, span := tracing.Start(ctx, name)
+	defer span.End()
+
+	err := stage()
+	span.RecordError(err)
+	return err
+}
+
+// startTimings records how long each stage of a container start took, for its start event
+type startTimings map[string]time.Duration
+
+// run runs a stage of a container start in its own span, recording how long it took
+func (t startTimings) run(ctx context.Context, name string, stage func() error) error {
+	begin := time.Now()
+	err := traceStage(ctx, "container."+name, stage)
+	t[name] = time.Since(begin)
+	return err
+}
+
+// attributes returns the stage timings as event attributes, "stage.<name>" with a duration such as "1.25ms"
+// total is the time the whole start took, stages and the checks between them
+func (t startTimings) attributes(total time.Duration) map[string]string {
+	attrs := make(map[string]string, len(t)+1)
+	for name, took := range t {
+		attrs["stage."+name] = took.Round(time.Microsecond).String()
+	}
+	attrs["stage.total"] = total.Round(time.Microsecond).String()
+	return attrs
+}
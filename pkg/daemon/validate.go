@@ -0,0 +1,273 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+)
+
+// dangerousRootfs are host directories that can never be a container's rootfs
+var dangerousRootfs = []string{"/", "/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib64", "/proc", "/root", "/run", "/sbin", "/sys", "/usr", "/var"}
+
+// validateCreateRequest checks the parts of a create request that don't depend on other containers
+// The rootfs is replaced by its canonical path so later symlink changes can't redirect the container
+func (d *Daemon) validateCreateRequest(req *api.ContainerCreateRequest) error {
+	if len(req.Command) == 0 || req.Command[0] == "" {
+		return fmt.Errorf("command cannot be empty")
+	}
+
+	rootfs, err := d.validateRootfs(req.Rootfs)
+	if err != nil {
+		return err
+	}
+	req.Rootfs = rootfs
+
+	return validateWaitConditions(req.WaitFor)
+}
+
+// validateRootfs resolves rootfs to a canonical directory and checks it against the rootfs policy
+// A plain name refers to a directory in the daemon's rootfs directory, which is always allowed
+// Absolute paths must be under one of the configured rootfs-roots, if any
+func (d *Daemon) validateRootfs(rootfs string) (string, error) {
+	if rootfs == "" {
+		return "", fmt.Errorf("rootfs cannot be empty")
+	}
+
+	managed := !filepath.IsAbs(rootfs)
+	if managed {
+		if rootfs == "." || rootfs == ".." || strings.Contains(rootfs, "/") {
+			return "", fmt.Errorf("rootfs must be an absolute path or the name of a directory in %s: %s", d.rootfsDir, rootfs)
+		}
+		rootfs = filepath.Join(d.rootfsDir, rootfs)
+	}
+
+	info, err := os.Stat(rootfs)
+	if err != nil {
+		return "", fmt.Errorf("rootfs %s: %v", rootfs, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("rootfs is not a directory: %s", rootfs)
+	}
+
+	// Check resolved paths so symlinks and ".." can't escape the allowed roots
+	resolved, err := filepath.EvalSymlinks(rootfs)
+	if err != nil {
+		return "", fmt.Errorf("rootfs %s: %v", rootfs, err)
+	}
+	if slices.Contains(dangerousRootfs, resolved) {
+		return "", fmt.Errorf("rootfs %s resolves to %s, which cannot be used as a rootfs", rootfs, resolved)
+	}
+
+	// The container must not see or modify the daemon's state
+	rootfsDir, err := filepath.EvalSymlinks(d.rootfsDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve rootfs directory: %v", err)
+	}
+	inRootfsDir := resolved != rootfsDir && isUnder(resolved, rootfsDir)
+	if !inRootfsDir {
+		overlaps, err := d.overlapsDataDir(resolved)
+		if err != nil {
+			return "", err
+		}
+		if overlaps {
+			return "", fmt.Errorf("rootfs %s overlaps the daemon's data directory", rootfs)
+		}
+	}
+	if managed && !inRootfsDir {
+		return "", fmt.Errorf("rootfs %s escapes %s", rootfs, d.rootfsDir)
+	}
+
+	roots := d.currentConfig().RootfsRoots
+	if len(roots) == 0 || inRootfsDir {
+		return resolved, nil
+	}
+	ok, err := underRoots(resolved, roots)
+	if err != nil {
+		return "", fmt.Errorf("rootfs %s: %v", rootfs, err)
+	}
+	if !ok {
+		return "", fmt.Errorf("rootfs %s is not under an allowed root (%s)", rootfs, strings.Join(roots, ", "))
+	}
+	return resolved, nil
+}
+
+// validateMounts checks requested mounts against the allowed mount types and bind-mount-roots
+// Bind mount sources are replaced by their canonical paths so later symlink changes can't redirect them
+// The bind sources to relabel for SELinux, asked for with the z and Z options, are returned separately
+func (d *Daemon) validateMounts(mounts []api.Mount) ([]namespace.Mount, []bindRelabel, error) {
+	cfg := d.currentConfig()
+
+	result := make([]namespace.Mount, 0, len(mounts))
+	var relabels []bindRelabel
+	destinations := make(map[string]bool)
+	for _, m := range mounts {
+		relabel, options, err := parseRelabelOption(m.Options)
+		if err != nil {
+			return nil, nil, err
+		}
+		if relabel != "" && m.Type != "bind" {
+			return nil, nil, fmt.Errorf("the %s option only applies to bind mounts: %s", relabel, m.Destination)
+		}
+
+		mount := namespace.Mount{Source: m.Source, Destination: m.Destination, Type: m.Type, Options: options}
+		if err := namespace.ValidateMount(mount); err != nil {
+			return nil, nil, err
+		}
+		if !slices.Contains(cfg.MountTypes(), mount.Type) {
+			return nil, nil, fmt.Errorf("mount type %q is not allowed (allowed: %s)", mount.Type, strings.Join(cfg.MountTypes(), ", "))
+		}
+		if destinations[mount.Destination] {
+			return nil, nil, fmt.Errorf("duplicate mount destination: %s", mount.Destination)
+		}
+		destinations[mount.Destination] = true
+
+		if mount.Type == "bind" {
+			source, err := d.validateBindSource(mount.Source)
+			if err != nil {
+				return nil, nil, err
+			}
+			mount.Source = source
+			if relabel != "" {
+				relabels = append(relabels, bindRelabel{source: source, private: relabel == "Z"})
+			}
+		}
+		result = append(result, mount)
+	}
+	return result, relabels, nil
+}
+
+// bindRelabel is a bind mount source to relabel for SELinux before the container uses it
+type bindRelabel struct {
+	source  string
+	private bool // Z gives the source the container's own level; z the shared level every container can read
+}
+
+// parseRelabelOption takes the z or Z relabel option out of a mount's options, which are otherwise passed to mount(2)
+// It returns the option, empty if neither was given, and the remaining options
+func parseRelabelOption(options []string) (string, []string, error) {
+	relabel := ""
+	var rest []string
+	for _, option := range options {
+		if option != "z" && option != "Z" {
+			rest = append(rest, option)
+			continue
+		}
+		if relabel != "" && relabel != option {
+			return "", nil, fmt.Errorf("mount options z and Z conflict")
+		}
+		relabel = option
+	}
+	return relabel, rest, nil
+}
+
+// validateBindSource resolves a bind mount source to its canonical path and checks it against the mount policy
+func (d *Daemon) validateBindSource(source string) (string, error) {
+	return d.validateHostSource("bind mount source", source)
+}
+
+// validateSecretSource resolves a secret's source file to its canonical path and checks it against the mount policy
+// Secrets are read inside the container like bind mounts, and they also can't come from the host's own
+// configuration and kernel directories, since any client of the socket could read files such as /etc/shadow
+func (d *Daemon) validateSecretSource(source string) (string, error) {
+	resolved, err := d.validateHostSource("secret source", source)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range hostSecretDirs {
+		if isUnder(resolved, dir) {
+			return "", fmt.Errorf("secret source %s resolves to %s, which is in %s", source, resolved, dir)
+		}
+	}
+	return resolved, nil
+}
+
+// hostSecretDirs hold the host's own configuration and kernel state, which secrets can't be read from
+var hostSecretDirs = []string{"/boot", "/dev", "/etc", "/proc", "/sys"}
+
+// validateHostSource resolves a host path exposed to a container, described by what in errors, to its
+// canonical path. Like a rootfs, it can't be a system directory or overlap the daemon's data directory
+func (d *Daemon) validateHostSource(what, source string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %v", what, source, err)
+	}
+	if slices.Contains(dangerousRootfs, resolved) {
+		return "", fmt.Errorf("%s %s resolves to %s, which cannot be exposed to a container", what, source, resolved)
+	}
+	overlaps, err := d.overlapsDataDir(resolved)
+	if err != nil {
+		return "", err
+	}
+	if overlaps {
+		return "", fmt.Errorf("%s %s overlaps the daemon's data directory", what, source)
+	}
+
+	// Without roots of their own, bind mounts and secrets are held to the same sandbox as the rootfs
+	cfg := d.currentConfig()
+	roots := cfg.BindMountRoots
+	if len(roots) == 0 {
+		roots = cfg.RootfsRoots
+	}
+	if len(roots) == 0 {
+		return resolved, nil
+	}
+	ok, err := underRoots(resolved, roots)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %v", what, source, err)
+	}
+	if !ok {
+		return "", fmt.Errorf("%s %s is not under an allowed root (%s)", what, source, strings.Join(roots, ", "))
+	}
+	return resolved, nil
+}
+
+// overlapsDataDir reports whether the resolved path is the daemon's data directory, inside it or one of its parents
+func (d *Daemon) overlapsDataDir(resolved string) (bool, error) {
+	dataDir, err := filepath.EvalSymlinks(d.dataDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve data directory: %v", err)
+	}
+	return isUnder(dataDir, resolved) || isUnder(resolved, dataDir), nil
+}
+
+// maskedPaths returns the default masked paths followed by the requested ones
+func maskedPaths(requested []string) ([]string, error) {
+	paths := append([]string(nil), namespace.DefaultMaskedPaths...)
+	for _, path := range requested {
+		if err := namespace.ValidateMaskedPath(path); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// underRoots reports whether path lies within one of roots
+// Resolved paths are compared so symlinks and ".." can't escape the roots
+func underRoots(path string, roots []string) (bool, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+	for _, root := range roots {
+		if resolvedRoot, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolvedRoot
+		}
+		if isUnder(resolved, root) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isUnder reports whether path is dir or inside it; both must be clean
+func isUnder(path, dir string) bool {
+	return path == dir || dir == "/" || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
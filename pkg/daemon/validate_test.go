@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/config"
+)
+
+func TestValidateSecretSource(t *testing.T) {
+	tmp, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := filepath.Join(tmp, "allowed")
+	other := filepath.Join(tmp, "other")
+	dataDir := filepath.Join(tmp, "data")
+	for _, dir := range []string{allowed, other, dataDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{filepath.Join(allowed, "db"), filepath.Join(other, "db"), filepath.Join(dataDir, "key")} {
+		if err := os.WriteFile(file, []byte("secret"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A symlink can't carry a secret out of the allowed root
+	if err := os.Symlink("/etc/passwd", filepath.Join(allowed, "passwd")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		roots   []string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{name: "no roots", source: filepath.Join(other, "db"), want: filepath.Join(other, "db")},
+		{name: "host configuration", source: "/etc/passwd", wantErr: true},
+		{name: "kernel state", source: "/proc/self/status", wantErr: true},
+		{name: "symlink to host configuration", source: filepath.Join(allowed, "passwd"), wantErr: true},
+		{name: "data directory", source: filepath.Join(dataDir, "key"), wantErr: true},
+		{name: "missing", source: filepath.Join(other, "missing"), wantErr: true},
+		{name: "under a root", roots: []string{allowed}, source: filepath.Join(allowed, "db"), want: filepath.Join(allowed, "db")},
+		{name: "outside the roots", roots: []string{allowed}, source: filepath.Join(other, "db"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Daemon{dataDir: dataDir, config: &config.DaemonConfig{BindMountRoots: tt.roots}}
+			got, err := d.validateSecretSource(tt.source)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateSecretSource(%q) = %q, want an error", tt.source, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateSecretSource(%q) failed: %v", tt.source, err)
+			}
+			if got != tt.want {
+				t.Errorf("validateSecretSource(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRelabelOption(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []string
+		want     string
+		wantRest []string
+		wantErr  bool
+	}{
+		{name: "none", options: []string{"ro", "nosuid"}, wantRest: []string{"ro", "nosuid"}},
+		{name: "shared", options: []string{"z"}, want: "z"},
+		{name: "private", options: []string{"ro", "Z", "nodev"}, want: "Z", wantRest: []string{"ro", "nodev"}},
+		{name: "repeated", options: []string{"z", "z"}, want: "z"},
+		{name: "both", options: []string{"z", "Z"}, wantErr: true},
+		{name: "data is left alone", options: []string{"size=64m", "z"}, want: "z", wantRest: []string{"size=64m"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := parseRelabelOption(tt.options)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRelabelOption(%q) = %q, want an error", tt.options, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRelabelOption(%q) failed: %v", tt.options, err)
+			}
+			if got != tt.want || !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("parseRelabelOption(%q) = %q, %q, want %q, %q", tt.options, got, rest, tt.want, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestValidateMountsRelabel(t *testing.T) {
+	tmp, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(tmp, "data")
+	if err := os.Mkdir(source, 0755); err != nil {
+		t.Fatal(err)
+	}
+	d := &Daemon{dataDir: filepath.Join(tmp, "state"), config: &config.DaemonConfig{}}
+	if err := os.Mkdir(d.dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	mounts, relabels, err := d.validateMounts([]api.Mount{
+		{Type: "bind", Source: source, Destination: "/shared", Options: []string{"ro", "z"}},
+		{Type: "bind", Source: source, Destination: "/private", Options: []string{"Z"}},
+		{Type: "bind", Source: source, Destination: "/plain"},
+	})
+	if err != nil {
+		t.Fatalf("validateMounts failed: %v", err)
+	}
+	if !reflect.DeepEqual(mounts[0].Options, []string{"ro"}) || mounts[1].Options != nil {
+		t.Errorf("relabel options were passed on as mount options: %q, %q", mounts[0].Options, mounts[1].Options)
+	}
+	want := []bindRelabel{{source: source}, {source: source, private: true}}
+	if !reflect.DeepEqual(relabels, want) {
+		t.Errorf("validateMounts returned relabels %+v, want %+v", relabels, want)
+	}
+
+	if _, _, err := d.validateMounts([]api.Mount{{Type: "tmpfs", Source: "tmpfs", Destination: "/scratch", Options: []string{"z"}}}); err == nil {
+		t.Error("validateMounts accepted z on a tmpfs mount")
+	}
+}
@@ -0,0 +1,41 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/version"
+)
+
+// apiVersionHeader is set on every response so clients can explain failures against mismatched daemons
+const apiVersionHeader = "Api-Version"
+
+// Version returns the daemon's build and host information
+func (d *Daemon) Version() api.VersionResponse {
+	return api.VersionResponse{
+		Version:       version.Version,
+		GitCommit:     version.GitCommit,
+		APIVersion:    version.APIVersion,
+		MinAPIVersion: version.MinAPIVersion,
+		GoVersion:     runtime.Version(),
+		Os:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		KernelVersion: kernelVersion(),
+		CgroupDriver:  "cgroupfs",
+		CgroupVersion: strconv.Itoa(int(d.cgroupVersion)),
+	}
+}
+
+// handleVersion handles version requests
+func (d *Daemon) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.Version())
+}
@@ -0,0 +1,258 @@
+package daemon
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// websocketGUID is the fixed GUID from RFC 6455 used to compute Sec-WebSocket-Accept
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// maxWebSocketPayload limits the size of a single incoming frame
+const maxWebSocketPayload = 1 << 20
+
+// maxWebSocketControlPayload is the largest payload RFC 6455 allows in a close, ping or pong frame
+const maxWebSocketControlPayload = 125
+
+// wsConn wraps a hijacked connection and speaks the WebSocket framing protocol.
+// Reads return the payload of incoming data frames, writes are sent as binary frames.
+type wsConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+
+	// Remaining payload of the frame currently being read
+	remaining uint64
+	mask      [4]byte
+	maskPos   int
+
+	// closed is set once a close frame has been sent or received; Close may run while a read is in progress
+	closed atomic.Bool
+}
+
+// isWebSocketUpgrade reports whether the request asks for a WebSocket upgrade
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// headerContainsToken checks if a comma-separated header contains the given token
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h[http.CanonicalHeaderKey(name)] {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeWebSocket performs the WebSocket handshake and returns the framed connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		writeError(w, errInvalidRequest(fmt.Errorf("unsupported WebSocket version")))
+		return nil, fmt.Errorf("unsupported websocket version")
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		writeError(w, errInvalidRequest(fmt.Errorf("missing Sec-WebSocket-Key")))
+		return nil, fmt.Errorf("missing websocket key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, fmt.Errorf("hijacking not supported"))
+		return nil, fmt.Errorf("hijacking not supported")
+	}
+
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hijack connection: %v", err)
+	}
+
+	// Compute the accept key as defined in RFC 6455
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(hash[:])
+
+	fmt.Fprintf(bufrw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", accept)
+	if err := bufrw.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send handshake: %v", err)
+	}
+
+	return &wsConn{
+		conn:   conn,
+		reader: bufrw.Reader,
+	}, nil
+}
+
+// Read reads payload data from incoming text/binary frames
+func (c *wsConn) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.closed.Load() {
+			return 0, io.EOF
+		}
+		if err := c.nextDataFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	if uint64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+
+	n, err := c.reader.Read(p)
+	for i := 0; i < n; i++ {
+		p[i] ^= c.mask[c.maskPos%4]
+		c.maskPos++
+	}
+	c.remaining -= uint64(n)
+
+	return n, err
+}
+
+// nextDataFrame reads frame headers until a data frame is found, handling control frames
+func (c *wsConn) nextDataFrame() error {
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+			return err
+		}
+
+		fin := header[0]&0x80 != 0
+		opcode := header[0] & 0x0F
+		masked := header[1]&0x80 != 0
+		length := uint64(header[1] & 0x7F)
+
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+				return err
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+				return err
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+
+		if length > maxWebSocketPayload {
+			return fmt.Errorf("websocket frame too large: %d bytes", length)
+		}
+		// Control frames are never fragmented and carry at most 125 bytes (RFC 6455 section 5.5)
+		if opcode&0x8 != 0 {
+			if !fin {
+				return fmt.Errorf("received fragmented websocket control frame")
+			}
+			if length > maxWebSocketControlPayload {
+				return fmt.Errorf("websocket control frame too large: %d bytes", length)
+			}
+		}
+
+		// Clients must mask every frame they send
+		if !masked {
+			return fmt.Errorf("received unmasked websocket frame")
+		}
+		if _, err := io.ReadFull(c.reader, c.mask[:]); err != nil {
+			return err
+		}
+		c.maskPos = 0
+
+		switch opcode {
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			c.remaining = length
+			if length == 0 {
+				continue
+			}
+			return nil
+		case wsOpClose, wsOpPing, wsOpPong:
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(c.reader, payload); err != nil {
+				return err
+			}
+			for i := range payload {
+				payload[i] ^= c.mask[i%4]
+			}
+
+			if opcode == wsOpClose {
+				// Echo the close unless Close already sent one
+				if c.closed.CompareAndSwap(false, true) {
+					c.writeFrame(wsOpClose, payload)
+				}
+				return io.EOF
+			}
+			if opcode == wsOpPing {
+				if err := c.writeFrame(wsOpPong, payload); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unknown websocket opcode: %d", opcode)
+		}
+	}
+}
+
+// Write sends data to the client as a single binary frame
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(wsOpBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeFrame writes a single unmasked frame (server frames are never masked)
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	length := len(payload)
+	switch {
+	case length < 126:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// Close sends a close frame and closes the underlying connection
+func (c *wsConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.writeFrame(wsOpClose, []byte{0x03, 0xE8}) // 1000: normal closure
+	}
+	return c.conn.Close()
+}
@@ -0,0 +1,243 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// clientFrame encodes a frame as a client sends it, masked unless masked is false
+func clientFrame(fin bool, opcode byte, payload []byte, masked bool) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(length))
+	default:
+		frame = append(frame, maskBit|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(length))
+	}
+
+	if !masked {
+		return append(frame, payload...)
+	}
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// readServerFrame reads one unmasked frame the server sent
+func readServerFrame(t *testing.T, r io.Reader) (byte, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		t.Fatalf("failed to read frame header: %v", err)
+	}
+	if header[0]&0x80 == 0 {
+		t.Errorf("server frame without FIN set")
+	}
+	if header[1]&0x80 != 0 {
+		t.Errorf("server frame is masked")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(r, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("failed to read frame payload: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+// pipeWebSocket returns a server-side wsConn and the client's end of the connection
+func pipeWebSocket(t *testing.T) (*wsConn, net.Conn) {
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	deadline := time.Now().Add(5 * time.Second)
+	server.SetDeadline(deadline)
+	client.SetDeadline(deadline)
+	return &wsConn{conn: server, reader: bufio.NewReader(server)}, client
+}
+
+// sendFrames writes frames from the client in the background, since net.Pipe writes wait for the reader
+func sendFrames(client net.Conn, frames ...[]byte) {
+	go func() {
+		for _, frame := range frames {
+			if _, err := client.Write(frame); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func TestWebSocketReadDataFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+	}{
+		{name: "short", length: 5},
+		{name: "empty", length: 0},
+		{name: "most in the header", length: 125},
+		{name: "16-bit length", length: 126},
+		{name: "largest 16-bit length", length: 0xFFFF},
+		{name: "64-bit length", length: 0x10000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, client := pipeWebSocket(t)
+			payload := make([]byte, tt.length)
+			for i := range payload {
+				payload[i] = byte(i * 13)
+			}
+			// The empty frame is skipped, so a trailing one marks where the payload ends
+			sendFrames(client, clientFrame(true, wsOpBinary, payload, true), clientFrame(true, wsOpText, []byte("end"), true))
+
+			got := make([]byte, tt.length+3)
+			if _, err := io.ReadFull(ws, got); err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if !bytes.Equal(got[:tt.length], payload) || string(got[tt.length:]) != "end" {
+				t.Error("Read returned a different payload than the client sent")
+			}
+		})
+	}
+}
+
+func TestWebSocketReadFragmented(t *testing.T) {
+	ws, client := pipeWebSocket(t)
+	sendFrames(client,
+		clientFrame(false, wsOpText, []byte("hel"), true),
+		clientFrame(true, wsOpContinuation, []byte("lo"), true),
+	)
+
+	got := make([]byte, 5)
+	if _, err := io.ReadFull(ws, got); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("Read returned %q, want hello", got)
+	}
+}
+
+func TestWebSocketReadRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{name: "unmasked data", frame: clientFrame(true, wsOpBinary, []byte("data"), false)},
+		{name: "unmasked ping", frame: clientFrame(true, wsOpPing, []byte("ping"), false)},
+		{name: "fragmented ping", frame: clientFrame(false, wsOpPing, []byte("ping"), true)},
+		{name: "fragmented close", frame: clientFrame(false, wsOpClose, []byte{0x03, 0xE8}, true)},
+		{name: "control frame over 125 bytes", frame: clientFrame(true, wsOpPing, make([]byte, 126), true)},
+		{name: "control frame with a 64-bit length", frame: clientFrame(true, wsOpPong, make([]byte, 0x10000), true)},
+		{name: "data frame over the limit", frame: clientFrame(true, wsOpBinary, make([]byte, maxWebSocketPayload+1), true)},
+		{name: "unknown opcode", frame: clientFrame(true, 0x3, []byte("data"), true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, client := pipeWebSocket(t)
+			// Were the frame accepted, Read would go on to return the data after it
+			sendFrames(client, tt.frame, clientFrame(true, wsOpText, []byte("data"), true))
+			go io.Copy(io.Discard, client)
+
+			n, err := ws.Read(make([]byte, 16))
+			if err == nil || err == io.EOF {
+				t.Errorf("Read = %d, %v, want an error", n, err)
+			}
+		})
+	}
+}
+
+func TestWebSocketPing(t *testing.T) {
+	ws, client := pipeWebSocket(t)
+	sendFrames(client,
+		clientFrame(true, wsOpPing, []byte("are you there"), true),
+		clientFrame(true, wsOpText, []byte("data"), true),
+	)
+
+	done := make(chan []byte)
+	go func() {
+		got := make([]byte, 4)
+		io.ReadFull(ws, got)
+		done <- got
+	}()
+
+	opcode, payload := readServerFrame(t, client)
+	if opcode != wsOpPong || string(payload) != "are you there" {
+		t.Errorf("server answered the ping with opcode %#x %q, want a pong with the ping's payload", opcode, payload)
+	}
+	if got := <-done; string(got) != "data" {
+		t.Errorf("Read after the ping returned %q, want data", got)
+	}
+}
+
+func TestWebSocketCloseEcho(t *testing.T) {
+	ws, client := pipeWebSocket(t)
+	closePayload := []byte{0x03, 0xE9, 'b', 'y', 'e'} // 1001: going away
+	sendFrames(client, clientFrame(true, wsOpClose, closePayload, true))
+
+	done := make(chan error)
+	go func() {
+		_, err := ws.Read(make([]byte, 16))
+		done <- err
+	}()
+
+	opcode, payload := readServerFrame(t, client)
+	if opcode != wsOpClose || !bytes.Equal(payload, closePayload) {
+		t.Errorf("server answered the close with opcode %#x %x, want the close echoed", opcode, payload)
+	}
+	if err := <-done; err != io.EOF {
+		t.Errorf("Read after a close returned %v, want EOF", err)
+	}
+
+	// The close was already answered, so closing the connection sends no second close frame
+	ws.Close()
+	if n, err := client.Read(make([]byte, 1)); err == nil {
+		t.Errorf("read %d more bytes after the close echo", n)
+	}
+}
+
+func TestWebSocketWriteFrames(t *testing.T) {
+	for _, length := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		ws, client := pipeWebSocket(t)
+		payload := bytes.Repeat([]byte{'x'}, length)
+
+		go ws.Write(payload)
+		opcode, got := readServerFrame(t, client)
+		if opcode != wsOpBinary || !bytes.Equal(got, payload) {
+			t.Errorf("Write of %d bytes sent opcode %#x with %d bytes", length, opcode, len(got))
+		}
+	}
+}
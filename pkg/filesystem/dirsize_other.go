@@ -0,0 +1,14 @@
+//go:build !unix
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// DirSize always fails where files have no device and inode numbers to deduplicate hard links by
+func DirSize(root string) (int64, error) {
+	return 0, fmt.Errorf("%w on %s", errors.ErrUnsupported, runtime.GOOS)
+}
@@ -0,0 +1,63 @@
+//go:build unix
+
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// DirSize returns the total size in bytes of the regular files under root
+// Hard-linked files are counted once and mount points inside root are not crossed
+func DirSize(root string) (int64, error) {
+	rootInfo, err := os.Lstat(root)
+	if err != nil {
+		return 0, err
+	}
+	rootStat, ok := rootInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("unsupported filesystem for %s", root)
+	}
+
+	var total int64
+	seen := make(map[uint64]bool)
+
+	err = filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			// Skip entries that vanish or can't be read instead of failing the whole walk
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return nil
+		}
+
+		// Don't descend into other filesystems (e.g. a mounted proc)
+		if entry.IsDir() && stat.Dev != rootStat.Dev {
+			return filepath.SkipDir
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if stat.Nlink > 1 {
+			if seen[stat.Ino] {
+				return nil
+			}
+			seen[stat.Ino] = true
+		}
+
+		total += info.Size()
+		return nil
+	})
+
+	return total, err
+}
@@ -0,0 +1,109 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// Special --format values
+const (
+	// JSON prints one JSON object per line
+	JSON = "json"
+	// Table (or an empty format) selects the command's default table output
+	Table = "table"
+)
+
+// Formatter renders values according to a --format flag
+type Formatter struct {
+	format string
+	tmpl   *template.Template
+}
+
+// templateFuncs are the helper functions available inside format templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"truncate": func(s string, n int) string {
+		if len(s) <= n {
+			return s
+		}
+		return s[:n]
+	},
+}
+
+// New parses a --format value
+// "json" and Go templates are supported; "" and "table" mean the default table output
+func New(format string) (*Formatter, error) {
+	f := &Formatter{format: format}
+
+	if f.IsTable() || f.IsJSON() {
+		return f, nil
+	}
+
+	// Allow escaped tabs and newlines as typed on a shell command line
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format template: %v", err)
+	}
+	f.tmpl = tmpl
+
+	return f, nil
+}
+
+// IsTable reports whether the caller should print its default table
+func (f *Formatter) IsTable() bool {
+	return f.format == "" || f.format == Table
+}
+
+// IsJSON reports whether the output is JSON
+func (f *Formatter) IsJSON() bool {
+	return f.format == JSON
+}
+
+// Write renders v to w. If v is a slice, every element is rendered on its own line
+func (f *Formatter) Write(w io.Writer, v interface{}) error {
+	if f.IsTable() {
+		return fmt.Errorf("table format must be rendered by the caller")
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			if err := f.writeOne(w, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return f.writeOne(w, v)
+}
+
+// writeOne renders a single value followed by a newline
+func (f *Formatter) writeOne(w io.Writer, v interface{}) error {
+	if f.IsJSON() {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode JSON: %v", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	if err := f.tmpl.Execute(w, v); err != nil {
+		return fmt.Errorf("failed to execute format template: %v", err)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
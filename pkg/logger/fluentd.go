@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// fluentdOptions are the options of the fluentd driver besides the common ones
+var fluentdOptions = []string{"fluentd-address", "fluentd-buffer-limit", "fluentd-retry-wait", "fluentd-max-retries"}
+
+// Defaults of the fluentd driver
+const (
+	defaultFluentdAddress     = "localhost:24224"
+	defaultFluentdBufferLimit = 1024
+	defaultFluentdRetryWait   = time.Second
+	defaultFluentdMaxRetries  = 10
+
+	fluentdMaxRetryWait = 30 * time.Second
+	fluentdDialTimeout  = 5 * time.Second
+	fluentdWriteTimeout = 10 * time.Second
+	fluentdCloseTimeout = 10 * time.Second
+)
+
+// fluentdConfig holds parsed fluentd options
+type fluentdConfig struct {
+	network, address string
+	bufferLimit      int
+	retryWait        time.Duration
+	maxRetries       int
+}
+
+// parseFluentdOptions parses the fluentd driver's options
+// The address is host:port, tcp://host:port or unix:///path/to/socket
+func parseFluentdOptions(opts map[string]string) (*fluentdConfig, error) {
+	cfg := &fluentdConfig{network: "tcp", address: defaultFluentdAddress}
+	if value := opts["fluentd-address"]; value != "" {
+		switch {
+		case strings.HasPrefix(value, "unix://"):
+			cfg.network, cfg.address = "unix", strings.TrimPrefix(value, "unix://")
+		case strings.HasPrefix(value, "tcp://"):
+			cfg.address = strings.TrimPrefix(value, "tcp://")
+		case strings.Contains(value, "://"):
+			return nil, fmt.Errorf("invalid fluentd-address %q, expected host:port, tcp://host:port or unix:///path", value)
+		default:
+			cfg.address = value
+		}
+		if cfg.network == "tcp" {
+			if _, _, err := net.SplitHostPort(cfg.address); err != nil {
+				return nil, fmt.Errorf("invalid fluentd-address %q: %v", value, err)
+			}
+		}
+	}
+
+	var err error
+	if cfg.bufferLimit, err = intOption(opts, "fluentd-buffer-limit", defaultFluentdBufferLimit); err != nil {
+		return nil, err
+	}
+	if cfg.bufferLimit == 0 {
+		return nil, fmt.Errorf("fluentd-buffer-limit must be at least 1")
+	}
+	if cfg.retryWait, err = durationOption(opts, "fluentd-retry-wait", defaultFluentdRetryWait); err != nil {
+		return nil, err
+	}
+	if cfg.maxRetries, err = intOption(opts, "fluentd-max-retries", defaultFluentdMaxRetries); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// fluentd ships container output to a fluentd or fluent-bit forward input
+// Each line is one event in the forward protocol's message mode: [tag, time, record]
+type fluentd struct {
+	*queue
+	cfg  *fluentdConfig
+	tag  string
+	info Info
+	conn net.Conn // Only used by the sender goroutine
+}
+
+// newFluentd starts a fluentd driver; the collector is connected to lazily, so it may come up after the container
+func newFluentd(opts map[string]string, info Info) (Driver, error) {
+	cfg, err := parseFluentdOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	tagName, err := tag(opts, info)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fluentd{cfg: cfg, tag: tagName, info: info}
+	f.queue = newQueue(cfg.bufferLimit, f.send)
+	return f, nil
+}
+
+// send delivers one message, reconnecting with a growing wait between attempts
+// After fluentd-max-retries failed attempts the message is dropped; the next message starts over
+func (f *fluentd) send(msg *Message) {
+	event := f.encode(msg)
+
+	wait := f.cfg.retryWait
+	for attempt := 0; ; attempt++ {
+		err := f.write(event)
+		if err == nil {
+			return
+		}
+		if f.conn != nil {
+			f.conn.Close()
+			f.conn = nil
+		}
+		if attempt >= f.cfg.maxRetries {
+			fmt.Printf("Warning: dropping log line of container %s, fluentd at %s is unreachable: %v\n", f.info.ShortID(), f.cfg.address, err)
+			return
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-f.closing:
+			return
+		}
+		wait = min(wait*2, fluentdMaxRetryWait)
+	}
+}
+
+// write sends an encoded event, connecting first if needed
+func (f *fluentd) write(event []byte) error {
+	if f.conn == nil {
+		conn, err := net.DialTimeout(f.cfg.network, f.cfg.address, fluentdDialTimeout)
+		if err != nil {
+			return err
+		}
+		f.conn = conn
+	}
+	f.conn.SetWriteDeadline(time.Now().Add(fluentdWriteTimeout))
+	_, err := f.conn.Write(event)
+	return err
+}
+
+// encode builds the msgpack forward protocol event for a message
+func (f *fluentd) encode(msg *Message) []byte {
+	record := [][2]string{
+		{"container_id", f.info.ID},
+		{"source", msg.Source},
+		{"log", string(msg.Line)},
+	}
+
+	buf := []byte{0x93} // fixarray of 3
+	buf = msgpackString(buf, f.tag)
+	buf = msgpackUint(buf, uint64(msg.Timestamp.Unix()))
+	buf = append(buf, 0x80|byte(len(record))) // fixmap
+	for _, kv := range record {
+		buf = msgpackString(buf, kv[0])
+		buf = msgpackString(buf, kv[1])
+	}
+	return buf
+}
+
+// Close sends the queued lines, waiting a limited time for an unreachable collector
+func (f *fluentd) Close() error {
+	f.queue.Close(fluentdCloseTimeout)
+	if f.conn != nil {
+		return f.conn.Close()
+	}
+	return nil
+}
+
+// msgpackString appends s as a msgpack str
+func msgpackString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n < 1<<8:
+		buf = append(buf, 0xd9, byte(n))
+	case n < 1<<16:
+		buf = append(buf, 0xda)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 0xdb)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(n))
+	}
+	return append(buf, s...)
+}
+
+// msgpackUint appends n as a msgpack unsigned integer
+func msgpackUint(buf []byte, n uint64) []byte {
+	switch {
+	case n < 1<<7:
+		return append(buf, byte(n))
+	case n < 1<<32:
+		buf = append(buf, 0xce)
+		return binary.BigEndian.AppendUint32(buf, uint32(n))
+	default:
+		buf = append(buf, 0xcf)
+		return binary.BigEndian.AppendUint64(buf, n)
+	}
+}
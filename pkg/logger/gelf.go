@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// gelfOptions are the options of the gelf driver besides the common ones
+var gelfOptions = []string{"gelf-address", "gelf-compression-type"}
+
+const (
+	// gelfBufferLimit is how many lines wait for the sender before the container's writes block
+	gelfBufferLimit = 1024
+	// gelfCloseTimeout bounds how long Close waits for queued lines to go out
+	gelfCloseTimeout = 5 * time.Second
+
+	// gelfChunkSize is the largest datagram sent, small enough for common MTUs
+	gelfChunkSize = 1420
+	// gelfChunkHeader is the size of the magic bytes, message ID, sequence number and count starting each chunk
+	gelfChunkHeader = 12
+	// gelfMaxChunks is the most chunks a GELF message may be split into
+	gelfMaxChunks = 128
+)
+
+// Syslog levels GELF messages are sent with
+const (
+	gelfLevelError = 3 // stderr
+	gelfLevelInfo  = 6 // stdout
+)
+
+// gelfConfig holds parsed gelf options
+type gelfConfig struct {
+	address     string
+	compression string
+}
+
+// parseGelfOptions parses the gelf driver's options; gelf-address is required and must be udp://host:port
+func parseGelfOptions(opts map[string]string) (*gelfConfig, error) {
+	value := opts["gelf-address"]
+	if value == "" {
+		return nil, fmt.Errorf("the gelf log driver needs gelf-address")
+	}
+	address, ok := strings.CutPrefix(value, "udp://")
+	if !ok {
+		return nil, fmt.Errorf("invalid gelf-address %q, expected udp://host:port", value)
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("invalid gelf-address %q: %v", value, err)
+	}
+
+	cfg := &gelfConfig{address: address, compression: "gzip"}
+	if compression, ok := opts["gelf-compression-type"]; ok {
+		switch compression {
+		case "gzip", "zlib", "none":
+			cfg.compression = compression
+		default:
+			return nil, fmt.Errorf("invalid gelf-compression-type %q, expected gzip, zlib or none", compression)
+		}
+	}
+	return cfg, nil
+}
+
+// gelf ships container output to a Graylog or other GELF collector over UDP
+type gelf struct {
+	*queue
+	cfg    *gelfConfig
+	fields map[string]any // Fields every message carries
+	conn   net.Conn       // Only used by the sender goroutine
+}
+
+// newGelf starts a gelf driver
+func newGelf(opts map[string]string, info Info) (Driver, error) {
+	cfg, err := parseGelfOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	tagName, err := tag(opts, info)
+	if err != nil {
+		return nil, err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+
+	g := &gelf{
+		cfg: cfg,
+		fields: map[string]any{
+			"version":       "1.1",
+			"host":          host,
+			"_container_id": info.ID,
+			"_command":      strings.Join(info.Command, " "),
+			"_created":      info.Created.Format(time.RFC3339Nano),
+			"_tag":          tagName,
+		},
+	}
+	g.queue = newQueue(gelfBufferLimit, g.send)
+	return g, nil
+}
+
+// send delivers one message; UDP gives no delivery guarantee, so a failed write just drops the connection
+// and the next message dials the collector again, picking up a changed address
+func (g *gelf) send(msg *Message) {
+	payload, err := g.encode(msg)
+	if err != nil {
+		fmt.Printf("Warning: dropping log line of container %s: %v\n", g.fields["_container_id"], err)
+		return
+	}
+
+	if g.conn == nil {
+		conn, err := net.Dial("udp", g.cfg.address)
+		if err != nil {
+			fmt.Printf("Warning: dropping log line, failed to reach GELF collector at %s: %v\n", g.cfg.address, err)
+			return
+		}
+		g.conn = conn
+	}
+	if err := g.write(payload); err != nil {
+		g.conn.Close()
+		g.conn = nil
+	}
+}
+
+// encode builds the compressed GELF payload for a message
+func (g *gelf) encode(msg *Message) ([]byte, error) {
+	record := make(map[string]any, len(g.fields)+4)
+	for key, value := range g.fields {
+		record[key] = value
+	}
+	record["short_message"] = string(msg.Line)
+	record["timestamp"] = float64(msg.Timestamp.UnixNano()) / float64(time.Second)
+	record["_source"] = msg.Source
+	record["level"] = gelfLevelInfo
+	if msg.Source == "stderr" {
+		record["level"] = gelfLevelError
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch g.cfg.compression {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	default:
+		return data, nil
+	}
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// write sends a payload in one datagram, or in chunks sharing a random message ID if it doesn't fit
+func (g *gelf) write(payload []byte) error {
+	if len(payload) <= gelfChunkSize {
+		_, err := g.conn.Write(payload)
+		return err
+	}
+
+	size := gelfChunkSize - gelfChunkHeader
+	count := (len(payload) + size - 1) / size
+	if count > gelfMaxChunks {
+		fmt.Printf("Warning: dropping log line of %d bytes, too large for GELF\n", len(payload))
+		return nil
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	for seq := 0; seq < count; seq++ {
+		chunk := payload[seq*size : min((seq+1)*size, len(payload))]
+		datagram := append([]byte{0x1e, 0x0f}, id...)
+		datagram = append(datagram, byte(seq), byte(count))
+		if _, err := g.conn.Write(append(datagram, chunk...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close sends the queued lines
+func (g *gelf) Close() error {
+	g.queue.Close(gelfCloseTimeout)
+	if g.conn != nil {
+		return g.conn.Close()
+	}
+	return nil
+}
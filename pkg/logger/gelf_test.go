@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// recordConn keeps the datagrams written to it
+type recordConn struct {
+	net.Conn
+	datagrams [][]byte
+}
+
+func (c *recordConn) Write(p []byte) (int, error) {
+	c.datagrams = append(c.datagrams, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func TestGelfWrite(t *testing.T) {
+	size := gelfChunkSize - gelfChunkHeader
+
+	tests := []struct {
+		name       string
+		length     int
+		wantChunks int // 0 for a single unchunked datagram
+	}{
+		{name: "small", length: 100},
+		{name: "one datagram", length: gelfChunkSize},
+		{name: "two chunks", length: gelfChunkSize + 1, wantChunks: 2},
+		{name: "full chunks", length: 2 * size, wantChunks: 2},
+		{name: "one byte more", length: 2*size + 1, wantChunks: 3},
+		{name: "8192 bytes", length: 8192, wantChunks: 6},
+		{name: "8193 bytes", length: 8193, wantChunks: 6},
+		{name: "most chunks", length: gelfMaxChunks * size, wantChunks: gelfMaxChunks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := make([]byte, tt.length)
+			for i := range payload {
+				payload[i] = byte(i * 7)
+			}
+			conn := &recordConn{}
+			g := &gelf{conn: conn}
+			if err := g.write(payload); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+
+			if tt.wantChunks == 0 {
+				if len(conn.datagrams) != 1 || !bytes.Equal(conn.datagrams[0], payload) {
+					t.Fatalf("got %d datagrams, want the payload in one", len(conn.datagrams))
+				}
+				return
+			}
+
+			if len(conn.datagrams) != tt.wantChunks {
+				t.Fatalf("got %d chunks, want %d", len(conn.datagrams), tt.wantChunks)
+			}
+			var joined []byte
+			id := conn.datagrams[0][2:10]
+			for seq, datagram := range conn.datagrams {
+				if len(datagram) > gelfChunkSize {
+					t.Errorf("chunk %d is %d bytes, larger than %d", seq, len(datagram), gelfChunkSize)
+				}
+				if datagram[0] != 0x1e || datagram[1] != 0x0f {
+					t.Errorf("chunk %d starts with %#x %#x, want the GELF chunk magic", seq, datagram[0], datagram[1])
+				}
+				if !bytes.Equal(datagram[2:10], id) {
+					t.Errorf("chunk %d has message ID %x, want %x", seq, datagram[2:10], id)
+				}
+				if int(datagram[10]) != seq || int(datagram[11]) != tt.wantChunks {
+					t.Errorf("chunk %d has sequence %d of %d, want %d of %d", seq, datagram[10], datagram[11], seq, tt.wantChunks)
+				}
+				joined = append(joined, datagram[gelfChunkHeader:]...)
+			}
+			if !bytes.Equal(joined, payload) {
+				t.Error("chunks don't reassemble into the payload")
+			}
+		})
+	}
+}
+
+func TestGelfWriteTooLarge(t *testing.T) {
+	conn := &recordConn{}
+	g := &gelf{conn: conn}
+	payload := make([]byte, gelfMaxChunks*(gelfChunkSize-gelfChunkHeader)+1)
+	if err := g.write(payload); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if len(conn.datagrams) != 0 {
+		t.Errorf("sent %d datagrams for a payload needing more than %d chunks, want it dropped", len(conn.datagrams), gelfMaxChunks)
+	}
+}
+
+func TestGelfWriteMessageIDs(t *testing.T) {
+	conn := &recordConn{}
+	g := &gelf{conn: conn}
+	payload := make([]byte, 2*gelfChunkSize)
+	for i := 0; i < 2; i++ {
+		if err := g.write(payload); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	first, second := conn.datagrams[0][2:10], conn.datagrams[len(conn.datagrams)-1][2:10]
+	if bytes.Equal(first, second) {
+		t.Errorf("two messages were sent with the same ID %x", first)
+	}
+}
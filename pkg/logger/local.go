@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// followerBuffer is how many lines a follower may fall behind before the container's output waits for it
+const followerBuffer = 256
+
+// Entry is one line of a container's output as the local store keeps it
+type Entry struct {
+	Line      string    `json:"log"` // Without the trailing newline
+	Stream    string    `json:"stream"`
+	Timestamp time.Time `json:"time"`
+}
+
+// Local keeps a container's output in a file of JSON lines, one Entry per line, so it can be read back later
+// Containers have one whichever driver also ships their output elsewhere. Followers get the lines logged
+// after they subscribed; one that falls behind holds up the container's output like a slow attached client
+type Local struct {
+	mu        sync.Mutex
+	file      *os.File
+	size      int64 // Bytes in the file, always whole lines
+	followers map[*follower]bool
+	closed    bool
+}
+
+// follower is a reader of the lines logged to a Local store
+type follower struct {
+	entries chan Entry
+	done    chan struct{} // Closed once the follower stops reading
+	stop    sync.Once
+}
+
+// OpenLocal opens the local store at path for appending, creating it and its directory if needed
+func OpenLocal(path string) (*Local, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open log file: %v", err)
+	}
+	return &Local{file: file, size: info.Size(), followers: make(map[*follower]bool)}, nil
+}
+
+// Log appends a line to the file and passes it on to the followers
+func (l *Local) Log(msg *Message) error {
+	entry := Entry{Line: string(msg.Line), Stream: msg.Source, Timestamp: msg.Timestamp}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return fmt.Errorf("log file is closed")
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	for f := range l.followers {
+		select {
+		case f.entries <- entry:
+		case <-f.done:
+		}
+	}
+	return err
+}
+
+// Follow subscribes to the lines logged from now on, returning how many bytes of the file came before them
+// The channel is closed once the store is; stop must be called when the caller stops reading before then
+func (l *Local) Follow() (offset int64, entries <-chan Entry, stop func()) {
+	f := &follower{entries: make(chan Entry, followerBuffer), done: make(chan struct{})}
+	stop = func() {
+		f.stop.Do(func() { close(f.done) })
+		l.mu.Lock()
+		delete(l.followers, f)
+		l.mu.Unlock()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		close(f.entries)
+		return l.size, f.entries, stop
+	}
+	l.followers[f] = true
+	return l.size, f.entries, stop
+}
+
+// Close closes the file and ends the followers' channels
+func (l *Local) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	for f := range l.followers {
+		close(f.entries)
+	}
+	l.followers = nil
+	return l.file.Close()
+}
+
+// ReadLocal calls fn with the entries stored in the local store at path, in order, stopping at the first error
+// Only the first limit bytes are read, all of them when limit is negative; a missing file holds no entries
+func ReadLocal(path string, limit int64, fn func(Entry) error) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if limit >= 0 {
+		r = io.LimitReader(file, limit)
+	}
+	scanner := bufio.NewScanner(r)
+	// Lines are split at maxLineSize before they are stored, escaping can at most multiply that by six
+	scanner.Buffer(make([]byte, 64*1024), 8*maxLineSize)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A line cut short by a crash is skipped rather than hiding everything after it
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read log file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Message is one line of container output
+type Message struct {
+	Line      []byte // Without the trailing newline
+	Source    string // "stdout" or "stderr"
+	Timestamp time.Time
+}
+
+// Info identifies the container a driver ships output for
+type Info struct {
+	ID      string // Full container ID
+	Command []string
+	Created time.Time
+	Labels  map[string]string
+}
+
+// ShortID returns the container ID as the CLI shows it
+func (i Info) ShortID() string {
+	if len(i.ID) > 12 {
+		return i.ID[:12]
+	}
+	return i.ID
+}
+
+// Driver ships the output of one container to a log collector
+// Log may block while the driver's buffer is full, which holds up the container's writes, unless the driver
+// runs in non-blocking mode
+type Driver interface {
+	Log(msg *Message) error
+	Close() error
+}
+
+// driver describes a log driver and the options it accepts
+type driver struct {
+	options []string
+	new     func(opts map[string]string, info Info) (Driver, error)
+}
+
+// commonOptions are the options every log driver accepts
+var commonOptions = []string{"tag", "mode", "max-buffer-size"}
+
+// drivers are the log drivers by name
+var drivers = map[string]driver{
+	"fluentd": {options: fluentdOptions, new: newFluentd},
+	"gelf":    {options: gelfOptions, new: newGelf},
+}
+
+// Names returns the names of the log drivers, sorted
+func Names() []string {
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateOptions checks that name is a log driver and opts only holds options it knows, with valid values
+func ValidateOptions(name string, opts map[string]string) error {
+	d, ok := drivers[name]
+	if !ok {
+		return fmt.Errorf("unknown log driver %q, expected one of: %s", name, strings.Join(Names(), ", "))
+	}
+	for key := range opts {
+		if !slices.Contains(commonOptions, key) && !slices.Contains(d.options, key) {
+			return fmt.Errorf("log driver %s has no option %q", name, key)
+		}
+	}
+	if _, err := template.New("tag").Parse(opts["tag"]); err != nil {
+		return fmt.Errorf("invalid tag template: %v", err)
+	}
+	if _, err := parseModeOptions(opts); err != nil {
+		return err
+	}
+	// Parsing the options without connecting catches the remaining mistakes
+	switch name {
+	case "fluentd":
+		_, err := parseFluentdOptions(opts)
+		return err
+	case "gelf":
+		_, err := parseGelfOptions(opts)
+		return err
+	}
+	return nil
+}
+
+// New starts a log driver for a container; opts must have passed ValidateOptions
+// In non-blocking mode the driver is fronted by a ring buffer of max-buffer-size bytes
+func New(name string, opts map[string]string, info Info) (Driver, error) {
+	d, ok := drivers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown log driver %q", name)
+	}
+	bufferSize, err := parseModeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := d.new(opts, info)
+	if err != nil {
+		return nil, err
+	}
+	if bufferSize > 0 {
+		return newRing(driver, bufferSize), nil
+	}
+	return driver, nil
+}
+
+// tag renders the "tag" option for a container, a template over Info with .ID giving the short ID
+// Containers are tagged with their short ID when no tag is set
+func tag(opts map[string]string, info Info) (string, error) {
+	text := opts["tag"]
+	if text == "" {
+		return info.ShortID(), nil
+	}
+
+	tmpl, err := template.New("tag").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag template: %v", err)
+	}
+	data := struct {
+		ID      string
+		FullID  string
+		Command string
+		Labels  map[string]string
+	}{info.ShortID(), info.ID, strings.Join(info.Command, " "), info.Labels}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid tag template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// durationOption parses a duration option, returning def when it's unset
+func durationOption(opts map[string]string, key string, def time.Duration) (time.Duration, error) {
+	value, ok := opts[key]
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a positive duration such as 1s", key, value)
+	}
+	return d, nil
+}
+
+// intOption parses a non-negative integer option, returning def when it's unset
+func intOption(opts map[string]string, key string, def int) (int, error) {
+	value, ok := opts[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a non-negative integer", key, value)
+	}
+	return n, nil
+}
+
+// queue hands messages from the container's output to a single sender goroutine through a bounded buffer
+// Log blocks while the buffer is full, so a slow collector slows the container down rather than losing output
+type queue struct {
+	messages chan *Message
+	closing  chan struct{} // Closed by Close so a sender waiting to retry gives up
+	done     chan struct{} // Closed once the sender has returned
+
+	mu     sync.RWMutex // Held for reading while logging, so Close waits for writers in the middle of one
+	closed bool
+}
+
+// newQueue starts send on a goroutine for every message logged; send returns once the message is
+// delivered or dropped
+func newQueue(size int, send func(msg *Message)) *queue {
+	q := &queue{
+		messages: make(chan *Message, size),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(q.done)
+		for msg := range q.messages {
+			send(msg)
+		}
+	}()
+	return q
+}
+
+// Log queues a message for the sender
+func (q *queue) Log(msg *Message) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return fmt.Errorf("log driver is closed")
+	}
+	q.messages <- msg
+	return nil
+}
+
+// Close sends what is still queued, giving up on messages once waiting for the collector outlasts timeout
+func (q *queue) Close(timeout time.Duration) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.messages)
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+	case <-time.After(timeout):
+		close(q.closing)
+		<-q.done
+	}
+}
+
+// tee logs every line to several drivers
+type tee []Driver
+
+// Tee returns a driver logging every line to each of drivers in turn
+// Closing it closes them all, returning the first error
+func Tee(drivers ...Driver) Driver {
+	return tee(drivers)
+}
+
+// Log hands the line to every driver, returning the first error
+func (t tee) Log(msg *Message) error {
+	var first error
+	for _, d := range t {
+		if err := d.Log(msg); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close closes every driver, returning the first error
+func (t tee) Close() error {
+	var first error
+	for _, d := range t {
+		if err := d.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Writer splits a container stream into lines for a driver
+type Writer struct {
+	driver Driver
+	source string
+	buf    []byte
+}
+
+// maxLineSize is the longest line sent as one message; longer lines are split
+const maxLineSize = 16 * 1024
+
+// NewWriter returns a writer logging every line written to it as coming from source
+// Close logs what is left after the last newline
+func NewWriter(d Driver, source string) *Writer {
+	return &Writer{driver: d, source: source}
+}
+
+// Write logs every complete line in p and keeps the rest for the next write
+// It never fails, since a container whose output stopped being read would block; lines the driver refuses are dropped
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 && len(w.buf) < maxLineSize {
+			return len(p), nil
+		}
+		next := i + 1
+		if i < 0 || i > maxLineSize {
+			i, next = maxLineSize, maxLineSize
+		}
+		line := append([]byte(nil), w.buf[:i]...)
+		w.buf = w.buf[next:]
+		w.driver.Log(&Message{Line: line, Source: w.source, Timestamp: time.Now()})
+	}
+}
+
+// Close logs any output left without a trailing newline
+func (w *Writer) Close() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.driver.Log(&Message{Line: line, Source: w.source, Timestamp: time.Now()})
+}
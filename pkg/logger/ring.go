@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Modes a driver's lines are handed to it in, set with the "mode" option
+const (
+	ModeBlocking    = "blocking"     // Writes wait while the driver's buffer is full
+	ModeNonBlocking = "non-blocking" // Writes never wait; the oldest buffered lines are dropped to make room
+)
+
+// defaultMaxBufferSize is the size of a non-blocking ring buffer when max-buffer-size is unset
+const defaultMaxBufferSize = 1024 * 1024
+
+// ring sits in front of a driver in non-blocking mode, buffering up to maxSize bytes of lines in memory
+// When a line doesn't fit, the oldest lines are dropped and counted, so a slow collector costs log lines
+// instead of stalling the container's output
+type ring struct {
+	driver  Driver
+	maxSize int
+	dropped atomic.Uint64
+
+	mu       sync.Mutex
+	cond     *sync.Cond // Signalled when a line is buffered or the ring closes
+	messages []*Message
+	size     int // Bytes of lines buffered
+	closed   bool
+
+	done chan struct{} // Closed once every buffered line has been passed to the driver
+}
+
+// newRing starts forwarding lines to driver through a ring buffer of maxSize bytes
+func newRing(driver Driver, maxSize int) *ring {
+	r := &ring{driver: driver, maxSize: maxSize, done: make(chan struct{})}
+	r.cond = sync.NewCond(&r.mu)
+	go r.forward()
+	return r
+}
+
+// Log buffers a message without waiting, dropping the oldest lines if it doesn't fit
+// A line larger than the whole buffer is dropped itself
+func (r *ring) Log(msg *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("log driver is closed")
+	}
+	if len(msg.Line) > r.maxSize {
+		r.dropped.Add(1)
+		return nil
+	}
+	for r.size+len(msg.Line) > r.maxSize {
+		r.size -= len(r.messages[0].Line)
+		r.messages[0] = nil
+		r.messages = r.messages[1:]
+		r.dropped.Add(1)
+	}
+	r.messages = append(r.messages, msg)
+	r.size += len(msg.Line)
+	r.cond.Signal()
+	return nil
+}
+
+// forward passes buffered lines to the driver, which may block, until the ring is closed and empty
+func (r *ring) forward() {
+	defer close(r.done)
+	for {
+		r.mu.Lock()
+		for len(r.messages) == 0 && !r.closed {
+			r.cond.Wait()
+		}
+		if len(r.messages) == 0 {
+			r.mu.Unlock()
+			return
+		}
+		msg := r.messages[0]
+		r.messages[0] = nil
+		r.messages = r.messages[1:]
+		r.size -= len(msg.Line)
+		r.mu.Unlock()
+
+		r.driver.Log(msg)
+	}
+}
+
+// Dropped returns how many lines were dropped because the buffer was full
+func (r *ring) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+// Close passes the buffered lines on and closes the driver
+func (r *ring) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.cond.Signal()
+	r.mu.Unlock()
+
+	<-r.done
+	return r.driver.Close()
+}
+
+// DroppedLines returns how many lines a driver dropped because its buffer was full, 0 in blocking mode
+func DroppedLines(d Driver) uint64 {
+	if r, ok := d.(*ring); ok {
+		return r.Dropped()
+	}
+	return 0
+}
+
+// parseModeOptions parses the mode and max-buffer-size options every driver accepts, returning the buffer size
+// in non-blocking mode and 0 in blocking mode
+func parseModeOptions(opts map[string]string) (int, error) {
+	mode := opts["mode"]
+	switch mode {
+	case "", ModeBlocking:
+		if _, ok := opts["max-buffer-size"]; ok {
+			return 0, fmt.Errorf("max-buffer-size needs mode=%s", ModeNonBlocking)
+		}
+		return 0, nil
+	case ModeNonBlocking:
+	default:
+		return 0, fmt.Errorf("invalid mode %q, expected %s or %s", mode, ModeBlocking, ModeNonBlocking)
+	}
+
+	size, err := intOption(opts, "max-buffer-size", defaultMaxBufferSize)
+	if err != nil {
+		return 0, err
+	}
+	if size == 0 {
+		return 0, fmt.Errorf("max-buffer-size must be at least 1")
+	}
+	return size, nil
+}
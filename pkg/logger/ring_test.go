@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// gatedDriver passes every line it is given to lines, then waits for gate to close before returning
+type gatedDriver struct {
+	lines  chan string
+	gate   chan struct{}
+	closed bool
+}
+
+func newGatedDriver() *gatedDriver {
+	return &gatedDriver{lines: make(chan string, 2000), gate: make(chan struct{})}
+}
+
+func (d *gatedDriver) Log(msg *Message) error {
+	d.lines <- string(msg.Line)
+	<-d.gate
+	return nil
+}
+
+func (d *gatedDriver) Close() error {
+	d.closed = true
+	close(d.lines)
+	return nil
+}
+
+// received returns the lines the driver has been given, once it was closed
+func (d *gatedDriver) received() []string {
+	var lines []string
+	for line := range d.lines {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// holdRing returns a ring of maxSize bytes whose driver is blocked on a first line, so later lines stay buffered
+func holdRing(t *testing.T, maxSize int) (*ring, *gatedDriver) {
+	t.Helper()
+	driver := newGatedDriver()
+	r := newRing(driver, maxSize)
+	if err := r.Log(&Message{Line: []byte("x")}); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	select {
+	case <-driver.lines:
+	case <-time.After(5 * time.Second):
+		t.Fatal("driver was not given the first line")
+	}
+	return r, driver
+}
+
+func TestRing(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSize     int
+		lines       []string
+		want        []string // Lines the driver gets after the first one, in order
+		wantDropped uint64
+	}{
+		{
+			name:    "fits",
+			maxSize: 10,
+			lines:   []string{"0123", "4567", "89"},
+			want:    []string{"0123", "4567", "89"},
+		},
+		{
+			name:        "oldest dropped",
+			maxSize:     10,
+			lines:       []string{"0123", "4567", "89", "ab"},
+			want:        []string{"4567", "89", "ab"},
+			wantDropped: 1,
+		},
+		{
+			name:        "several dropped for one line",
+			maxSize:     10,
+			lines:       []string{"01", "23", "45", "6789", "abcdefgh"},
+			want:        []string{"abcdefgh"},
+			wantDropped: 4,
+		},
+		{
+			name:        "line larger than the buffer",
+			maxSize:     4,
+			lines:       []string{"ab", "abcde", "cd"},
+			want:        []string{"ab", "cd"},
+			wantDropped: 1,
+		},
+		{
+			name:        "line the size of the buffer",
+			maxSize:     4,
+			lines:       []string{"ab", "abcd"},
+			want:        []string{"abcd"},
+			wantDropped: 1,
+		},
+		{
+			name:    "empty lines take no room",
+			maxSize: 2,
+			lines:   []string{"ab", "", ""},
+			want:    []string{"ab", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, driver := holdRing(t, tt.maxSize)
+			for _, line := range tt.lines {
+				if err := r.Log(&Message{Line: []byte(line)}); err != nil {
+					t.Fatalf("Log(%q) failed: %v", line, err)
+				}
+			}
+			if got := DroppedLines(r); got != tt.wantDropped {
+				t.Errorf("DroppedLines = %d, want %d", got, tt.wantDropped)
+			}
+
+			close(driver.gate)
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if !driver.closed {
+				t.Error("Close did not close the driver")
+			}
+			if got := driver.received(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("driver got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingOrderWithoutDrops(t *testing.T) {
+	driver := newGatedDriver()
+	close(driver.gate)
+	r := newRing(driver, defaultMaxBufferSize)
+
+	var want []string
+	for i := 0; i < 1000; i++ {
+		line := fmt.Sprintf("line %d", i)
+		want = append(want, line)
+		if err := r.Log(&Message{Line: []byte(line)}); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := driver.received(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("driver got the lines out of order or incomplete: %d lines", len(got))
+	}
+	if dropped := DroppedLines(r); dropped != 0 {
+		t.Errorf("DroppedLines = %d, want 0", dropped)
+	}
+}
+
+func TestRingLogAfterClose(t *testing.T) {
+	driver := newGatedDriver()
+	close(driver.gate)
+	r := newRing(driver, 10)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := r.Log(&Message{Line: []byte("late")}); err == nil {
+		t.Error("Log after Close succeeded, want an error")
+	}
+}
+
+func TestDroppedLinesBlocking(t *testing.T) {
+	if got := DroppedLines(newGatedDriver()); got != 0 {
+		t.Errorf("DroppedLines of a blocking driver = %d, want 0", got)
+	}
+}
@@ -0,0 +1,158 @@
+package namespace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// ConfigFD is the descriptor container-init reads its configuration from, the first of the command's ExtraFiles
+// The configuration is passed over a pipe rather than the environment, which the container's own variables share
+const ConfigFD = 3
+
+// reservedEnv are the variables container-init took its configuration from before it was passed on ConfigFD
+// Containers can't set them, so a container-init left over from an older install can't be configured by a
+// container's environment
+var reservedEnv = []string{
+	"CONTAINER_ROOTFS",
+	"CONTAINER_SECRETS",
+	"CONTAINER_SYNC_FD",
+	"CONTAINER_ERROR_FD",
+	"CONTAINER_APPARMOR_PROFILE",
+	"CONTAINER_PROCESS_LABEL",
+	"CONTAINER_MOUNT_LABEL",
+	"CONTAINER_TIME_OFFSETS",
+	"CONTAINER_MOUNTS",
+	"CONTAINER_MASKED_PATHS",
+}
+
+// ReservedEnv reports whether key is a variable containers can't set
+func ReservedEnv(key string) bool {
+	return slices.Contains(reservedEnv, key)
+}
+
+// DefaultEnv is the base environment of every container process
+var DefaultEnv = []string{
+	"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+	"HOME=/root",
+	"TERM=xterm",
+}
+
+// InitConfig describes how container-init should set up the container before exec
+type InitConfig struct {
+	Rootfs  string   `json:"rootfs"`
+	Secrets []Secret `json:"secrets,omitempty"`
+	SyncFD  int      `json:"sync_fd,omitempty"`  // Pipe to wait on before setup so the daemon can finish placing the process (0 if none)
+	ErrorFD int      `json:"error_fd,omitempty"` // Pipe to report setup failures on; it closes on a successful exec (0 if none)
+
+	AppArmorProfile string `json:"apparmor_profile,omitempty"` // Profile to confine the container command with (empty or "unconfined" for none)
+	ProcessLabel    string `json:"process_label,omitempty"`    // SELinux context to exec the container command with (empty for none)
+	MountLabel      string `json:"mount_label,omitempty"`      // SELinux context for the mounts container-init creates (empty for none)
+
+	TimeOffsets *TimeOffsets `json:"time_offsets,omitempty"` // Run the command in a new time namespace with these offsets (nil to share the host's)
+
+	Mounts      []Mount  `json:"mounts,omitempty"`       // Extra mounts made after proc, sys and dev
+	MaskedPaths []string `json:"masked_paths,omitempty"` // Paths hidden from the container
+}
+
+// Send writes the init configuration to the pipe container-init reads it from on ConfigFD
+func (c *InitConfig) Send(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		return fmt.Errorf("failed to send init configuration: %v", err)
+	}
+	return nil
+}
+
+// ReadInitConfig reads the init configuration the daemon sent on ConfigFD
+// It is checked again here rather than trusted, since it decides what container-init mounts and writes
+func ReadInitConfig() (*InitConfig, error) {
+	pipe := os.NewFile(ConfigFD, "config-pipe")
+	defer pipe.Close()
+
+	cfg := &InitConfig{}
+	if err := json.NewDecoder(pipe).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to read init configuration: %v", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid init configuration: %v", err)
+	}
+
+	if cfg.ErrorFD > 0 {
+		// Close on exec so the daemon sees EOF once the container command is running
+		closeOnExec(cfg.ErrorFD)
+	}
+	return cfg, nil
+}
+
+// validate checks the paths of the init configuration the same way the daemon did
+func (c *InitConfig) validate() error {
+	if !filepath.IsAbs(c.Rootfs) {
+		return fmt.Errorf("rootfs must be an absolute path: %q", c.Rootfs)
+	}
+	for _, secret := range c.Secrets {
+		if err := ValidateSecret(secret); err != nil {
+			return err
+		}
+	}
+	for _, m := range c.Mounts {
+		if err := ValidateMount(m); err != nil {
+			return err
+		}
+	}
+	for _, path := range c.MaskedPaths {
+		if err := ValidateMaskedPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitForParent blocks until the daemon signals on the sync pipe that setup may begin
+// The pipe is closed afterwards so it doesn't leak into the container command
+func (c *InitConfig) WaitForParent() error {
+	if c.SyncFD <= 0 {
+		return nil
+	}
+
+	pipe := os.NewFile(uintptr(c.SyncFD), "sync-pipe")
+	defer pipe.Close()
+
+	// The daemon writes a single byte; EOF without it means the daemon gave up on the container
+	buf := make([]byte, 1)
+	if _, err := pipe.Read(buf); err != nil {
+		return fmt.Errorf("failed to wait for daemon: %v", err)
+	}
+	return nil
+}
+
+// InitError is a setup failure container-init reports to the daemon before exiting
+type InitError struct {
+	Message string `json:"message"`
+}
+
+// ReportError sends a setup failure to the daemon over the error pipe
+func (c *InitConfig) ReportError(err error) {
+	if c.ErrorFD <= 0 {
+		return
+	}
+
+	pipe := os.NewFile(uintptr(c.ErrorFD), "error-pipe")
+	defer pipe.Close()
+	json.NewEncoder(pipe).Encode(InitError{Message: err.Error()})
+}
+
+// ReadInitError waits until container-init has exec'd the container command or failed
+// It returns the reported setup failure, or nil once the pipe closes without one
+func ReadInitError(pipe io.Reader) error {
+	var initErr InitError
+	if err := json.NewDecoder(pipe).Decode(&initErr); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return fmt.Errorf("failed to read container-init status: %v", err)
+	}
+	return fmt.Errorf("%s", initErr.Message)
+}
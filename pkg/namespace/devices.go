@@ -0,0 +1,14 @@
+package namespace
+
+import "os"
+
+// Device is a host device node passed into a running container
+type Device struct {
+	Path  string // Same path inside the container as on the host, e.g. /dev/ttyUSB0
+	Block bool   // Block device rather than character device
+	Major uint32
+	Minor uint32
+	Mode  os.FileMode // Permission bits
+	UID   int
+	GID   int
+}
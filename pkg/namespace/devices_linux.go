@@ -0,0 +1,94 @@
+package namespace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// AddDevice creates a device node in the /dev of the running container with the given PID
+// A node already there for the same device is left alone
+func AddDevice(pid int, dev Device) error {
+	target, err := secureTarget(fmt.Sprintf("/proc/%d/root", pid), dev.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(dev.Path), err)
+	}
+
+	kind := uint32(unix.S_IFCHR)
+	if dev.Block {
+		kind = unix.S_IFBLK
+	}
+	rdev := unix.Mkdev(dev.Major, dev.Minor)
+	if err := unix.Mknod(target, kind|uint32(dev.Mode.Perm()), int(rdev)); err != nil {
+		if err == unix.EEXIST && isDevice(target, rdev) {
+			return nil
+		}
+		return fmt.Errorf("failed to create device %s: %v", dev.Path, err)
+	}
+
+	// Set the owner and mode the umask took away through a handle, so a symlink swapped in can't redirect them
+	fd, err := unix.Open(target, unix.O_PATH|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open device %s: %v", dev.Path, err)
+	}
+	defer unix.Close(fd)
+	if err := unix.Fchownat(fd, "", dev.UID, dev.GID, unix.AT_EMPTY_PATH); err != nil {
+		return fmt.Errorf("failed to chown device %s: %v", dev.Path, err)
+	}
+	if err := unix.Chmod(fmt.Sprintf("/proc/self/fd/%d", fd), uint32(dev.Mode.Perm())); err != nil {
+		return fmt.Errorf("failed to chmod device %s: %v", dev.Path, err)
+	}
+	return nil
+}
+
+// RemoveDevice removes a device node from the /dev of the running container with the given PID
+// Only a node for the same device is removed, so files the container put there itself are kept
+func RemoveDevice(pid int, dev Device) error {
+	target, err := secureTarget(fmt.Sprintf("/proc/%d/root", pid), dev.Path)
+	if err != nil {
+		return err
+	}
+	if !isDevice(target, unix.Mkdev(dev.Major, dev.Minor)) {
+		return nil
+	}
+	if err := os.Remove(target); err != nil {
+		return fmt.Errorf("failed to remove device %s: %v", dev.Path, err)
+	}
+	return nil
+}
+
+// HostDevice describes the host's device node at path
+func HostDevice(path string) (Device, error) {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return Device{}, err
+	}
+	kind := st.Mode & unix.S_IFMT
+	if kind != unix.S_IFCHR && kind != unix.S_IFBLK {
+		return Device{}, fmt.Errorf("%s is not a device", path)
+	}
+	return Device{
+		Path:  path,
+		Block: kind == unix.S_IFBLK,
+		Major: unix.Major(st.Rdev),
+		Minor: unix.Minor(st.Rdev),
+		Mode:  os.FileMode(st.Mode).Perm(),
+		UID:   int(st.Uid),
+		GID:   int(st.Gid),
+	}, nil
+}
+
+// isDevice reports whether path is a device node for rdev, without following symlinks
+func isDevice(path string, rdev uint64) bool {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return false
+	}
+	kind := st.Mode & unix.S_IFMT
+	return (kind == unix.S_IFCHR || kind == unix.S_IFBLK) && st.Rdev == rdev
+}
@@ -0,0 +1,93 @@
+package namespace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// errNotFound is reported when a bare command name isn't in any PATH directory
+var errNotFound = errors.New("executable file not found in $PATH")
+
+// execCommand replaces the current process with the container command
+// Bare names are looked up in the container's PATH, and files without a shebang or ELF header run under /bin/sh,
+// as execvp does
+func execCommand(command string, args []string, env []string) error {
+	path, err := lookPath(command, envValue(env, "PATH"))
+	if err != nil {
+		return fmt.Errorf("exec: %q: %v", command, err)
+	}
+
+	argv := append([]string{command}, args...)
+	err = syscall.Exec(path, argv, env)
+	if err == syscall.ENOEXEC {
+		err = syscall.Exec("/bin/sh", append([]string{"sh", path}, args...), env)
+	}
+	return fmt.Errorf("exec: %q: %v", command, err)
+}
+
+// lookPath resolves command inside the container's filesystem
+// Commands containing a slash are used as is; a PATH match that isn't executable is reported
+// as permission denied rather than not found so the cause is clear
+func lookPath(command, pathEnv string) (string, error) {
+	if strings.Contains(command, "/") {
+		return command, checkExecutable(command)
+	}
+
+	var denied error
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			dir = "."
+		}
+		path := filepath.Join(dir, command)
+
+		err := checkExecutable(path)
+		if err == nil {
+			return path, nil
+		}
+		if errors.Is(err, os.ErrPermission) && denied == nil {
+			denied = err
+		}
+	}
+
+	if denied != nil {
+		return "", denied
+	}
+	return "", errNotFound
+}
+
+// checkExecutable reports whether path is a file the container may execute
+func checkExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return syscall.ENOENT
+		}
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("is a directory")
+	}
+	if err := syscall.Access(path, 0x1); err != nil { // X_OK
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// closeOnExec keeps fd from leaking into the container command
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
+
+// envValue returns the value of key in a KEY=VALUE list, or "" if it isn't set
+func envValue(env []string, key string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if value, ok := strings.CutPrefix(env[i], key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
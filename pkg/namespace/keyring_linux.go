@@ -0,0 +1,34 @@
+package namespace
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// keyctl operations, which aren't in the syscall package
+const (
+	keyctlJoinSessionKeyring = 1
+	keyctlSetPerm            = 5
+)
+
+// sessionKeyringPerm gives the possessor and owning user full access and nobody else any
+const sessionKeyringPerm = 0x3f3f0000
+
+// joinSessionKeyring gives the calling thread a new anonymous session keyring
+// Without it the container inherits the daemon's session keyring and could read or plant keys in it
+func joinSessionKeyring() error {
+	// A nil name always creates a new keyring instead of joining an existing one by name
+	id, _, errno := syscall.Syscall(syscall.SYS_KEYCTL, keyctlJoinSessionKeyring, 0, 0)
+	if errno != 0 {
+		// Kernels without key management have no keyring to leak
+		if errno == syscall.ENOSYS {
+			return nil
+		}
+		return fmt.Errorf("failed to create session keyring: %v", errno)
+	}
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_KEYCTL, keyctlSetPerm, id, sessionKeyringPerm); errno != 0 {
+		return fmt.Errorf("failed to set session keyring permissions: %v", errno)
+	}
+	return nil
+}
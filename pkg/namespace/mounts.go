@@ -0,0 +1,35 @@
+package namespace
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Mount is a mount requested for the container, made after the system mounts
+type Mount struct {
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`       // Absolute path inside the container
+	Type        string   `json:"type"`              // "bind" or a filesystem type such as "tmpfs"
+	Options     []string `json:"options,omitempty"` // Flags such as "ro" and "nosuid", or filesystem data such as "size=64m"
+}
+
+// DefaultMaskedPaths are hidden from every container because they expose host kernel state
+var DefaultMaskedPaths = []string{
+	"/proc/acpi",
+	"/proc/kcore",
+	"/proc/keys",
+	"/proc/latency_stats",
+	"/proc/sched_debug",
+	"/proc/scsi",
+	"/proc/timer_list",
+	"/proc/timer_stats",
+	"/sys/firmware",
+}
+
+// ValidateMaskedPath checks that a masked path is a clean absolute path
+func ValidateMaskedPath(path string) error {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path || path == "/" {
+		return fmt.Errorf("masked path must be a clean absolute path other than /: %q", path)
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package namespace
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// mountFlags are the mount options that map to mount(2) flags
+var mountFlags = map[string]uintptr{
+	"ro":          syscall.MS_RDONLY,
+	"rw":          0,
+	"nosuid":      syscall.MS_NOSUID,
+	"nodev":       syscall.MS_NODEV,
+	"noexec":      syscall.MS_NOEXEC,
+	"noatime":     syscall.MS_NOATIME,
+	"nodiratime":  syscall.MS_NODIRATIME,
+	"relatime":    syscall.MS_RELATIME,
+	"strictatime": syscall.MS_STRICTATIME,
+	"rbind":       syscall.MS_BIND | syscall.MS_REC,
+}
+
+// reservedDestinations are set up by container-init itself and can't be mounted over
+var reservedDestinations = []string{"/proc", "/sys", "/dev/pts", SecretsDir}
+
+// parseMountOptions splits mount options into mount(2) flags and filesystem data
+func parseMountOptions(options []string) (uintptr, string, error) {
+	var flags uintptr
+	var data []string
+	for _, option := range options {
+		if strings.Contains(option, "=") {
+			data = append(data, option)
+			continue
+		}
+		flag, ok := mountFlags[option]
+		if !ok {
+			return 0, "", fmt.Errorf("unknown mount option %q", option)
+		}
+		flags |= flag
+	}
+	return flags, strings.Join(data, ","), nil
+}
+
+// ValidateMount checks that a mount is well formed and doesn't replace one container-init relies on
+func ValidateMount(m Mount) error {
+	if m.Type == "" {
+		return fmt.Errorf("mount type is required for %s", m.Destination)
+	}
+	if !filepath.IsAbs(m.Destination) || filepath.Clean(m.Destination) != m.Destination {
+		return fmt.Errorf("mount destination must be a clean absolute path: %q", m.Destination)
+	}
+	if m.Destination == "/" {
+		return fmt.Errorf("mount destination cannot be /")
+	}
+	for _, reserved := range reservedDestinations {
+		if m.Destination == reserved || strings.HasPrefix(m.Destination, reserved+"/") {
+			return fmt.Errorf("mount destination %s is reserved", m.Destination)
+		}
+	}
+
+	flags, data, err := parseMountOptions(m.Options)
+	if err != nil {
+		return err
+	}
+
+	if m.Type != "bind" {
+		if flags&syscall.MS_BIND != 0 {
+			return fmt.Errorf("rbind option requires a bind mount")
+		}
+		return nil
+	}
+
+	if data != "" {
+		return fmt.Errorf("bind mounts take no filesystem options: %s", data)
+	}
+	if !filepath.IsAbs(m.Source) {
+		return fmt.Errorf("bind mount source must be an absolute path: %s", m.Source)
+	}
+	if _, err := os.Stat(m.Source); err != nil {
+		return fmt.Errorf("bind mount source %s: %v", m.Source, err)
+	}
+	return nil
+}
+
+// mountPoint is one step of the mount plan container-init carries out before pivot_root
+type mountPoint struct {
+	source string
+	target string // Path inside the container
+	fstype string
+	flags  uintptr
+	data   string
+	file   bool // Bind mount of a single file, so the target is created as an empty file
+}
+
+// hostDevices are bind mounted from the host into the container's private /dev
+var hostDevices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/random", "/dev/urandom", "/dev/tty"}
+
+// devSymlinks are created in /dev after the mounts, target -> link
+var devSymlinks = [][2]string{
+	{"/proc/self/fd", "/dev/fd"},
+	{"/proc/self/fd/0", "/dev/stdin"},
+	{"/proc/self/fd/1", "/dev/stdout"},
+	{"/proc/self/fd/2", "/dev/stderr"},
+	{"pts/ptmx", "/dev/ptmx"},
+}
+
+// mountPlan returns the container's mounts in the order they must be made
+// Later mounts may depend on earlier ones, e.g. /dev/pts lives on the /dev tmpfs
+func mountPlan(mountLabel string) []mountPoint {
+	const nosuidNodevNoexec = syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC
+
+	plan := []mountPoint{
+		{source: "proc", target: "/proc", fstype: "proc", flags: nosuidNodevNoexec},
+		{source: "sysfs", target: "/sys", fstype: "sysfs", flags: nosuidNodevNoexec | syscall.MS_RDONLY},
+		{source: "tmpfs", target: "/dev", fstype: "tmpfs", flags: syscall.MS_NOSUID | syscall.MS_STRICTATIME, data: labeled("mode=755,size=65536k", mountLabel)},
+		{source: "devpts", target: "/dev/pts", fstype: "devpts", flags: syscall.MS_NOSUID | syscall.MS_NOEXEC, data: labeled("newinstance,ptmxmode=0666,mode=0620", mountLabel)},
+		{source: "shm", target: "/dev/shm", fstype: "tmpfs", flags: nosuidNodevNoexec, data: labeled("mode=1777,size=65536k", mountLabel)},
+	}
+	for _, dev := range hostDevices {
+		plan = append(plan, mountPoint{source: dev, target: dev, flags: syscall.MS_BIND, file: true})
+	}
+	return plan
+}
+
+// labeled adds an SELinux context option to tmpfs-like mount data
+func labeled(data, mountLabel string) string {
+	if mountLabel == "" {
+		return data
+	}
+	// Quoted because MCS levels contain commas
+	return data + fmt.Sprintf(",context=%q", mountLabel)
+}
+
+// setupMounts carries out the mount plan, user mounts such as secrets included, under rootfs
+// Mounts already present in this mount namespace are skipped, so running the plan twice is harmless
+func setupMounts(cfg *InitConfig) error {
+	rootfs := cfg.Rootfs
+
+	// Make / private so none of our mounts propagate back to the host
+	if err := syscall.Mount("none", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make / private: %v", err)
+	}
+
+	mounted, err := mountedTypes()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mountPlan(cfg.MountLabel) {
+		target, err := secureTarget(rootfs, m.target)
+		if err != nil {
+			return err
+		}
+		if m.fstype != "" && mounted[target] == m.fstype {
+			continue
+		}
+
+		if err := createTarget(target, m.file); err != nil {
+			return err
+		}
+		if err := syscall.Mount(m.source, target, m.fstype, m.flags, m.data); err != nil {
+			return fmt.Errorf("failed to mount %s at %s: %v", m.source, m.target, err)
+		}
+	}
+
+	for _, link := range devSymlinks {
+		path := filepath.Join(rootfs, link[1])
+		if err := os.Symlink(link[0], path); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("failed to create %s: %v", link[1], err)
+		}
+	}
+
+	// User mounts go after the system mounts so they can't be hidden by them
+	if err := setupUserMounts(rootfs, cfg.Mounts); err != nil {
+		return err
+	}
+	if err := setupSecrets(rootfs, cfg.Secrets, cfg.MountLabel); err != nil {
+		return err
+	}
+
+	// Masks go last so nothing can be mounted over them
+	return maskPaths(rootfs, cfg.MaskedPaths)
+}
+
+// LeakedMounts returns the mount points under rootfs that container-init would have made,
+// but that are mounted in the caller's mount namespace instead of the container's
+// The result is ordered so nested mounts come before their parents and can be unmounted in order
+func LeakedMounts(rootfs string, mounts []Mount) ([]string, error) {
+	targets := []string{SecretsDir}
+	for _, m := range mountPlan("") {
+		targets = append(targets, m.target)
+	}
+	for _, m := range mounts {
+		targets = append(targets, m.Destination)
+	}
+
+	mounted, err := mountedTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var leaked []string
+	for _, target := range targets {
+		path := filepath.Join(rootfs, target)
+		if _, ok := mounted[path]; ok {
+			leaked = append(leaked, path)
+		}
+	}
+	sort.Slice(leaked, func(i, j int) bool {
+		return len(leaked[i]) > len(leaked[j])
+	})
+	return leaked, nil
+}
+
+// setupUserMounts makes the requested mounts, parents before the mounts nested in them
+func setupUserMounts(rootfs string, mounts []Mount) error {
+	sorted := append([]Mount(nil), mounts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.Count(sorted[i].Destination, "/") < strings.Count(sorted[j].Destination, "/")
+	})
+
+	for _, m := range sorted {
+		flags, data, err := parseMountOptions(m.Options)
+		if err != nil {
+			return err
+		}
+		target, err := secureTarget(rootfs, m.Destination)
+		if err != nil {
+			return err
+		}
+
+		fstype := m.Type
+		isFile := false
+		if m.Type == "bind" {
+			fstype = ""
+			flags |= syscall.MS_BIND
+			if info, err := os.Stat(m.Source); err == nil && !info.IsDir() {
+				isFile = true
+			}
+		}
+		if err := createTarget(target, isFile); err != nil {
+			return err
+		}
+
+		if err := syscall.Mount(m.Source, target, fstype, flags, data); err != nil {
+			return fmt.Errorf("failed to mount %s at %s: %v", m.Source, m.Destination, err)
+		}
+
+		// The kernel ignores all flags but MS_REC on the initial bind, so apply the rest with a remount
+		if m.Type == "bind" && flags&^(syscall.MS_BIND|syscall.MS_REC) != 0 {
+			remount := flags&^syscall.MS_REC | syscall.MS_REMOUNT
+			if err := syscall.Mount("", target, "", remount, ""); err != nil {
+				return fmt.Errorf("failed to apply options to %s: %v", m.Destination, err)
+			}
+		}
+	}
+	return nil
+}
+
+// maskPaths hides paths by mounting /dev/null over files and an empty read-only tmpfs over directories
+// Paths that don't exist in the container are skipped
+func maskPaths(rootfs string, paths []string) error {
+	for _, path := range paths {
+		target, err := secureTarget(rootfs, path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(target)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mask %s: %v", path, err)
+		}
+
+		if info.IsDir() {
+			err = syscall.Mount("tmpfs", target, "tmpfs", syscall.MS_RDONLY, "size=0")
+		} else {
+			err = syscall.Mount("/dev/null", target, "", syscall.MS_BIND, "")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mask %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// secureTarget joins target onto rootfs, refusing paths that pass through a symlink
+// A symlink in the rootfs could otherwise redirect a mount onto the host
+func secureTarget(rootfs, target string) (string, error) {
+	path := rootfs
+	for _, part := range strings.Split(strings.Trim(target, "/"), "/") {
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to check mount target %s: %v", target, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("mount target %s is a symlink in the rootfs", target)
+		}
+	}
+	return filepath.Join(rootfs, target), nil
+}
+
+// createTarget makes sure a mount target exists as a directory, or as a file for single-file bind mounts
+func createTarget(path string, file bool) error {
+	if !file {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return fmt.Errorf("failed to create mount point %s: %v", path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create mount point %s: %v", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create mount point %s: %v", path, err)
+	}
+	return f.Close()
+}
+
+// mountedTypes maps the mount points of this mount namespace to their filesystem types
+func mountedTypes() (map[string]string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mountinfo: %v", err)
+	}
+	defer file.Close()
+
+	// Fields: id parent major:minor root mount-point options [optional...] - fstype source super-options
+	mounted := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		for i, field := range fields {
+			if field == "-" && i+1 < len(fields) && len(fields) > 4 {
+				mounted[fields[4]] = fields[i+1]
+				break
+			}
+		}
+	}
+	return mounted, scanner.Err()
+}
@@ -0,0 +1,187 @@
+package namespace
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"syscall"
+
+	"github.com/AbhishekGY/mydocker/pkg/apparmor"
+	"github.com/AbhishekGY/mydocker/pkg/selinux"
+	"golang.org/x/sys/unix"
+)
+
+// PrepareNamespaces configures an exec.Cmd to run with Linux namespaces
+// This should be called before starting the command
+func PrepareNamespaces(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		// Set up namespaces (no user namespace - we're running as root)
+		// CLONE_NEWPID: Isolate process IDs
+		// CLONE_NEWNS: Isolate mount points
+		// CLONE_NEWUTS: Isolate hostname
+		// CLONE_NEWNET: Isolate network
+		Cloneflags: syscall.CLONE_NEWPID | syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS | syscall.CLONE_NEWNET,
+
+		// Create a new session for the terminal
+		Setsid: true,
+	}
+}
+
+// ContainerInit sets up the container environment (mounts, rootfs, etc.)
+// This is called by the container-init binary inside the container namespaces
+func ContainerInit(cfg *InitConfig, command string, args []string) error {
+	rootfs := cfg.Rootfs
+
+	// Security attributes for exec are per thread, so set them and exec from the same one
+	runtime.LockOSThread()
+
+	// Wait until the daemon has moved us into the container's cgroup
+	if err := cfg.WaitForParent(); err != nil {
+		return err
+	}
+
+	// Keep the container out of the daemon's session keyring
+	if err := joinSessionKeyring(); err != nil {
+		return err
+	}
+
+	fmt.Println("Container init: Setting up container environment...")
+
+	// Mount proc, sys, dev and user mounts under the rootfs while the host's devices are still reachable
+	if err := setupMounts(cfg); err != nil {
+		return err
+	}
+
+	// Change root using pivot_root or fallback to chroot
+	if err := pivotRoot(rootfs); err != nil {
+		fmt.Printf("pivot_root failed, using chroot: %v\n", err)
+		if err := syscall.Chroot(rootfs); err != nil {
+			return fmt.Errorf("chroot failed: %v", err)
+		}
+	}
+
+	// Change directory to /
+	if err := os.Chdir("/"); err != nil {
+		return fmt.Errorf("failed to chdir: %v", err)
+	}
+
+	// The command enters the time namespace at exec, so its clocks are offset from the first instruction
+	if cfg.TimeOffsets != nil {
+		if err := setupTimeNamespace(*cfg.TimeOffsets); err != nil {
+			return err
+		}
+	}
+
+	// Confine the container command; the profile takes effect at exec so setup above isn't restricted
+	if err := apparmor.ApplyProfile(cfg.AppArmorProfile); err != nil {
+		return err
+	}
+	if err := selinux.SetExecLabel(cfg.ProcessLabel); err != nil {
+		return err
+	}
+
+	fmt.Printf("Container init: Executing command: %s %v\n", command, args)
+
+	// Execute the actual container command
+	// This replaces the current process with the container command
+	return execCommand(command, args, os.Environ())
+}
+
+// pivotRoot performs a pivot_root operation to change the root filesystem
+func pivotRoot(newRoot string) error {
+	// Ensure new root is an absolute path
+	if !filepath.IsAbs(newRoot) {
+		return fmt.Errorf("rootfs must be an absolute path")
+	}
+
+	// Mount rootfs as MS_BIND to make it a mount point
+	if err := syscall.Mount(newRoot, newRoot, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("mount rootfs failed: %v", err)
+	}
+
+	// Create pivot directory for old root
+	pivotDir := filepath.Join(newRoot, ".pivot_root")
+	if err := os.Mkdir(pivotDir, 0777); err != nil {
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create pivot dir: %v", err)
+		}
+	}
+
+	// Pivot root - swaps the root mount
+	if err := syscall.PivotRoot(newRoot, pivotDir); err != nil {
+		return fmt.Errorf("pivot_root failed: %v", err)
+	}
+
+	// Change working directory to new root
+	if err := os.Chdir("/"); err != nil {
+		return fmt.Errorf("chdir failed: %v", err)
+	}
+
+	// Unmount old root
+	if err := syscall.Unmount("/.pivot_root", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount pivot_root dir failed: %v", err)
+	}
+
+	// Remove pivot directory
+	return os.Remove("/.pivot_root")
+}
+
+// sharedNamespace is a namespace a container can join with StartInNamespaces, with its clone flag
+type sharedNamespace struct {
+	name string
+	flag int
+}
+
+// sharedNamespaces are the namespaces a container always joins with StartInNamespaces
+var sharedNamespaces = []sharedNamespace{
+	{"net", syscall.CLONE_NEWNET},
+	{"pid", syscall.CLONE_NEWPID},
+	{"ipc", syscall.CLONE_NEWIPC},
+}
+
+// StartInNamespaces calls start, which must start cmd, so that the process joins the network, PID and IPC
+// namespaces of pid rather than getting new ones, and its UTS namespace too if shareUTS is set; it still gets
+// its own mount namespace
+// The process is forked from a thread that has entered the namespaces, and that thread is thrown away after
+func StartInNamespaces(cmd *exec.Cmd, pid int, shareUTS bool, start func() error) error {
+	namespaces := sharedNamespaces
+	if shareUTS {
+		namespaces = append(slices.Clip(namespaces), sharedNamespace{"uts", syscall.CLONE_NEWUTS})
+	}
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, ns := range namespaces {
+		f, err := os.Open(fmt.Sprintf("/proc/%d/ns/%s", pid, ns.name))
+		if err != nil {
+			return fmt.Errorf("failed to open %s namespace of process %d: %v", ns.name, pid, err)
+		}
+		files = append(files, f)
+		if cmd.SysProcAttr != nil {
+			cmd.SysProcAttr.Cloneflags &^= uintptr(ns.flag)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		// Never unlocked: the runtime ends the thread when this goroutine returns, so no other
+		// goroutine ever runs in the joined namespaces
+		runtime.LockOSThread()
+
+		for i, ns := range namespaces {
+			if err := unix.Setns(int(files[i].Fd()), ns.flag); err != nil {
+				done <- fmt.Errorf("failed to join %s namespace of process %d: %v", ns.name, pid, err)
+				return
+			}
+		}
+		done <- start()
+	}()
+	return <-done
+}
@@ -0,0 +1,74 @@
+//go:build !linux
+
+package namespace
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// errUnsupported is returned by everything that needs Linux namespaces and mounts
+var errUnsupported = fmt.Errorf("%w on %s: containers need Linux namespaces", errors.ErrUnsupported, runtime.GOOS)
+
+// PrepareNamespaces leaves cmd unchanged; there are no namespaces to create outside Linux
+func PrepareNamespaces(cmd *exec.Cmd) {}
+
+// ContainerInit always fails outside Linux
+func ContainerInit(cfg *InitConfig, command string, args []string) error {
+	return errUnsupported
+}
+
+// closeOnExec does nothing; container-init never execs a container command outside Linux
+func closeOnExec(fd int) {}
+
+// ValidateMount always fails outside Linux, where mounts can't be made
+func ValidateMount(m Mount) error {
+	return errUnsupported
+}
+
+// LeakedMounts always fails outside Linux, where there is no mountinfo to check
+func LeakedMounts(rootfs string, mounts []Mount) ([]string, error) {
+	return nil, errUnsupported
+}
+
+// BindNetns always fails outside Linux, where containers have no network namespace
+func BindNetns(id string, pid int) error {
+	return errUnsupported
+}
+
+// ReleaseNetns does nothing; no handles are made outside Linux
+func ReleaseNetns(id string) error {
+	return nil
+}
+
+// BoundNetns returns no handles outside Linux
+func BoundNetns() ([]string, error) {
+	return nil, nil
+}
+
+// ExecInNetns always fails outside Linux
+func ExecInNetns(path string, argv []string) error {
+	return errUnsupported
+}
+
+// StartInNamespaces always fails outside Linux
+func StartInNamespaces(cmd *exec.Cmd, pid int, shareUTS bool, start func() error) error {
+	return errUnsupported
+}
+
+// AddDevice always fails outside Linux, where containers have no /dev of their own
+func AddDevice(pid int, dev Device) error {
+	return errUnsupported
+}
+
+// RemoveDevice always fails outside Linux, where containers have no /dev of their own
+func RemoveDevice(pid int, dev Device) error {
+	return errUnsupported
+}
+
+// HostDevice always fails outside Linux
+func HostDevice(path string) (Device, error) {
+	return Device{}, errUnsupported
+}
@@ -0,0 +1,12 @@
+package namespace
+
+import "path/filepath"
+
+// NetnsDir holds a bind mount of the network namespace of every running container, named by container ID
+// Tools that take a namespace path, such as nsenter --net, can use them directly
+const NetnsDir = "/run/mydocker/netns"
+
+// NetnsPath returns where the network namespace of a container is bind-mounted while it runs
+func NetnsPath(id string) string {
+	return filepath.Join(NetnsDir, id)
+}
@@ -0,0 +1,112 @@
+package namespace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// BindNetns bind-mounts the network namespace of pid to NetnsPath(id), keeping it reachable by path
+func BindNetns(id string, pid int) error {
+	if err := ensureNetnsDir(); err != nil {
+		return err
+	}
+
+	path := NetnsPath(id)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDONLY, 0444)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	f.Close()
+
+	if err := syscall.Mount(fmt.Sprintf("/proc/%d/ns/net", pid), path, "", syscall.MS_BIND, ""); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to bind-mount network namespace: %v", err)
+	}
+	return nil
+}
+
+// ensureNetnsDir creates NetnsDir as a shared mount point
+// Mount namespaces created afterwards get copies of the bind mounts in it; with shared propagation,
+// unmounting a handle here also unmounts those copies so they don't keep the namespace alive (as ip netns does)
+func ensureNetnsDir() error {
+	if err := os.MkdirAll(NetnsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", NetnsDir, err)
+	}
+
+	err := syscall.Mount("", NetnsDir, "none", syscall.MS_SHARED|syscall.MS_REC, "")
+	if err == syscall.EINVAL {
+		// Not a mount point yet
+		if err := syscall.Mount(NetnsDir, NetnsDir, "none", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind-mount %s: %v", NetnsDir, err)
+		}
+		err = syscall.Mount("", NetnsDir, "none", syscall.MS_SHARED|syscall.MS_REC, "")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to make %s shared: %v", NetnsDir, err)
+	}
+	return nil
+}
+
+// ReleaseNetns unmounts and removes the network namespace handle of a container, if it has one
+func ReleaseNetns(id string) error {
+	path := NetnsPath(id)
+	if err := syscall.Unmount(path, syscall.MNT_DETACH); err != nil && err != syscall.EINVAL && err != syscall.ENOENT {
+		return fmt.Errorf("failed to unmount %s: %v", path, err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %v", path, err)
+	}
+	return nil
+}
+
+// BoundNetns returns the IDs of the containers that have a network namespace handle
+func BoundNetns() ([]string, error) {
+	entries, err := os.ReadDir(NetnsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", NetnsDir, err)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.Name())
+	}
+	return ids, nil
+}
+
+// ExecInNetns replaces the current process with argv, running in the network namespace at path
+// Only the network namespace changes; the command still sees the host's filesystem and processes
+func ExecInNetns(path string, argv []string) error {
+	if len(argv) == 0 {
+		return fmt.Errorf("command cannot be empty")
+	}
+	bin, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open network namespace: %v", err)
+	}
+	defer f.Close()
+
+	// Namespaces are per thread, so join and exec from the same one
+	runtime.LockOSThread()
+	if err := unix.Setns(int(f.Fd()), unix.CLONE_NEWNET); err != nil {
+		runtime.UnlockOSThread()
+		if errors.Is(err, unix.EPERM) {
+			return fmt.Errorf("failed to join network namespace: %v (are you root?)", err)
+		}
+		return fmt.Errorf("failed to join network namespace: %v", err)
+	}
+	return syscall.Exec(bin, argv, os.Environ())
+}
@@ -0,0 +1,38 @@
+package namespace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretsDir is where secrets are exposed inside the container
+const SecretsDir = "/run/secrets"
+
+// Secret is a host file exposed read-only inside the container at /run/secrets/<Target>
+type Secret struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// ValidateSecret checks that the source is a readable regular file and the target a plain file name
+func ValidateSecret(s Secret) error {
+	if !filepath.IsAbs(s.Source) {
+		return fmt.Errorf("secret source must be an absolute path: %s", s.Source)
+	}
+
+	info, err := os.Stat(s.Source)
+	if err != nil {
+		return fmt.Errorf("secret source %s: %v", s.Source, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("secret source must be a regular file: %s", s.Source)
+	}
+
+	if s.Target == "" || s.Target == "." || s.Target == ".." || strings.Contains(s.Target, "/") {
+		return fmt.Errorf("secret target must be a plain file name: %q", s.Target)
+	}
+
+	return nil
+}
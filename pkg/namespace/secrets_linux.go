@@ -0,0 +1,50 @@
+package namespace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// setupSecrets copies secrets into a read-only tmpfs at <rootfs>/run/secrets
+// Each file is owned by root with mode 0400, and the contents never touch the container's rootfs on disk
+// A non-empty mountLabel sets the SELinux context of the mount so the container can read it
+func setupSecrets(rootfs string, secrets []Secret, mountLabel string) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	dir := filepath.Join(rootfs, SecretsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create secrets dir: %v", err)
+	}
+
+	flags := uintptr(syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC)
+	data := "mode=0755"
+	if mountLabel != "" {
+		// Quoted because MCS levels contain commas
+		data += fmt.Sprintf(",context=%q", mountLabel)
+	}
+	if err := syscall.Mount("tmpfs", dir, "tmpfs", flags, data); err != nil {
+		return fmt.Errorf("failed to mount secrets tmpfs: %v", err)
+	}
+
+	for _, secret := range secrets {
+		data, err := os.ReadFile(secret.Source)
+		if err != nil {
+			return fmt.Errorf("failed to read secret %s: %v", secret.Target, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, secret.Target), data, 0400); err != nil {
+			return fmt.Errorf("failed to write secret %s: %v", secret.Target, err)
+		}
+	}
+
+	// Make the secrets mount read-only now that it's populated
+	if err := syscall.Mount("", dir, "", flags|syscall.MS_REMOUNT|syscall.MS_RDONLY, ""); err != nil {
+		return fmt.Errorf("failed to remount secrets read-only: %v", err)
+	}
+
+	return nil
+}
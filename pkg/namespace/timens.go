@@ -0,0 +1,19 @@
+package namespace
+
+import (
+	"os"
+	"time"
+)
+
+// TimeOffsets shifts the monotonic and boot-time clocks seen inside a container's time namespace
+// The realtime clock can't be offset and stays shared with the host
+type TimeOffsets struct {
+	Monotonic time.Duration `json:"monotonic"`
+	Boottime  time.Duration `json:"boottime"`
+}
+
+// TimeNamespaceSupported reports whether the kernel supports time namespaces
+func TimeNamespaceSupported() bool {
+	_, err := os.Stat("/proc/self/ns/time")
+	return err == nil
+}
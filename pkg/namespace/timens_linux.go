@@ -0,0 +1,39 @@
+package namespace
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// CLONE_NEWTIME isn't in the syscall package
+const cloneNewTime = 0x80
+
+// setupTimeNamespace creates a time namespace with the given offsets that the calling thread enters on exec
+// Must run on the thread that execs, after /proc belongs to the container's PID namespace
+func setupTimeNamespace(offsets TimeOffsets) error {
+	// unshare doesn't move the caller into the new namespace, so its offsets can still be set before exec
+	if err := syscall.Unshare(cloneNewTime); err != nil {
+		return fmt.Errorf("failed to create time namespace: %v", err)
+	}
+
+	// /proc/self points at the thread group leader, so address this thread by its ID
+	path := fmt.Sprintf("/proc/%d/timens_offsets", syscall.Gettid())
+	data := formatTimeOffset("monotonic", offsets.Monotonic) + formatTimeOffset("boottime", offsets.Boottime)
+	if err := os.WriteFile(path, []byte(data), 0); err != nil {
+		return fmt.Errorf("failed to set time namespace offsets: %v", err)
+	}
+	return nil
+}
+
+// formatTimeOffset formats a line of timens_offsets, "<clock> <secs> <nanosecs>" with nanosecs in [0, 1e9)
+func formatTimeOffset(clock string, offset time.Duration) string {
+	secs := int64(offset / time.Second)
+	nsecs := int64(offset % time.Second)
+	if nsecs < 0 {
+		secs--
+		nsecs += int64(time.Second)
+	}
+	return fmt.Sprintf("%s %d %d\n", clock, secs, nsecs)
+}
@@ -0,0 +1,124 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// binfmtDir is where the kernel's binfmt_misc filesystem is mounted
+const binfmtDir = "/proc/sys/fs/binfmt_misc"
+
+// emulator describes how binfmt_misc recognizes an architecture's ELF binaries and which qemu user emulator runs them
+type emulator struct {
+	name  string // qemu architecture name, e.g. "aarch64" for qemu-aarch64-static
+	magic string // ELF header bytes, escaped for the register file
+	mask  string
+}
+
+// emulators maps a Go architecture name to its emulator, with the magic and mask used by qemu-binfmt-conf.sh
+var emulators = map[string]emulator{
+	"amd64": {
+		name:  "x86_64",
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x3e\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xfe\xfe\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"386": {
+		name:  "i386",
+		magic: `\x7fELF\x01\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x03\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xfe\xfe\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"arm64": {
+		name:  "aarch64",
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xb7\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"arm": {
+		name:  "arm",
+		magic: `\x7fELF\x01\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x28\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"riscv64": {
+		name:  "riscv64",
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xf3\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"ppc64le": {
+		name:  "ppc64le",
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x15\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\xfc\xff\xff\xff\xff\xff\xff\xff\x00\xfe\xff\xff\x00`,
+	},
+	"s390x": {
+		name:  "s390x",
+		magic: `\x7fELF\x02\x02\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x16`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff`,
+	},
+}
+
+// EnsureEmulation makes sure binaries for the platform can run on this host
+// A missing binfmt_misc handler is registered from qemu-<arch>-static if it is installed
+func EnsureEmulation(p Platform) error {
+	if p.IsNative() {
+		return nil
+	}
+
+	emu, ok := emulators[p.Architecture]
+	if !ok {
+		return fmt.Errorf("unsupported architecture %q", p.Architecture)
+	}
+
+	if err := mountBinfmt(); err != nil {
+		return err
+	}
+
+	handler := "qemu-" + emu.name
+	data, err := os.ReadFile(filepath.Join(binfmtDir, handler))
+	if err == nil {
+		return checkHandler(p, handler, string(data))
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read binfmt_misc handler %s: %v", handler, err)
+	}
+
+	interpreter, err := findInterpreter(emu.name)
+	if err != nil {
+		return fmt.Errorf("cannot run %s containers: no binfmt_misc handler is registered and %v", p, err)
+	}
+
+	// F loads the interpreter now, so it works inside containers whose rootfs doesn't contain it
+	// O and C keep executable-only and setuid binaries behaving as they would natively
+	register := fmt.Sprintf(":%s:M::%s:%s:%s:FOC", handler, emu.magic, emu.mask, interpreter)
+	if err := os.WriteFile(filepath.Join(binfmtDir, "register"), []byte(register), 0); err != nil {
+		return fmt.Errorf("failed to register binfmt_misc handler %s: %v", handler, err)
+	}
+
+	fmt.Printf("Registered binfmt_misc handler %s using %s\n", handler, interpreter)
+	return nil
+}
+
+// checkHandler verifies that an existing handler can run binaries inside a container
+func checkHandler(p Platform, handler, status string) error {
+	if !strings.HasPrefix(status, "enabled") {
+		return fmt.Errorf("cannot run %s containers: binfmt_misc handler %s is disabled", p, handler)
+	}
+
+	// Without F the kernel looks up the interpreter path inside the container's rootfs at exec
+	for _, line := range strings.Split(status, "\n") {
+		if flags, ok := strings.CutPrefix(line, "flags: "); ok && !strings.Contains(flags, "F") {
+			return fmt.Errorf("cannot run %s containers: binfmt_misc handler %s is registered without the F flag", p, handler)
+		}
+	}
+	return nil
+}
+
+// findInterpreter looks for a statically linked qemu user emulator on the PATH
+func findInterpreter(name string) (string, error) {
+	for _, candidate := range []string{"qemu-" + name + "-static", "qemu-" + name} {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return filepath.Abs(path)
+		}
+	}
+	return "", fmt.Errorf("qemu-%s-static was not found (install qemu-user-static)", name)
+}
@@ -0,0 +1,19 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// mountBinfmt mounts binfmt_misc if the host hasn't already
+func mountBinfmt() error {
+	if _, err := os.Stat(filepath.Join(binfmtDir, "register")); err == nil {
+		return nil
+	}
+	if err := syscall.Mount("binfmt_misc", binfmtDir, "binfmt_misc", 0, ""); err != nil {
+		return fmt.Errorf("binfmt_misc is not available: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// mountBinfmt always fails; binfmt_misc is a Linux feature
+func mountBinfmt() error {
+	return fmt.Errorf("binfmt_misc is not available: %w on %s", errors.ErrUnsupported, runtime.GOOS)
+}
@@ -0,0 +1,53 @@
+package platform
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Platform identifies the OS and CPU architecture a rootfs was built for, e.g. linux/arm64 or linux/arm/v7
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// Parse parses "os/arch[/variant]"
+func Parse(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q, expected os/arch[/variant]", s)
+	}
+
+	p := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+
+	if p.OS != "linux" {
+		return Platform{}, fmt.Errorf("unsupported platform %q, only linux containers can run", s)
+	}
+	if _, ok := emulators[p.Architecture]; !ok {
+		return Platform{}, fmt.Errorf("unsupported architecture %q", p.Architecture)
+	}
+	return p, nil
+}
+
+// String formats the platform as "os/arch[/variant]"
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// IsNative reports whether the host CPU runs binaries for this platform without emulation
+func (p Platform) IsNative() bool {
+	if p.Architecture == runtime.GOARCH {
+		return true
+	}
+	// 64-bit x86 kernels run 32-bit x86 binaries directly
+	return runtime.GOARCH == "amd64" && p.Architecture == "386"
+}
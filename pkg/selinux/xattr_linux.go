@@ -0,0 +1,41 @@
+package selinux
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// lsetxattr and lgetxattr aren't in the syscall package
+func lsetxattr(path, attr string, data []byte) error {
+	pathPtr, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attrPtr, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall6(syscall.SYS_LSETXATTR, uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(attrPtr)),
+		uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func lgetxattr(path, attr string, dest []byte) (int, error) {
+	pathPtr, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	attrPtr, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return 0, err
+	}
+	n, _, errno := syscall.Syscall6(syscall.SYS_LGETXATTR, uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(attrPtr)),
+		uintptr(unsafe.Pointer(&dest[0])), uintptr(len(dest)), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package selinux
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// Extended attributes are only read and written on Linux
+func lsetxattr(path, attr string, data []byte) error {
+	return fmt.Errorf("%w on %s", errors.ErrUnsupported, runtime.GOOS)
+}
+
+func lgetxattr(path, attr string, dest []byte) (int, error) {
+	return 0, fmt.Errorf("%w on %s", errors.ErrUnsupported, runtime.GOOS)
+}
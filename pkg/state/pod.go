@@ -0,0 +1,88 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Pod is a group of containers sharing the namespaces of an infra container
+// Its containers are the ones labelled with its ID; only the infra container is recorded here
+type Pod struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Infra   string            `json:"infra"` // ID of the container whose namespaces the pod's containers join
+	Labels  map[string]string `json:"labels,omitempty"`
+	Created time.Time         `json:"created"`
+	Owner   *uint32           `json:"owner_uid,omitempty"` // UID of the client that created the pod
+}
+
+// podDir returns the directory holding pod definitions
+func (s *Store) podDir() string {
+	return filepath.Join(s.dataDir, "pods")
+}
+
+// SavePod saves a pod to disk
+func (s *Store) SavePod(pod *Pod) error {
+	if err := os.MkdirAll(s.podDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create pod directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(pod, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pod: %v", err)
+	}
+
+	filename := filepath.Join(s.podDir(), fmt.Sprintf("%s.json", pod.ID))
+	if err := writeFileAtomic(filename, data); err != nil {
+		return fmt.Errorf("failed to write pod: %v", err)
+	}
+
+	return nil
+}
+
+// ListPods returns all pods stored on disk
+func (s *Store) ListPods() ([]*Pod, error) {
+	entries, err := os.ReadDir(s.podDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod directory: %v", err)
+	}
+
+	var pods []*Pod
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.podDir(), entry.Name()))
+		if err != nil {
+			fmt.Printf("Warning: failed to read pod %s: %v\n", entry.Name(), err)
+			continue
+		}
+		var pod Pod
+		if err := json.Unmarshal(data, &pod); err != nil {
+			fmt.Printf("Warning: failed to load pod %s: %v\n", entry.Name(), err)
+			continue
+		}
+
+		pods = append(pods, &pod)
+	}
+
+	return pods, nil
+}
+
+// DeletePod removes a pod from disk
+func (s *Store) DeletePod(id string) error {
+	filename := filepath.Join(s.podDir(), fmt.Sprintf("%s.json", id))
+
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete pod: %v", err)
+	}
+
+	return nil
+}
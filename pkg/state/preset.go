@@ -0,0 +1,88 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// Preset is a named container template created through the API
+type Preset struct {
+	Name     string                     `json:"name"`
+	Template api.ContainerCreateRequest `json:"template"`
+	Created  time.Time                  `json:"created"`
+	Owner    *uint32                    `json:"owner_uid,omitempty"` // UID of the client that created the preset
+}
+
+// presetDir returns the directory holding preset definitions
+func (s *Store) presetDir() string {
+	return filepath.Join(s.dataDir, "presets")
+}
+
+// SavePreset saves a preset to disk
+func (s *Store) SavePreset(preset *Preset) error {
+	if err := os.MkdirAll(s.presetDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create preset directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(preset, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal preset: %v", err)
+	}
+
+	// Preset names are restricted to characters that are safe in file names
+	filename := filepath.Join(s.presetDir(), fmt.Sprintf("%s.json", preset.Name))
+	if err := writeFileAtomic(filename, data); err != nil {
+		return fmt.Errorf("failed to write preset: %v", err)
+	}
+
+	return nil
+}
+
+// ListPresets returns all presets stored on disk
+func (s *Store) ListPresets() ([]*Preset, error) {
+	entries, err := os.ReadDir(s.presetDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preset directory: %v", err)
+	}
+
+	var presets []*Preset
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.presetDir(), entry.Name()))
+		if err != nil {
+			fmt.Printf("Warning: failed to read preset %s: %v\n", entry.Name(), err)
+			continue
+		}
+		var preset Preset
+		if err := json.Unmarshal(data, &preset); err != nil {
+			fmt.Printf("Warning: failed to load preset %s: %v\n", entry.Name(), err)
+			continue
+		}
+
+		presets = append(presets, &preset)
+	}
+
+	return presets, nil
+}
+
+// DeletePreset removes a preset from disk
+func (s *Store) DeletePreset(name string) error {
+	filename := filepath.Join(s.presetDir(), fmt.Sprintf("%s.json", name))
+
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete preset: %v", err)
+	}
+
+	return nil
+}
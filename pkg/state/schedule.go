@@ -0,0 +1,114 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// Schedule is a container template the daemon runs on a cron schedule
+type Schedule struct {
+	ID       string                     `json:"id"`
+	Cron     string                     `json:"cron"`
+	Template api.ContainerCreateRequest `json:"template"`
+	Enabled  bool                       `json:"enabled"`
+	Created  time.Time                  `json:"created"`
+	Owner    *uint32                    `json:"owner_uid,omitempty"` // UID of the client that created the schedule; runs count against its quota
+	Runs     []ScheduleRun              `json:"runs,omitempty"`      // Most recent last
+}
+
+// ScheduleRun is one run of a schedule
+type ScheduleRun struct {
+	ContainerID string    `json:"container_id,omitempty"` // Empty if no container was started
+	Started     time.Time `json:"started"`
+	Finished    time.Time `json:"finished"`
+	ExitCode    *int      `json:"exit_code,omitempty"` // nil until the container exits
+	Error       string    `json:"error,omitempty"`     // Why the run failed to start or was skipped
+}
+
+// scheduleDir returns the directory holding schedule definitions
+// They live in a subdirectory so ListContainers doesn't mistake them for container state
+func (s *Store) scheduleDir() string {
+	return filepath.Join(s.dataDir, "schedules")
+}
+
+// SaveSchedule saves a schedule to disk
+func (s *Store) SaveSchedule(schedule *Schedule) error {
+	if err := os.MkdirAll(s.scheduleDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create schedule directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(schedule, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %v", err)
+	}
+
+	// Schedules are rewritten after every run, so replace the file atomically
+	filename := filepath.Join(s.scheduleDir(), fmt.Sprintf("%s.json", schedule.ID))
+	if err := writeFileAtomic(filename, data); err != nil {
+		return fmt.Errorf("failed to write schedule: %v", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic replaces filename with data so readers never see a partial file
+func writeFileAtomic(filename string, data []byte) error {
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// ListSchedules returns all schedules stored on disk
+func (s *Store) ListSchedules() ([]*Schedule, error) {
+	entries, err := os.ReadDir(s.scheduleDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule directory: %v", err)
+	}
+
+	var schedules []*Schedule
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.scheduleDir(), entry.Name()))
+		if err != nil {
+			fmt.Printf("Warning: failed to read schedule %s: %v\n", entry.Name(), err)
+			continue
+		}
+		var schedule Schedule
+		if err := json.Unmarshal(data, &schedule); err != nil {
+			fmt.Printf("Warning: failed to load schedule %s: %v\n", entry.Name(), err)
+			continue
+		}
+
+		schedules = append(schedules, &schedule)
+	}
+
+	return schedules, nil
+}
+
+// DeleteSchedule removes a schedule from disk
+func (s *Store) DeleteSchedule(id string) error {
+	filename := filepath.Join(s.scheduleDir(), fmt.Sprintf("%s.json", id))
+
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete schedule: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/namespace"
+)
+
+// SchemaVersion is the layout of container state files written by this daemon
+// Version 0 is the original flat layout, which is migrated when loaded
+const SchemaVersion = 1
+
+// ContainerConfig describes what a container runs, independent of the host it runs on
+type ContainerConfig struct {
+	Command    []string          `json:"command"`
+	Env        []string          `json:"env,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Platform   string            `json:"platform,omitempty"`    // Platform the rootfs was built for, empty for the host's
+	User       string            `json:"user,omitempty"`        // User to run the command as, empty for root
+	Hostname   string            `json:"hostname,omitempty"`    // Hostname inside the container, empty for the default
+	StopSignal string            `json:"stop_signal,omitempty"` // Signal sent to stop the container, empty for SIGTERM
+
+	NoTTY     bool `json:"no_tty,omitempty"`     // Attached runs get stdio pipes instead of a PTY
+	StdinOnce bool `json:"stdin_once,omitempty"` // Close the container's stdin once the first attached client's stdin ends
+
+	// Streams an attach carries unless it picks its own; none set means all
+	AttachStdin  bool `json:"attach_stdin,omitempty"`
+	AttachStdout bool `json:"attach_stdout,omitempty"`
+	AttachStderr bool `json:"attach_stderr,omitempty"`
+}
+
+// HostConfig describes how the host sets up and constrains a container
+type HostConfig struct {
+	Rootfs      string                 `json:"rootfs"`
+	Limits      cgroups.ResourceLimits `json:"limits"`
+	Secrets     []namespace.Secret     `json:"secrets,omitempty"`
+	Mounts      []namespace.Mount      `json:"mounts,omitempty"`
+	MaskedPaths []string               `json:"masked_paths,omitempty"` // Default and requested masked paths
+	Profile     string                 `json:"profile,omitempty"`      // Resource profile the limits were filled in from
+	Owner       *uint32                `json:"owner_uid,omitempty"`    // UID of the client that created the container, nil if unknown
+
+	PressureThresholds map[string]float64 `json:"pressure_thresholds,omitempty"` // avg10 stall percentages that trigger pressure events
+	AppArmorProfile    string             `json:"apparmor_profile,omitempty"`    // Resolved at create time; empty when AppArmor is unavailable
+	ProcessLabel       string             `json:"process_label,omitempty"`       // SELinux context of the container process
+	MountLabel         string             `json:"mount_label,omitempty"`         // SELinux context of mounts set up for the container
+
+	TimeOffsets *namespace.TimeOffsets `json:"time_offsets,omitempty"` // Clock offsets of the container's time namespace, nil for none
+
+	WaitFor *api.WaitConditions `json:"wait_for,omitempty"` // Host resources every start waits for, nil for none
+
+	NamespacesFrom string `json:"namespaces_from,omitempty"` // ID of the container whose network, PID and IPC namespaces are joined
+	AutoRemove     bool   `json:"auto_remove,omitempty"`     // Remove the container once it exits
+
+	ResourceViews bool `json:"resource_views,omitempty"` // Bind generated /proc and /sys files reflecting the limits over the host's
+
+	Autoscale *api.AutoscalePolicy `json:"autoscale,omitempty"` // Raises Limits while the container hits them, nil for none
+
+	Adopted bool `json:"adopted,omitempty"` // The process was started outside the daemon and adopted; Rootfs is its root at the time
+
+	LogConfig *api.LogConfig `json:"log_config,omitempty"` // Log driver for the container's output, nil for the daemon's output
+
+	DeviceHotplug []string `json:"device_hotplug,omitempty"` // Globs of host devices mirrored into /dev while running
+}
+
+// stateV0 is the flat layout written before ContainerConfig and HostConfig were split out
+type stateV0 struct {
+	ID                 string                 `json:"id"`
+	PID                int                    `json:"pid"`
+	Status             string                 `json:"status"`
+	Command            []string               `json:"command"`
+	Rootfs             string                 `json:"rootfs"`
+	Env                []string               `json:"env,omitempty"`
+	Secrets            []namespace.Secret     `json:"secrets,omitempty"`
+	Labels             map[string]string      `json:"labels,omitempty"`
+	Created            time.Time              `json:"created"`
+	Exited             time.Time              `json:"exited"`
+	Limits             cgroups.ResourceLimits `json:"limits"`
+	PressureThresholds map[string]float64     `json:"pressure_thresholds,omitempty"`
+	AppArmorProfile    string                 `json:"apparmor_profile,omitempty"`
+	ProcessLabel       string                 `json:"process_label,omitempty"`
+	MountLabel         string                 `json:"mount_label,omitempty"`
+	Platform           string                 `json:"platform,omitempty"`
+	TimeOffsets        *namespace.TimeOffsets `json:"time_offsets,omitempty"`
+	Mounts             []namespace.Mount      `json:"mounts,omitempty"`
+	MaskedPaths        []string               `json:"masked_paths,omitempty"`
+}
+
+// migrate converts a version 0 state to the current layout
+func (old *stateV0) migrate() *ContainerState {
+	return &ContainerState{
+		SchemaVersion: SchemaVersion,
+		ID:            old.ID,
+		PID:           old.PID,
+		Status:        old.Status,
+		Created:       old.Created,
+		Exited:        old.Exited,
+		ContainerConfig: ContainerConfig{
+			Command:  old.Command,
+			Env:      old.Env,
+			Labels:   old.Labels,
+			Platform: old.Platform,
+		},
+		HostConfig: HostConfig{
+			Rootfs:             old.Rootfs,
+			Limits:             old.Limits,
+			Secrets:            old.Secrets,
+			Mounts:             old.Mounts,
+			MaskedPaths:        old.MaskedPaths,
+			PressureThresholds: old.PressureThresholds,
+			AppArmorProfile:    old.AppArmorProfile,
+			ProcessLabel:       old.ProcessLabel,
+			MountLabel:         old.MountLabel,
+			TimeOffsets:        old.TimeOffsets,
+		},
+	}
+}
+
+// containerStateFields are the top-level JSON keys ContainerState knows about
+var containerStateFields = jsonFields(reflect.TypeOf(ContainerState{}))
+
+// jsonFields returns the JSON keys of a struct's exported fields
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+// stateAlias has ContainerState's fields without its JSON methods
+type stateAlias ContainerState
+
+// UnmarshalJSON decodes any schema version, migrating the flat version 0 layout
+// Fields written by a newer daemon are ignored, but kept so saving the state doesn't drop them
+func (s *ContainerState) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var version int
+	if v, ok := raw["schema_version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return fmt.Errorf("invalid schema_version: %v", err)
+		}
+	}
+
+	if version == 0 {
+		var old stateV0
+		if err := json.Unmarshal(data, &old); err != nil {
+			return err
+		}
+		*s = *old.migrate()
+		return nil
+	}
+
+	if err := json.Unmarshal(data, (*stateAlias)(s)); err != nil {
+		return err
+	}
+	for key := range raw {
+		if containerStateFields[key] {
+			delete(raw, key)
+		}
+	}
+	if len(raw) > 0 {
+		s.unknown = raw
+	}
+	return nil
+}
+
+// MarshalJSON encodes the state in the current layout, along with any fields kept from a newer daemon
+func (s ContainerState) MarshalJSON() ([]byte, error) {
+	if s.SchemaVersion < SchemaVersion {
+		s.SchemaVersion = SchemaVersion
+	}
+
+	data, err := json.Marshal(stateAlias(s))
+	if err != nil || len(s.unknown) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range s.unknown {
+		fields[key] = value
+	}
+	return json.Marshal(fields)
+}
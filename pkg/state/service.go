@@ -0,0 +1,96 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AbhishekGY/mydocker/pkg/api"
+)
+
+// Service is a named group of identical containers that the daemon keeps at a desired count
+type Service struct {
+	ID       string                     `json:"id"`
+	Name     string                     `json:"name"`
+	Replicas int                        `json:"replicas"`
+	Template api.ContainerCreateRequest `json:"template"`
+	Created  time.Time                  `json:"created"`
+	Owner    *uint32                    `json:"owner_uid,omitempty"` // UID of the client that created the service; replicas count against its quota
+
+	// Version is bumped by every update; replicas are labelled with the version they were created from
+	Version int `json:"version,omitempty"`
+	// PreviousTemplate and PreviousVersion are what the last update replaced, restored on rollback
+	PreviousTemplate *api.ContainerCreateRequest `json:"previous_template,omitempty"`
+	PreviousVersion  int                         `json:"previous_version,omitempty"`
+	UpdateStatus     *api.ServiceUpdateStatus    `json:"update_status,omitempty"`
+}
+
+// serviceDir returns the directory holding service definitions
+func (s *Store) serviceDir() string {
+	return filepath.Join(s.dataDir, "services")
+}
+
+// SaveService saves a service to disk
+func (s *Store) SaveService(service *Service) error {
+	if err := os.MkdirAll(s.serviceDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create service directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(service, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal service: %v", err)
+	}
+
+	filename := filepath.Join(s.serviceDir(), fmt.Sprintf("%s.json", service.ID))
+	if err := writeFileAtomic(filename, data); err != nil {
+		return fmt.Errorf("failed to write service: %v", err)
+	}
+
+	return nil
+}
+
+// ListServices returns all services stored on disk
+func (s *Store) ListServices() ([]*Service, error) {
+	entries, err := os.ReadDir(s.serviceDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service directory: %v", err)
+	}
+
+	var services []*Service
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.serviceDir(), entry.Name()))
+		if err != nil {
+			fmt.Printf("Warning: failed to read service %s: %v\n", entry.Name(), err)
+			continue
+		}
+		var service Service
+		if err := json.Unmarshal(data, &service); err != nil {
+			fmt.Printf("Warning: failed to load service %s: %v\n", entry.Name(), err)
+			continue
+		}
+
+		services = append(services, &service)
+	}
+
+	return services, nil
+}
+
+// DeleteService removes a service from disk
+func (s *Store) DeleteService(id string) error {
+	filename := filepath.Join(s.serviceDir(), fmt.Sprintf("%s.json", id))
+
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete service: %v", err)
+	}
+
+	return nil
+}
@@ -1,13 +1,15 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/AbhishekGY/mydocker/pkg/cgroups"
+	"github.com/AbhishekGY/mydocker/pkg/api"
 )
 
 // Store manages persistent storage of container state
@@ -16,14 +18,25 @@ type Store struct {
 }
 
 // ContainerState represents the persistent state of a container
+// What the container runs and how the host sets it up live in ContainerConfig and HostConfig;
+// their fields are promoted so callers can use them directly
 type ContainerState struct {
-	ID      string                  `json:"id"`
-	PID     int                     `json:"pid"`
-	Status  string                  `json:"status"`
-	Command []string                `json:"command"`
-	Rootfs  string                  `json:"rootfs"`
-	Created time.Time               `json:"created"`
-	Limits  cgroups.ResourceLimits  `json:"limits"`
+	SchemaVersion int       `json:"schema_version"`
+	ID            string    `json:"id"`
+	PID           int       `json:"pid"`
+	Status        string    `json:"status"`
+	Created       time.Time `json:"created"`
+	Started       time.Time `json:"started"` // When the container was last started
+	Exited        time.Time `json:"exited"`
+	ExitCode      *int      `json:"exit_code,omitempty"` // nil until the container exits, and again once it restarts
+
+	// AutoscaleAdjustments are the most recent changes the autoscale policy made to Limits, oldest first
+	AutoscaleAdjustments []api.AutoscaleAdjustment `json:"autoscale_adjustments,omitempty"`
+
+	ContainerConfig `json:"config"`
+	HostConfig      `json:"host_config"`
+
+	unknown map[string]json.RawMessage // Top-level fields written by a newer daemon, kept when saving
 }
 
 // NewStore creates a new state store
@@ -98,6 +111,25 @@ func (s *Store) ListContainers() ([]*ContainerState, error) {
 	return containers, nil
 }
 
+// HasContainer reports whether state for a container ID exists on disk
+func (s *Store) HasContainer(id string) bool {
+	filename := filepath.Join(s.dataDir, fmt.Sprintf("%s.json", id))
+
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// ContainerSize returns the number of bytes a container's state occupies on disk
+func (s *Store) ContainerSize(id string) int64 {
+	filename := filepath.Join(s.dataDir, fmt.Sprintf("%s.json", id))
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
 // DeleteContainer removes a container's state from disk
 func (s *Store) DeleteContainer(id string) error {
 	filename := filepath.Join(s.dataDir, fmt.Sprintf("%s.json", id))
@@ -105,6 +137,105 @@ func (s *Store) DeleteContainer(id string) error {
 	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete container state: %v", err)
 	}
+	if err := os.Remove(s.historyFile(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete container history: %v", err)
+	}
 
 	return nil
 }
+
+// Transition is one change of a container's status, kept in its history
+type Transition struct {
+	From   string    `json:"from"` // Empty for the transition that created the container
+	To     string    `json:"to"`
+	Reason string    `json:"reason"`
+	Actor  string    `json:"actor"` // Who caused the transition, e.g. "uid=1000 pid=4242" or "daemon"
+	Time   time.Time `json:"time"`
+}
+
+// historyFile returns the path of a container's append-only history
+func (s *Store) historyFile(id string) string {
+	return filepath.Join(s.dataDir, fmt.Sprintf("%s-history.jsonl", id))
+}
+
+// AppendHistory adds a transition to the end of a container's history
+func (s *Store) AppendHistory(id string, t Transition) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transition: %v", err)
+	}
+
+	file, err := os.OpenFile(s.historyFile(id), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open container history: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write container history: %v", err)
+	}
+	return nil
+}
+
+// LoadHistory returns a container's transitions, oldest first
+func (s *Store) LoadHistory(id string) ([]Transition, error) {
+	data, err := os.ReadFile(s.historyFile(id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container history: %v", err)
+	}
+
+	var history []Transition
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var t Transition
+		if err := json.Unmarshal([]byte(line), &t); err != nil {
+			// A crash can leave a partial last line; the rest of the history is still good
+			fmt.Printf("Warning: skipping corrupt history entry for container %s: %v\n", id, err)
+			continue
+		}
+		history = append(history, t)
+	}
+	return history, nil
+}
+
+// TrimHistory drops transitions older than cutoff from a container's history
+// Returns the number of transitions removed
+func (s *Store) TrimHistory(id string, cutoff time.Time) (int, error) {
+	history, err := s.LoadHistory(id)
+	if err != nil || len(history) == 0 {
+		return 0, err
+	}
+
+	keep := 0
+	for keep < len(history) && history[keep].Time.Before(cutoff) {
+		keep++
+	}
+	if keep == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	for _, t := range history[keep:] {
+		data, err := json.Marshal(t)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal transition: %v", err)
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	// Replace the file atomically so a crash can't lose the entries being kept
+	tmp := s.historyFile(id) + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write container history: %v", err)
+	}
+	if err := os.Rename(tmp, s.historyFile(id)); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to replace container history: %v", err)
+	}
+	return keep, nil
+}
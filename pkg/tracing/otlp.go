@@ -0,0 +1,226 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// exportQueueSize is how many ended spans wait for the exporter before new ones are dropped
+	exportQueueSize = 2048
+	// exportBatchSize is the most spans sent in one request
+	exportBatchSize = 512
+	// exportInterval is how often queued spans are sent when fewer than a batch are waiting
+	exportInterval = 5 * time.Second
+	// exportTimeout bounds each request to the collector, and how long Shutdown waits for the last one
+	exportTimeout = 10 * time.Second
+
+	// instrumentationScope names the code that recorded the spans
+	instrumentationScope = "github.com/AbhishekGY/mydocker"
+)
+
+// Exporter sends ended spans to an OpenTelemetry collector in batches, as OTLP/HTTP with JSON encoding
+// Spans that arrive while its queue is full are dropped rather than slowing down the operations they trace
+type Exporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+
+	spans   chan spanData
+	dropped atomic.Uint64
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewExporter starts an exporter posting to the traces endpoint of the collector at endpoint, e.g. http://localhost:4318
+// serviceName is the service.name resource attribute of the spans
+func NewExporter(endpoint, serviceName string) *Exporter {
+	e := &Exporter{
+		url:         strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: exportTimeout},
+		spans:       make(chan spanData, exportQueueSize),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// enqueue queues an ended span for the next batch
+func (e *Exporter) enqueue(span spanData) {
+	select {
+	case e.spans <- span:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// Shutdown sends the spans still queued and stops the exporter
+func (e *Exporter) Shutdown() {
+	close(e.done)
+	<-e.stopped
+}
+
+// run sends batches until Shutdown, then sends what is left
+func (e *Exporter) run() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]spanData, 0, exportBatchSize)
+	for {
+		select {
+		case span := <-e.spans:
+			if batch = append(batch, span); len(batch) < exportBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-e.done:
+			for len(e.spans) > 0 {
+				batch = append(batch, <-e.spans)
+			}
+			for len(batch) > 0 {
+				n := min(len(batch), exportBatchSize)
+				e.export(batch[:n])
+				batch = batch[n:]
+			}
+			return
+		}
+
+		if len(batch) > 0 {
+			e.export(batch)
+			batch = batch[:0]
+		}
+		if dropped := e.dropped.Swap(0); dropped > 0 {
+			fmt.Printf("Warning: dropped %d spans, the trace exporter's queue was full\n", dropped)
+		}
+	}
+}
+
+// export posts a batch of spans to the collector
+// Failures are reported as warnings; the spans are not retried
+func (e *Exporter) export(batch []spanData) {
+	body, err := json.Marshal(e.encode(batch))
+	if err != nil {
+		fmt.Printf("Warning: failed to encode %d spans: %v\n", len(batch), err)
+		return
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		fmt.Printf("Warning: failed to export %d spans to %s: %v\n", len(batch), e.url, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		fmt.Printf("Warning: failed to export %d spans to %s: %s: %s\n", len(batch), e.url, resp.Status, bytes.TrimSpace(msg))
+	}
+}
+
+// OTLP/JSON messages, see opentelemetry/proto/collector/trace/v1/trace_service.proto
+// IDs are hex strings and 64-bit integers decimal strings, as the JSON mapping of OTLP requires
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// encode builds the export request for a batch of spans
+func (e *Exporter) encode(batch []spanData) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.attributes),
+			Status:            otlpStatus{Code: s.status, Message: s.message},
+		}
+		if s.parentID != (SpanID{}) {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		spans = append(spans, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttributes([]Attribute{String("service.name", e.serviceName)})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentationScope}, Spans: spans}},
+	}}}
+}
+
+// encodeAttributes converts attributes to OTLP key-values, with other value types sent as strings
+func encodeAttributes(attrs []Attribute) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var value map[string]any
+		switch v := attr.Value.(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		kvs = append(kvs, otlpKeyValue{Key: attr.Key, Value: value})
+	}
+	return kvs
+}
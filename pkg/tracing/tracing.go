@@ -0,0 +1,193 @@
+// Package tracing records spans of daemon operations, such as API requests and the stages of a container start,
+// and exports them to an OpenTelemetry collector. Spans are only recorded while an exporter is set
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace, the tree of spans making up one operation
+type TraceID [16]byte
+
+// SpanID identifies a span within its trace
+type SpanID [8]byte
+
+// Span kinds, as numbered by OTLP
+const (
+	kindInternal = 1
+	kindServer   = 2
+)
+
+// Span status codes, as numbered by OTLP
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// Attribute is a key and a string, int64 or bool value describing a span
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// spanContext is what a child span needs from its parent, which may belong to another process
+type spanContext struct {
+	traceID TraceID
+	spanID  SpanID
+}
+
+type spanContextKey struct{}
+
+// spanData is a span as it is handed to the exporter once it has ended
+type spanData struct {
+	traceID    TraceID
+	spanID     SpanID
+	parentID   SpanID // Zero for the root span of a trace
+	name       string
+	kind       int
+	start, end time.Time
+	attributes []Attribute
+	status     int
+	message    string // Why the span failed, with statusError
+}
+
+// Span is an operation being traced
+// A nil Span records nothing, so callers don't need to check whether tracing is enabled
+type Span struct {
+	exporter *Exporter
+
+	mu    sync.Mutex
+	data  spanData
+	ended bool
+}
+
+// exporter is where ended spans go, nil while tracing is disabled
+var exporter atomic.Pointer[Exporter]
+
+// SetExporter starts sending spans to e, or stops recording them when e is nil
+// Returns the previous exporter, which the caller should shut down
+func SetExporter(e *Exporter) *Exporter {
+	return exporter.Swap(e)
+}
+
+// Start begins a span named name as a child of the span in ctx, or as the root of a new trace
+// The returned context carries the new span, for the spans of the stages within it
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, kindInternal, attrs)
+}
+
+// StartServer begins a span for a request received from a client; see Extract for continuing the client's trace
+func StartServer(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, kindServer, attrs)
+}
+
+func start(ctx context.Context, name string, kind int, attrs []Attribute) (context.Context, *Span) {
+	e := exporter.Load()
+	if e == nil {
+		return ctx, nil
+	}
+
+	data := spanData{name: name, kind: kind, start: time.Now(), attributes: attrs}
+	if parent, ok := ctx.Value(spanContextKey{}).(spanContext); ok {
+		data.traceID, data.parentID = parent.traceID, parent.spanID
+	} else {
+		rand.Read(data.traceID[:])
+	}
+	rand.Read(data.spanID[:])
+
+	span := &Span{exporter: e, data: data}
+	return context.WithValue(ctx, spanContextKey{}, spanContext{data.traceID, data.spanID}), span
+}
+
+// SetName renames the span, e.g. once the route that handles a request is known
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.name = name
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.attributes = append(s.data.attributes, attrs...)
+}
+
+// RecordError marks the span as failed with err; a nil err is ignored
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.status, s.data.message = statusError, err.Error()
+}
+
+// End finishes the span and queues it for export; later calls do nothing
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.end = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.exporter.enqueue(data)
+}
+
+// Extract returns ctx with the client's span from a W3C traceparent header as the parent of spans started from it
+// ctx is returned as is without a valid header
+func Extract(ctx context.Context, header http.Header) context.Context {
+	// version-traceid-parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	parts := strings.Split(header.Get("traceparent"), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return ctx
+	}
+
+	var sc spanContext
+	traceID, err1 := hex.DecodeString(parts[1])
+	spanID, err2 := hex.DecodeString(parts[2])
+	if err1 != nil || err2 != nil || len(traceID) != len(sc.traceID) || len(spanID) != len(sc.spanID) {
+		return ctx
+	}
+	copy(sc.traceID[:], traceID)
+	copy(sc.spanID[:], spanID)
+	if sc.traceID == (TraceID{}) || sc.spanID == (SpanID{}) {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
@@ -0,0 +1,65 @@
+// Package udev watches the kernel's device events, the uevents udev itself acts on, so devices plugged
+// in or removed while containers run can be reflected inside them
+package udev
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Event is a device being added to or removed from the host
+type Event struct {
+	Action    string // "add", "remove", "change", ...
+	DevPath   string // Path under /sys, e.g. /devices/pci0000:00/.../ttyUSB0
+	Subsystem string // e.g. "tty", "usb" or "block"
+	DevName   string // Device node under /dev, e.g. /dev/ttyUSB0; empty for devices without a node
+	Major     uint32
+	Minor     uint32
+}
+
+// Block reports whether the event's device node is a block device rather than a character device
+func (e *Event) Block() bool {
+	return e.Subsystem == "block"
+}
+
+// parseEvent parses a kernel uevent: "ACTION@DEVPATH" followed by KEY=VALUE pairs, all NUL-terminated
+// Messages rebroadcast by udev start with "libudev" and aren't parsed
+func parseEvent(msg []byte) (*Event, bool) {
+	fields := bytes.Split(bytes.TrimRight(msg, "\x00"), []byte{0})
+	if len(fields) == 0 || !bytes.Contains(fields[0], []byte("@")) {
+		return nil, false
+	}
+
+	event := &Event{}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ACTION":
+			event.Action = value
+		case "DEVPATH":
+			event.DevPath = value
+		case "SUBSYSTEM":
+			event.Subsystem = value
+		case "DEVNAME":
+			// Relative to /dev, except on some older kernels
+			if !strings.HasPrefix(value, "/") {
+				value = "/dev/" + value
+			}
+			event.DevName = value
+		case "MAJOR":
+			n, _ := strconv.ParseUint(value, 10, 32)
+			event.Major = uint32(n)
+		case "MINOR":
+			n, _ := strconv.ParseUint(value, 10, 32)
+			event.Minor = uint32(n)
+		}
+	}
+	if event.Action == "" {
+		return nil, false
+	}
+	return event, true
+}
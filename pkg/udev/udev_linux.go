@@ -0,0 +1,73 @@
+package udev
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// kernelGroup is the netlink multicast group the kernel sends uevents to
+const kernelGroup = 1
+
+// Monitor receives device events from the kernel
+type Monitor struct {
+	file *os.File
+	buf  []byte
+}
+
+// Listen starts receiving device events; events from before the call are not seen
+func Listen() (*Monitor, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, unix.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uevent socket: %v", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: kernelGroup}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to listen for uevents: %v", err)
+	}
+
+	// The socket is non-blocking so the runtime poller can interrupt Receive when the monitor is closed
+	return &Monitor{file: os.NewFile(uintptr(fd), "uevent"), buf: make([]byte, 64*1024)}, nil
+}
+
+// Receive waits for the next device event
+// Messages that don't come from the kernel are ignored, so a process on the host can't fake a device
+func (m *Monitor) Receive() (*Event, error) {
+	raw, err := m.file.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var n int
+		var from unix.Sockaddr
+		var recvErr error
+		err := raw.Read(func(fd uintptr) bool {
+			n, from, recvErr = unix.Recvfrom(int(fd), m.buf, 0)
+			return recvErr != unix.EAGAIN
+		})
+		if err != nil {
+			return nil, err
+		}
+		if recvErr != nil {
+			if recvErr == unix.ENOBUFS {
+				// Events were lost while the socket's buffer was full; carry on with the next ones
+				continue
+			}
+			return nil, fmt.Errorf("failed to receive uevent: %v", recvErr)
+		}
+
+		if sender, ok := from.(*unix.SockaddrNetlink); !ok || sender.Pid != 0 {
+			continue
+		}
+		if event, ok := parseEvent(m.buf[:n]); ok {
+			return event, nil
+		}
+	}
+}
+
+// Close stops the monitor; a pending Receive returns an error
+func (m *Monitor) Close() error {
+	return m.file.Close()
+}
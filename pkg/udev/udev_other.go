@@ -0,0 +1,27 @@
+//go:build !linux
+
+package udev
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// Monitor receives device events from the kernel
+type Monitor struct{}
+
+// Listen always fails; uevents are a Linux feature
+func Listen() (*Monitor, error) {
+	return nil, fmt.Errorf("device events are not available: %w on %s", errors.ErrUnsupported, runtime.GOOS)
+}
+
+// Receive always fails outside Linux
+func (m *Monitor) Receive() (*Event, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// Close does nothing outside Linux
+func (m *Monitor) Close() error {
+	return nil
+}
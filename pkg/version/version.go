@@ -0,0 +1,58 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version and GitCommit are set at build time via -ldflags "-X"
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+)
+
+// APIVersion is the API version this build speaks
+// MinAPIVersion is the oldest API version it still accepts from the other side
+const (
+	APIVersion    = "1.0"
+	MinAPIVersion = "1.0"
+)
+
+// CompareAPIVersions compares two "major.minor" API versions
+// Returns -1 if a < b, 0 if equal and 1 if a > b
+func CompareAPIVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+
+		if aNum < bNum {
+			return -1
+		}
+		if aNum > bNum {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// CheckCompatibility reports whether a peer speaking peerAPIVersion (and accepting down to peerMinAPIVersion)
+// can talk to this build. Returns a descriptive error if not
+func CheckCompatibility(peerAPIVersion, peerMinAPIVersion string) error {
+	if CompareAPIVersions(peerAPIVersion, MinAPIVersion) < 0 {
+		return fmt.Errorf("peer API version %s is too old, minimum supported is %s", peerAPIVersion, MinAPIVersion)
+	}
+	if peerMinAPIVersion != "" && CompareAPIVersions(APIVersion, peerMinAPIVersion) < 0 {
+		return fmt.Errorf("API version %s is too old for peer, which requires at least %s", APIVersion, peerMinAPIVersion)
+	}
+	return nil
+}